@@ -0,0 +1,27 @@
+// Command reencrypt re-encrypts the sensitive columns with the active master key of
+// ENCRYPTION_KEYS, after a key rotation has been rolled out to every instance.
+//
+// To rotate the master key, add a new key at the head of ENCRYPTION_KEYS and deploy,
+// run this command, then remove the retired keys from ENCRYPTION_KEYS.
+package main
+
+import (
+	"log"
+
+	"github.com/drunkleen/rasta/config"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	"github.com/drunkleen/rasta/pkg/database"
+)
+
+func main() {
+	config.Init()
+	if err := auth.InitEncryption(); err != nil {
+		log.Panicf("failed to load encryption keys: %v", err)
+	}
+	database.InitDB()
+	count, err := database.ReencryptColumns(database.DB)
+	if err != nil {
+		log.Panicf("failed to re-encrypt sensitive columns after %d values: %v", count, err)
+	}
+	log.Printf("re-encrypted %d sensitive values with key %q", count, auth.ActiveEncryptionKeyId())
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 )
 
 var (
@@ -17,6 +18,13 @@ var (
 	envJwtIssuer          string
 	envJwtExpiryInSeconds int
 
+	envJwtRefreshExpiryInSeconds int
+	envJwtSigningAlg             string
+	envJwtKeysDir                string
+	envJwtKeyRotationInSeconds   int
+	envJwtKeyGraceInSeconds      int
+	envJwtHmacGraceInSeconds     int
+
 	envEmailHost      string
 	envEmailPort      string
 	envEmailUsername  string
@@ -26,6 +34,95 @@ var (
 	envHelpCenterEmail   string
 	envHelpCenterAddress string
 
+	envLockoutThreshold         int
+	envLockoutIpThreshold       int
+	envLockoutDurationInSeconds int
+	envLockoutWindowInSeconds   int
+	envOtpMaxAttempts           int
+
+	envWebAuthnRPID      string
+	envWebAuthnRPName    string
+	envWebAuthnRPOrigins string
+
+	envEmailLoginEnabled bool
+	envEmailLoginUrl     string
+
+	envSocialRedirectUrl        string
+	envSocialGoogleClientId     string
+	envSocialGoogleClientSecret string
+	envSocialGithubClientId     string
+	envSocialGithubClientSecret string
+	envSocialOidcName           string
+	envSocialOidcClientId       string
+	envSocialOidcClientSecret   string
+	envSocialOidcDiscoveryUrl   string
+
+	envOidcIssuerUrl  string
+	envOidcConsentUrl string
+
+	envApiKeyRateLimit    int
+	envApiKeyMaxRateLimit int
+
+	envImpersonationExpiryInSeconds int
+
+	envEmailChangeCancelUrl             string
+	envEmailChangeCancelWindowInSeconds int
+
+	envAccountDeletionGraceInSeconds int
+	envDataExportExpiryInSeconds     int
+
+	envPasswordHashAlgorithm string
+	envArgon2Memory          int
+	envArgon2Iterations      int
+	envArgon2Parallelism     int
+	envBcryptCost            int
+
+	envPasswordMinLength        int
+	envPasswordMaxLength        int
+	envPasswordRequireUppercase bool
+	envPasswordRequireLowercase bool
+	envPasswordRequireDigit     bool
+	envPasswordRequireSymbol    bool
+	envPasswordMinStrength      int
+	envPasswordBreachedList     string
+	envPasswordHistory          int
+
+	envGeoIPDatabase              string
+	envLoginReportUrl             string
+	envLoginReportWindowInSeconds int
+
+	envEncryptionKeys string
+
+	envSmsDriver                  string
+	envSmsFile                    string
+	envSmsHttpUrl                 string
+	envSmsHttpToken               string
+	envSmsFrom                    string
+	envSmsCodeExpiryInSeconds     int
+	envSmsResendIntervalInSeconds int
+
+	envSignupBlockedDomains           string
+	envSignupStrictEmailDomain        bool
+	envSignupPowDifficulty            int
+	envSignupChallengeExpiryInSeconds int
+	envSignupChallengeSecret          string
+	envSignupIpQuota                  int
+	envSignupIpQuotaWindowInSeconds   int
+
+	envEmailPlusAddressing string
+
+	envEmailDriver                   string
+	envEmailFileDir                  string
+	envEmailSmtpTls                  string
+	envEmailSmtpPoolSize             int
+	envEmailSmtpIdleTimeoutInSeconds int
+
+	envEmailOutboxMaxAttempts           int
+	envEmailOutboxRetryDelayInSeconds   int
+	envEmailOutboxPollIntervalInSeconds int
+	envEmailOutboxRetentionInSeconds    int
+	envTrustedProxies                   string
+
 	DevMode bool
 )
 
@@ -35,6 +132,7 @@ func Init() {
 	if err := loadEnv(); DevMode && err != nil {
 		panic(err)
 	}
+	loadOptionalEnv()
 	log.Println("configs successfully loaded")
 }
 
@@ -108,6 +206,88 @@ func loadEnv() error {
 	return nil
 }
 
+// loadOptionalEnv loads the settings that have sane defaults. It runs even when
+// a required setting is missing, since those settings never fail to load.
+func loadOptionalEnv() {
+	envJwtRefreshExpiryInSeconds, _ = strconv.Atoi(lookupEnv("JWT_REFRESH_EXPIRY", "2592000"))
+	envJwtSigningAlg = lookupEnv("JWT_SIGNING_ALG", "HS256")
+	envJwtKeysDir = lookupEnv("JWT_KEYS_DIR", "keys")
+	envJwtKeyRotationInSeconds, _ = strconv.Atoi(lookupEnv("JWT_KEY_ROTATION", "0"))
+	envJwtKeyGraceInSeconds, _ = strconv.Atoi(lookupEnv("JWT_KEY_GRACE", "0"))
+	envJwtHmacGraceInSeconds, _ = strconv.Atoi(lookupEnv("JWT_HMAC_GRACE", "0"))
+	envLockoutThreshold, _ = strconv.Atoi(lookupEnv("LOCKOUT_THRESHOLD", "5"))
+	envLockoutIpThreshold, _ = strconv.Atoi(lookupEnv("LOCKOUT_IP_THRESHOLD", "20"))
+	envLockoutDurationInSeconds, _ = strconv.Atoi(lookupEnv("LOCKOUT_DURATION", "900"))
+	envLockoutWindowInSeconds, _ = strconv.Atoi(lookupEnv("LOCKOUT_WINDOW", "3600"))
+	envOtpMaxAttempts, _ = strconv.Atoi(lookupEnv("OTP_MAX_ATTEMPTS", "5"))
+	envWebAuthnRPID = lookupEnv("WEBAUTHN_RP_ID", "localhost")
+	envWebAuthnRPName = lookupEnv("WEBAUTHN_RP_NAME", "")
+	envWebAuthnRPOrigins = lookupEnv("WEBAUTHN_RP_ORIGINS", "")
+	envEmailLoginEnabled = lookupEnv("EMAIL_LOGIN_ENABLED", "false") == "true"
+	envEmailLoginUrl = lookupEnv("EMAIL_LOGIN_URL", "")
+	envSocialRedirectUrl = lookupEnv("SOCIAL_REDIRECT_URL", "")
+	envSocialGoogleClientId = lookupEnv("SOCIAL_GOOGLE_CLIENT_ID", "")
+	envSocialGoogleClientSecret = lookupEnv("SOCIAL_GOOGLE_CLIENT_SECRET", "")
+	envSocialGithubClientId = lookupEnv("SOCIAL_GITHUB_CLIENT_ID", "")
+	envSocialGithubClientSecret = lookupEnv("SOCIAL_GITHUB_CLIENT_SECRET", "")
+	envSocialOidcName = lookupEnv("SOCIAL_OIDC_NAME", "oidc")
+	envSocialOidcClientId = lookupEnv("SOCIAL_OIDC_CLIENT_ID", "")
+	envSocialOidcClientSecret = lookupEnv("SOCIAL_OIDC_CLIENT_SECRET", "")
+	envSocialOidcDiscoveryUrl = lookupEnv("SOCIAL_OIDC_DISCOVERY_URL", "")
+	envOidcIssuerUrl = lookupEnv("OIDC_ISSUER_URL", "")
+	envOidcConsentUrl = lookupEnv("OIDC_CONSENT_URL", "")
+	envApiKeyRateLimit, _ = strconv.Atoi(lookupEnv("API_KEY_RATE_LIMIT", "60"))
+	envApiKeyMaxRateLimit, _ = strconv.Atoi(lookupEnv("API_KEY_MAX_RATE_LIMIT", "600"))
+	envImpersonationExpiryInSeconds, _ = strconv.Atoi(lookupEnv("IMPERSONATION_EXPIRY", "900"))
+	envEmailChangeCancelUrl = lookupEnv("EMAIL_CHANGE_CANCEL_URL", "")
+	envEmailChangeCancelWindowInSeconds, _ = strconv.Atoi(lookupEnv("EMAIL_CHANGE_CANCEL_WINDOW", "604800"))
+	envAccountDeletionGraceInSeconds, _ = strconv.Atoi(lookupEnv("ACCOUNT_DELETION_GRACE", "2592000"))
+	envDataExportExpiryInSeconds, _ = strconv.Atoi(lookupEnv("DATA_EXPORT_EXPIRY", "604800"))
+	envPasswordHashAlgorithm = lookupEnv("PASSWORD_HASH_ALGORITHM", "argon2id")
+	envArgon2Memory, _ = strconv.Atoi(lookupEnv("ARGON2_MEMORY", "65536"))
+	envArgon2Iterations, _ = strconv.Atoi(lookupEnv("ARGON2_ITERATIONS", "3"))
+	envArgon2Parallelism, _ = strconv.Atoi(lookupEnv("ARGON2_PARALLELISM", "2"))
+	envBcryptCost, _ = strconv.Atoi(lookupEnv("BCRYPT_COST", "12"))
+	envPasswordMinLength, _ = strconv.Atoi(lookupEnv("PASSWORD_MIN_LENGTH", "8"))
+	envPasswordMaxLength, _ = strconv.Atoi(lookupEnv("PASSWORD_MAX_LENGTH", "128"))
+	envPasswordRequireUppercase = lookupEnv("PASSWORD_REQUIRE_UPPERCASE", "true") == "true"
+	envPasswordRequireLowercase = lookupEnv("PASSWORD_REQUIRE_LOWERCASE", "true") == "true"
+	envPasswordRequireDigit = lookupEnv("PASSWORD_REQUIRE_DIGIT", "true") == "true"
+	envPasswordRequireSymbol = lookupEnv("PASSWORD_REQUIRE_SYMBOL", "true") == "true"
+	envPasswordMinStrength, _ = strconv.Atoi(lookupEnv("PASSWORD_MIN_STRENGTH", "2"))
+	envPasswordBreachedList = lookupEnv("PASSWORD_BREACHED_LIST", "")
+	envPasswordHistory, _ = strconv.Atoi(lookupEnv("PASSWORD_HISTORY", "5"))
+	envGeoIPDatabase = lookupEnv("GEOIP_DATABASE", "")
+	envLoginReportUrl = lookupEnv("LOGIN_REPORT_URL", "")
+	envLoginReportWindowInSeconds, _ = strconv.Atoi(lookupEnv("LOGIN_REPORT_WINDOW", "604800"))
+	envEncryptionKeys = lookupEnv("ENCRYPTION_KEYS", "")
+	envSmsDriver = lookupEnv("SMS_DRIVER", "console")
+	envSmsFile = lookupEnv("SMS_FILE", "logs/sms.log")
+	envSmsHttpUrl = lookupEnv("SMS_HTTP_URL", "")
+	envSmsHttpToken = lookupEnv("SMS_HTTP_TOKEN", "")
+	envSmsFrom = lookupEnv("SMS_FROM", "")
+	envSmsCodeExpiryInSeconds, _ = strconv.Atoi(lookupEnv("SMS_CODE_EXPIRY", "300"))
+	envSmsResendIntervalInSeconds, _ = strconv.Atoi(lookupEnv("SMS_RESEND_INTERVAL", "60"))
+	envSignupBlockedDomains = lookupEnv("SIGNUP_BLOCKED_DOMAINS", "")
+	envSignupStrictEmailDomain = lookupEnv("SIGNUP_STRICT_EMAIL_DOMAIN", "true") == "true"
+	envSignupPowDifficulty, _ = strconv.Atoi(lookupEnv("SIGNUP_POW_DIFFICULTY", "20"))
+	envSignupChallengeExpiryInSeconds, _ = strconv.Atoi(lookupEnv("SIGNUP_CHALLENGE_EXPIRY", "300"))
+	envSignupChallengeSecret = lookupEnv("SIGNUP_CHALLENGE_SECRET", "")
+	envSignupIpQuota, _ = strconv.Atoi(lookupEnv("SIGNUP_IP_QUOTA", "5"))
+	envSignupIpQuotaWindowInSeconds, _ = strconv.Atoi(lookupEnv("SIGNUP_IP_QUOTA_WINDOW", "3600"))
+	envEmailPlusAddressing = lookupEnv("EMAIL_PLUS_ADDRESSING", "dedupe")
+	envEmailDriver = lookupEnv("EMAIL_DRIVER", "smtp")
+	envEmailFileDir = lookupEnv("EMAIL_FILE_DIR", "logs/mail")
+	envEmailSmtpTls = lookupEnv("EMAIL_SMTP_TLS", "starttls")
+	envEmailSmtpPoolSize, _ = strconv.Atoi(lookupEnv("EMAIL_SMTP_POOL_SIZE", "4"))
+	envEmailSmtpIdleTimeoutInSeconds, _ = strconv.Atoi(lookupEnv("EMAIL_SMTP_IDLE_TIMEOUT", "30"))
+	envEmailOutboxMaxAttempts, _ = strconv.Atoi(lookupEnv("EMAIL_OUTBOX_MAX_ATTEMPTS", "8"))
+	envEmailOutboxRetryDelayInSeconds, _ = strconv.Atoi(lookupEnv("EMAIL_OUTBOX_RETRY_DELAY", "30"))
+	envEmailOutboxPollIntervalInSeconds, _ = strconv.Atoi(lookupEnv("EMAIL_OUTBOX_POLL_INTERVAL", "5"))
+	envEmailOutboxRetentionInSeconds, _ = strconv.Atoi(lookupEnv("EMAIL_OUTBOX_RETENTION", "2592000"))
+	envTrustedProxies = lookupEnv("TRUSTED_PROXIES", "")
+}
+
 func getEnv(key string, defaultVal string) (string, error) {
 	val, ok := os.LookupEnv(key)
 	if val != "" || ok {
@@ -119,6 +299,15 @@ func getEnv(key string, defaultVal string) (string, error) {
 	return "", errors.New("failed to find environment variable: " + key)
 }
 
+// lookupEnv returns the value of an optional environment variable, or
+// defaultVal when it is not set.
+func lookupEnv(key string, defaultVal string) string {
+	if val, ok := os.LookupEnv(key); ok && val != "" {
+		return val
+	}
+	return defaultVal
+}
+
 func GetServerPort() string {
 	return envServerPort
 }
@@ -137,12 +326,60 @@ func GetJwtIssuer() string {
 
 func GetJwtExpiry() int {
 	if envJwtExpiryInSeconds == 0 {
-		envJwtExpiryInSeconds = 3600
+		envJwtExpiryInSeconds = 900
 	}
 
 	return envJwtExpiryInSeconds
 }
 
+func GetJwtRefreshExpiry() int {
+	if envJwtRefreshExpiryInSeconds == 0 {
+		envJwtRefreshExpiryInSeconds = 2592000
+	}
+
+	return envJwtRefreshExpiryInSeconds
+}
+
+// GetJwtSigningAlg returns the algorithm access tokens are signed with: HS256, RS256 or EdDSA.
+func GetJwtSigningAlg() string {
+	if envJwtSigningAlg == "" {
+		envJwtSigningAlg = "HS256"
+	}
+	return envJwtSigningAlg
+}
+
+// GetJwtKeysDir returns the directory holding the asymmetric signing keys.
+func GetJwtKeysDir() string {
+	if envJwtKeysDir == "" {
+		envJwtKeysDir = "keys"
+	}
+	return envJwtKeysDir
+}
+
+// GetJwtKeyRotation returns the lifetime of a signing key in seconds, 0 disables rotation.
+func GetJwtKeyRotation() int {
+	return envJwtKeyRotationInSeconds
+}
+
+// GetJwtKeyGrace returns how long, in seconds, a retired signing key is still accepted.
+// It defaults to the access token lifetime, so tokens signed right before a rotation stay valid.
+func GetJwtKeyGrace() int {
+	if envJwtKeyGraceInSeconds == 0 {
+		return GetJwtExpiry()
+	}
+	return envJwtKeyGraceInSeconds
+}
+
+// GetJwtHmacGrace returns how long, in seconds after startup, tokens signed with JWT_SECRET are
+// still accepted once JWT_SIGNING_ALG is RS256 or EdDSA, while migrating away from HS256.
+// 0 rejects them at once.
+func GetJwtHmacGrace() int {
+	if envJwtHmacGraceInSeconds < 0 {
+		return 0
+	}
+	return envJwtHmacGraceInSeconds
+}
+
 func GetEmailHost() string {
 	return envEmailHost
 }
@@ -178,17 +415,619 @@ func GetHelpCenterEmail() string {
 func GetHelpCenterAddress() string {
 	return envHelpCenterAddress
 }
+
+// GetLockoutThreshold returns the number of failed logins after which an account is locked.
+func GetLockoutThreshold() int {
+	if envLockoutThreshold <= 0 {
+		envLockoutThreshold = 5
+	}
+	return envLockoutThreshold
+}
+
+// GetLockoutIpThreshold returns the number of failed attempts after which an IP address is locked.
+func GetLockoutIpThreshold() int {
+	if envLockoutIpThreshold <= 0 {
+		envLockoutIpThreshold = 20
+	}
+	return envLockoutIpThreshold
+}
+
+// GetLockoutDuration returns the duration of a first lockout in seconds. Every further
+// lockout of the same account or IP address lasts twice as long as the previous one.
+func GetLockoutDuration() int {
+	if envLockoutDurationInSeconds <= 0 {
+		envLockoutDurationInSeconds = 900
+	}
+	return envLockoutDurationInSeconds
+}
+
+// GetLockoutWindow returns how long, in seconds, a failed attempt is remembered.
+func GetLockoutWindow() int {
+	if envLockoutWindowInSeconds <= 0 {
+		envLockoutWindowInSeconds = 3600
+	}
+	return envLockoutWindowInSeconds
+}
+
+// GetOtpMaxAttempts returns the number of wrong guesses after which an emailed OTP is invalidated.
+func GetOtpMaxAttempts() int {
+	if envOtpMaxAttempts <= 0 {
+		envOtpMaxAttempts = 5
+	}
+	return envOtpMaxAttempts
+}
+
+// GetWebAuthnRPID returns the relying party ID passkeys are bound to, the domain of the application.
+func GetWebAuthnRPID() string {
+	if envWebAuthnRPID == "" {
+		envWebAuthnRPID = "localhost"
+	}
+	return envWebAuthnRPID
+}
+
+// GetWebAuthnRPName returns the relying party name shown by authenticators, the JWT issuer by default.
+func GetWebAuthnRPName() string {
+	if envWebAuthnRPName == "" {
+		return GetJwtIssuer()
+	}
+	return envWebAuthnRPName
+}
+
+// GetWebAuthnRPOrigins returns the origins passkey ceremonies may be performed from.
+// Defaults to the local server when none are configured.
+func GetWebAuthnRPOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(envWebAuthnRPOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	if len(origins) == 0 {
+		origins = []string{"http://localhost:" + GetServerPort()}
+	}
+	return origins
+}
+
+// GetEmailLoginEnabled reports whether users may sign in with a link or code sent by email instead of a password.
+func GetEmailLoginEnabled() bool {
+	return envEmailLoginEnabled
+}
+
+// GetEmailLoginUrl returns the page sign-in links point to. The token is appended as the token query parameter.
+func GetEmailLoginUrl() string {
+	if envEmailLoginUrl == "" {
+		return "http://localhost:" + GetServerPort() + "/login/email"
+	}
+	return envEmailLoginUrl
+}
+
+// GetSocialRedirectUrl returns the page identity providers redirect back to. The name of the
+// provider is appended as the last path segment, so every provider has its own callback URL.
+func GetSocialRedirectUrl() string {
+	if envSocialRedirectUrl == "" {
+		return "http://localhost:" + GetServerPort() + "/login/social"
+	}
+	return strings.TrimSuffix(envSocialRedirectUrl, "/")
+}
+
+// GetSocialGoogleClient returns the OAuth2 client ID and secret registered at Google.
+// Sign-in with Google is disabled when the client ID is empty.
+func GetSocialGoogleClient() (string, string) {
+	return envSocialGoogleClientId, envSocialGoogleClientSecret
+}
+
+// GetSocialGithubClient returns the OAuth2 client ID and secret registered at GitHub.
+// Sign-in with GitHub is disabled when the client ID is empty.
+func GetSocialGithubClient() (string, string) {
+	return envSocialGithubClientId, envSocialGithubClientSecret
+}
+
+// GetSocialOidcName returns the name the generic OpenID Connect provider is known by, oidc by default.
+func GetSocialOidcName() string {
+	if envSocialOidcName == "" {
+		envSocialOidcName = "oidc"
+	}
+	return envSocialOidcName
+}
+
+// GetSocialOidcClient returns the client ID and secret registered at the generic OpenID Connect provider.
+// Sign-in with the provider is disabled when the client ID or the discovery URL is empty.
+func GetSocialOidcClient() (string, string) {
+	return envSocialOidcClientId, envSocialOidcClientSecret
+}
+
+// GetSocialOidcDiscoveryUrl returns the URL of the discovery document of the generic OpenID Connect provider.
+func GetSocialOidcDiscoveryUrl() string {
+	return envSocialOidcDiscoveryUrl
+}
+
+// GetOidcIssuerUrl returns the issuer identifier of the tokens Rasta issues to partner apps as an
+// OpenID Connect provider. The discovery document is served below it at /.well-known/openid-configuration.
+func GetOidcIssuerUrl() string {
+	if envOidcIssuerUrl == "" {
+		return "http://localhost:" + GetServerPort()
+	}
+	return strings.TrimSuffix(envOidcIssuerUrl, "/")
+}
+
+// GetOidcConsentUrl returns the page users are sent to when a partner app asks them to sign in.
+// The authorization request is passed on in the query string.
+func GetOidcConsentUrl() string {
+	if envOidcConsentUrl == "" {
+		return "http://localhost:" + GetServerPort() + "/oidc/consent"
+	}
+	return envOidcConsentUrl
+}
+
+// GetApiKeyRateLimit returns how many requests per minute an API key may make unless it was given its own limit.
+func GetApiKeyRateLimit() int {
+	if envApiKeyRateLimit <= 0 {
+		return 60
+	}
+	return envApiKeyRateLimit
+}
+
+// GetApiKeyMaxRateLimit returns the highest rate limit, in requests per minute, an API key may be given.
+func GetApiKeyMaxRateLimit() int {
+	if envApiKeyMaxRateLimit < GetApiKeyRateLimit() {
+		return GetApiKeyRateLimit()
+	}
+	return envApiKeyMaxRateLimit
+}
+
+// GetImpersonationExpiry returns how long, in seconds, an impersonation token issued to an admin stays valid.
+func GetImpersonationExpiry() int {
+	if envImpersonationExpiryInSeconds <= 0 {
+		return 900
+	}
+	return envImpersonationExpiryInSeconds
+}
+
+// GetEmailChangeCancelUrl returns the page the links cancelling an email change point to. The token is
+// appended as the token query parameter.
+func GetEmailChangeCancelUrl() string {
+	if envEmailChangeCancelUrl == "" {
+		return "http://localhost:" + GetServerPort() + "/email-change/cancel"
+	}
+	return envEmailChangeCancelUrl
+}
+
+// GetEmailChangeCancelWindow returns how long, in seconds, the old address of a user may cancel or revert
+// a change of their email address.
+func GetEmailChangeCancelWindow() int {
+	if envEmailChangeCancelWindowInSeconds <= 0 {
+		return 604800
+	}
+	return envEmailChangeCancelWindowInSeconds
+}
+
+// GetAccountDeletionGrace returns how long, in seconds, an account scheduled for deletion is kept, during
+// which the user may cancel the deletion.
+func GetAccountDeletionGrace() int {
+	if envAccountDeletionGraceInSeconds <= 0 {
+		return 2592000
+	}
+	return envAccountDeletionGraceInSeconds
+}
+
+// GetDataExportExpiry returns how long, in seconds, a data export can be downloaded once it is ready.
+func GetDataExportExpiry() int {
+	if envDataExportExpiryInSeconds <= 0 {
+		return 604800
+	}
+	return envDataExportExpiryInSeconds
+}
+
+// GetPasswordHashAlgorithm returns the algorithm new password hashes are made with, argon2id or bcrypt.
+func GetPasswordHashAlgorithm() string {
+	if envPasswordHashAlgorithm == "" {
+		return "argon2id"
+	}
+	return envPasswordHashAlgorithm
+}
+
+// GetArgon2Memory returns the memory, in KiB, an argon2id password hash uses.
+func GetArgon2Memory() int {
+	if envArgon2Memory <= 0 {
+		return 65536
+	}
+	return envArgon2Memory
+}
+
+// GetArgon2Iterations returns the number of passes an argon2id password hash makes over its memory.
+func GetArgon2Iterations() int {
+	if envArgon2Iterations <= 0 {
+		return 3
+	}
+	return envArgon2Iterations
+}
+
+// GetArgon2Parallelism returns the number of threads an argon2id password hash uses.
+func GetArgon2Parallelism() int {
+	if envArgon2Parallelism <= 0 || envArgon2Parallelism > 255 {
+		return 2
+	}
+	return envArgon2Parallelism
+}
+
+// GetBcryptCost returns the cost of bcrypt password hashes, used when PASSWORD_HASH_ALGORITHM is bcrypt.
+func GetBcryptCost() int {
+	if envBcryptCost < 10 || envBcryptCost > 31 {
+		return 12
+	}
+	return envBcryptCost
+}
+
+// GetPasswordMinLength returns the number of characters a password must at least have.
+func GetPasswordMinLength() int {
+	if envPasswordMinLength <= 0 {
+		return 8
+	}
+	return envPasswordMinLength
+}
+
+// GetPasswordMaxLength returns the number of characters a password may at most have.
+func GetPasswordMaxLength() int {
+	if envPasswordMaxLength < GetPasswordMinLength() {
+		return max(128, GetPasswordMinLength())
+	}
+	return envPasswordMaxLength
+}
+
+// GetPasswordRequireUppercase reports whether a password must contain an uppercase letter.
+func GetPasswordRequireUppercase() bool {
+	return envPasswordRequireUppercase
+}
+
+// GetPasswordRequireLowercase reports whether a password must contain a lowercase letter.
+func GetPasswordRequireLowercase() bool {
+	return envPasswordRequireLowercase
+}
+
+// GetPasswordRequireDigit reports whether a password must contain a digit.
+func GetPasswordRequireDigit() bool {
+	return envPasswordRequireDigit
+}
+
+// GetPasswordRequireSymbol reports whether a password must contain a character other than a letter or a digit.
+func GetPasswordRequireSymbol() bool {
+	return envPasswordRequireSymbol
+}
+
+// GetPasswordMinStrength returns the strength score, from 0 to 4, a password must at least have.
+func GetPasswordMinStrength() int {
+	if envPasswordMinStrength < 0 || envPasswordMinStrength > 4 {
+		return 2
+	}
+	return envPasswordMinStrength
+}
+
+// GetPasswordBreachedList returns the file of breached passwords that are refused, or an empty string if none is.
+func GetPasswordBreachedList() string {
+	return envPasswordBreachedList
+}
+
+// GetPasswordHistory returns the number of most recent passwords of a user, the current one included,
+// a new password must differ from. 0 allows reusing any password.
+func GetPasswordHistory() int {
+	if envPasswordHistory < 0 {
+		return 5
+	}
+	return envPasswordHistory
+}
+
+// GetGeoIPDatabase returns the CSV file IP addresses are located with, or an empty string if none is.
+func GetGeoIPDatabase() string {
+	return envGeoIPDatabase
+}
+
+// GetLoginReportUrl returns the page the link of a new device notification points to, with which
+// the user reports a login that was not theirs. The token is appended as the token query parameter.
+func GetLoginReportUrl() string {
+	if envLoginReportUrl == "" {
+		return "http://localhost:" + GetServerPort() + "/login/report"
+	}
+	return envLoginReportUrl
+}
+
+// GetLoginReportWindow returns the number of seconds the link of a new device notification works.
+func GetLoginReportWindow() int {
+	if envLoginReportWindowInSeconds <= 0 {
+		return 604800
+	}
+	return envLoginReportWindowInSeconds
+}
+
+// GetEncryptionKeys returns the master keys sensitive columns are encrypted with, as a comma
+// separated list of "id:base64key". The first key encrypts new values, the others are retired.
+func GetEncryptionKeys() string {
+	return envEncryptionKeys
+}
+
+// GetSmsDriver returns how text messages are delivered: console, file or http.
+func GetSmsDriver() string {
+	if envSmsDriver == "" {
+		return "console"
+	}
+	return envSmsDriver
+}
+
+// GetSmsFile returns the file the file SMS driver appends messages to.
+func GetSmsFile() string {
+	if envSmsFile == "" {
+		return "logs/sms.log"
+	}
+	return envSmsFile
+}
+
+// GetSmsHttpUrl returns the endpoint of the SMS gateway the http SMS driver posts messages to.
+func GetSmsHttpUrl() string {
+	return envSmsHttpUrl
+}
+
+// GetSmsHttpToken returns the bearer token the http SMS driver authenticates with, if any.
+func GetSmsHttpToken() string {
+	return envSmsHttpToken
+}
+
+// GetSmsFrom returns the sender ID or number text messages are sent from, if the gateway needs one.
+func GetSmsFrom() string {
+	return envSmsFrom
+}
+
+// GetSmsCodeExpiry returns the number of seconds a code sent by SMS is valid.
+func GetSmsCodeExpiry() int {
+	if envSmsCodeExpiryInSeconds <= 0 {
+		return 300
+	}
+	return envSmsCodeExpiryInSeconds
+}
+
+// GetSmsResendInterval returns the number of seconds before another code may be sent by SMS for
+// the same purpose, 0 allows resending right away.
+func GetSmsResendInterval() int {
+	if envSmsResendIntervalInSeconds < 0 {
+		return 60
+	}
+	return envSmsResendIntervalInSeconds
+}
+
+// GetSignupBlockedDomains returns the file of email domains refused at signup, such as disposable
+// email providers, or an empty string if none is.
+func GetSignupBlockedDomains() string {
+	return envSignupBlockedDomains
+}
+
+// GetSignupStrictEmailDomain reports whether the domain of the email address of a new user must be
+// a well-formed host name, without looking it up.
+func GetSignupStrictEmailDomain() bool {
+	return envSignupStrictEmailDomain
+}
+
+// GetSignupPowDifficulty returns the number of leading zero bits the proof of work solving a signup
+// challenge must have, 0 disables signup challenges.
+func GetSignupPowDifficulty() int {
+	if envSignupPowDifficulty < 0 || envSignupPowDifficulty > 32 {
+		return 20
+	}
+	return envSignupPowDifficulty
+}
+
+// GetSignupChallengeExpiry returns the number of seconds a signup challenge may be solved in.
+func GetSignupChallengeExpiry() int {
+	if envSignupChallengeExpiryInSeconds <= 0 {
+		return 300
+	}
+	return envSignupChallengeExpiryInSeconds
+}
+
+// GetSignupChallengeSecret returns the secret signup challenges are signed with, or an empty string
+// if a random one is generated at startup, which does not work across several instances.
+func GetSignupChallengeSecret() string {
+	return envSignupChallengeSecret
+}
+
+// GetSignupIpQuota returns the number of accounts that may be signed up from an IP address every
+// SIGNUP_IP_QUOTA_WINDOW seconds, 0 lifts the quota.
+func GetSignupIpQuota() int {
+	if envSignupIpQuota < 0 {
+		return 5
+	}
+	return envSignupIpQuota
+}
+
+// GetSignupIpQuotaWindow returns the number of seconds the signups of an IP address are counted over.
+func GetSignupIpQuotaWindow() int {
+	if envSignupIpQuotaWindowInSeconds <= 0 {
+		return 3600
+	}
+	return envSignupIpQuotaWindowInSeconds
+}
+
+// GetEmailPlusAddressing returns how email addresses with a plus tag, such as jane+news@example.com,
+// are handled: allow accepts them as distinct addresses, dedupe accepts them but refuses an account
+// for an address that is another one but for its tag, and reject refuses them.
+func GetEmailPlusAddressing() string {
+	switch envEmailPlusAddressing {
+	case "allow", "dedupe", "reject":
+		return envEmailPlusAddressing
+	default:
+		return "dedupe"
+	}
+}
+
+// GetEmailDriver returns how emails are delivered: smtp, file or memory.
+func GetEmailDriver() string {
+	if envEmailDriver == "" {
+		return "smtp"
+	}
+	return envEmailDriver
+}
+
+// GetEmailFileDir returns the maildir the file email driver saves messages to.
+func GetEmailFileDir() string {
+	if envEmailFileDir == "" {
+		return "logs/mail"
+	}
+	return envEmailFileDir
+}
+
+// GetEmailSmtpTls returns how connections to the SMTP server are secured: tls, starttls or none.
+func GetEmailSmtpTls() string {
+	if envEmailSmtpTls == "" {
+		return "starttls"
+	}
+	return envEmailSmtpTls
+}
+
+// GetEmailSmtpPoolSize returns the number of connections to the SMTP server kept open between
+// messages. 0 closes every connection once its message is sent.
+func GetEmailSmtpPoolSize() int {
+	if envEmailSmtpPoolSize < 0 {
+		return 4
+	}
+	return envEmailSmtpPoolSize
+}
+
+// GetEmailSmtpIdleTimeout returns the number of seconds an unused connection to the SMTP server is
+// kept open.
+func GetEmailSmtpIdleTimeout() int {
+	if envEmailSmtpIdleTimeoutInSeconds <= 0 {
+		return 30
+	}
+	return envEmailSmtpIdleTimeoutInSeconds
+}
+
+// GetEmailOutboxMaxAttempts returns the number of attempts at delivering an email after which it is
+// given up on and marked as failed.
+func GetEmailOutboxMaxAttempts() int {
+	if envEmailOutboxMaxAttempts <= 0 {
+		return 8
+	}
+	return envEmailOutboxMaxAttempts
+}
+
+// GetEmailOutboxRetryDelay returns the number of seconds before an email that failed to be delivered
+// is retried for the first time. The delay doubles with every failed attempt.
+func GetEmailOutboxRetryDelay() int {
+	if envEmailOutboxRetryDelayInSeconds <= 0 {
+		return 30
+	}
+	return envEmailOutboxRetryDelayInSeconds
+}
+
+// GetEmailOutboxPollInterval returns the number of seconds between two looks for emails to deliver.
+func GetEmailOutboxPollInterval() int {
+	if envEmailOutboxPollIntervalInSeconds <= 0 {
+		return 5
+	}
+	return envEmailOutboxPollIntervalInSeconds
+}
+
+// GetEmailOutboxRetention returns the number of seconds emails are kept once sent or given up on.
+// 0 keeps them forever.
+func GetEmailOutboxRetention() int {
+	if envEmailOutboxRetentionInSeconds < 0 {
+		return 2592000
+	}
+	return envEmailOutboxRetentionInSeconds
+}
+
+// GetTrustedProxies returns the IP addresses and CIDR ranges of the reverse proxies whose
+// X-Forwarded-For and X-Real-IP headers are trusted to tell the address of the client. None are
+// trusted by default, so clients cannot pick the address rate limits and lockouts are kept for.
+func GetTrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(envTrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
 func GetEnvVars() map[string]any {
 	return map[string]any{
-		"SERVER_PORT":      envServerPort,
-		"DB_STRING":        envDBString,
-		"JWT_SECRET":       envJwtSecret,
-		"JWT_ISSUER":       envJwtIssuer,
-		"JWT_EXPIRY":       envJwtExpiryInSeconds,
-		"EMAIL_HOST":       envEmailHost,
-		"EMAIL_PORT":       envEmailPort,
-		"EMAIL_USERNAME":   envEmailUsername,
-		"EMAIL_PASSWORD":   envEmailPassword,
-		"EMAIL_OTP_EXPIRY": envEmailOTPExpiry,
+		"SERVER_PORT":                envServerPort,
+		"DB_STRING":                  envDBString,
+		"JWT_SECRET":                 envJwtSecret,
+		"JWT_ISSUER":                 envJwtIssuer,
+		"JWT_EXPIRY":                 envJwtExpiryInSeconds,
+		"JWT_REFRESH_EXPIRY":         envJwtRefreshExpiryInSeconds,
+		"JWT_SIGNING_ALG":            envJwtSigningAlg,
+		"JWT_KEYS_DIR":               envJwtKeysDir,
+		"JWT_KEY_ROTATION":           envJwtKeyRotationInSeconds,
+		"JWT_KEY_GRACE":              envJwtKeyGraceInSeconds,
+		"JWT_HMAC_GRACE":             envJwtHmacGraceInSeconds,
+		"EMAIL_HOST":                 envEmailHost,
+		"EMAIL_PORT":                 envEmailPort,
+		"EMAIL_USERNAME":             envEmailUsername,
+		"EMAIL_PASSWORD":             envEmailPassword,
+		"EMAIL_OTP_EXPIRY":           envEmailOTPExpiry,
+		"LOCKOUT_THRESHOLD":          envLockoutThreshold,
+		"LOCKOUT_IP_THRESHOLD":       envLockoutIpThreshold,
+		"LOCKOUT_DURATION":           envLockoutDurationInSeconds,
+		"LOCKOUT_WINDOW":             envLockoutWindowInSeconds,
+		"OTP_MAX_ATTEMPTS":           envOtpMaxAttempts,
+		"WEBAUTHN_RP_ID":             envWebAuthnRPID,
+		"WEBAUTHN_RP_NAME":           envWebAuthnRPName,
+		"WEBAUTHN_RP_ORIGINS":        envWebAuthnRPOrigins,
+		"EMAIL_LOGIN_ENABLED":        envEmailLoginEnabled,
+		"EMAIL_LOGIN_URL":            envEmailLoginUrl,
+		"SOCIAL_REDIRECT_URL":        envSocialRedirectUrl,
+		"SOCIAL_GOOGLE_CLIENT_ID":    envSocialGoogleClientId,
+		"SOCIAL_GITHUB_CLIENT_ID":    envSocialGithubClientId,
+		"SOCIAL_OIDC_NAME":           envSocialOidcName,
+		"SOCIAL_OIDC_CLIENT_ID":      envSocialOidcClientId,
+		"SOCIAL_OIDC_DISCOVERY_URL":  envSocialOidcDiscoveryUrl,
+		"OIDC_ISSUER_URL":            envOidcIssuerUrl,
+		"OIDC_CONSENT_URL":           envOidcConsentUrl,
+		"API_KEY_RATE_LIMIT":         envApiKeyRateLimit,
+		"API_KEY_MAX_RATE_LIMIT":     envApiKeyMaxRateLimit,
+		"IMPERSONATION_EXPIRY":       envImpersonationExpiryInSeconds,
+		"EMAIL_CHANGE_CANCEL_URL":    envEmailChangeCancelUrl,
+		"EMAIL_CHANGE_CANCEL_WINDOW": envEmailChangeCancelWindowInSeconds,
+		"ACCOUNT_DELETION_GRACE":     envAccountDeletionGraceInSeconds,
+		"DATA_EXPORT_EXPIRY":         envDataExportExpiryInSeconds,
+		"SMS_DRIVER":                 envSmsDriver,
+		"SMS_FILE":                   envSmsFile,
+		"SMS_HTTP_URL":               envSmsHttpUrl,
+		"SMS_FROM":                   envSmsFrom,
+		"SMS_CODE_EXPIRY":            envSmsCodeExpiryInSeconds,
+		"SMS_RESEND_INTERVAL":        envSmsResendIntervalInSeconds,
+		"PASSWORD_HASH_ALGORITHM":    envPasswordHashAlgorithm,
+		"ARGON2_MEMORY":              envArgon2Memory,
+		"ARGON2_ITERATIONS":          envArgon2Iterations,
+		"ARGON2_PARALLELISM":         envArgon2Parallelism,
+		"BCRYPT_COST":                envBcryptCost,
+		"PASSWORD_MIN_LENGTH":        envPasswordMinLength,
+		"PASSWORD_MAX_LENGTH":        envPasswordMaxLength,
+		"PASSWORD_REQUIRE_UPPERCASE": envPasswordRequireUppercase,
+		"PASSWORD_REQUIRE_LOWERCASE": envPasswordRequireLowercase,
+		"PASSWORD_REQUIRE_DIGIT":     envPasswordRequireDigit,
+		"PASSWORD_REQUIRE_SYMBOL":    envPasswordRequireSymbol,
+		"PASSWORD_MIN_STRENGTH":      envPasswordMinStrength,
+		"PASSWORD_BREACHED_LIST":     envPasswordBreachedList,
+		"PASSWORD_HISTORY":           envPasswordHistory,
+		"GEOIP_DATABASE":             envGeoIPDatabase,
+		"LOGIN_REPORT_URL":           envLoginReportUrl,
+		"LOGIN_REPORT_WINDOW":        envLoginReportWindowInSeconds,
+		"SIGNUP_BLOCKED_DOMAINS":     envSignupBlockedDomains,
+		"SIGNUP_STRICT_EMAIL_DOMAIN": envSignupStrictEmailDomain,
+		"SIGNUP_POW_DIFFICULTY":      envSignupPowDifficulty,
+		"SIGNUP_CHALLENGE_EXPIRY":    envSignupChallengeExpiryInSeconds,
+		"SIGNUP_IP_QUOTA":            envSignupIpQuota,
+		"SIGNUP_IP_QUOTA_WINDOW":     envSignupIpQuotaWindowInSeconds,
+		"EMAIL_PLUS_ADDRESSING":      envEmailPlusAddressing,
+		"EMAIL_DRIVER":               envEmailDriver,
+		"EMAIL_FILE_DIR":             envEmailFileDir,
+		"EMAIL_SMTP_TLS":             envEmailSmtpTls,
+		"EMAIL_SMTP_POOL_SIZE":       envEmailSmtpPoolSize,
+		"EMAIL_SMTP_IDLE_TIMEOUT":    envEmailSmtpIdleTimeoutInSeconds,
+		"EMAIL_OUTBOX_MAX_ATTEMPTS":  envEmailOutboxMaxAttempts,
+		"EMAIL_OUTBOX_RETRY_DELAY":   envEmailOutboxRetryDelayInSeconds,
+		"EMAIL_OUTBOX_POLL_INTERVAL": envEmailOutboxPollIntervalInSeconds,
+		"EMAIL_OUTBOX_RETENTION":     envEmailOutboxRetentionInSeconds,
+		"TRUSTED_PROXIES":            envTrustedProxies,
 	}
 }
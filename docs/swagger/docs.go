@@ -15,24 +15,50 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/admin/users": {
+        "/admin/audit-logs": {
             "get": {
-                "description": "Get a list of users with pagination support",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Lists the audit log, most recent entries first, such as every request made by an admin while impersonating a user. Entries can be filtered by action, actor, user and session.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Audit"
                 ],
-                "summary": "Get users with pagination",
+                "summary": "List audit log entries",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Action, e.g. impersonation.start or impersonation.request",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the account that performed the action",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the account the action was performed on",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the session the action was performed in",
+                        "name": "session_id",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "default": 10,
-                        "description": "Number of users per page",
+                        "description": "Number of entries per page, at most 100",
                         "name": "limit",
                         "in": "query"
                     },
@@ -48,64 +74,168 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.GenericResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/auditDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/auditDTO.AuditLogPage"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.GenericResponse"
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     }
                 }
             }
         },
-        "/admin/users/count": {
+        "/admin/emails": {
             "get": {
-                "description": "Retrieve the total count of users in the system",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Lists the emails of the outbox, most recent first, with their delivery status: queued, sent, failed after too many attempts, or bounced. Bodies are never returned. Emails can be filtered by status, kind, recipient and user.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Emails"
+                ],
+                "summary": "List outbox emails",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Status: queued, sent, failed or bounced",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Kind, e.g. verify_email, reset_password, login_link, email_change_code, new_login, account_locked or newsletter",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Email address of the recipient",
+                        "name": "recipient",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user the email was sent to",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of emails per page, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get the total number of users",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.GenericResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/emailDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/emailDTO.OutboxEmailPage"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.GenericResponse"
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     }
                 }
             }
         },
-        "/admin/users/id/{id}": {
+        "/admin/emails/{id}": {
             "get": {
-                "description": "Retrieve user details by their ID",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Returns an email of the outbox with its delivery status and the error of its last failed attempt, if any. The body is never returned.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Emails"
                 ],
-                "summary": "Get user by ID",
+                "summary": "Get an outbox email",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
+                        "description": "Email ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -115,33 +245,67 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.GenericResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/emailDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/emailDTO.OutboxEmail"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
                     "404": {
-                        "description": "Not Found",
+                        "description": "Email not found",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.GenericResponse"
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a user by their ID",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/admin/emails/{id}/resend": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Queues an email that was sent, failed or bounced to be delivered again, with a fresh count of attempts. Codes and links it holds may have expired since it was first sent.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Emails"
                 ],
-                "summary": "Delete a user",
+                "summary": "Resend an outbox email",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
+                        "description": "Email ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -151,54 +315,105 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.GenericResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/emailDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/emailDTO.OutboxEmail"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Email not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "409": {
+                        "description": "Email already queued",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.GenericResponse"
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     }
                 }
             }
         },
-        "/newsletter/delete": {
-            "delete": {
-                "description": "Deletes a subscriber from the newsletter system using the provided email address.",
-                "consumes": [
-                    "application/json"
+        "/admin/oidc/clients": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Lists every partner app allowed to sign users in with their Rasta account.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Newsletter"
-                ],
-                "summary": "Delete Subscriber",
-                "parameters": [
-                    {
-                        "description": "User email",
-                        "name": "email",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
+                    "OpenID Connect Clients"
                 ],
+                "summary": "List partner apps",
                 "responses": {
                     "200": {
-                        "description": "Successfully deleted subscriber",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/newsletterDTO.GenericResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/oidcDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/oidcDTO.Client"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "400": {
-                        "description": "Invalid request body",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
@@ -206,15 +421,18 @@ const docTemplate = `{
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/commonerrors.GenericResponseError"
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     }
                 }
-            }
-        },
-        "/newsletter/send": {
+            },
             "post": {
-                "description": "Sends the newsletter email to all active subscribers.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Registers a partner app. Confidential clients get a client secret, which is only returned in this response; public clients, such as single-page and mobile apps, get none and rely on PKCE alone.",
                 "consumes": [
                     "application/json"
                 ],
@@ -222,29 +440,53 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Newsletter"
+                    "OpenID Connect Clients"
                 ],
-                "summary": "Send Newsletter to Active Subscribers",
+                "summary": "Register a partner app",
                 "parameters": [
                     {
-                        "description": "Newsletter content and limit",
-                        "name": "newsletter",
+                        "description": "Client payload",
+                        "name": "client",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/newsletterDTO.CreateNewsletterRequest"
+                            "$ref": "#/definitions/oidcDTO.ClientRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Successfully sent newsletter to all active participants",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/newsletterDTO.GenericResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/oidcDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/oidcDTO.ClientSecret"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
-                        "description": "Invalid request body",
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
@@ -252,109 +494,90 @@ const docTemplate = `{
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/commonerrors.GenericResponseError"
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     }
                 }
             }
         },
-        "/newsletter/subscribe": {
-            "post": {
-                "description": "Subscribes the user to the newsletter with the provided email address.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/oidc/clients/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Renames a partner app and replaces its redirect URIs.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Newsletter"
+                    "OpenID Connect Clients"
                 ],
-                "summary": "Subscribe to Newsletter",
+                "summary": "Update a partner app",
                 "parameters": [
                     {
-                        "description": "User email",
-                        "name": "email",
+                        "type": "string",
+                        "description": "Client ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Client payload",
+                        "name": "client",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/oidcDTO.ClientUpdate"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Successfully subscribed to newsletter",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/newsletterDTO.GenericResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/oidcDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/oidcDTO.Client"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
-                        "description": "Invalid request body",
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
-                    "406": {
-                        "description": "Email already subscribed",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
-                    }
-                }
-            }
-        },
-        "/newsletter/subscribers": {
-            "get": {
-                "description": "Retrieves a list of all active newsletter subscribers.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Newsletter"
-                ],
-                "summary": "Get Active Subscribers",
-                "responses": {
-                    "200": {
-                        "description": "Successfully fetched subscribers",
-                        "schema": {
-                            "$ref": "#/definitions/newsletterDTO.GenericResponse"
-                        }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Client not found",
                         "schema": {
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
-                    }
-                }
-            }
-        },
-        "/newsletter/subscribers/count": {
-            "get": {
-                "description": "Retrieves the count of active newsletter subscribers.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Newsletter"
-                ],
-                "summary": "Get Active Subscribers Count",
-                "responses": {
-                    "200": {
-                        "description": "Successfully fetched subscribers count",
-                        "schema": {
-                            "$ref": "#/definitions/newsletterDTO.GenericResponse"
-                        }
                     },
                     "500": {
                         "description": "Internal Server Error",
@@ -363,78 +586,59 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/newsletter/unsubscribe": {
-            "post": {
-                "description": "Unsubscribes the user from the newsletter using the provided email address.",
-                "consumes": [
-                    "application/json"
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Deletes a partner app together with the consents users gave it. Tokens already issued to the app stay valid until they expire.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Newsletter"
+                    "OpenID Connect Clients"
                 ],
-                "summary": "Unsubscribe from Newsletter",
+                "summary": "Delete a partner app",
                 "parameters": [
                     {
-                        "description": "User email",
-                        "name": "email",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
+                        "type": "string",
+                        "description": "Client ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully unsubscribed from newsletter",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/newsletterDTO.GenericResponse"
+                            "$ref": "#/definitions/oidcDTO.GenericResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid request body",
+                        "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
-                    "406": {
-                        "description": "Email not subscribed",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
-                    }
-                }
-            }
-        },
-        "/newsletter/unsubscribed/count": {
-            "get": {
-                "description": "Retrieves the count of unsubscribed users from the newsletter.",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Newsletter"
-                ],
-                "summary": "Get Unsubscribed Count",
-                "responses": {
-                    "200": {
-                        "description": "Successfully fetched unsubscribed count",
+                    },
+                    "404": {
+                        "description": "Client not found",
                         "schema": {
-                            "$ref": "#/definitions/newsletterDTO.GenericResponse"
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
                     "500": {
@@ -446,99 +650,112 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/login": {
+        "/admin/oidc/clients/{id}/secret": {
             "post": {
-                "description": "Authenticates a user and returns a JWT token",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Issues a new client secret, which is only returned in this response. The previous secret stops working at once, and a public client becomes confidential.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "OpenID Connect Clients"
                 ],
-                "summary": "User login",
+                "summary": "Rotate the secret of a partner app",
                 "parameters": [
                     {
-                        "description": "User login payload",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/userDTO.UserLogin"
-                        }
+                        "type": "string",
+                        "description": "Client ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Accepted",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.LoginResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/oidcDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/oidcDTO.ClientSecret"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.GenericResponse"
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Client not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.GenericResponse"
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     }
                 }
             }
         },
-        "/users/oauth/disable": {
-            "delete": {
+        "/admin/roles": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Disables OAuth for the user's account by verifying the provided OAuth code.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lists every role together with the permissions it grants.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "OAuth"
-                ],
-                "summary": "Disable OAuth",
-                "parameters": [
-                    {
-                        "description": "OAuth code",
-                        "name": "oauth",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
+                    "Roles"
                 ],
+                "summary": "List roles",
                 "responses": {
                     "200": {
-                        "description": "OAuth disabled successfully",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/oauthDTO.Response"
+                            "$ref": "#/definitions/userDTO.GenericResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
@@ -550,16 +767,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/users/oauth/enable": {
+            },
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Verifies the OAuth code provided by the user and enables OAuth for the account.",
+                "description": "Creates a role granting the given permissions. Only permissions held by the caller, and granted to the API key the request is made with if any, may be granted.",
                 "consumes": [
                     "application/json"
                 ],
@@ -567,28 +782,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "OAuth"
+                    "Roles"
                 ],
-                "summary": "Verify and Enable OAuth",
+                "summary": "Create a role",
                 "parameters": [
                     {
-                        "description": "OAuth code",
-                        "name": "oauth",
+                        "description": "Role payload",
+                        "name": "role",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/userDTO.RoleRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OAuth enabled successfully",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/oauthDTO.Response"
+                            "$ref": "#/definitions/userDTO.GenericResponse"
                         }
                     },
                     "400": {
@@ -603,6 +815,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden or a permission not held by the caller",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "409": {
+                        "description": "Role already exists",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -612,26 +836,26 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/oauth/generate": {
+        "/admin/roles/permissions": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Generates an OAuth secret and URL for the user to enable OAuth.",
+                "description": "Lists every permission that can be granted through a role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "OAuth"
+                    "Roles"
                 ],
-                "summary": "Generate OAuth Secret and URL",
+                "summary": "List permissions",
                 "responses": {
                     "200": {
-                        "description": "OAuth secret and URL",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/oauthDTO.Response"
+                            "$ref": "#/definitions/userDTO.GenericResponse"
                         }
                     },
                     "401": {
@@ -640,8 +864,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
@@ -649,36 +873,45 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/otp/resend": {
-            "post": {
-                "description": "Resends the OTP to the user's email for verification purposes.",
-                "consumes": [
-                    "application/json"
+        "/admin/roles/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Updates the name and description of a role and replaces the permissions it grants. The caller must hold every permission the role grants, before and after the update, and so must the API key the request is made with if any.",
+                "consumes": [
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "OTP"
+                    "Roles"
                 ],
-                "summary": "Resend OTP to Email",
+                "summary": "Update a role",
                 "parameters": [
                     {
-                        "description": "User email",
-                        "name": "email",
+                        "type": "string",
+                        "description": "Role ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role payload",
+                        "name": "role",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/userDTO.RoleRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OTP sent successfully",
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/userDTO.GenericResponse"
                         }
@@ -689,6 +922,30 @@ const docTemplate = `{
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or a permission not held by the caller",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Role not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "409": {
+                        "description": "Role already exists",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -696,57 +953,51 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/users/otp/{id}/verify": {
-            "post": {
-                "description": "Verifies the user's email using the provided OTP. If successful, marks the email as verified and deletes the OTP.",
-                "consumes": [
-                    "application/json"
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Deletes a role and removes it from every user it is assigned to. The caller must hold every permission of the role, and so must the API key the request is made with if any.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "OTP"
+                    "Roles"
                 ],
-                "summary": "Verify Email with OTP",
+                "summary": "Delete a role",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
+                        "description": "Role ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "OTP code",
-                        "name": "otp",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Email verified successfully",
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/userDTO.GenericResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "403": {
+                        "description": "Forbidden or a permission not held by the caller",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Role not found",
                         "schema": {
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
@@ -760,42 +1011,51 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/reset-password": {
+        "/admin/service-accounts": {
             "get": {
-                "description": "Generates a password reset code and sends it to the user's email.",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Lists every service account.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Password Reset"
-                ],
-                "summary": "Send Password Reset Code",
-                "parameters": [
-                    {
-                        "description": "User email",
-                        "name": "email",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
+                    "Service Accounts"
                 ],
+                "summary": "List service accounts",
                 "responses": {
                     "200": {
-                        "description": "Password reset code sent successfully",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.GenericResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/userDTO.User"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
@@ -807,11 +1067,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/users/reset-password/{id}/verify": {
+            },
             "post": {
-                "description": "Verifies the provided OTP and, if valid, allows the user to reset their password.",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates a non-human account for an integration. It cannot sign in and authenticates with the API keys issued to it; its permissions are granted by assigning it roles.",
                 "consumes": [
                     "application/json"
                 ],
@@ -819,32 +1082,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Password Reset"
+                    "Service Accounts"
                 ],
-                "summary": "Verify OTP and Reset Password",
+                "summary": "Create a service account",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Password reset request body",
-                        "name": "ResetPassword",
+                        "description": "Service account payload",
+                        "name": "account",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/userDTO.ResetPassword"
+                            "$ref": "#/definitions/userDTO.ServiceAccountCreate"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Password reset successfully",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.GenericResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.User"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -859,8 +1127,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
-                    "406": {
-                        "description": "Not Acceptable",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "409": {
+                        "description": "Username already exists",
                         "schema": {
                             "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
@@ -874,28 +1148,28 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/signup": {
-            "post": {
-                "description": "Create a new user account and send a verification OTP email",
-                "consumes": [
-                    "application/json"
+        "/admin/service-accounts/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Deletes a service account and revokes every API key issued to it.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Service Accounts"
                 ],
-                "summary": "Create a new user",
+                "summary": "Delete a service account",
                 "parameters": [
                     {
-                        "description": "User creation payload",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/userDTO.UserCreate"
-                        }
+                        "type": "string",
+                        "description": "Service account ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -908,36 +1182,56 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.GenericResponse"
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Service account not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.GenericResponse"
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     }
                 }
             }
         },
-        "/users/{username}": {
+        "/admin/service-accounts/{id}/api-keys": {
             "get": {
-                "description": "Retrieve user details by their username",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Lists the API keys issued to a service account, with when and from where each was last used.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Service Accounts"
                 ],
-                "summary": "Get user by username",
+                "summary": "List the API keys of a service account",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Username",
-                        "name": "username",
+                        "description": "Service account ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -946,21 +1240,63 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.GenericResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/userDTO.ApiKey"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
                     "404": {
-                        "description": "Not Found",
+                        "description": "Service account not found",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.GenericResponse"
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     }
                 }
-            }
-        },
-        "/users/{username}/update-password": {
-            "put": {
-                "description": "Updates the password for the currently authenticated user",
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Issues an API key to a service account. The key is only returned in this response. It grants the permissions in scopes as far as the roles of the service account hold them.",
                 "consumes": [
                     "application/json"
                 ],
@@ -968,160 +1304,6888 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Service Accounts"
                 ],
-                "summary": "Update user password",
+                "summary": "Issue an API key to a service account",
                 "parameters": [
                     {
-                        "description": "Password update payload",
-                        "name": "updatePassword",
+                        "type": "string",
+                        "description": "Service account ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "API key payload",
+                        "name": "key",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/userDTO.UpdatePassword"
+                            "$ref": "#/definitions/userDTO.ApiKeyCreate"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.GenericResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.ApiKeyCreated"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/userDTO.GenericResponse"
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Service account not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "409": {
+                        "description": "Too many API keys",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/service-accounts/{id}/api-keys/{keyId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revokes an API key issued to a service account. Requests made with it are rejected at once.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Service Accounts"
+                ],
+                "summary": "Revoke an API key of a service account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Service account ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
                             "$ref": "#/definitions/userDTO.GenericResponse"
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Service account or API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/tickets": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the support tickets of every user, most recently updated first, without their comments. Tickets can be filtered by status, priority, category, author and assignee; set unassigned to list the tickets no one handles yet.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tickets"
+                ],
+                "summary": "List support tickets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Status: open, in progress, resolved or closed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Priority: low, medium, high or urgent",
+                        "name": "priority",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category: bug report, feature request, account issue or general query",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the user who opened the ticket",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the member of staff the ticket is assigned to",
+                        "name": "assigned_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only list the tickets assigned to no one",
+                        "name": "unassigned",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of tickets per page, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/ticketDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/ticketDTO.TicketPage"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/tickets/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns any support ticket with its comments, oldest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tickets"
+                ],
+                "summary": "Get a support ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/ticketDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/ticketDTO.Ticket"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Ticket not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Changes the status and the priority of a support ticket. Fields left out are unchanged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tickets"
+                ],
+                "summary": "Update a support ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Ticket payload",
+                        "name": "ticket",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ticketDTO.UpdateTicketRequest"
+                        }
                     }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/ticketDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/ticketDTO.Ticket"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Ticket not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/tickets/{id}/assignee": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Assigns a support ticket to a member of staff, who must hold the tickets.write permission, or unassigns it when assignee_id is left out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tickets"
+                ],
+                "summary": "Assign a support ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Assignee payload",
+                        "name": "assignee",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ticketDTO.AssignTicketRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/ticketDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/ticketDTO.Ticket"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Ticket not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/tickets/{id}/comments": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Adds a comment of the authenticated member of staff to any support ticket, closed or not.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tickets"
+                ],
+                "summary": "Reply to a support ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Comment payload",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ticketDTO.CommentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/ticketDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/ticketDTO.Comment"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Ticket not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users": {
+            "get": {
+                "description": "Get a list of users with pagination support",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get users with pagination",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of users per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/count": {
+            "get": {
+                "description": "Retrieve the total count of users in the system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get the total number of users",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/id/{id}": {
+            "get": {
+                "description": "Retrieve user details by their ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get user by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes a user account at once, without grace period, anonymizing it like a deletion requested by the user, and notifies the user by email. Admins and service accounts cannot be deleted this way.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Delete a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or the account cannot be deleted",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/id/{id}/impersonate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Issues the admin a token to act as a user, for instance to see what a buyer or seller sees. The token carries the admin in its act claim, expires after IMPERSONATION_EXPIRY seconds and cannot be refreshed. Responses to requests made with it carry the X-Impersonated-By header, and every request is recorded in the audit log together with the reason given here. The token cannot be used to change the password, second factors or other credentials of the user, nor on admin routes. Admins, service accounts and suspended users cannot be impersonated.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Impersonate a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason for the impersonation",
+                        "name": "impersonation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.ImpersonationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.Impersonation"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or the user cannot be impersonated",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/id/{id}/lockout": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Clears the failed logins of an account, lifting its lockout.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Unlock an account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/id/{id}/roles": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the roles assigned to a user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Roles"
+                ],
+                "summary": "List the roles of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Assigns a role to a user, granting the user every permission of the role. The caller must hold every permission of the role, and so must the API key the request is made with if any.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Roles"
+                ],
+                "summary": "Assign a role to a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role assignment payload",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.AssignRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or a permission not held by the caller",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "User or role not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/id/{id}/roles/{roleId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Removes a role from a user, revoking the permissions granted through it. The caller must hold every permission of the role, and so must the API key the request is made with if any.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Roles"
+                ],
+                "summary": "Remove a role from a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Role ID",
+                        "name": "roleId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden or a permission not held by the caller",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "User or role not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/id/{id}/suspend": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Suspends a user account with a reason and an optional end date, logs the user out of every session and notifies the user by email. Suspended users cannot log in and their tokens are rejected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Suspend a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Suspension payload",
+                        "name": "suspension",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.SuspendUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/id/{id}/unsuspend": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lifts the suspension of a user account and notifies the user by email.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Unsuspend a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/locked": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the accounts currently locked after too many failed logins.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "List locked accounts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/newsletter/delete": {
+            "delete": {
+                "description": "Deletes a subscriber from the newsletter system using the provided email address.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Newsletter"
+                ],
+                "summary": "Delete Subscriber",
+                "parameters": [
+                    {
+                        "description": "User email",
+                        "name": "email",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully deleted subscriber",
+                        "schema": {
+                            "$ref": "#/definitions/newsletterDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.GenericResponseError"
+                        }
+                    }
+                }
+            }
+        },
+        "/newsletter/send": {
+            "post": {
+                "description": "Queues the newsletter email to all active subscribers in the email outbox, from which it is delivered in the background.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Newsletter"
+                ],
+                "summary": "Send Newsletter to Active Subscribers",
+                "parameters": [
+                    {
+                        "description": "Newsletter content and limit",
+                        "name": "newsletter",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/newsletterDTO.CreateNewsletterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully sent newsletter to all active participants",
+                        "schema": {
+                            "$ref": "#/definitions/newsletterDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.GenericResponseError"
+                        }
+                    }
+                }
+            }
+        },
+        "/newsletter/subscribe": {
+            "post": {
+                "description": "Subscribes the user to the newsletter with the provided email address.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Newsletter"
+                ],
+                "summary": "Subscribe to Newsletter",
+                "parameters": [
+                    {
+                        "description": "User email",
+                        "name": "email",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Successfully subscribed to newsletter",
+                        "schema": {
+                            "$ref": "#/definitions/newsletterDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "406": {
+                        "description": "Email already subscribed",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/newsletter/subscribers": {
+            "get": {
+                "description": "Retrieves a list of all active newsletter subscribers.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Newsletter"
+                ],
+                "summary": "Get Active Subscribers",
+                "responses": {
+                    "200": {
+                        "description": "Successfully fetched subscribers",
+                        "schema": {
+                            "$ref": "#/definitions/newsletterDTO.GenericResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/newsletter/subscribers/count": {
+            "get": {
+                "description": "Retrieves the count of active newsletter subscribers.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Newsletter"
+                ],
+                "summary": "Get Active Subscribers Count",
+                "responses": {
+                    "200": {
+                        "description": "Successfully fetched subscribers count",
+                        "schema": {
+                            "$ref": "#/definitions/newsletterDTO.GenericResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/newsletter/unsubscribe": {
+            "post": {
+                "description": "Unsubscribes the user from the newsletter using the provided email address.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Newsletter"
+                ],
+                "summary": "Unsubscribe from Newsletter",
+                "parameters": [
+                    {
+                        "description": "User email",
+                        "name": "email",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully unsubscribed from newsletter",
+                        "schema": {
+                            "$ref": "#/definitions/newsletterDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "406": {
+                        "description": "Email not subscribed",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/newsletter/unsubscribed/count": {
+            "get": {
+                "description": "Retrieves the count of unsubscribed users from the newsletter.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Newsletter"
+                ],
+                "summary": "Get Unsubscribed Count",
+                "responses": {
+                    "200": {
+                        "description": "Successfully fetched unsubscribed count",
+                        "schema": {
+                            "$ref": "#/definitions/newsletterDTO.GenericResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/oidc/authorize": {
+            "get": {
+                "description": "Partner apps send users here to sign in with their Rasta account, using the authorization code flow with a S256 PKCE challenge. A valid request is redirected to OIDC_CONSENT_URL with the same query string, where the user signs in and approves access. Invalid requests are redirected back to the app with an error, unless the client or the redirect URI is invalid.",
+                "tags": [
+                    "OpenID Connect"
+                ],
+                "summary": "OpenID Connect authorization endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client ID",
+                        "name": "client_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Registered redirect URI",
+                        "name": "redirect_uri",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Must be code",
+                        "name": "response_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space-separated scopes, including openid",
+                        "name": "scope",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque value sent back to the app",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Value copied into the ID token",
+                        "name": "nonce",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "S256 PKCE code challenge",
+                        "name": "code_challenge",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Must be S256",
+                        "name": "code_challenge_method",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the consent page"
+                    },
+                    "400": {
+                        "description": "Invalid redirect URI",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Client not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/oidc/consent": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns what the consent page shows the authenticated user for an authorization request: the partner app and the scopes it requests, and whether the user already allowed them, in which case the page may approve the request right away.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OpenID Connect"
+                ],
+                "summary": "Describe an authorization request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client ID",
+                        "name": "client_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Registered redirect URI",
+                        "name": "redirect_uri",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Must be code",
+                        "name": "response_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Space-separated scopes, including openid",
+                        "name": "scope",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "S256 PKCE code challenge",
+                        "name": "code_challenge",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Must be S256",
+                        "name": "code_challenge_method",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/oidcDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/oidcDTO.ConsentPrompt"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Client not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Records the decision of the authenticated user on an authorization request and returns the URL of the partner app to send the user to, carrying an authorization code and the state, or an access_denied error. The code must be exchanged at /oidc/token within a minute.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OpenID Connect"
+                ],
+                "summary": "Approve or deny an authorization request",
+                "parameters": [
+                    {
+                        "description": "Authorization request and decision",
+                        "name": "decision",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/oidcDTO.ConsentDecision"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/oidcDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/oidcDTO.ConsentRedirect"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Client not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/oidc/consents": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the partner apps the authenticated user allowed to access their account, and the scopes they were granted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OpenID Connect"
+                ],
+                "summary": "List partner app consents",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/oidcDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/oidcDTO.Consent"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/oidc/consents/{clientId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revokes the consent the authenticated user gave to a partner app, so the user is asked again on the next sign-in. Tokens already issued to the app stay valid until they expire.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OpenID Connect"
+                ],
+                "summary": "Revoke a partner app consent",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Client ID",
+                        "name": "clientId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/oidcDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Consent not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/oidc/token": {
+            "post": {
+                "description": "Exchanges an authorization code for an access token and an ID token carrying the username, account type and region of the user. Confidential clients authenticate with HTTP Basic or client_secret; public clients send their client_id. The code_verifier must match the PKCE challenge of the authorization request. Errors follow RFC 6749.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OpenID Connect"
+                ],
+                "summary": "OpenID Connect token endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Must be authorization_code",
+                        "name": "grant_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Redirect URI of the authorization request",
+                        "name": "redirect_uri",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "PKCE code verifier",
+                        "name": "code_verifier",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client ID, unless sent with HTTP Basic",
+                        "name": "client_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client secret, unless sent with HTTP Basic",
+                        "name": "client_secret",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/oidcDTO.Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or grant",
+                        "schema": {
+                            "$ref": "#/definitions/oidcDTO.TokenError"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid client",
+                        "schema": {
+                            "$ref": "#/definitions/oidcDTO.TokenError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/oidcDTO.TokenError"
+                        }
+                    }
+                }
+            }
+        },
+        "/oidc/userinfo": {
+            "get": {
+                "description": "Returns the claims about the user a partner app was granted, for an access token issued by /oidc/token and sent as a Bearer token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OpenID Connect"
+                ],
+                "summary": "OpenID Connect userinfo endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer access token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/oidcDTO.TokenError"
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the support tickets the authenticated user opened, most recent first, without their comments.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tickets"
+                ],
+                "summary": "List my support tickets",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/ticketDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/ticketDTO.Ticket"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Opens a support ticket on behalf of the authenticated user. The category is one of bug report, feature request, account issue or general query, and the priority one of low, medium, high or urgent, medium by default.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tickets"
+                ],
+                "summary": "Open a support ticket",
+                "parameters": [
+                    {
+                        "description": "Ticket payload",
+                        "name": "ticket",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ticketDTO.CreateTicketRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/ticketDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/ticketDTO.Ticket"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden while impersonating a user",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns a support ticket the authenticated user opened, with its comments, oldest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tickets"
+                ],
+                "summary": "Get one of my support tickets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/ticketDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/ticketDTO.Ticket"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Ticket not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/tickets/{id}/comments": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Adds a comment of the authenticated user to a support ticket they opened. Closed tickets no longer take comments; open a new ticket instead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tickets"
+                ],
+                "summary": "Comment on one of my support tickets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Comment payload",
+                        "name": "comment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ticketDTO.CommentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/ticketDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/ticketDTO.Comment"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden while impersonating a user",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Ticket not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "409": {
+                        "description": "Ticket closed",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the API keys of the authenticated user, with when and from where each was last used.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Keys"
+                ],
+                "summary": "List API keys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/userDTO.ApiKey"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Issues an API key to the authenticated user, for integrations to send as \"Authorization: ApiKey \u003ckey\u003e\". The key is only returned in this response. It grants the permissions in scopes that the user holds, may make rate_limit requests per minute (API_KEY_RATE_LIMIT by default) and expires after expires_in_days days, or never when 0.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Keys"
+                ],
+                "summary": "Create an API key",
+                "parameters": [
+                    {
+                        "description": "API key payload",
+                        "name": "key",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.ApiKeyCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.ApiKeyCreated"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Scopes not held by the user",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "409": {
+                        "description": "Too many API keys",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revokes one of the API keys of the authenticated user. Requests made with it are rejected at once.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Keys"
+                ],
+                "summary": "Revoke an API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/email/cancel": {
+            "post": {
+                "description": "Cancels an email change with the token of the link sent to the old address, or reverts it if it has already been completed. Every session of the user is revoked, since the change may not have been made by the user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Cancel an email change",
+                "parameters": [
+                    {
+                        "description": "Token of the cancellation link",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.EmailChangeCancel"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid or expired link",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "409": {
+                        "description": "The old address is now used by another account",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/login": {
+            "post": {
+                "description": "Authenticates a user and returns a short-lived JWT access token and a refresh token. Users with two-factor authentication enabled send either their TOTP code as otp or one of their recovery codes as recovery_code. Users with a registered passkey who do not send a TOTP code get a 401 with status passkey_required and a WebAuthn ceremony to complete at /users/webauthn/login/finish. Users with SMS two-factor authentication enabled who do not send a TOTP code get a 401 with status sms_code_required and a token to send to /users/login/sms along with the code texted to them. Repeated failures delay further attempts and temporarily lock the account and the client IP address.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "User login",
+                "parameters": [
+                    {
+                        "description": "User login payload",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.UserLogin"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.LoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "SMS code required",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.SmsChallenge"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Account suspended, or password reset required after a reported login",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts, or SMS code sent too recently",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/login/email": {
+            "post": {
+                "description": "Sends a single-use sign-in link and code to the email address of a verified account, valid for EMAIL_OTP_EXPIRY seconds. The response is the same whether or not an account matches, and the returned ID is needed to sign in with the code. Only available when EMAIL_LOGIN_ENABLED is set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Send a sign-in link",
+                "parameters": [
+                    {
+                        "description": "User email",
+                        "name": "email",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.LoginLinkSend"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.LoginLinkSent"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Passwordless sign-in disabled",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/login/email/verify": {
+            "post": {
+                "description": "Signs in with the token of a sign-in link, or with the ID returned by /users/login/email and the emailed code, and returns a JWT access token and a refresh token. The link is used up by the first attempt that matches it and passes the second factor, if any, so a mistyped TOTP code can be retried with the same link. Users with two-factor authentication enabled also send their TOTP code as otp or a recovery code as recovery_code; users with a registered passkey who send neither get a 401 with status passkey_required and a WebAuthn ceremony to complete at /users/webauthn/login/finish, and users with SMS two-factor authentication enabled get a 401 with status sms_code_required and a token to send to /users/login/sms along with the code texted to them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Sign in with a link or code",
+                "parameters": [
+                    {
+                        "description": "Token, or ID and code",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.LoginLinkVerify"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "SMS code required",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.SmsChallenge"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Account suspended, or passwordless sign-in disabled",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/login/report": {
+            "post": {
+                "description": "Reports the sign-in from a new device with the token of the \"this wasn't me\" link sent by email. Every session of the user is revoked, as are the passkeys, linked social accounts, API keys and partner app consents added since that sign-in, along with pending sign-in links and email changes. Signing in with the password is refused until it has been reset, and a password reset code is sent by email.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sessions"
+                ],
+                "summary": "Report a login that was not made by the user",
+                "parameters": [
+                    {
+                        "description": "Token of the link",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.LoginReport"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid, expired or already used link",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/login/sms": {
+            "post": {
+                "description": "Finishes a sign-in of a user with SMS two-factor authentication enabled with the token returned in the sms_code_required response and the code texted to them, and returns a JWT access token and a refresh token. The code is invalidated after too many wrong guesses.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMS"
+                ],
+                "summary": "Finish sign-in with an SMS code",
+                "parameters": [
+                    {
+                        "description": "Sign-in token and SMS code",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.SmsLogin"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or expired code",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Account suspended",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revokes the access token the request was made with and the session it belongs to.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sessions"
+                ],
+                "summary": "Log out",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves the profile of the authenticated user, including the email address, phone number, locale and timezone that are not shown to other users.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get the authenticated user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Schedules the deletion of the account of the authenticated user after ACCOUNT_DELETION_GRACE seconds. The current password is required, and the TOTP code or a recovery code when two-factor authentication is enabled. The account stays usable until then and the deletion can be canceled. The account is then anonymized: its personal data is erased, the tickets and comments the user wrote are redacted and its sessions, credentials, subscriptions and exports are deleted. Admins cannot delete their own account.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Delete the authenticated user",
+                "parameters": [
+                    {
+                        "description": "Account deletion payload",
+                        "name": "deletion",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.AccountDeletionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.AccountDeletion"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid password, otp or recovery code",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "This account cannot be deleted",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "409": {
+                        "description": "Deletion already scheduled",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Updates the profile of the authenticated user. Only the fields sent are changed; display_name, locale, timezone, phone and bio are cleared when sent empty. The locale is a BCP 47 language tag such as en-US, the timezone an IANA time zone such as Europe/Berlin and the phone number in the E.164 format such as +14155550123. The email address and password are changed through their own endpoints.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Update the authenticated user",
+                "parameters": [
+                    {
+                        "description": "Profile fields to update",
+                        "name": "profile",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.ProfileUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.User"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "409": {
+                        "description": "Username already exists",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/deletion": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves when the account of the authenticated user is scheduled to be deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get the scheduled deletion of the authenticated user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.AccountDeletion"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "No deletion is scheduled",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Cancels the scheduled deletion of the account of the authenticated user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Cancel the deletion of the authenticated user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "No deletion is scheduled",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/devices": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the devices the authenticated user has signed in from with a password. Signing in from a device not in the list is notified by email.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sessions"
+                ],
+                "summary": "List known devices",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/userDTO.KnownDevice"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/devices/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Forgets one of the known devices of the authenticated user, so the next sign-in from it is notified again. Its sessions are not revoked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sessions"
+                ],
+                "summary": "Forget a known device",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Device not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/email": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Starts changing the email address of the authenticated user. The current password is required, and the TOTP code or a recovery code when two-factor authentication is enabled. A code is sent to the new address, which completes the change, and a notice to the current address with a link cancelling it. The address only changes once the code has been verified.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Change the email address",
+                "parameters": [
+                    {
+                        "description": "Email change payload",
+                        "name": "change",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.EmailChangeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.EmailChange"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid password, otp or recovery code",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "409": {
+                        "description": "Email already exists",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/email/verify": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Completes the pending email change of the authenticated user with the code sent to the new address. The old address can still cancel the change until cancelable_until.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Confirm the new email address",
+                "parameters": [
+                    {
+                        "description": "Code sent to the new address",
+                        "name": "code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.EmailChangeVerify"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.EmailChange"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid or expired code",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "409": {
+                        "description": "Email already exists",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/exports": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the data exports of the authenticated user, newest first, with their status.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "List the data exports of the authenticated user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/userDTO.DataExport"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Starts assembling a copy of the data held about the authenticated user: profile, tickets, comments, newsletter subscriptions, sessions, known devices, linked identities, passkeys, API keys, consents given to partner apps and email changes. The export is prepared in the background as a ZIP archive of JSON files, and can be downloaded for DATA_EXPORT_EXPIRY seconds once its status is ready.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Export the data of the authenticated user",
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.DataExport"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "409": {
+                        "description": "An export is already being prepared",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/exports/{id}/download": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Downloads the ZIP archive of a data export of the authenticated user once it is ready.",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Download a data export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Data export ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ZIP archive of JSON files",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Data export not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "409": {
+                        "description": "Data export not ready or expired",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/password": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Updates the password for the currently authenticated user and logs the user out of every session. The current password is checked first, and a wrong one counts as a failed login attempt. A password that breaks the password policy or is one of the most recent passwords of the user is refused with the rules it breaks in errors.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Update user password",
+                "parameters": [
+                    {
+                        "description": "Password update payload",
+                        "name": "updatePassword",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.UpdatePassword"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request, or password refused by the password policy",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Wrong current password",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/phone/2fa": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Enables SMS two-factor authentication for the authenticated user, whose phone number must be verified. Signing in then texts a code to finish the sign-in with at /users/login/sms, unless a TOTP code is sent.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMS"
+                ],
+                "summary": "Enable SMS two-factor authentication",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Phone number not verified, or already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Disables SMS two-factor authentication for the authenticated user with a code sent by /users/me/phone/code.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMS"
+                ],
+                "summary": "Disable SMS two-factor authentication",
+                "parameters": [
+                    {
+                        "description": "SMS code",
+                        "name": "code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.SmsCode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request, or not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or expired code",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/phone/code": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Texts a code to the phone number of the authenticated user, valid for SMS_CODE_EXPIRY seconds, to verify the phone number at /users/me/phone/verify or to disable SMS two-factor authentication. Another code cannot be sent before SMS_RESEND_INTERVAL seconds have passed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMS"
+                ],
+                "summary": "Send a code to the phone number",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "No phone number",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "429": {
+                        "description": "Code sent too recently",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/phone/verify": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Verifies the phone number of the authenticated user with the code sent by /users/me/phone/code. A verified phone number can receive password reset codes and be used for two-factor authentication.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMS"
+                ],
+                "summary": "Verify the phone number",
+                "parameters": [
+                    {
+                        "description": "SMS code",
+                        "name": "code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.SmsCode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request, or phone number already verified",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or expired code",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/oauth/disable": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Disables OAuth for the user's account by verifying the provided OAuth code.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Disable OAuth",
+                "parameters": [
+                    {
+                        "description": "OAuth code",
+                        "name": "oauth",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OAuth disabled successfully",
+                        "schema": {
+                            "$ref": "#/definitions/oauthDTO.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/oauth/enable": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Verifies the OAuth code provided by the user and enables OAuth for the account. The response carries a set of one-time recovery codes, which are shown only once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Verify and Enable OAuth",
+                "parameters": [
+                    {
+                        "description": "OAuth code",
+                        "name": "oauth",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OAuth enabled successfully",
+                        "schema": {
+                            "$ref": "#/definitions/oauthDTO.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/oauth/generate": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Generates an OAuth secret and URL for the user to enable OAuth.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Generate OAuth Secret and URL",
+                "responses": {
+                    "200": {
+                        "description": "OAuth secret and URL",
+                        "schema": {
+                            "$ref": "#/definitions/oauthDTO.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/oauth/recovery-codes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the number of unused recovery codes of the authenticated user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Count remaining recovery codes",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/oauthDTO.RecoveryCodesCount"
+                        }
+                    },
+                    "400": {
+                        "description": "OAuth is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces the recovery codes of the authenticated user after verifying a TOTP code. The previous codes stop working and the new codes are shown only once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Regenerate recovery codes",
+                "parameters": [
+                    {
+                        "description": "OAuth code",
+                        "name": "oauth",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "New recovery codes",
+                        "schema": {
+                            "$ref": "#/definitions/oauthDTO.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/otp/resend": {
+            "post": {
+                "description": "Resends the OTP to the user's email for verification purposes. With channel set to sms, a code is texted to the phone number given at sign-up instead; another one cannot be sent before SMS_RESEND_INTERVAL seconds have passed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OTP"
+                ],
+                "summary": "Resend OTP to Email",
+                "parameters": [
+                    {
+                        "description": "User email, and channel: email (default) or sms",
+                        "name": "email",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OTP sent successfully",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "429": {
+                        "description": "Code sent too recently",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/otp/{id}/verify": {
+            "post": {
+                "description": "Verifies the user's email using the provided OTP. If successful, marks the email as verified and deletes the OTP. The OTP is invalidated after too many wrong guesses. With channel set to sms, the code texted by /users/otp/resend is verified instead, which verifies the phone number of the user as well.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OTP"
+                ],
+                "summary": "Verify Email with OTP",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "OTP code, and channel: email (default) or sms",
+                        "name": "otp",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Email verified successfully",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/password-policy": {
+            "get": {
+                "description": "Returns the rules new passwords must follow, so that clients can show them before a password is submitted. Passwords found in the breached password list are refused as well.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get the password policy",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.PasswordPolicy"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/users/reset-password": {
+            "get": {
+                "description": "Generates a password reset code and sends it to the user's email. With channel set to sms, the code is texted to the verified phone number of the user instead; another one cannot be sent before SMS_RESEND_INTERVAL seconds have passed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Password Reset"
+                ],
+                "summary": "Send Password Reset Code",
+                "parameters": [
+                    {
+                        "description": "User email, and channel: email (default) or sms",
+                        "name": "email",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password reset code sent successfully",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request, or no verified phone number",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "429": {
+                        "description": "Code sent too recently",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/reset-password/{id}/verify": {
+            "post": {
+                "description": "Verifies the provided OTP and, if valid, allows the user to reset their password. Every session of the user is revoked afterwards. A password that breaks the password policy or is one of the most recent passwords of the user is refused with the rules it breaks in errors. Set channel to sms to reset it with a code texted by /users/reset-password instead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Password Reset"
+                ],
+                "summary": "Verify OTP and Reset Password",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Password reset request body",
+                        "name": "ResetPassword",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.ResetPassword"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password reset successfully",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the active sessions of the authenticated user, with device, IP address, user agent and last activity.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sessions"
+                ],
+                "summary": "List active sessions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revokes every session of the authenticated user, including the current one.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sessions"
+                ],
+                "summary": "Log out everywhere",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revokes one of the authenticated user's sessions, logging that device out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sessions"
+                ],
+                "summary": "Revoke a session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/signup": {
+            "post": {
+                "description": "Create a new user account and send a verification OTP email. A password that breaks the password policy is refused with the rules it breaks in errors. Unless signup challenges are disabled, a challenge from /users/signup/challenge must be solved and sent as challenge along with its solution; it is used up by the attempt. Email addresses of blocked domains are refused, and only SIGNUP_IP_QUOTA accounts may be signed up from an IP address every SIGNUP_IP_QUOTA_WINDOW seconds.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Create a new user",
+                "parameters": [
+                    {
+                        "description": "User creation payload",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.UserCreate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request, password refused by the password policy, blocked email domain or invalid challenge",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many signups from this IP address",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/signup/challenge": {
+            "get": {
+                "description": "Issues a proof-of-work challenge to solve before signing up at /users/signup: find a solution of at most 64 characters such that the SHA-256 hash of the challenge followed by the solution starts with difficulty zero bits. A difficulty of 0 means signup challenges are disabled. Every challenge can be used once, before it expires.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get a signup challenge",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.SignupChallenge"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/social/identities": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the external identities linked to the authenticated user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Social"
+                ],
+                "summary": "List linked identity providers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/userDTO.Identity"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/social/identities/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Unlinks one of the external identities of the authenticated user, who can no longer sign in with it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Social"
+                ],
+                "summary": "Unlink an identity provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Identity ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Identity not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/social/providers": {
+            "get": {
+                "description": "Lists the external identity providers users may sign in with.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Social"
+                ],
+                "summary": "List identity providers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "type": "string"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/users/social/{provider}/begin": {
+            "post": {
+                "description": "Starts a sign-in with an external identity provider. Send the user to the returned URL; the provider redirects back to SOCIAL_REDIRECT_URL/{provider} with a code and a state to send to /users/social/{provider}/callback within ten minutes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Social"
+                ],
+                "summary": "Begin sign-in with an identity provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.SocialAuthorization"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Provider not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/social/{provider}/callback": {
+            "post": {
+                "description": "Completes a sign-in started at /users/social/{provider}/begin with the code and state the provider redirected back with, and returns a JWT access token and a refresh token. An identity not linked to any account signs up a new user, whose email address is verified if the provider asserts it; if another account already uses the email address, sign in to it and link the provider instead. Users with two-factor authentication enabled also send their TOTP code as otp or a recovery code as recovery_code, and may retry with the same state; users with a registered passkey who send neither get a 401 with status passkey_required and a WebAuthn ceremony to complete at /users/webauthn/login/finish, and users with SMS two-factor authentication enabled get a 401 with status sms_code_required and a token to send to /users/login/sms along with the code texted to them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Social"
+                ],
+                "summary": "Finish sign-in with an identity provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Code and state sent back by the provider",
+                        "name": "callback",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.SocialCallback"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "SMS code required",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.SmsChallenge"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "Account suspended",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Provider not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "409": {
+                        "description": "Email address already used by another account",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/social/{provider}/link": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Starts linking an account at an external identity provider to the authenticated user. Send the user to the returned URL; the provider redirects back with a code and a state to send to /users/social/{provider}/link/callback within ten minutes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Social"
+                ],
+                "summary": "Begin linking an identity provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.SocialAuthorization"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Provider not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/social/{provider}/link/callback": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Completes linking started at /users/social/{provider}/link with the code and state the provider redirected back with. The user can then sign in with the provider, and their email address is verified if the provider asserts it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Social"
+                ],
+                "summary": "Finish linking an identity provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Code and state sent back by the provider",
+                        "name": "callback",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.SocialCallback"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.Identity"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Provider not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "409": {
+                        "description": "Identity linked to another account",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/token/refresh": {
+            "post": {
+                "description": "Exchanges a refresh token for a new access token and a new refresh token. Each refresh token can be used only once; reusing one revokes the whole session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sessions"
+                ],
+                "summary": "Refresh access token",
+                "parameters": [
+                    {
+                        "description": "Refresh token payload",
+                        "name": "refreshToken",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.RefreshTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Account suspended",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/webauthn/credentials": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lists the passkeys registered by the authenticated user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "WebAuthn"
+                ],
+                "summary": "List passkeys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/userDTO.WebAuthnCredential"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/webauthn/credentials/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes one of the passkeys of the authenticated user.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "WebAuthn"
+                ],
+                "summary": "Delete a passkey",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Passkey ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "404": {
+                        "description": "Passkey not found",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/webauthn/login/begin": {
+            "post": {
+                "description": "Starts a passkey-only login. Pass the options to navigator.credentials.get and send the result to /users/webauthn/login/finish with the ceremony ID within five minutes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "WebAuthn"
+                ],
+                "summary": "Begin passkey login",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.WebAuthnCeremony"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/webauthn/login/finish": {
+            "post": {
+                "description": "Verifies the assertion of the authenticator and returns a JWT access token and a refresh token. Completes both passkey-only logins started at /users/webauthn/login/begin and the passkey second factor requested by /users/login.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "WebAuthn"
+                ],
+                "summary": "Finish passkey login",
+                "parameters": [
+                    {
+                        "description": "Ceremony ID and PublicKeyCredential",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.WebAuthnLogin"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "403": {
+                        "description": "Account suspended",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/webauthn/register/begin": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Starts the registration of a passkey for the authenticated user. Pass the options to navigator.credentials.create and send the result to /users/webauthn/register/finish with the ceremony ID within five minutes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "WebAuthn"
+                ],
+                "summary": "Begin passkey registration",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.WebAuthnCeremony"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/webauthn/register/finish": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Verifies the credential created by the authenticator and registers it as a passkey of the authenticated user. Once a passkey is registered, logging in with a password also requires a passkey.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "WebAuthn"
+                ],
+                "summary": "Finish passkey registration",
+                "parameters": [
+                    {
+                        "description": "Ceremony ID and PublicKeyCredential",
+                        "name": "registration",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.WebAuthnRegister"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/userDTO.GenericResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/userDTO.WebAuthnCredential"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/commonerrors.ErrorMap"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{username}": {
+            "get": {
+                "description": "Retrieve user details by their username. Accepts a bearer token or an API key sent as \"Authorization: ApiKey \u003ckey\u003e\".",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get user by username",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/userDTO.GenericResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "auditDTO.AuditLog": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/auditmodel.Action"
+                },
+                "actor_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "user_agent": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "auditDTO.AuditLogPage": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/auditDTO.AuditLog"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "auditDTO.GenericResponse": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "auditmodel.Action": {
+            "type": "string",
+            "enum": [
+                "impersonation.start",
+                "impersonation.request"
+            ],
+            "x-enum-varnames": [
+                "ActionImpersonationStart",
+                "ActionImpersonationRequest"
+            ]
+        },
+        "commonerrors.ErrorMap": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/commonerrors.FieldError"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "commonerrors.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string"
+                }
+            }
+        },
+        "commonerrors.GenericResponseError": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "emailDTO.GenericResponse": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "emailDTO.OutboxEmail": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "recipient": {
+                    "type": "string"
+                },
+                "sent_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/emailmodel.OutboxStatus"
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "emailDTO.OutboxEmailPage": {
+            "type": "object",
+            "properties": {
+                "emails": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/emailDTO.OutboxEmail"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "emailmodel.OutboxStatus": {
+            "type": "string",
+            "enum": [
+                "queued",
+                "sent",
+                "failed",
+                "bounced"
+            ],
+            "x-enum-varnames": [
+                "OutboxStatusQueued",
+                "OutboxStatusSent",
+                "OutboxStatusFailed",
+                "OutboxStatusBounced"
+            ]
+        },
+        "newsletterDTO.CreateNewsletterRequest": {
+            "type": "object",
+            "required": [
+                "email_text"
+            ],
+            "properties": {
+                "email_text": {
+                    "type": "string"
+                },
+                "limit": {
+                    "type": "integer"
+                }
+            }
+        },
+        "newsletterDTO.GenericResponse": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "oauthDTO.RecoveryCodesCount": {
+            "type": "object",
+            "properties": {
+                "remaining": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "oauthDTO.Response": {
+            "type": "object",
+            "properties": {
+                "is_active": {
+                    "type": "boolean"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "oauth_token": {
+                    "type": "string"
+                },
+                "oauth_url": {
+                    "type": "string"
+                },
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "oidcDTO.Client": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "public": {
+                    "type": "boolean"
+                },
+                "redirect_uris": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "oidcDTO.ClientRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "redirect_uris"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "public": {
+                    "type": "boolean"
+                },
+                "redirect_uris": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "oidcDTO.ClientSecret": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                },
+                "client_secret": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "public": {
+                    "type": "boolean"
+                },
+                "redirect_uris": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "oidcDTO.ClientUpdate": {
+            "type": "object",
+            "required": [
+                "name",
+                "redirect_uris"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "redirect_uris": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "oidcDTO.Consent": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                },
+                "client_name": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "oidcDTO.ConsentDecision": {
+            "type": "object",
+            "required": [
+                "client_id",
+                "redirect_uri"
+            ],
+            "properties": {
+                "approve": {
+                    "type": "boolean"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "code_challenge": {
+                    "type": "string"
+                },
+                "code_challenge_method": {
+                    "type": "string"
+                },
+                "nonce": {
+                    "type": "string"
+                },
+                "redirect_uri": {
+                    "type": "string"
+                },
+                "response_type": {
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "oidcDTO.ConsentPrompt": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                },
+                "client_name": {
+                    "type": "string"
+                },
+                "granted": {
+                    "type": "boolean"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "oidcDTO.ConsentRedirect": {
+            "type": "object",
+            "properties": {
+                "redirect_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "oidcDTO.GenericResponse": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "oidcDTO.Token": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "expires_in": {
+                    "type": "integer"
+                },
+                "id_token": {
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "oidcDTO.TokenError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "error_description": {
+                    "type": "string"
+                }
+            }
+        },
+        "ticketDTO.AssignTicketRequest": {
+            "type": "object",
+            "properties": {
+                "assignee_id": {
+                    "description": "AssigneeId is the member of staff to assign the ticket to. Leave it out to unassign the ticket.",
+                    "type": "string"
+                }
+            }
+        },
+        "ticketDTO.Comment": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "ticketDTO.CommentRequest": {
+            "type": "object",
+            "required": [
+                "comment"
+            ],
+            "properties": {
+                "comment": {
+                    "type": "string"
+                }
+            }
+        },
+        "ticketDTO.CreateTicketRequest": {
+            "type": "object",
+            "required": [
+                "category",
+                "description",
+                "title"
+            ],
+            "properties": {
+                "category": {
+                    "$ref": "#/definitions/ticketmodel.TicketCategory"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "priority": {
+                    "$ref": "#/definitions/ticketmodel.TicketPriority"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "ticketDTO.GenericResponse": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "ticketDTO.Ticket": {
+            "type": "object",
+            "properties": {
+                "assigned_to": {
+                    "type": "string"
+                },
+                "category": {
+                    "$ref": "#/definitions/ticketmodel.TicketCategory"
+                },
+                "comments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ticketDTO.Comment"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "priority": {
+                    "$ref": "#/definitions/ticketmodel.TicketPriority"
+                },
+                "status": {
+                    "$ref": "#/definitions/ticketmodel.TicketStatus"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "ticketDTO.TicketPage": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "tickets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ticketDTO.Ticket"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "ticketDTO.UpdateTicketRequest": {
+            "type": "object",
+            "properties": {
+                "priority": {
+                    "$ref": "#/definitions/ticketmodel.TicketPriority"
+                },
+                "status": {
+                    "$ref": "#/definitions/ticketmodel.TicketStatus"
+                }
+            }
+        },
+        "ticketmodel.TicketCategory": {
+            "type": "string",
+            "enum": [
+                "bug report",
+                "feature request",
+                "account issue",
+                "general query"
+            ],
+            "x-enum-varnames": [
+                "TicketCategoryBugReport",
+                "TicketCategoryFeatureRequest",
+                "TicketCategoryAccountIssue",
+                "TicketCategoryGeneralQuery"
+            ]
+        },
+        "ticketmodel.TicketPriority": {
+            "type": "string",
+            "enum": [
+                "low",
+                "medium",
+                "high",
+                "urgent"
+            ],
+            "x-enum-varnames": [
+                "TicketPriorityLow",
+                "TicketPriorityMedium",
+                "TicketPriorityHigh",
+                "TicketPriorityUrgent"
+            ]
+        },
+        "ticketmodel.TicketStatus": {
+            "type": "string",
+            "enum": [
+                "open",
+                "in progress",
+                "resolved",
+                "closed"
+            ],
+            "x-enum-varnames": [
+                "TicketStatusOpen",
+                "TicketStatusInProgress",
+                "TicketStatusResolved",
+                "TicketStatusClosed"
+            ]
+        },
+        "userDTO.AccountDeletion": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "scheduled_for": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.AccountDeletionRequest": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "otp": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "recovery_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.ApiKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "last_used_ip": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "rate_limit": {
+                    "type": "integer"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/usermodel.Permission"
+                    }
+                }
+            }
+        },
+        "userDTO.ApiKeyCreate": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "expires_in_days": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rate_limit": {
+                    "type": "integer"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/usermodel.Permission"
+                    }
+                }
+            }
+        },
+        "userDTO.ApiKeyCreated": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "last_used_ip": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "rate_limit": {
+                    "type": "integer"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/usermodel.Permission"
+                    }
+                }
+            }
+        },
+        "userDTO.AssignRoleRequest": {
+            "type": "object",
+            "required": [
+                "role_id"
+            ],
+            "properties": {
+                "role_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.DataExport": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/usermodel.DataExportStatus"
+                }
+            }
+        },
+        "userDTO.EmailChange": {
+            "type": "object",
+            "properties": {
+                "cancelable_until": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "new_email": {
+                    "type": "string"
+                },
+                "verified_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.EmailChangeCancel": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.EmailChangeRequest": {
+            "type": "object",
+            "required": [
+                "new_email",
+                "password"
+            ],
+            "properties": {
+                "new_email": {
+                    "type": "string"
+                },
+                "otp": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "recovery_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.EmailChangeVerify": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.GenericResponse": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.Identity": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.Impersonation": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "session_id": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.ImpersonationRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.KnownDevice": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "device": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.LoginLinkSend": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.LoginLinkSent": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.LoginLinkVerify": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "otp": {
+                    "type": "string"
+                },
+                "recovery_code": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
                 }
             }
-        }
-    },
-    "definitions": {
-        "commonerrors.ErrorMap": {
+        },
+        "userDTO.LoginReport": {
             "type": "object",
+            "required": [
+                "token"
+            ],
             "properties": {
-                "message": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
                     "type": "string"
                 },
                 "status": {
                     "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/userDTO.User"
                 }
             }
         },
-        "commonerrors.GenericResponseError": {
+        "userDTO.PasswordPolicy": {
             "type": "object",
             "properties": {
-                "data": {},
-                "error": {
+                "history": {
+                    "type": "integer"
+                },
+                "max_length": {
+                    "type": "integer"
+                },
+                "min_length": {
+                    "type": "integer"
+                },
+                "min_strength": {
+                    "type": "integer"
+                },
+                "require_digit": {
+                    "type": "boolean"
+                },
+                "require_lowercase": {
+                    "type": "boolean"
+                },
+                "require_symbol": {
+                    "type": "boolean"
+                },
+                "require_uppercase": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "userDTO.ProfileUpdate": {
+            "type": "object",
+            "properties": {
+                "bio": {
                     "type": "string"
                 },
-                "message": {
+                "display_name": {
                     "type": "string"
                 },
-                "status": {
+                "first_name": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "locale": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "region": {
+                    "$ref": "#/definitions/usermodel.RegionType"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "username": {
                     "type": "string"
                 }
             }
         },
-        "newsletterDTO.CreateNewsletterRequest": {
+        "userDTO.RefreshTokenRequest": {
             "type": "object",
             "required": [
-                "email_text"
+                "refresh_token"
             ],
             "properties": {
-                "email_text": {
+                "refresh_token": {
                     "type": "string"
-                },
-                "limit": {
-                    "type": "integer"
                 }
             }
         },
-        "newsletterDTO.GenericResponse": {
+        "userDTO.ResetPassword": {
             "type": "object",
+            "required": [
+                "new_password1",
+                "new_password2",
+                "otp"
+            ],
             "properties": {
-                "data": {},
-                "error": {
+                "channel": {
+                    "description": "Channel is the channel the code has been sent through: email, the default, or sms.",
                     "type": "string"
                 },
-                "message": {
+                "new_password1": {
                     "type": "string"
                 },
-                "status": {
+                "new_password2": {
+                    "type": "string"
+                },
+                "otp": {
                     "type": "string"
                 }
             }
         },
-        "oauthDTO.Response": {
+        "userDTO.RoleRequest": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
-                "is_active": {
-                    "type": "boolean"
+                "description": {
+                    "type": "string"
                 },
-                "message": {
+                "name": {
                     "type": "string"
                 },
-                "oauth_token": {
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/usermodel.Permission"
+                    }
+                }
+            }
+        },
+        "userDTO.ServiceAccountCreate": {
+            "type": "object",
+            "required": [
+                "name",
+                "region",
+                "username"
+            ],
+            "properties": {
+                "name": {
                     "type": "string"
                 },
-                "oauth_url": {
+                "region": {
+                    "$ref": "#/definitions/usermodel.RegionType"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.SignupChallenge": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
                     "type": "string"
                 },
-                "status": {
+                "challenge": {
+                    "type": "string"
+                },
+                "difficulty": {
+                    "type": "integer"
+                },
+                "expires_at": {
                     "type": "string"
                 }
             }
         },
-        "userDTO.GenericResponse": {
+        "userDTO.SmsChallenge": {
             "type": "object",
             "properties": {
-                "data": {},
-                "error": {
+                "expires_in": {
+                    "type": "integer"
+                },
+                "phone": {
                     "type": "string"
                 },
-                "status": {
+                "token": {
                     "type": "string"
                 }
             }
         },
-        "userDTO.LoginResponse": {
+        "userDTO.SmsCode": {
             "type": "object",
+            "required": [
+                "code"
+            ],
             "properties": {
-                "status": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.SmsLogin": {
+            "type": "object",
+            "required": [
+                "code",
+                "token"
+            ],
+            "properties": {
+                "code": {
                     "type": "string"
                 },
                 "token": {
                     "type": "string"
+                }
+            }
+        },
+        "userDTO.SocialAuthorization": {
+            "type": "object",
+            "properties": {
+                "auth_url": {
+                    "type": "string"
                 },
-                "user": {
-                    "$ref": "#/definitions/userDTO.User"
+                "provider": {
+                    "type": "string"
                 }
             }
         },
-        "userDTO.ResetPassword": {
+        "userDTO.SocialCallback": {
             "type": "object",
             "required": [
-                "new_password1",
-                "new_password2",
-                "otp"
+                "state"
             ],
             "properties": {
-                "new_password1": {
+                "code": {
                     "type": "string"
                 },
-                "new_password2": {
+                "otp": {
                     "type": "string"
                 },
-                "otp": {
+                "recovery_code": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.SuspendUser": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 256
+                },
+                "until": {
                     "type": "string"
                 }
             }
@@ -1151,12 +8215,24 @@ const docTemplate = `{
                 "account": {
                     "$ref": "#/definitions/usermodel.AccountType"
                 },
+                "bio": {
+                    "type": "string"
+                },
                 "country": {
                     "$ref": "#/definitions/usermodel.RegionType"
                 },
                 "created_at": {
                     "type": "string"
                 },
+                "disabled_reason": {
+                    "type": "string"
+                },
+                "disabled_until": {
+                    "type": "string"
+                },
+                "display_name": {
+                    "type": "string"
+                },
                 "email": {
                     "type": "string"
                 },
@@ -1175,9 +8251,24 @@ const docTemplate = `{
                 "last_name": {
                     "type": "string"
                 },
+                "locale": {
+                    "type": "string"
+                },
                 "oauth": {
                     "$ref": "#/definitions/oauthDTO.Response"
                 },
+                "phone": {
+                    "type": "string"
+                },
+                "phone_verified": {
+                    "type": "boolean"
+                },
+                "sms_two_factor_enabled": {
+                    "type": "boolean"
+                },
+                "timezone": {
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string"
                 },
@@ -1197,6 +8288,10 @@ const docTemplate = `{
                 "username"
             ],
             "properties": {
+                "challenge": {
+                    "description": "Challenge and Solution are the proof-of-work challenge issued by /users/signup/challenge and\nits solution, required unless signup challenges are disabled.",
+                    "type": "string"
+                },
                 "email": {
                     "type": "string"
                 },
@@ -1209,9 +8304,16 @@ const docTemplate = `{
                 "password": {
                     "type": "string"
                 },
+                "phone": {
+                    "description": "Phone is optional, in the E.164 format. The account can be verified with a code sent to it by SMS.",
+                    "type": "string"
+                },
                 "region": {
                     "$ref": "#/definitions/usermodel.RegionType"
                 },
+                "solution": {
+                    "type": "string"
+                },
                 "username": {
                     "type": "string"
                 }
@@ -1231,22 +8333,139 @@ const docTemplate = `{
                     "type": "string",
                     "minLength": 8
                 },
+                "recovery_code": {
+                    "type": "string"
+                },
                 "username": {
                     "type": "string"
                 }
             }
         },
+        "userDTO.WebAuthnCeremony": {
+            "type": "object",
+            "properties": {
+                "ceremony_id": {
+                    "type": "string"
+                },
+                "options": {}
+            }
+        },
+        "userDTO.WebAuthnCredential": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "userDTO.WebAuthnLogin": {
+            "type": "object",
+            "required": [
+                "ceremony_id",
+                "credential"
+            ],
+            "properties": {
+                "ceremony_id": {
+                    "type": "string"
+                },
+                "credential": {
+                    "type": "object"
+                }
+            }
+        },
+        "userDTO.WebAuthnRegister": {
+            "type": "object",
+            "required": [
+                "ceremony_id",
+                "credential"
+            ],
+            "properties": {
+                "ceremony_id": {
+                    "type": "string"
+                },
+                "credential": {
+                    "type": "object"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64
+                }
+            }
+        },
         "usermodel.AccountType": {
             "type": "string",
             "enum": [
                 "User",
                 "Seller",
-                "Admin"
+                "Admin",
+                "Service"
             ],
             "x-enum-varnames": [
                 "AccountTypeNormal",
                 "AccountTypeSeller",
-                "AccountTypeAdmin"
+                "AccountTypeAdmin",
+                "AccountTypeService"
+            ]
+        },
+        "usermodel.DataExportStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "ready",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "DataExportStatusPending",
+                "DataExportStatusReady",
+                "DataExportStatusFailed"
+            ]
+        },
+        "usermodel.Permission": {
+            "type": "string",
+            "enum": [
+                "users.read",
+                "users.write",
+                "users.impersonate",
+                "roles.read",
+                "roles.write",
+                "newsletter.read",
+                "newsletter.send",
+                "newsletter.delete",
+                "tickets.read",
+                "tickets.write",
+                "tickets.assign",
+                "clients.read",
+                "clients.write",
+                "audit.read",
+                "emails.read",
+                "emails.write"
+            ],
+            "x-enum-varnames": [
+                "PermissionUsersRead",
+                "PermissionUsersWrite",
+                "PermissionUsersImpersonate",
+                "PermissionRolesRead",
+                "PermissionRolesWrite",
+                "PermissionNewsletterRead",
+                "PermissionNewsletterSend",
+                "PermissionNewsletterDelete",
+                "PermissionTicketsRead",
+                "PermissionTicketsWrite",
+                "PermissionTicketsAssign",
+                "PermissionClientsRead",
+                "PermissionClientsWrite",
+                "PermissionAuditRead",
+                "PermissionEmailsRead",
+                "PermissionEmailsWrite"
             ]
         },
         "usermodel.RegionType": {
@@ -0,0 +1,58 @@
+package auditDTO
+
+import (
+	auditmodel "github.com/drunkleen/rasta/internal/models/audit"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type GenericResponse struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+type AuditLog struct {
+	Id        uuid.UUID         `json:"id"`
+	Action    auditmodel.Action `json:"action"`
+	ActorId   *uuid.UUID        `json:"actor_id,omitempty"`
+	UserId    *uuid.UUID        `json:"user_id,omitempty"`
+	SessionId *uuid.UUID        `json:"session_id,omitempty"`
+	Method    string            `json:"method,omitempty"`
+	Path      string            `json:"path,omitempty"`
+	Status    int               `json:"status,omitempty"`
+	IpAddress string            `json:"ip_address,omitempty"`
+	UserAgent string            `json:"user_agent,omitempty"`
+	Detail    string            `json:"detail,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type AuditLogPage struct {
+	Entries []AuditLog `json:"entries"`
+	Total   int64      `json:"total"`
+	Page    int        `json:"page"`
+	Limit   int        `json:"limit"`
+}
+
+// FromModelsToAuditLogResponse converts a slice of auditmodel.AuditLog to a slice of AuditLog DTOs.
+func FromModelsToAuditLogResponse(entries []auditmodel.AuditLog) []AuditLog {
+	respEntries := make([]AuditLog, len(entries))
+	for i, entry := range entries {
+		respEntries[i] = AuditLog{
+			Id:        entry.Id,
+			Action:    entry.Action,
+			ActorId:   entry.ActorId,
+			UserId:    entry.UserId,
+			SessionId: entry.SessionId,
+			Method:    entry.Method,
+			Path:      entry.Path,
+			Status:    entry.Status,
+			IpAddress: entry.IpAddress,
+			UserAgent: entry.UserAgent,
+			Detail:    entry.Detail,
+			CreatedAt: entry.CreatedAt,
+		}
+	}
+	return respEntries
+}
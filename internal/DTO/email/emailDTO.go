@@ -0,0 +1,69 @@
+package emailDTO
+
+import (
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type GenericResponse struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// OutboxEmail is an email of the outbox and its delivery, without its body.
+type OutboxEmail struct {
+	Id            uuid.UUID               `json:"id"`
+	UserId        *uuid.UUID              `json:"user_id,omitempty"`
+	Kind          string                  `json:"kind"`
+	Recipient     string                  `json:"recipient"`
+	Subject       string                  `json:"subject"`
+	Status        emailmodel.OutboxStatus `json:"status"`
+	Attempts      int                     `json:"attempts"`
+	NextAttemptAt *time.Time              `json:"next_attempt_at,omitempty"`
+	LastError     string                  `json:"last_error,omitempty"`
+	SentAt        *time.Time              `json:"sent_at,omitempty"`
+	CreatedAt     time.Time               `json:"created_at"`
+	UpdatedAt     time.Time               `json:"updated_at"`
+}
+
+type OutboxEmailPage struct {
+	Emails []OutboxEmail `json:"emails"`
+	Total  int64         `json:"total"`
+	Page   int           `json:"page"`
+	Limit  int           `json:"limit"`
+}
+
+// FromModelToOutboxEmailResponse converts an emailmodel.EmailOutbox to an OutboxEmail DTO. The next
+// attempt is only given for queued emails.
+func FromModelToOutboxEmailResponse(email *emailmodel.EmailOutbox) OutboxEmail {
+	resp := OutboxEmail{
+		Id:        email.Id,
+		UserId:    email.UserId,
+		Kind:      email.Kind,
+		Recipient: email.Recipient,
+		Subject:   email.Subject,
+		Status:    email.Status,
+		Attempts:  email.Attempts,
+		LastError: email.LastError,
+		SentAt:    email.SentAt,
+		CreatedAt: email.CreatedAt,
+		UpdatedAt: email.UpdatedAt,
+	}
+	if email.Status == emailmodel.OutboxStatusQueued {
+		nextAttemptAt := email.NextAttemptAt
+		resp.NextAttemptAt = &nextAttemptAt
+	}
+	return resp
+}
+
+// FromModelsToOutboxEmailResponse converts a slice of emailmodel.EmailOutbox to a slice of OutboxEmail DTOs.
+func FromModelsToOutboxEmailResponse(emails []emailmodel.EmailOutbox) []OutboxEmail {
+	respEmails := make([]OutboxEmail, len(emails))
+	for i := range emails {
+		respEmails[i] = FromModelToOutboxEmailResponse(&emails[i])
+	}
+	return respEmails
+}
@@ -6,6 +6,13 @@ type Response struct {
 	Token   string `json:"oauth_token,omitempty"`
 	OtpUrl  string `json:"oauth_url,omitempty"`
 	Enabled bool   `json:"is_active"`
+
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+type RecoveryCodesCount struct {
+	Status    string `json:"status"`
+	Remaining int64  `json:"remaining"`
 }
 
 // ToOAuthResponse generates an OAuth response based on the provided parameters.
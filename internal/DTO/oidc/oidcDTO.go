@@ -0,0 +1,153 @@
+package oidcDTO
+
+import (
+	oidcmodel "github.com/drunkleen/rasta/internal/models/oidc"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type GenericResponse struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+type ClientRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	RedirectUris []string `json:"redirect_uris" binding:"required"`
+	Public       bool     `json:"public"`
+}
+
+type ClientUpdate struct {
+	Name         string   `json:"name" binding:"required"`
+	RedirectUris []string `json:"redirect_uris" binding:"required"`
+}
+
+type Client struct {
+	Id           uuid.UUID `json:"client_id"`
+	Name         string    `json:"name"`
+	RedirectUris []string  `json:"redirect_uris"`
+	Public       bool      `json:"public"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type ClientSecret struct {
+	Client
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// AuthorizationRequest carries the parameters a partner app sends users to the authorization endpoint with.
+type AuthorizationRequest struct {
+	ClientId            string `json:"client_id" form:"client_id" binding:"required"`
+	RedirectUri         string `json:"redirect_uri" form:"redirect_uri" binding:"required"`
+	ResponseType        string `json:"response_type" form:"response_type"`
+	Scope               string `json:"scope" form:"scope"`
+	State               string `json:"state" form:"state"`
+	Nonce               string `json:"nonce" form:"nonce"`
+	CodeChallenge       string `json:"code_challenge" form:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method" form:"code_challenge_method"`
+}
+
+type ConsentDecision struct {
+	AuthorizationRequest
+	Approve bool `json:"approve"`
+}
+
+type ConsentPrompt struct {
+	ClientId   uuid.UUID `json:"client_id"`
+	ClientName string    `json:"client_name"`
+	Scopes     []string  `json:"scopes"`
+	Granted    bool      `json:"granted"`
+}
+
+type ConsentRedirect struct {
+	RedirectUrl string `json:"redirect_url"`
+}
+
+type Consent struct {
+	ClientId   uuid.UUID `json:"client_id"`
+	ClientName string    `json:"client_name"`
+	Scopes     []string  `json:"scopes"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TokenRequest carries the form parameters of a request to the token endpoint.
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectUri  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	ClientId     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	IdToken     string `json:"id_token"`
+	Scope       string `json:"scope"`
+}
+
+// TokenError is an error answered by the token and userinfo endpoints, in the format of RFC 6749.
+type TokenError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// Discovery is the OpenID Connect discovery document.
+type Discovery struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JwksUri                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IdTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// FromModelToClientResponse converts an oidcmodel.OidcClient to a Client DTO.
+func FromModelToClientResponse(client *oidcmodel.OidcClient) *Client {
+	return &Client{
+		Id:           client.Id,
+		Name:         client.Name,
+		RedirectUris: client.GetRedirectUris(),
+		Public:       client.IsPublic(),
+		CreatedAt:    client.CreatedAt,
+		UpdatedAt:    client.UpdatedAt,
+	}
+}
+
+// FromModelsToClientResponse converts a slice of oidcmodel.OidcClient to a slice of Client DTOs.
+func FromModelsToClientResponse(clients []oidcmodel.OidcClient) []Client {
+	respClients := make([]Client, len(clients))
+	for i := range clients {
+		respClients[i] = *FromModelToClientResponse(&clients[i])
+	}
+	return respClients
+}
+
+// FromModelsToConsentResponse converts a slice of oidcmodel.OidcConsent, with their clients loaded, to a slice of Consent DTOs.
+func FromModelsToConsentResponse(consents []oidcmodel.OidcConsent) []Consent {
+	respConsents := make([]Consent, len(consents))
+	for i, consent := range consents {
+		respConsents[i] = Consent{
+			ClientId:   consent.ClientId,
+			ClientName: consent.Client.Name,
+			Scopes:     strings.Fields(consent.Scope),
+			CreatedAt:  consent.CreatedAt,
+			UpdatedAt:  consent.UpdatedAt,
+		}
+	}
+	return respConsents
+}
@@ -0,0 +1,105 @@
+package ticketDTO
+
+import (
+	ticketmodel "github.com/drunkleen/rasta/internal/models/ticket"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type GenericResponse struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+type CreateTicketRequest struct {
+	Title       string                     `json:"title" binding:"required"`
+	Description string                     `json:"description" binding:"required"`
+	Category    ticketmodel.TicketCategory `json:"category" binding:"required"`
+	Priority    ticketmodel.TicketPriority `json:"priority,omitempty"`
+}
+
+type CommentRequest struct {
+	Comment string `json:"comment" binding:"required"`
+}
+
+type UpdateTicketRequest struct {
+	Status   *ticketmodel.TicketStatus   `json:"status,omitempty"`
+	Priority *ticketmodel.TicketPriority `json:"priority,omitempty"`
+}
+
+type AssignTicketRequest struct {
+	// AssigneeId is the member of staff to assign the ticket to. Leave it out to unassign the ticket.
+	AssigneeId *uuid.UUID `json:"assignee_id,omitempty"`
+}
+
+type Comment struct {
+	Id        uuid.UUID `json:"id"`
+	UserId    uuid.UUID `json:"user_id"`
+	Comment   string    `json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Ticket struct {
+	Id          uuid.UUID                  `json:"id"`
+	Title       string                     `json:"title"`
+	Description string                     `json:"description"`
+	Status      ticketmodel.TicketStatus   `json:"status"`
+	Priority    ticketmodel.TicketPriority `json:"priority"`
+	Category    ticketmodel.TicketCategory `json:"category"`
+	UserId      uuid.UUID                  `json:"user_id"`
+	AssignedTo  *uuid.UUID                 `json:"assigned_to,omitempty"`
+	CreatedAt   time.Time                  `json:"created_at"`
+	UpdatedAt   time.Time                  `json:"updated_at"`
+	Comments    []Comment                  `json:"comments,omitempty"`
+}
+
+type TicketPage struct {
+	Tickets []Ticket `json:"tickets"`
+	Total   int64    `json:"total"`
+	Page    int      `json:"page"`
+	Limit   int      `json:"limit"`
+}
+
+// FromModelToTicketResponse converts a ticketmodel.Ticket, with the comments loaded along with it, to a Ticket DTO.
+func FromModelToTicketResponse(ticket *ticketmodel.Ticket) Ticket {
+	resp := Ticket{
+		Id:          ticket.Id,
+		Title:       ticket.Title,
+		Description: ticket.Description,
+		Status:      ticket.Status,
+		Priority:    ticket.Priority,
+		Category:    ticket.Category,
+		UserId:      ticket.UserId,
+		AssignedTo:  ticket.AssignedTo,
+		CreatedAt:   ticket.CreatedAt,
+		UpdatedAt:   ticket.UpdatedAt,
+	}
+	if len(ticket.Comments) > 0 {
+		resp.Comments = make([]Comment, len(ticket.Comments))
+		for i := range ticket.Comments {
+			resp.Comments[i] = FromModelToCommentResponse(&ticket.Comments[i])
+		}
+	}
+	return resp
+}
+
+// FromModelsToTicketResponse converts a slice of ticketmodel.Ticket to a slice of Ticket DTOs.
+func FromModelsToTicketResponse(tickets []ticketmodel.Ticket) []Ticket {
+	respTickets := make([]Ticket, len(tickets))
+	for i := range tickets {
+		respTickets[i] = FromModelToTicketResponse(&tickets[i])
+	}
+	return respTickets
+}
+
+// FromModelToCommentResponse converts a ticketmodel.TicketComment to a Comment DTO.
+func FromModelToCommentResponse(comment *ticketmodel.TicketComment) Comment {
+	return Comment{
+		Id:        comment.Id,
+		UserId:    comment.UserId,
+		Comment:   comment.Comment,
+		CreatedAt: comment.CreatedAt,
+	}
+}
@@ -0,0 +1,68 @@
+package userDTO
+
+import (
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AccountDeletionRequest struct {
+	Password     string `json:"password" binding:"required"`
+	OTP          string `json:"otp" binding:"-"`
+	RecoveryCode string `json:"recovery_code" binding:"-"`
+}
+
+type AccountDeletion struct {
+	Id           uuid.UUID `json:"id"`
+	ScheduledFor time.Time `json:"scheduled_for"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// FromModelToAccountDeletionResponse converts a usermodel.AccountDeletion to an AccountDeletion DTO.
+//
+// It takes a pointer to a usermodel.AccountDeletion struct as a parameter.
+// Returns a pointer to an AccountDeletion struct.
+func FromModelToAccountDeletionResponse(deletion *usermodel.AccountDeletion) *AccountDeletion {
+	return &AccountDeletion{
+		Id:           deletion.Id,
+		ScheduledFor: deletion.ScheduledFor,
+		CreatedAt:    deletion.CreatedAt,
+	}
+}
+
+type DataExport struct {
+	Id          uuid.UUID                  `json:"id"`
+	Status      usermodel.DataExportStatus `json:"status"`
+	Size        int                        `json:"size,omitempty"`
+	ExpiresAt   *time.Time                 `json:"expires_at,omitempty"`
+	CompletedAt *time.Time                 `json:"completed_at,omitempty"`
+	CreatedAt   time.Time                  `json:"created_at"`
+}
+
+// FromModelToDataExportResponse converts a usermodel.DataExport to a DataExport DTO.
+//
+// It takes a pointer to a usermodel.DataExport struct as a parameter.
+// Returns a pointer to a DataExport struct.
+func FromModelToDataExportResponse(export *usermodel.DataExport) *DataExport {
+	return &DataExport{
+		Id:          export.Id,
+		Status:      export.Status,
+		Size:        export.Size,
+		ExpiresAt:   export.ExpiresAt,
+		CompletedAt: export.CompletedAt,
+		CreatedAt:   export.CreatedAt,
+	}
+}
+
+// FromModelsToDataExportResponse converts a slice of usermodel.DataExport to a slice of DataExport DTOs.
+//
+// It takes a slice of usermodel.DataExport structs as a parameter.
+// Returns a slice of DataExport structs.
+func FromModelsToDataExportResponse(exports []usermodel.DataExport) []DataExport {
+	resp := make([]DataExport, len(exports))
+	for i := range exports {
+		resp[i] = *FromModelToDataExportResponse(&exports[i])
+	}
+	return resp
+}
@@ -0,0 +1,65 @@
+package userDTO
+
+import (
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ApiKeyCreate struct {
+	Name          string                 `json:"name" binding:"required"`
+	Scopes        []usermodel.Permission `json:"scopes"`
+	RateLimit     int                    `json:"rate_limit"`
+	ExpiresInDays int                    `json:"expires_in_days"`
+}
+
+type ApiKey struct {
+	Id         uuid.UUID              `json:"id"`
+	Name       string                 `json:"name"`
+	Prefix     string                 `json:"prefix"`
+	Scopes     []usermodel.Permission `json:"scopes"`
+	RateLimit  int                    `json:"rate_limit"`
+	ExpiresAt  *time.Time             `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time             `json:"last_used_at,omitempty"`
+	LastUsedIp string                 `json:"last_used_ip,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+type ApiKeyCreated struct {
+	ApiKey
+	Key string `json:"key"`
+}
+
+type ServiceAccountCreate struct {
+	Username string               `json:"username" binding:"required"`
+	Name     string               `json:"name" binding:"required"`
+	Region   usermodel.RegionType `json:"region" binding:"required"`
+}
+
+// FromModelToApiKeyResponse converts a usermodel.ApiKey to an ApiKey DTO.
+//
+// It takes a pointer to a usermodel.ApiKey struct as a parameter.
+// Returns a pointer to an ApiKey struct.
+func FromModelToApiKeyResponse(key *usermodel.ApiKey) *ApiKey {
+	return &ApiKey{
+		Id:         key.Id,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.GetScopes(),
+		RateLimit:  key.RateLimit,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		LastUsedIp: key.LastUsedIp,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+// FromModelsToApiKeyResponse converts a slice of usermodel.ApiKey to a slice of ApiKey DTOs.
+func FromModelsToApiKeyResponse(keys []usermodel.ApiKey) []ApiKey {
+	respKeys := make([]ApiKey, len(keys))
+	for i := range keys {
+		respKeys[i] = *FromModelToApiKeyResponse(&keys[i])
+	}
+	return respKeys
+}
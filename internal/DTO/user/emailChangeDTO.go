@@ -0,0 +1,46 @@
+package userDTO
+
+import (
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EmailChangeRequest struct {
+	NewEmail string `json:"new_email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	OTP      string `json:"otp" binding:"-"`
+
+	RecoveryCode string `json:"recovery_code" binding:"-"`
+}
+
+type EmailChangeVerify struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type EmailChangeCancel struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type EmailChange struct {
+	Id              uuid.UUID  `json:"id"`
+	NewEmail        string     `json:"new_email"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	CancelableUntil time.Time  `json:"cancelable_until"`
+	VerifiedAt      *time.Time `json:"verified_at,omitempty"`
+}
+
+// FromModelToEmailChangeResponse converts a usermodel.EmailChange to an EmailChange DTO.
+//
+// It takes a pointer to a usermodel.EmailChange struct as a parameter.
+// Returns a pointer to an EmailChange struct.
+func FromModelToEmailChangeResponse(change *usermodel.EmailChange) *EmailChange {
+	return &EmailChange{
+		Id:              change.Id,
+		NewEmail:        change.NewEmail,
+		ExpiresAt:       change.ExpiresAt,
+		CancelableUntil: change.CancelableUntil,
+		VerifiedAt:      change.VerifiedAt,
+	}
+}
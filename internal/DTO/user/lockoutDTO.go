@@ -0,0 +1,29 @@
+package userDTO
+
+import (
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	"time"
+)
+
+type LockedAccount struct {
+	User         *User     `json:"user"`
+	Failures     int       `json:"failures"`
+	LastFailedAt time.Time `json:"last_failed_at"`
+	LockedUntil  time.Time `json:"locked_until"`
+}
+
+// FromModelToLockedAccountResponse converts a locked user and its usermodel.Lockout to a LockedAccount DTO.
+//
+// It takes a pointer to a usermodel.User struct and a pointer to a usermodel.Lockout struct.
+// Returns a pointer to a LockedAccount struct.
+func FromModelToLockedAccountResponse(user *usermodel.User, lockout *usermodel.Lockout) *LockedAccount {
+	lockedAccount := &LockedAccount{
+		User:         FromModelToUserResponseForAdmins(user),
+		Failures:     lockout.Failures,
+		LastFailedAt: lockout.LastFailedAt,
+	}
+	if lockout.LockedUntil != nil {
+		lockedAccount.LockedUntil = *lockout.LockedUntil
+	}
+	return lockedAccount
+}
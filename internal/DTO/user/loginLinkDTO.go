@@ -0,0 +1,21 @@
+package userDTO
+
+import "github.com/google/uuid"
+
+type LoginLinkSend struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type LoginLinkSent struct {
+	Message string    `json:"message"`
+	Id      uuid.UUID `json:"id"`
+}
+
+type LoginLinkVerify struct {
+	Token string    `json:"token"`
+	Id    uuid.UUID `json:"id"`
+	Code  string    `json:"code"`
+	OTP   string    `json:"otp" binding:"-"`
+
+	RecoveryCode string `json:"recovery_code" binding:"-"`
+}
@@ -0,0 +1,55 @@
+package userDTO
+
+import (
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RoleRequest struct {
+	Name        string                 `json:"name" binding:"required"`
+	Description string                 `json:"description"`
+	Permissions []usermodel.Permission `json:"permissions"`
+}
+
+type AssignRoleRequest struct {
+	RoleId uuid.UUID `json:"role_id" binding:"required"`
+}
+
+type Role struct {
+	Id          uuid.UUID              `json:"id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Permissions []usermodel.Permission `json:"permissions"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+// FromModelToRoleResponse converts a usermodel.Role to a Role DTO.
+//
+// It takes a pointer to a usermodel.Role struct.
+// Returns a pointer to a Role struct.
+func FromModelToRoleResponse(role *usermodel.Role) *Role {
+	permissions := make([]usermodel.Permission, len(role.Permissions))
+	for i, permission := range role.Permissions {
+		permissions[i] = permission.Permission
+	}
+	return &Role{
+		Id:          role.Id,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+		CreatedAt:   role.CreatedAt,
+		UpdatedAt:   role.UpdatedAt,
+	}
+}
+
+// FromModelsToRoleResponse converts a slice of usermodel.Role to a slice of Role DTOs.
+func FromModelsToRoleResponse(roles []usermodel.Role) []Role {
+	respRoles := make([]Role, len(roles))
+	for i := range roles {
+		respRoles[i] = *FromModelToRoleResponse(&roles[i])
+	}
+	return respRoles
+}
@@ -0,0 +1,77 @@
+package userDTO
+
+import (
+	"github.com/drunkleen/rasta/internal/common/utils"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Session struct {
+	Id             uuid.UUID  `json:"id"`
+	Device         string     `json:"device"`
+	IpAddress      string     `json:"ip_address"`
+	UserAgent      string     `json:"user_agent"`
+	Current        bool       `json:"current"`
+	ImpersonatedBy *uuid.UUID `json:"impersonated_by,omitempty"`
+	LastSeenAt     time.Time  `json:"last_seen_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type ImpersonationRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+type Impersonation struct {
+	AccessToken string    `json:"access_token"`
+	SessionId   uuid.UUID `json:"session_id"`
+	UserId      uuid.UUID `json:"user_id"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// FromModelToSessionResponse converts a usermodel.Session to a Session DTO.
+//
+// It takes a pointer to a usermodel.Session struct and the ID of the session the request was made with.
+// Returns a pointer to a Session struct.
+func FromModelToSessionResponse(session *usermodel.Session, currentSessionId uuid.UUID) *Session {
+	return &Session{
+		Id:             session.Id,
+		Device:         utils.DeviceFromUserAgent(session.UserAgent),
+		IpAddress:      session.IpAddress,
+		UserAgent:      session.UserAgent,
+		Current:        session.Id == currentSessionId,
+		ImpersonatedBy: session.ImpersonatorId,
+		LastSeenAt:     session.LastSeenAt,
+		CreatedAt:      session.CreatedAt,
+	}
+}
+
+type KnownDevice struct {
+	Id         uuid.UUID `json:"id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IpAddress  string    `json:"ip_address"`
+	Location   string    `json:"location,omitempty"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type LoginReport struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// FromModelToKnownDeviceResponse converts a usermodel.KnownDevice to a KnownDevice DTO.
+//
+// It takes a pointer to a usermodel.KnownDevice struct and returns a pointer to a KnownDevice struct.
+func FromModelToKnownDeviceResponse(device *usermodel.KnownDevice) *KnownDevice {
+	return &KnownDevice{
+		Id:         device.Id,
+		Device:     device.Device,
+		UserAgent:  device.UserAgent,
+		IpAddress:  device.IpAddress,
+		Location:   device.Location,
+		LastSeenAt: device.LastSeenAt,
+		CreatedAt:  device.CreatedAt,
+	}
+}
@@ -0,0 +1,29 @@
+package userDTO
+
+import "strings"
+
+// SmsChallenge is returned when a sign-in needs the code sent by SMS to the user.
+type SmsChallenge struct {
+	Token     string `json:"token"`
+	Phone     string `json:"phone"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+// NewSmsChallenge returns the challenge of a sign-in, with the phone number the code has been
+// sent to masked but for its last digits.
+func NewSmsChallenge(token, phone string, expiresIn int) SmsChallenge {
+	masked := phone
+	if len(phone) > 4 {
+		masked = phone[:1] + strings.Repeat("*", len(phone)-5) + phone[len(phone)-4:]
+	}
+	return SmsChallenge{Token: token, Phone: masked, ExpiresIn: expiresIn}
+}
+
+type SmsLogin struct {
+	Token string `json:"token" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+type SmsCode struct {
+	Code string `json:"code" binding:"required"`
+}
@@ -0,0 +1,43 @@
+package userDTO
+
+import (
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SocialAuthorization struct {
+	Provider string `json:"provider"`
+	AuthUrl  string `json:"auth_url"`
+}
+
+type SocialCallback struct {
+	State string `json:"state" binding:"required"`
+	Code  string `json:"code"`
+	OTP   string `json:"otp" binding:"-"`
+
+	RecoveryCode string `json:"recovery_code" binding:"-"`
+}
+
+type Identity struct {
+	Id         uuid.UUID  `json:"id"`
+	Provider   string     `json:"provider"`
+	Email      string     `json:"email,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// FromModelToIdentityResponse converts a usermodel.Identity to an Identity DTO.
+//
+// It takes a pointer to a usermodel.Identity struct as a parameter.
+// Returns a pointer to an Identity struct.
+func FromModelToIdentityResponse(identity *usermodel.Identity) *Identity {
+	return &Identity{
+		Id:         identity.Id,
+		Provider:   identity.Provider,
+		Email:      identity.Email,
+		LastUsedAt: identity.LastUsedAt,
+		CreatedAt:  identity.CreatedAt,
+	}
+}
@@ -3,6 +3,7 @@ package userDTO
 import (
 	"errors"
 	oauthDTO "github.com/drunkleen/rasta/internal/DTO/oauth"
+	"github.com/drunkleen/rasta/internal/common/auth"
 	"github.com/drunkleen/rasta/internal/models/user"
 	"time"
 
@@ -22,6 +23,12 @@ type UserCreate struct {
 	Email     string               `json:"email" binding:"required"`
 	Password  string               `json:"password" binding:"required"`
 	Region    usermodel.RegionType `json:"region" binding:"required"`
+	// Phone is optional, in the E.164 format. The account can be verified with a code sent to it by SMS.
+	Phone string `json:"phone"`
+	// Challenge and Solution are the proof-of-work challenge issued by /users/signup/challenge and
+	// its solution, required unless signup challenges are disabled.
+	Challenge string `json:"challenge"`
+	Solution  string `json:"solution"`
 }
 
 // UserCreateToModel converts a UserCreate DTO to a usermodel.User, ready to be
@@ -34,6 +41,7 @@ func (u *UserCreate) UserCreateToModel() *usermodel.User {
 		Email:     u.Email,
 		Password:  u.Password,
 		Region:    u.Region,
+		Phone:     u.Phone,
 	}
 }
 
@@ -77,6 +85,7 @@ func (u *UserCreate) UserCreateResponseToModel() *usermodel.User {
 		Email:     u.Email,
 		Password:  u.Password,
 		Region:    u.Region,
+		Phone:     u.Phone,
 	}
 }
 
@@ -84,6 +93,8 @@ type UserLogin struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required,min=8"`
 	OTP      string `json:"otp" binding:"-"`
+
+	RecoveryCode string `json:"recovery_code" binding:"-"`
 }
 
 type User struct {
@@ -99,6 +110,18 @@ type User struct {
 	CreatedAt  time.Time             `json:"created_at,omitempty"`
 	UpdatedAt  time.Time             `json:"updated_at,omitempty"`
 	OAuth      oauthDTO.Response     `json:"oauth,omitempty"`
+
+	DisabledReason string     `json:"disabled_reason,omitempty"`
+	DisabledUntil  *time.Time `json:"disabled_until,omitempty"`
+
+	DisplayName string `json:"display_name,omitempty"`
+	Locale      string `json:"locale,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
+	Phone       string `json:"phone,omitempty"`
+	Bio         string `json:"bio,omitempty"`
+
+	PhoneVerified       bool `json:"phone_verified,omitempty"`
+	SmsTwoFactorEnabled bool `json:"sms_two_factor_enabled,omitempty"`
 }
 
 // FromModelToUserResponse converts a usermodel.User to a User DTO.
@@ -114,6 +137,40 @@ func FromModelToUserResponse(user *usermodel.User) *User {
 		Account:   user.Account,
 		Region:    user.Region,
 		CreatedAt: user.CreatedAt,
+
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+	}
+}
+
+// FromModelToProfileResponse converts a usermodel.User to a User DTO for the user themselves.
+//
+// It takes a pointer to a usermodel.User struct as a parameter.
+// Returns a pointer to a User struct.
+func FromModelToProfileResponse(user *usermodel.User) *User {
+	return &User{
+		Id:         user.Id,
+		FirstName:  user.FirstName,
+		LastName:   user.LastName,
+		Username:   user.Username,
+		Email:      user.Email,
+		Account:    user.Account,
+		Region:     user.Region,
+		IsVerified: user.IsVerified,
+		CreatedAt:  user.CreatedAt,
+		UpdatedAt:  user.UpdatedAt,
+		OAuth: oauthDTO.Response{
+			Enabled: user.OAuth.Enabled,
+		},
+
+		DisplayName: user.DisplayName,
+		Locale:      user.Locale,
+		Timezone:    user.Timezone,
+		Phone:       user.Phone,
+		Bio:         user.Bio,
+
+		PhoneVerified:       user.PhoneVerified,
+		SmsTwoFactorEnabled: user.SmsTwoFactorEnabled,
 	}
 }
 
@@ -136,27 +193,64 @@ func FromModelToUserResponseForAdmins(user *usermodel.User) *User {
 		OAuth: oauthDTO.Response{
 			Enabled: user.OAuth.Enabled,
 		},
+		DisabledReason: user.DisabledReason,
+		DisabledUntil:  user.DisabledUntil,
+
+		DisplayName: user.DisplayName,
+		Locale:      user.Locale,
+		Timezone:    user.Timezone,
+		Phone:       user.Phone,
+		Bio:         user.Bio,
+
+		PhoneVerified:       user.PhoneVerified,
+		SmsTwoFactorEnabled: user.SmsTwoFactorEnabled,
 	}
 }
 
+// ProfileUpdate is a partial update of the profile of the authenticated user.
+// Fields left out are not changed; display_name, locale, timezone, phone and
+// bio are cleared when set to an empty string.
+type ProfileUpdate struct {
+	FirstName   *string               `json:"first_name"`
+	LastName    *string               `json:"last_name"`
+	Username    *string               `json:"username"`
+	Region      *usermodel.RegionType `json:"region"`
+	DisplayName *string               `json:"display_name"`
+	Locale      *string               `json:"locale"`
+	Timezone    *string               `json:"timezone"`
+	Phone       *string               `json:"phone"`
+	Bio         *string               `json:"bio"`
+}
+
 type LoginResponse struct {
-	Status string `json:"status"`
-	User   *User  `json:"user"`
-	Token  string `json:"token"`
+	Status       string `json:"status"`
+	User         *User  `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 // FromModelToUserLoginResponse converts a usermodel.User to a LoginResponse DTO.
 //
-// It takes a pointer to a usermodel.User struct and a token string as parameters.
+// It takes a pointer to a usermodel.User struct, an access token and a refresh token as parameters.
 // Returns a pointer to a LoginResponse struct.
-func FromModelToUserLoginResponse(user *usermodel.User, token string) *LoginResponse {
+func FromModelToUserLoginResponse(user *usermodel.User, token, refreshToken string) *LoginResponse {
 	return &LoginResponse{
-		Status: "success",
-		User:   FromModelToUserResponse(user),
-		Token:  token,
+		Status:       "success",
+		User:         FromModelToUserResponse(user),
+		Token:        token,
+		RefreshToken: refreshToken,
 	}
 }
 
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type SuspendUser struct {
+	Reason string     `json:"reason" binding:"required,max=256"`
+	Until  *time.Time `json:"until"`
+}
+
 type UpdatePassword struct {
 	OldPassword  string `json:"old_password" binding:"required"`
 	NewPassword1 string `json:"new_password1" binding:"required"`
@@ -174,8 +268,36 @@ func (u *UpdatePassword) Validate() error {
 	return nil
 }
 
+// PasswordPolicy lists the rules new passwords must follow.
+type PasswordPolicy struct {
+	MinLength        int  `json:"min_length"`
+	MaxLength        int  `json:"max_length"`
+	RequireUppercase bool `json:"require_uppercase"`
+	RequireLowercase bool `json:"require_lowercase"`
+	RequireDigit     bool `json:"require_digit"`
+	RequireSymbol    bool `json:"require_symbol"`
+	MinStrength      int  `json:"min_strength"`
+	History          int  `json:"history"`
+}
+
+// FromPasswordPolicy converts the password policy to its response.
+func FromPasswordPolicy(policy *auth.PasswordPolicy) PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:        policy.MinLength,
+		MaxLength:        policy.MaxLength,
+		RequireUppercase: policy.RequireUppercase,
+		RequireLowercase: policy.RequireLowercase,
+		RequireDigit:     policy.RequireDigit,
+		RequireSymbol:    policy.RequireSymbol,
+		MinStrength:      policy.MinStrength,
+		History:          policy.History,
+	}
+}
+
 type ResetPassword struct {
-	Otp          string `json:"otp" binding:"required"`
+	Otp string `json:"otp" binding:"required"`
+	// Channel is the channel the code has been sent through: email, the default, or sms.
+	Channel      string `json:"channel"`
 	NewPassword1 string `json:"new_password1" binding:"required"`
 	NewPassword2 string `json:"new_password2" binding:"required"`
 }
@@ -190,3 +312,13 @@ func (u *ResetPassword) Validate() error {
 	}
 	return nil
 }
+
+// SignupChallenge is a proof-of-work challenge to solve before signing up: find a Solution of at most
+// 64 characters such that the SHA-256 hash of Challenge followed by Solution starts with Difficulty
+// zero bits.
+type SignupChallenge struct {
+	Challenge  string    `json:"challenge"`
+	Algorithm  string    `json:"algorithm"`
+	Difficulty int       `json:"difficulty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
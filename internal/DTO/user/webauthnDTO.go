@@ -0,0 +1,45 @@
+package userDTO
+
+import (
+	"encoding/json"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WebAuthnCeremony struct {
+	CeremonyId uuid.UUID   `json:"ceremony_id"`
+	Options    interface{} `json:"options"`
+}
+
+type WebAuthnRegister struct {
+	CeremonyId uuid.UUID       `json:"ceremony_id" binding:"required"`
+	Name       string          `json:"name" binding:"max=64"`
+	Credential json.RawMessage `json:"credential" binding:"required" swaggertype:"object"`
+}
+
+type WebAuthnLogin struct {
+	CeremonyId uuid.UUID       `json:"ceremony_id" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required" swaggertype:"object"`
+}
+
+type WebAuthnCredential struct {
+	Id         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// FromModelToWebAuthnCredentialResponse converts a usermodel.WebAuthnCredential to a WebAuthnCredential DTO.
+//
+// It takes a pointer to a usermodel.WebAuthnCredential struct as a parameter.
+// Returns a pointer to a WebAuthnCredential struct.
+func FromModelToWebAuthnCredentialResponse(credential *usermodel.WebAuthnCredential) *WebAuthnCredential {
+	return &WebAuthnCredential{
+		Id:         credential.Id,
+		Name:       credential.Name,
+		LastUsedAt: credential.LastUsedAt,
+		CreatedAt:  credential.CreatedAt,
+	}
+}
@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// ApiKeyPrefix starts every API key, so leaked keys are easy to recognize.
+const ApiKeyPrefix = "rasta_"
+
+// apiKeyVisibleLength is how many characters of a key, prefix included, are kept to identify it.
+const apiKeyVisibleLength = len(ApiKeyPrefix) + 6
+
+// GenerateApiKey generates an API key.
+//
+// The key is ApiKeyPrefix followed by 32 bytes read from crypto/rand, URL-safe encoded.
+// Returns the key, the visible part of the key that is stored to identify it, and an error if the random source fails.
+func GenerateApiKey() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	key := ApiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:apiKeyVisibleLength], nil
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/drunkleen/rasta/config"
+)
+
+// blockedDomainsCheckInterval is how often the blocked domain list is checked for changes.
+const blockedDomainsCheckInterval = 30 * time.Second
+
+// domainBlocklist holds the email domains refused at signup, reloaded when its file changes so it
+// can be updated without a restart.
+type domainBlocklist struct {
+	mu        sync.RWMutex
+	path      string
+	modTime   time.Time
+	checkedAt time.Time
+	domains   map[string]struct{}
+}
+
+var blockedDomains = &domainBlocklist{}
+
+// InitBlockedDomains loads the list of email domains refused at signup, if SIGNUP_BLOCKED_DOMAINS
+// names one. The file holds one domain per line; blank lines and lines starting with # are ignored.
+//
+// Returns an error if the file cannot be read.
+func InitBlockedDomains() error {
+	list := &domainBlocklist{path: config.GetSignupBlockedDomains()}
+	if list.path != "" {
+		if err := list.load(); err != nil {
+			return err
+		}
+	}
+	blockedDomains = list
+	return nil
+}
+
+// IsDomainBlocked reports whether an email domain, or any domain it is a subdomain of, is in the
+// blocked domain list.
+func IsDomainBlocked(domain string) bool {
+	blockedDomains.refresh()
+	blockedDomains.mu.RLock()
+	defer blockedDomains.mu.RUnlock()
+	if len(blockedDomains.domains) == 0 {
+		return false
+	}
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	for {
+		if _, found := blockedDomains.domains[domain]; found {
+			return true
+		}
+		dot := strings.IndexByte(domain, '.')
+		if dot < 0 {
+			return false
+		}
+		domain = domain[dot+1:]
+	}
+}
+
+// refresh reloads the list if its file has changed since it was loaded. A list that cannot be
+// reloaded is kept as it was.
+func (l *domainBlocklist) refresh() {
+	if l.path == "" {
+		return
+	}
+	l.mu.Lock()
+	if time.Since(l.checkedAt) < blockedDomainsCheckInterval {
+		l.mu.Unlock()
+		return
+	}
+	l.checkedAt = time.Now()
+	modTime := l.modTime
+	l.mu.Unlock()
+
+	info, err := os.Stat(l.path)
+	if err != nil {
+		log.Printf("failed to check blocked domain list: %v", err)
+		return
+	}
+	if info.ModTime().Equal(modTime) {
+		return
+	}
+	if err = l.load(); err != nil {
+		log.Printf("failed to reload blocked domain list: %v", err)
+	}
+}
+
+// load reads the list from its file.
+func (l *domainBlocklist) load() error {
+	file, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	domains := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains[strings.TrimSuffix(strings.TrimLeft(line, "@."), ".")] = struct{}{}
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	l.domains = domains
+	l.modTime = info.ModTime()
+	l.checkedAt = time.Now()
+	l.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/drunkleen/rasta/config"
+)
+
+// EncryptedPrefix starts every value encrypted by Encrypt, followed by the ID of the master key
+// wrapping its data key, the wrapped data key and the ciphertext, separated by colons.
+const EncryptedPrefix = "enc:v2:"
+
+// legacyEncryptedPrefix starts the values encrypted with a context of the previous format, which
+// only named their column. Reencrypt encrypts them again with the context of the current format.
+const legacyEncryptedPrefix = "enc:v1:"
+
+// dataKeySize is the size of the AES-256 keys, master and data keys alike.
+const dataKeySize = 32
+
+// masterKey is a key encrypting the data keys of the encrypted values.
+type masterKey struct {
+	Id   string
+	AEAD cipher.AEAD
+}
+
+// encryptionKeys holds the master keys. The first one encrypts new values, the others are
+// retired and only decrypt the values that have not been re-encrypted yet.
+var encryptionKeys []*masterKey
+
+// InitEncryption loads the master keys from ENCRYPTION_KEYS.
+//
+// Returns an error if no key is configured or a key is not a base64 encoded 32 bytes key.
+func InitEncryption() error {
+	keys, err := parseEncryptionKeys(config.GetEncryptionKeys())
+	if err != nil {
+		return err
+	}
+	encryptionKeys = keys
+	return nil
+}
+
+// parseEncryptionKeys parses a comma separated list of "id:base64key" master keys.
+func parseEncryptionKeys(value string) ([]*masterKey, error) {
+	var keys []*masterKey
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, found := strings.Cut(entry, ":")
+		if !found || id == "" || strings.ContainsAny(id, ": ") {
+			return nil, fmt.Errorf("invalid encryption key %q, expected id:base64key", id)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("duplicate encryption key %q", id)
+		}
+		seen[id] = true
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(raw) != dataKeySize {
+			return nil, fmt.Errorf("encryption key %q must be %d base64 encoded bytes", id, dataKeySize)
+		}
+		aead, err := newAEAD(raw)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, &masterKey{Id: id, AEAD: aead})
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no encryption key configured, set ENCRYPTION_KEYS")
+	}
+	return keys, nil
+}
+
+// Encrypt encrypts a value with a new data key, itself encrypted with the active master key.
+//
+// context binds the ciphertext to where it is stored, such as "table.column/row", so it cannot be
+// moved to another column or row; the same context must be given to Decrypt.
+func Encrypt(plaintext, context string) (string, error) {
+	if len(encryptionKeys) == 0 {
+		return "", errors.New("encryption is not initialized")
+	}
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(aead, []byte(plaintext), []byte(context))
+	if err != nil {
+		return "", err
+	}
+	return envelope(dataKey, ciphertext)
+}
+
+// Decrypt decrypts a value returned by Encrypt with the same context.
+//
+// Returns an error if the value is not encrypted, its master key is not configured or it has been
+// tampered with.
+func Decrypt(value, context string) (string, error) {
+	dataKey, ciphertext, err := openEnvelope(value)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(aead, ciphertext, []byte(context))
+	if err != nil {
+		return "", errors.New("failed to decrypt value")
+	}
+	return string(plaintext), nil
+}
+
+// IsEncrypted reports whether a value has been encrypted by Encrypt, with a context of the current format.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, EncryptedPrefix)
+}
+
+// isLegacyEncrypted reports whether a value has been encrypted with a context of the previous format.
+func isLegacyEncrypted(value string) bool {
+	return strings.HasPrefix(value, legacyEncryptedPrefix)
+}
+
+// ActiveEncryptionKeyId returns the ID of the master key encrypting new values.
+func ActiveEncryptionKeyId() string {
+	if len(encryptionKeys) == 0 {
+		return ""
+	}
+	return encryptionKeys[0].Id
+}
+
+// ActiveEncryptionPrefix returns the prefix of the values encrypted with the active master key.
+// Values without it are either not encrypted or must be re-encrypted after a key rotation.
+func ActiveEncryptionPrefix() string {
+	return EncryptedPrefix + ActiveEncryptionKeyId() + ":"
+}
+
+// Reencrypt brings a stored value up to date with the active master key.
+//
+// A value that is not encrypted yet is encrypted with context, and a value encrypted with a context
+// of the previous format is decrypted with legacyContext and encrypted again with context. The data
+// key of a value encrypted with a retired master key is re-encrypted with the active one, leaving
+// the ciphertext untouched. Values encrypted with the active master key are returned as is.
+func Reencrypt(value, context, legacyContext string) (string, error) {
+	if isLegacyEncrypted(value) {
+		plaintext, err := Decrypt(value, legacyContext)
+		if err != nil {
+			return "", err
+		}
+		return Encrypt(plaintext, context)
+	}
+	if !IsEncrypted(value) {
+		return Encrypt(value, context)
+	}
+	if strings.HasPrefix(value, ActiveEncryptionPrefix()) {
+		return value, nil
+	}
+	dataKey, ciphertext, err := openEnvelope(value)
+	if err != nil {
+		return "", err
+	}
+	return envelope(dataKey, ciphertext)
+}
+
+// envelope encrypts a data key with the active master key and joins it to the ciphertext.
+func envelope(dataKey, ciphertext []byte) (string, error) {
+	key := encryptionKeys[0]
+	wrappedKey, err := seal(key.AEAD, dataKey, []byte(key.Id))
+	if err != nil {
+		return "", err
+	}
+	return EncryptedPrefix + key.Id + ":" +
+		base64.RawURLEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.RawURLEncoding.EncodeToString(ciphertext), nil
+}
+
+// openEnvelope splits an encrypted value and decrypts its data key with its master key.
+func openEnvelope(value string) ([]byte, []byte, error) {
+	var parts []string
+	switch {
+	case IsEncrypted(value):
+		parts = strings.Split(strings.TrimPrefix(value, EncryptedPrefix), ":")
+	case isLegacyEncrypted(value):
+		parts = strings.Split(strings.TrimPrefix(value, legacyEncryptedPrefix), ":")
+	default:
+		return nil, nil, errors.New("value is not encrypted")
+	}
+	if len(parts) != 3 {
+		return nil, nil, errors.New("malformed encrypted value")
+	}
+	key := findEncryptionKey(parts[0])
+	if key == nil {
+		return nil, nil, fmt.Errorf("unknown encryption key %q", parts[0])
+	}
+	wrappedKey, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, nil, errors.New("malformed encrypted value")
+	}
+	ciphertext, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, errors.New("malformed encrypted value")
+	}
+	dataKey, err := open(key.AEAD, wrappedKey, []byte(key.Id))
+	if err != nil || len(dataKey) != dataKeySize {
+		return nil, nil, errors.New("failed to decrypt data key")
+	}
+	return dataKey, ciphertext, nil
+}
+
+// findEncryptionKey returns the master key with the given ID, or nil.
+func findEncryptionKey(id string) *masterKey {
+	for _, key := range encryptionKeys {
+		if key.Id == id {
+			return key
+		}
+	}
+	return nil
+}
+
+// newAEAD returns AES-GCM with the given key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce, which is prepended to the ciphertext.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts a ciphertext returned by seal.
+func open(aead cipher.AEAD, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, additionalData)
+}
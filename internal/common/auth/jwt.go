@@ -4,53 +4,169 @@ import (
 	"errors"
 	"fmt"
 	"github.com/drunkleen/rasta/config"
+	"log"
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 )
 
-// GenerateJWTToken generates a JWT token based on the provided email and user ID.
+// keySet holds the asymmetric signing keys. It is nil when tokens are signed with JWT_SECRET (HS256).
+var keySet *KeySet
+
+// hmacAcceptedUntil is when tokens signed with JWT_SECRET stop being accepted once a key set is loaded.
+var hmacAcceptedUntil time.Time
+
+// InitKeySet loads the asymmetric signing keys when JWT_SIGNING_ALG is RS256 or EdDSA,
+// and starts the scheduled key rotation when JWT_KEY_ROTATION is set. Tokens signed with
+// JWT_SECRET are then only accepted for JWT_HMAC_GRACE seconds, so that switching algorithms
+// retires the shared secret.
+//
+// It does nothing when tokens are signed with HS256.
+func InitKeySet() error {
+	alg := config.GetJwtSigningAlg()
+	if alg == jwt.SigningMethodHS256.Alg() {
+		return nil
+	}
+	ks, err := NewKeySet(config.GetJwtKeysDir(), alg, time.Duration(config.GetJwtKeyGrace())*time.Second)
+	if err != nil {
+		return err
+	}
+	if rotation := config.GetJwtKeyRotation(); rotation > 0 {
+		ks.StartRotation(time.Duration(rotation) * time.Second)
+	}
+	keySet = ks
+	hmacAcceptedUntil = time.Now().Add(time.Duration(config.GetJwtHmacGrace()) * time.Second)
+	log.Printf("signing access tokens with %s", alg)
+	return nil
+}
+
+// GetJWKS returns the public signing keys as a JSON Web Key Set.
+//
+// The set is empty when tokens are signed with HS256, since the secret must never be published.
+func GetJWKS() JWKS {
+	if keySet == nil {
+		return JWKS{Keys: []JWK{}}
+	}
+	return keySet.JWKS()
+}
+
+// Claims are the claims carried by every access token issued by Rasta.
+//
+// Every token carries a unique ID (jti) so it can be revoked before it expires.
+// Impersonation tokens also carry the admin acting as the user in the act claim.
+type Claims struct {
+	UserId    string `json:"userId"`
+	Email     string `json:"email"`
+	SessionId string `json:"sid,omitempty"`
+	Actor     *Actor `json:"act,omitempty"`
+	jwt.StandardClaims
+}
+
+// Actor identifies the admin an impersonation token was issued to, as in the act claim of RFC 8693.
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// GenerateJWTToken generates a JWT token based on the provided email, user ID and session ID.
 //
-// Parameter email is the user's email address and userId is the unique identifier of the user.
+// Parameter email is the user's email address, userId is the unique identifier of the user and
+// sessionId is the identifier of the session the token belongs to.
 // Return type is a string representing the generated JWT token and an error object that is returned if the generation fails.
-func GenerateJWTToken(email string, userId string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"email":  email,
-		"userId": userId,
-		"exp":    time.Now().Add(time.Second * time.Duration(config.GetJwtExpiry())).Unix(),
+func GenerateJWTToken(email string, userId string, sessionId string) (string, error) {
+	now := time.Now()
+	return signClaims(&Claims{
+		UserId:    userId,
+		Email:     email,
+		SessionId: sessionId,
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.NewString(),
+			Issuer:    config.GetJwtIssuer(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(time.Second * time.Duration(config.GetJwtExpiry())).Unix(),
+		},
 	})
-	return token.SignedString([]byte(config.GetJwtSecret()))
 }
 
-// ValidateJWTToken validates a JWT token and returns the user ID associated with it.
+// GenerateImpersonationToken generates an access token allowing an admin to act as a user.
 //
-// Parameter token is the JWT token to be validated.
-// Return type is a string representing the user ID and an error object that is returned if the validation fails.
-func ValidateJWTToken(token string) (string, string, error) {
-	parsedToken, err := jwt.Parse(token, func(token *jwt.Token) (any, error) {
-		// Ensure the signing method is HMAC
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method: " + token.Header["alg"].(string))
+// Parameter email is the user's email address, userId is the unique identifier of the user, sessionId
+// is the identifier of the impersonation session, actorId is the unique identifier of the admin and
+// expiresAt is when the token expires.
+// Return type is a string representing the generated JWT token and an error object that is returned if the generation fails.
+func GenerateImpersonationToken(email, userId, sessionId, actorId string, expiresAt time.Time) (string, error) {
+	return signClaims(&Claims{
+		UserId:    userId,
+		Email:     email,
+		SessionId: sessionId,
+		Actor:     &Actor{Subject: actorId},
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.NewString(),
+			Issuer:    config.GetJwtIssuer(),
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+	})
+}
+
+// signClaims signs the claims with the active asymmetric key, or with JWT_SECRET when no key set is loaded.
+func signClaims(claims jwt.Claims) (string, error) {
+	if keySet != nil {
+		return keySet.Sign(claims)
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(config.GetJwtSecret()))
+}
+
+// verificationKey returns the key a token must be verified with, based on its signing method and kid header.
+//
+// Tokens signed with JWT_SECRET are refused once a key set is loaded, past JWT_HMAC_GRACE.
+func verificationKey(token *jwt.Token) (any, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if config.GetJwtSecret() == "" || (keySet != nil && time.Now().After(hmacAcceptedUntil)) {
+			return nil, errors.New("HMAC signed tokens are not accepted")
 		}
 		return []byte(config.GetJwtSecret()), nil
-	})
+	}
+	if keySet == nil {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		return nil, errors.New("missing key ID")
+	}
+	return keySet.PublicKey(kid, token.Method)
+}
+
+// ParseJWTToken parses and validates a JWT token and returns its claims.
+//
+// Parameter token is the JWT token to be parsed.
+// Return type is a pointer to the token claims and an error object that is returned if the validation fails.
+func ParseJWTToken(token string) (*Claims, error) {
+	claims := &Claims{}
+	parsedToken, err := jwt.ParseWithClaims(token, claims, verificationKey)
 	if err != nil {
-		return "", "", fmt.Errorf("token parsing error: %w", err)
+		return nil, fmt.Errorf("token parsing error: %w", err)
 	}
 	if !parsedToken.Valid {
-		return "", "", errors.New("invalid or expired token")
+		return nil, errors.New("invalid or expired token")
 	}
-	claims, ok := parsedToken.Claims.(jwt.MapClaims)
-	if !ok {
-		return "", "", errors.New("invalid token claims")
+	if claims.UserId == "" || claims.Email == "" || claims.Id == "" {
+		return nil, errors.New("invalid token claims")
 	}
-	userId, ok := claims["userId"].(string)
-	if !ok {
-		return "", "", errors.New("invalid token claims")
+	if claims.Actor != nil && claims.Actor.Subject == "" {
+		return nil, errors.New("invalid token claims")
 	}
-	email, ok := claims["email"].(string)
-	if !ok {
-		return "", "", errors.New("invalid token claims")
+	return claims, nil
+}
+
+// ValidateJWTToken validates a JWT token and returns the user ID associated with it.
+//
+// Parameter token is the JWT token to be validated.
+// Return type is a string representing the user ID, a string representing the email and an error object that is returned if the validation fails.
+func ValidateJWTToken(token string) (string, string, error) {
+	claims, err := ParseJWTToken(token)
+	if err != nil {
+		return "", "", err
 	}
-	return userId, email, nil
+	return claims.UserId, claims.Email, nil
 }
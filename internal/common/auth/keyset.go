@@ -0,0 +1,303 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// signingKey is an asymmetric key used to sign access tokens.
+type signingKey struct {
+	Kid       string
+	Method    jwt.SigningMethod
+	Private   crypto.Signer
+	CreatedAt time.Time
+}
+
+// KeySet holds the asymmetric signing keys, loaded from a directory where every
+// key is stored as a PKCS#8 PEM file named after its key ID (kid).
+//
+// The newest key signs new tokens. Older keys are retired but kept for
+// verification until the grace window has passed, then they are pruned.
+type KeySet struct {
+	mu     sync.RWMutex
+	dir    string
+	method jwt.SigningMethod
+	grace  time.Duration
+	keys   []*signingKey
+}
+
+// JWK is the public part of a signing key as a JSON Web Key.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewKeySet loads the signing keys stored in dir for the given algorithm.
+//
+// alg must be RS256 or EdDSA. Keys of another type found in dir are ignored.
+// If dir holds no usable key, a first key is generated.
+func NewKeySet(dir, alg string, grace time.Duration) (*KeySet, error) {
+	method := jwt.GetSigningMethod(alg)
+	if method != jwt.SigningMethodRS256 && method != jwt.SigningMethodEdDSA {
+		return nil, fmt.Errorf("unsupported asymmetric signing algorithm: %s", alg)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	ks := &KeySet{dir: dir, method: method, grace: grace}
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+	if ks.active() == nil {
+		if err := ks.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+	return ks, nil
+}
+
+// Reload re-reads the key directory, picking up keys created by other instances.
+func (ks *KeySet) Reload() error {
+	entries, err := os.ReadDir(ks.dir)
+	if err != nil {
+		return err
+	}
+	var keys []*signingKey
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pem" {
+			continue
+		}
+		key, err := ks.readKey(filepath.Join(ks.dir, entry.Name()))
+		if err != nil {
+			log.Printf("skipping signing key %s: %v", entry.Name(), err)
+			continue
+		}
+		if key.Method == ks.method {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+	return nil
+}
+
+// Rotate generates a new signing key, which becomes the active key, and prunes
+// retired keys whose grace window has passed.
+func (ks *KeySet) Rotate() error {
+	var private crypto.Signer
+	var err error
+	switch ks.method {
+	case jwt.SigningMethodRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return err
+	}
+	// Random bytes keep the key IDs of instances rotating within the same second apart.
+	suffix := make([]byte, 6)
+	if _, err = rand.Read(suffix); err != nil {
+		return err
+	}
+	now := time.Now()
+	kid := fmt.Sprintf("%s-%d-%s", strings.ToLower(ks.method.Alg()), now.Unix(), hex.EncodeToString(suffix))
+	path := filepath.Join(ks.dir, kid+".pem")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("generated signing key %s", kid)
+
+	ks.mu.Lock()
+	ks.keys = append(ks.keys, &signingKey{Kid: kid, Method: ks.method, Private: private, CreatedAt: now})
+	ks.mu.Unlock()
+	ks.prune()
+	return nil
+}
+
+// StartRotation rotates the active key once it is older than interval and
+// keeps the key set in sync with the key directory. It returns immediately.
+func (ks *KeySet) StartRotation(interval time.Duration) {
+	check := time.Minute
+	if interval < check {
+		check = interval
+	}
+	go func() {
+		for range time.Tick(check) {
+			if err := ks.Reload(); err != nil {
+				log.Printf("failed to reload signing keys: %v", err)
+				continue
+			}
+			if active := ks.active(); active == nil || time.Since(active.CreatedAt) >= interval {
+				if err := ks.Rotate(); err != nil {
+					log.Printf("failed to rotate signing key: %v", err)
+				}
+				continue
+			}
+			ks.prune()
+		}
+	}()
+}
+
+// Sign signs the given claims with the active key.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	key := ks.active()
+	if key == nil {
+		return "", errors.New("no active signing key")
+	}
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.Kid
+	return token.SignedString(key.Private)
+}
+
+// PublicKey returns the public key with the given key ID, used to verify a token
+// signed with method.
+func (ks *KeySet) PublicKey(kid string, method jwt.SigningMethod) (crypto.PublicKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	for _, key := range ks.keys {
+		if key.Kid == kid {
+			if key.Method != method {
+				return nil, errors.New("signing method does not match key")
+			}
+			return key.Private.Public(), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key: %s", kid)
+}
+
+// JWKS returns the public keys of the key set, including retired keys still in their grace window.
+func (ks *KeySet) JWKS() JWKS {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	jwks := JWKS{Keys: make([]JWK, 0, len(ks.keys))}
+	for _, key := range ks.keys {
+		jwk := JWK{Kid: key.Kid, Use: "sig", Alg: key.Method.Alg()}
+		switch public := key.Private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+// active returns the newest key, or nil if the key set is empty.
+func (ks *KeySet) active() *signingKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	if len(ks.keys) == 0 {
+		return nil
+	}
+	return ks.keys[len(ks.keys)-1]
+}
+
+// prune removes retired keys whose grace window has passed. A key is retired
+// as soon as a newer key exists.
+func (ks *KeySet) prune() {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	kept := ks.keys[:0]
+	for i, key := range ks.keys {
+		if i < len(ks.keys)-1 && time.Since(ks.keys[i+1].CreatedAt) > ks.grace {
+			if err := os.Remove(filepath.Join(ks.dir, key.Kid+".pem")); err != nil && !os.IsNotExist(err) {
+				log.Printf("failed to remove signing key %s: %v", key.Kid, err)
+			}
+			log.Printf("pruned signing key %s", key.Kid)
+			continue
+		}
+		kept = append(kept, key)
+	}
+	ks.keys = kept
+}
+
+// readKey reads a PKCS#8 (or PKCS#1 RSA) PEM private key. The file name is the
+// key ID and its modification time the creation time of the key.
+func (ks *KeySet) readKey(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid PEM data")
+	}
+	var parsed any
+	if block.Type == "RSA PRIVATE KEY" {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	key := &signingKey{
+		Kid:       strings.TrimSuffix(filepath.Base(path), ".pem"),
+		CreatedAt: info.ModTime(),
+	}
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method = jwt.SigningMethodRS256
+		key.Private = private
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+		key.Private = private
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+	return key, nil
+}
@@ -0,0 +1,21 @@
+package auth
+
+// LoginLinkCodeLength is the number of digits of the code sent along with a sign-in link.
+const LoginLinkCodeLength = 8
+
+// GenerateLoginLink generates the secrets of a passwordless sign-in request.
+//
+// The token is carried by the emailed link and is as strong as a refresh token. The code is
+// a short numeric alternative typed by the user; both are built from crypto/rand.
+// Returns the token, the code and an error if the random source fails.
+func GenerateLoginLink() (string, string, error) {
+	token, err := GenerateRefreshToken()
+	if err != nil {
+		return "", "", err
+	}
+	code, err := generateDigits(LoginLinkCodeLength)
+	if err != nil {
+		return "", "", err
+	}
+	return token, code, nil
+}
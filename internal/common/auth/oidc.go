@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/drunkleen/rasta/config"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+)
+
+// OidcCodeTimeout is how long a partner app has to exchange an authorization code.
+const OidcCodeTimeout = time.Minute
+
+// OidcAccessClaims are the claims carried by the access tokens issued to partner apps.
+//
+// They lack the userId claim of Rasta access tokens, so they are never accepted by the API itself.
+type OidcAccessClaims struct {
+	ClientId string `json:"client_id"`
+	Scope    string `json:"scope"`
+	jwt.StandardClaims
+}
+
+// OidcAvailable reports whether Rasta can act as an OpenID Connect provider.
+//
+// Partner apps verify ID tokens with the published keys, so tokens must be signed with an
+// asymmetric key; a token signed with JWT_SECRET could not be verified without sharing the secret.
+func OidcAvailable() bool {
+	return keySet != nil
+}
+
+// OidcSigningAlg returns the algorithm ID tokens are signed with.
+func OidcSigningAlg() string {
+	return config.GetJwtSigningAlg()
+}
+
+// GenerateIdToken generates an ID token for a partner app.
+//
+// Parameter subject is the ID of the user, clientId the client ID of the app, nonce the value
+// the app sent with the authorization request, and claims the user claims granted to the app.
+// Return type is a string representing the signed ID token and an error object that is returned if the generation fails.
+func GenerateIdToken(subject, clientId, nonce string, claims map[string]any) (string, error) {
+	if keySet == nil {
+		return "", errors.New("no asymmetric signing key")
+	}
+	now := time.Now()
+	token := jwt.MapClaims{}
+	for name, value := range claims {
+		token[name] = value
+	}
+	token["jti"] = uuid.NewString()
+	token["iss"] = config.GetOidcIssuerUrl()
+	token["sub"] = subject
+	token["aud"] = clientId
+	token["azp"] = clientId
+	token["iat"] = now.Unix()
+	token["exp"] = now.Add(time.Second * time.Duration(config.GetJwtExpiry())).Unix()
+	if nonce != "" {
+		token["nonce"] = nonce
+	}
+	return keySet.Sign(token)
+}
+
+// GenerateOidcAccessToken generates an access token a partner app calls the userinfo endpoint with.
+//
+// Parameter subject is the ID of the user, clientId the client ID of the app and scope the scopes granted to it.
+// Return type is a string representing the signed access token and an error object that is returned if the generation fails.
+func GenerateOidcAccessToken(subject, clientId, scope string) (string, error) {
+	if keySet == nil {
+		return "", errors.New("no asymmetric signing key")
+	}
+	now := time.Now()
+	return keySet.Sign(&OidcAccessClaims{
+		ClientId: clientId,
+		Scope:    scope,
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.NewString(),
+			Issuer:    config.GetOidcIssuerUrl(),
+			Subject:   subject,
+			Audience:  clientId,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(time.Second * time.Duration(config.GetJwtExpiry())).Unix(),
+		},
+	})
+}
+
+// ParseOidcAccessToken parses and validates an access token issued to a partner app and returns its claims.
+//
+// Parameter token is the access token to be parsed.
+// Return type is a pointer to the token claims and an error object that is returned if the validation fails.
+func ParseOidcAccessToken(token string) (*OidcAccessClaims, error) {
+	if keySet == nil {
+		return nil, errors.New("no asymmetric signing key")
+	}
+	claims := &OidcAccessClaims{}
+	parsedToken, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (any, error) {
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, errors.New("missing key ID")
+		}
+		return keySet.PublicKey(kid, token.Method)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("token parsing error: %w", err)
+	}
+	if !parsedToken.Valid {
+		return nil, errors.New("invalid or expired token")
+	}
+	if claims.Issuer != config.GetOidcIssuerUrl() || claims.Subject == "" || claims.ClientId == "" {
+		return nil, errors.New("invalid token claims")
+	}
+	return claims, nil
+}
+
+// VerifyPkce reports whether a PKCE code verifier matches the S256 code challenge of an authorization request.
+//
+// Verifiers must be between 43 and 128 characters long, as required by RFC 7636.
+func VerifyPkce(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/drunkleen/rasta/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher hashes passwords into PHC strings, "$<id>$<parameters>$<salt>$<hash>",
+// so that hashes made with different algorithms can be told apart and coexist.
+type PasswordHasher interface {
+	// Ids returns the identifiers hashes made by the hasher start with.
+	Ids() []string
+	// Hash hashes a password with a random salt.
+	Hash(password string) (string, error)
+	// Verify reports whether password matches the hash.
+	Verify(password, hash string) bool
+	// NeedsRehash reports whether the hash was made with other parameters than the hasher uses.
+	NeedsRehash(hash string) bool
+}
+
+var (
+	passwordHasher  PasswordHasher = NewArgon2idHasher(65536, 3, 2)
+	passwordHashers                = []PasswordHasher{passwordHasher, NewBcryptHasher(12)}
+)
+
+// InitPasswordHasher configures the algorithm new password hashes are made with.
+//
+// Hashes made with the other supported algorithms can still be verified.
+// Returns an error if PASSWORD_HASH_ALGORITHM is not supported.
+func InitPasswordHasher() error {
+	argon2id := NewArgon2idHasher(uint32(config.GetArgon2Memory()), uint32(config.GetArgon2Iterations()), uint8(config.GetArgon2Parallelism()))
+	bcryptHasher := NewBcryptHasher(config.GetBcryptCost())
+	switch config.GetPasswordHashAlgorithm() {
+	case "argon2id":
+		passwordHasher = argon2id
+	case "bcrypt":
+		passwordHasher = bcryptHasher
+	default:
+		return fmt.Errorf("unsupported password hash algorithm %q", config.GetPasswordHashAlgorithm())
+	}
+	passwordHashers = []PasswordHasher{argon2id, bcryptHasher}
+	return nil
+}
+
+// HashPassword hashes a password with the configured algorithm.
+func HashPassword(password string) (string, error) {
+	return passwordHasher.Hash(password)
+}
+
+// VerifyPassword reports whether password matches hash, whichever supported algorithm made it.
+//
+// Returns whether the password matches, and whether the hash should be replaced by a new
+// hash of the password because it was made with another algorithm or other parameters.
+func VerifyPassword(password, hash string) (bool, bool) {
+	hasher := findPasswordHasher(hash)
+	if hasher == nil || !hasher.Verify(password, hash) {
+		return false, false
+	}
+	return true, hasher != passwordHasher || hasher.NeedsRehash(hash)
+}
+
+// findPasswordHasher returns the hasher that made hash, or nil if no supported algorithm did.
+func findPasswordHasher(hash string) PasswordHasher {
+	id, _, found := strings.Cut(strings.TrimPrefix(hash, "$"), "$")
+	if !found {
+		return nil
+	}
+	for _, hasher := range passwordHashers {
+		for _, hasherId := range hasher.Ids() {
+			if id == hasherId {
+				return hasher
+			}
+		}
+	}
+	return nil
+}
+
+const (
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+)
+
+type argon2idHasher struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+// NewArgon2idHasher creates a hasher making argon2id hashes.
+//
+// memory is the memory used in KiB, iterations the number of passes over it and
+// parallelism the number of threads.
+func NewArgon2idHasher(memory, iterations uint32, parallelism uint8) PasswordHasher {
+	return &argon2idHasher{memory: memory, iterations: iterations, parallelism: parallelism}
+}
+
+func (h *argon2idHasher) Ids() []string {
+	return []string{"argon2id"}
+}
+
+func (h *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2idSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.iterations, h.memory, h.parallelism, argon2idKeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.memory, h.iterations, h.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *argon2idHasher) Verify(password, hash string) bool {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false
+	}
+	candidate := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(candidate, key) == 1
+}
+
+func (h *argon2idHasher) NeedsRehash(hash string) bool {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return *params != *h || len(salt) != argon2idSaltLength || len(key) != argon2idKeyLength
+}
+
+// decodeArgon2id parses an argon2id PHC string into its parameters, salt and key.
+func decodeArgon2id(hash string) (*argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, errors.New("invalid argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, errors.New("unsupported argon2id version")
+	}
+	var params argon2idHasher
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return nil, nil, nil, errors.New("invalid argon2id parameters")
+	}
+	if params.memory == 0 || params.iterations == 0 || params.parallelism == 0 {
+		return nil, nil, nil, errors.New("invalid argon2id parameters")
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, errors.New("invalid argon2id key")
+	}
+	return &params, salt, key, nil
+}
+
+type bcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher creates a hasher making bcrypt hashes of the given cost. bcrypt only
+// uses the first 72 bytes of a password, and refuses to hash longer ones.
+func NewBcryptHasher(cost int) PasswordHasher {
+	return &bcryptHasher{cost: cost}
+}
+
+func (h *bcryptHasher) Ids() []string {
+	return []string{"2a", "2b", "2y"}
+}
+
+func (h *bcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	return string(hash), err
+}
+
+func (h *bcryptHasher) Verify(password, hash string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func (h *bcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.cost
+}
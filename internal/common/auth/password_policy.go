@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/drunkleen/rasta/config"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+)
+
+// The rules a password can break, reported to clients so they can tell which one failed.
+const (
+	PasswordRuleMinLength  = "min_length"
+	PasswordRuleMaxLength  = "max_length"
+	PasswordRuleUppercase  = "uppercase"
+	PasswordRuleLowercase  = "lowercase"
+	PasswordRuleDigit      = "digit"
+	PasswordRuleSymbol     = "symbol"
+	PasswordRuleCharacters = "characters"
+	PasswordRuleStrength   = "strength"
+	PasswordRuleBreached   = "breached"
+	PasswordRuleHistory    = "history"
+)
+
+// bcryptMaxBytes is the length bcrypt refuses to hash passwords beyond.
+const bcryptMaxBytes = 72
+
+// PasswordPolicy holds the rules passwords must follow.
+type PasswordPolicy struct {
+	MinLength        int
+	MaxLength        int
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	// MinStrength is the score PasswordStrength must at least give a password, from 0 to 4.
+	MinStrength int
+	// History is the number of most recent passwords, the current one included, a new password must differ from.
+	History int
+
+	maxBytes int
+	breached map[[sha1.Size]byte]struct{}
+}
+
+// PasswordViolation is a rule a password breaks, with a message explaining it to the user.
+type PasswordViolation struct {
+	Rule    string
+	Message string
+}
+
+// PasswordPolicyError is returned for a password that breaks one or more rules of the policy.
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return commonerrors.ErrPasswordTooWeak
+}
+
+var passwordPolicy = &PasswordPolicy{
+	MinLength:        8,
+	MaxLength:        128,
+	RequireUppercase: true,
+	RequireLowercase: true,
+	RequireDigit:     true,
+	RequireSymbol:    true,
+	MinStrength:      2,
+	History:          5,
+}
+
+// InitPasswordPolicy configures the password policy and loads the breached password list, if any.
+//
+// When passwords are hashed with bcrypt, passwords longer than bcrypt can hash are refused.
+// Returns an error if the breached password list cannot be read.
+func InitPasswordPolicy() error {
+	policy := &PasswordPolicy{
+		MinLength:        config.GetPasswordMinLength(),
+		MaxLength:        config.GetPasswordMaxLength(),
+		RequireUppercase: config.GetPasswordRequireUppercase(),
+		RequireLowercase: config.GetPasswordRequireLowercase(),
+		RequireDigit:     config.GetPasswordRequireDigit(),
+		RequireSymbol:    config.GetPasswordRequireSymbol(),
+		MinStrength:      config.GetPasswordMinStrength(),
+		History:          config.GetPasswordHistory(),
+	}
+	if config.GetPasswordHashAlgorithm() == "bcrypt" {
+		policy.maxBytes = bcryptMaxBytes
+	}
+	if path := config.GetPasswordBreachedList(); path != "" {
+		breached, err := loadBreachedPasswords(path)
+		if err != nil {
+			return err
+		}
+		policy.breached = breached
+	}
+	passwordPolicy = policy
+	return nil
+}
+
+// GetPasswordPolicy returns the configured password policy.
+func GetPasswordPolicy() *PasswordPolicy {
+	return passwordPolicy
+}
+
+// CheckPassword checks a password against the configured policy.
+//
+// userInputs are the username, email address, names and the like of the user, which make a
+// password easier to guess when it contains them.
+// Returns a *PasswordPolicyError listing every rule the password breaks, or nil.
+func CheckPassword(password string, userInputs ...string) error {
+	if violations := passwordPolicy.Check(password, userInputs...); len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// Check returns every rule of the policy a password breaks.
+//
+// The strength and breached password checks only run once the password has the required length
+// and character classes, since their messages would not help before.
+func (p *PasswordPolicy) Check(password string, userInputs ...string) []PasswordViolation {
+	var violations []PasswordViolation
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRuleMinLength,
+			Message: fmt.Sprintf("must be at least %d characters long", p.MinLength),
+		})
+	}
+	if length > p.MaxLength {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRuleMaxLength,
+			Message: fmt.Sprintf("must be at most %d characters long", p.MaxLength),
+		})
+	} else if p.maxBytes > 0 && len(password) > p.maxBytes {
+		violations = append(violations, PasswordViolation{
+			Rule:    PasswordRuleMaxLength,
+			Message: fmt.Sprintf("must be at most %d bytes long", p.maxBytes),
+		})
+	}
+
+	var upper, lower, digit, symbol, control bool
+	for _, r := range password {
+		switch {
+		case unicode.IsControl(r):
+			control = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r) && !unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUppercase && !upper {
+		violations = append(violations, PasswordViolation{Rule: PasswordRuleUppercase, Message: "must contain an uppercase letter"})
+	}
+	if p.RequireLowercase && !lower {
+		violations = append(violations, PasswordViolation{Rule: PasswordRuleLowercase, Message: "must contain a lowercase letter"})
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, PasswordViolation{Rule: PasswordRuleDigit, Message: "must contain a number"})
+	}
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, PasswordViolation{Rule: PasswordRuleSymbol, Message: "must contain a special character"})
+	}
+	if control || !utf8.ValidString(password) {
+		violations = append(violations, PasswordViolation{Rule: PasswordRuleCharacters, Message: "must not contain control characters"})
+	}
+	if len(violations) > 0 {
+		return violations
+	}
+
+	if p.isBreached(password) {
+		return []PasswordViolation{{
+			Rule:    PasswordRuleBreached,
+			Message: "has appeared in a data breach and must not be used",
+		}}
+	}
+	if PasswordStrength(password, userInputs...) < p.MinStrength {
+		return []PasswordViolation{{
+			Rule:    PasswordRuleStrength,
+			Message: "is too easy to guess, avoid common words, names, dates, sequences and keyboard patterns or make it longer",
+		}}
+	}
+	return nil
+}
+
+// isBreached reports whether a password is in the breached password list.
+func (p *PasswordPolicy) isBreached(password string) bool {
+	if len(p.breached) == 0 {
+		return false
+	}
+	_, found := p.breached[sha1.Sum([]byte(password))]
+	return found
+}
+
+// loadBreachedPasswords reads a list of breached passwords, one per line, either in plain text or
+// as hexadecimal SHA-1 hashes optionally followed by ":count", as published by Have I Been Pwned.
+// Only the SHA-1 hashes are kept in memory.
+func loadBreachedPasswords(path string) (map[[sha1.Size]byte]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	defer file.Close()
+
+	breached := make(map[[sha1.Size]byte]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if hash, ok := parseBreachedHash(line); ok {
+			breached[hash] = struct{}{}
+			continue
+		}
+		breached[sha1.Sum([]byte(line))] = struct{}{}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+	return breached, nil
+}
+
+// parseBreachedHash parses a line made of a hexadecimal SHA-1 hash and an optional ":count".
+func parseBreachedHash(line string) ([sha1.Size]byte, bool) {
+	var hash [sha1.Size]byte
+	digest, count, hasCount := strings.Cut(line, ":")
+	if len(digest) != hex.EncodedLen(sha1.Size) {
+		return hash, false
+	}
+	if hasCount {
+		if _, err := strconv.Atoi(count); err != nil {
+			return hash, false
+		}
+	}
+	if _, err := hex.Decode(hash[:], []byte(digest)); err != nil {
+		return hash, false
+	}
+	return hash, true
+}
@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// commonPasswords are frequently used passwords and words, most common first. A password made
+// of them takes about as many guesses as the rank of each word in the list.
+var commonPasswords = strings.Fields(`
+	password 123456 qwerty 111111 abc123 letmein monkey dragon iloveyou admin welcome login
+	master sunshine princess football baseball shadow superman trustno1 starwars michael
+	jennifer hunter killer freedom whatever secret passw0rd batman access mustang charlie
+	donald hello flower soccer hockey ranger buster thomas tigger robert jordan harley love
+	ginger pepper daniel summer winter spring autumn london berlin paris computer internet
+	cookie cheese orange banana apple purple silver golden diamond angel heaven family
+	friend forever money pass test guest user root changeme default qazwsx zaq1 asdf zxcv
+	matrix ninja pokemon naruto mickey snoopy maggie buddy bailey coffee chocolate butterfly
+	rainbow blessed jesus lovely beautiful sweet happy smile chelsea arsenal liverpool
+	yankees dallas cowboys eagles lakers boston chicago america canada mexico india china
+	samsung google facebook twitter github linux windows qwertyuiop asdfghjkl
+	zxcvbnm hello123 admin123 welcome1 password1 letmein1 rasta
+`)
+
+// keyboardRows are the rows of a QWERTY keyboard, typed in either direction.
+var keyboardRows = []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm"}
+
+// leetSubstitutions maps characters commonly typed in place of letters back to the letters.
+var leetSubstitutions = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '3': 'e', '6': 'g', '1': 'i', '!': 'i', '|': 'l',
+	'0': 'o', '$': 's', '5': 's', '7': 't', '+': 't', '2': 'z',
+}
+
+// passwordMatch is a part of a password, runes [start, end), guessable in 10^guesses guesses.
+type passwordMatch struct {
+	start, end int
+	guesses    float64
+}
+
+// PasswordStrength estimates how hard a password is to guess and returns a score from 0 to 4.
+//
+// The password is split into the parts that are cheapest to guess, such as common words, user
+// inputs like the name or email address of the user, keyboard walks, sequences, repeats and years,
+// with leet substitutions and capitalization undone, and brute force for the rest. The score is
+// 0 when it takes fewer than 10^3 guesses, 1 below 10^6, 2 below 10^8, 3 below 10^10 and 4 beyond.
+func PasswordStrength(password string, userInputs ...string) int {
+	guesses := passwordGuesses(password, userInputs)
+	switch {
+	case guesses < 3:
+		return 0
+	case guesses < 6:
+		return 1
+	case guesses < 8:
+		return 2
+	case guesses < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// passwordGuesses returns the base 10 logarithm of the estimated number of guesses needed to find password.
+func passwordGuesses(password string, userInputs []string) float64 {
+	runes := []rune(password)
+	if len(runes) == 0 {
+		return 0
+	}
+	// Characters not part of any pattern are guessed among 10 each, as zxcvbn does, since
+	// attackers try likely characters first rather than every printable one.
+	bruteforce := 1.0
+
+	matches := dictionaryMatches(runes, userInputs)
+	matches = append(matches, repeatMatches(runes, bruteforce)...)
+	matches = append(matches, sequenceMatches(runes)...)
+	matches = append(matches, keyboardMatches(runes)...)
+	matches = append(matches, yearMatches(runes)...)
+
+	// best[i] holds the fewest guesses needed for the first i runes.
+	best := make([]float64, len(runes)+1)
+	for i := 1; i <= len(runes); i++ {
+		best[i] = best[i-1] + bruteforce
+		for _, match := range matches {
+			if match.end == i && best[match.start]+match.guesses < best[i] {
+				best[i] = best[match.start] + match.guesses
+			}
+		}
+	}
+	return best[len(runes)]
+}
+
+// dictionaryMatches finds the common words and user inputs of at least 3 characters in a password.
+func dictionaryMatches(runes []rune, userInputs []string) []passwordMatch {
+	normalized := make([]rune, len(runes))
+	for i, r := range runes {
+		r = unicode.ToLower(r)
+		if letter, ok := leetSubstitutions[r]; ok {
+			r = letter
+		}
+		normalized[i] = r
+	}
+	text := string(normalized)
+
+	words := make(map[string]int, len(commonPasswords)+len(userInputs))
+	for rank, word := range commonPasswords {
+		words[word] = rank + 1
+	}
+	for rank, input := range userInputs {
+		input = strings.ToLower(input)
+		if local, _, found := strings.Cut(input, "@"); found {
+			input = local
+		}
+		for _, word := range strings.FieldsFunc(input, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+			if existing, ok := words[word]; !ok || existing > rank+1 {
+				words[word] = rank + 1
+			}
+		}
+	}
+
+	var matches []passwordMatch
+	for word, rank := range words {
+		length := len([]rune(word))
+		if length < 3 {
+			continue
+		}
+		for offset := 0; ; {
+			index := strings.Index(text[offset:], word)
+			if index < 0 {
+				break
+			}
+			start := len([]rune(text[:offset+index]))
+			end := start + length
+			guesses := math.Log10(float64(rank)) + variationGuesses(runes[start:end])
+			matches = append(matches, passwordMatch{start: start, end: end, guesses: guesses})
+			offset += index + len(word)
+		}
+	}
+	return matches
+}
+
+// variationGuesses returns the base 10 logarithm of the guesses added by capitalizing a word
+// and substituting some of its letters.
+func variationGuesses(runes []rune) float64 {
+	var upper, substituted int
+	for _, r := range runes {
+		if unicode.IsUpper(r) {
+			upper++
+		}
+		if _, ok := leetSubstitutions[r]; ok {
+			substituted++
+		}
+	}
+	guesses := float64(substituted) * math.Log10(2)
+	switch {
+	case upper == 0:
+	case upper == len(runes), upper == 1 && unicode.IsUpper(runes[0]):
+		guesses += math.Log10(2)
+	default:
+		guesses += float64(upper) * math.Log10(2)
+	}
+	return guesses
+}
+
+// repeatMatches finds blocks of characters repeated at least 3 times in total length, like "aaa" or "abcabc".
+func repeatMatches(runes []rune, bruteforce float64) []passwordMatch {
+	var matches []passwordMatch
+	for start := 0; start < len(runes); start++ {
+		for size := 1; start+2*size <= len(runes); size++ {
+			end := start + size
+			for end+size <= len(runes) && string(runes[end:end+size]) == string(runes[start:start+size]) {
+				end += size
+			}
+			count := (end - start) / size
+			if count < 2 || end-start < 3 {
+				continue
+			}
+			guesses := float64(size)*bruteforce + math.Log10(float64(count))
+			matches = append(matches, passwordMatch{start: start, end: end, guesses: guesses})
+		}
+	}
+	return matches
+}
+
+// sequenceMatches finds runs of at least 3 characters that go up or down by one, like "abc" or "987".
+func sequenceMatches(runes []rune) []passwordMatch {
+	runes = []rune(strings.ToLower(string(runes)))
+	var matches []passwordMatch
+	for start := 0; start+2 < len(runes); start++ {
+		delta := runes[start+1] - runes[start]
+		if delta != 1 && delta != -1 {
+			continue
+		}
+		end := start + 2
+		for end < len(runes) && runes[end]-runes[end-1] == delta {
+			end++
+		}
+		if end-start < 3 {
+			continue
+		}
+		first := runes[start]
+		guesses := math.Log10(26)
+		if first == 'a' || first == 'z' || first == '0' || first == '1' || first == '9' {
+			guesses = math.Log10(4)
+		} else if unicode.IsDigit(first) {
+			guesses = math.Log10(10)
+		}
+		guesses += math.Log10(float64(end - start))
+		if delta < 0 {
+			guesses += math.Log10(2)
+		}
+		matches = append(matches, passwordMatch{start: start, end: end, guesses: guesses})
+	}
+	return matches
+}
+
+// keyboardMatches finds runs of at least 3 neighboring keys of a keyboard row, like "qwerty" or "lkjh".
+func keyboardMatches(runes []rune) []passwordMatch {
+	text := strings.ToLower(string(runes))
+	if len(text) != len(runes) {
+		return nil
+	}
+	var matches []passwordMatch
+	for _, row := range keyboardRows {
+		reversed := []rune(row)
+		for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+			reversed[i], reversed[j] = reversed[j], reversed[i]
+		}
+		for _, walk := range []string{row, string(reversed)} {
+			for start := 0; start+2 < len(text); start++ {
+				end := start
+				for end < len(text) && strings.Contains(walk, text[start:end+1]) {
+					end++
+				}
+				if end-start >= 3 {
+					guesses := math.Log10(float64(len(keyboardRows)*2*len(walk))) + math.Log10(float64(end-start))
+					matches = append(matches, passwordMatch{start: start, end: end, guesses: guesses})
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// yearMatches finds years between 1900 and 2099, which are guessed among a few hundred values.
+func yearMatches(runes []rune) []passwordMatch {
+	var matches []passwordMatch
+	for start := 0; start+4 <= len(runes); start++ {
+		year := string(runes[start : start+4])
+		if (strings.HasPrefix(year, "19") || strings.HasPrefix(year, "20")) && strings.Trim(year, "0123456789") == "" {
+			matches = append(matches, passwordMatch{start: start, end: start + 4, guesses: math.Log10(200)})
+		}
+	}
+	return matches
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+)
+
+// RecoveryCodeCount is the number of recovery codes issued at once.
+const RecoveryCodeCount = 10
+
+// recoveryCodeCharset leaves out characters that are easily mistaken for one another.
+const recoveryCodeCharset = "23456789abcdefghjkmnpqrstuvwxyz"
+
+// GenerateRecoveryCodes generates a set of one-time recovery codes.
+//
+// Every code has the form xxxxx-xxxxx and is built from crypto/rand.
+// Returns the codes and an error if the random source fails.
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	max := big.NewInt(int64(len(recoveryCodeCharset)))
+	for i := range codes {
+		b := make([]byte, 10)
+		for j := range b {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, err
+			}
+			b[j] = recoveryCodeCharset[n.Int64()]
+		}
+		codes[i] = string(b[:5]) + "-" + string(b[5:])
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode brings a recovery code typed by a user to its canonical form,
+// ignoring case, spaces and the dash, so it can be hashed and looked up.
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	if len(code) != 10 {
+		return code
+	}
+	return code[:5] + "-" + code[5:]
+}
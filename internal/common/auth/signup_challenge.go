@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/drunkleen/rasta/config"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+)
+
+// SignupChallengeAlgorithm names the proof of work a signup challenge is solved with: a solution
+// such that the SHA-256 hash of the challenge followed by the solution starts with as many zero
+// bits as the difficulty of the challenge.
+const SignupChallengeAlgorithm = "sha256"
+
+// maxSignupSolutionLength caps the length of a solution, which is hashed before anything else.
+const maxSignupSolutionLength = 64
+
+var (
+	signupChallengeKey []byte
+
+	// usedSignupChallenges holds the challenges already solved, until they expire, so that one
+	// solution cannot sign up several accounts. It is kept in memory, per instance.
+	usedSignupChallenges   = make(map[string]time.Time)
+	usedSignupChallengesMu sync.Mutex
+)
+
+// InitSignupChallenge sets the key signup challenges are signed with, from SIGNUP_CHALLENGE_SECRET
+// or generated at random. Challenges are stateless, so instances behind a load balancer must share
+// the secret.
+//
+// Returns an error if the random source fails.
+func InitSignupChallenge() error {
+	if secret := config.GetSignupChallengeSecret(); secret != "" {
+		key := sha256.Sum256([]byte(secret))
+		signupChallengeKey = key[:]
+		return nil
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	signupChallengeKey = key
+	return nil
+}
+
+// GenerateSignupChallenge generates a signup challenge with the configured difficulty.
+//
+// The challenge carries its expiry and difficulty, signed with HMAC-SHA256, so nothing is stored.
+// Returns the challenge, its difficulty, its expiry and an error if the random source fails.
+func GenerateSignupChallenge() (string, int, time.Time, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", 0, time.Time{}, err
+	}
+	difficulty := config.GetSignupPowDifficulty()
+	expiresAt := time.Now().Add(time.Duration(config.GetSignupChallengeExpiry()) * time.Second)
+	payload := fmt.Sprintf("%s.%d.%d", base64.RawURLEncoding.EncodeToString(nonce), expiresAt.Unix(), difficulty)
+	return payload + "." + signSignupChallenge(payload), difficulty, expiresAt, nil
+}
+
+// VerifySignupChallenge checks the solution of a signup challenge and uses the challenge up.
+//
+// Returns ErrInvalidSignupChallenge if the challenge was not issued by GenerateSignupChallenge,
+// has expired or been used, or the solution does not meet its difficulty.
+func VerifySignupChallenge(challenge, solution string) error {
+	invalid := errors.New(commonerrors.ErrInvalidSignupChallenge)
+	if solution == "" || len(solution) > maxSignupSolutionLength {
+		return invalid
+	}
+	dot := strings.LastIndexByte(challenge, '.')
+	if dot < 0 || !hmac.Equal([]byte(challenge[dot+1:]), []byte(signSignupChallenge(challenge[:dot]))) {
+		return invalid
+	}
+	fields := strings.Split(challenge[:dot], ".")
+	if len(fields) != 3 {
+		return invalid
+	}
+	expiry, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || time.Now().Unix() > expiry {
+		return invalid
+	}
+	difficulty, err := strconv.Atoi(fields[2])
+	if err != nil || leadingZeroBits(sha256.Sum256([]byte(challenge+solution))) < difficulty {
+		return invalid
+	}
+	if !useSignupChallenge(challenge, time.Unix(expiry, 0)) {
+		return invalid
+	}
+	return nil
+}
+
+// signSignupChallenge returns the signature of the payload of a signup challenge.
+func signSignupChallenge(payload string) string {
+	mac := hmac.New(sha256.New, signupChallengeKey)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// useSignupChallenge records that a challenge has been solved, forgetting expired ones.
+// Returns false if it had already been.
+func useSignupChallenge(challenge string, expiresAt time.Time) bool {
+	usedSignupChallengesMu.Lock()
+	defer usedSignupChallengesMu.Unlock()
+	now := time.Now()
+	for used, expiry := range usedSignupChallenges {
+		if now.After(expiry) {
+			delete(usedSignupChallenges, used)
+		}
+	}
+	if _, used := usedSignupChallenges[challenge]; used {
+		log.Printf("refusing a signup challenge solved twice")
+		return false
+	}
+	usedSignupChallenges[challenge] = expiresAt
+	return true
+}
+
+// leadingZeroBits returns the number of zero bits a hash starts with.
+func leadingZeroBits(hash [sha256.Size]byte) int {
+	count := 0
+	for _, b := range hash {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// SmsCodeLength is the number of digits of the codes sent by SMS.
+const SmsCodeLength = 6
+
+// GenerateSmsCode generates a numeric one-time code to send by SMS from crypto/rand.
+//
+// Codes are short enough to be typed from a phone, so they rely on expiring quickly and on
+// being invalidated after OTP_MAX_ATTEMPTS wrong guesses.
+func GenerateSmsCode() (string, error) {
+	return generateDigits(SmsCodeLength)
+}
+
+// generateDigits generates a string of random decimal digits from crypto/rand.
+func generateDigits(length int) (string, error) {
+	digits := make([]byte, length)
+	for i := range digits {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		digits[i] = byte('0' + n.Int64())
+	}
+	return string(digits), nil
+}
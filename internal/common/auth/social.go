@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/drunkleen/rasta/config"
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/github"
+	"github.com/markbates/goth/providers/google"
+	"github.com/markbates/goth/providers/openidConnect"
+)
+
+// SocialStateTimeout is how long a user has to sign in at an identity provider.
+const SocialStateTimeout = 10 * time.Minute
+
+// InitSocialProviders registers the identity providers users may sign in with, every provider
+// whose client ID is configured. The generic OpenID Connect provider is set up from its discovery document.
+//
+// Returns an error if the discovery document cannot be loaded.
+func InitSocialProviders() error {
+	goth.ClearProviders()
+	if clientId, secret := config.GetSocialGoogleClient(); clientId != "" {
+		goth.UseProviders(google.New(clientId, secret, socialCallbackUrl("google"), "openid", "email", "profile"))
+	}
+	if clientId, secret := config.GetSocialGithubClient(); clientId != "" {
+		goth.UseProviders(github.New(clientId, secret, socialCallbackUrl("github"), "read:user", "user:email"))
+	}
+	clientId, secret := config.GetSocialOidcClient()
+	if clientId != "" && config.GetSocialOidcDiscoveryUrl() != "" {
+		name := config.GetSocialOidcName()
+		provider, err := openidConnect.NewNamed(name, clientId, secret, socialCallbackUrl(name), config.GetSocialOidcDiscoveryUrl(), "email", "profile")
+		if err != nil {
+			return err
+		}
+		// NewNamed appends "-oidc" to the name, which would not match the callback URL.
+		provider.SetName(name)
+		goth.UseProviders(provider)
+	}
+	return nil
+}
+
+// GetSocialProvider returns a provider registered by InitSocialProviders.
+func GetSocialProvider(name string) (goth.Provider, error) {
+	return goth.GetProvider(name)
+}
+
+// GetSocialProviderNames returns the names of the providers registered by InitSocialProviders, sorted.
+func GetSocialProviderNames() []string {
+	names := make([]string, 0, len(goth.GetProviders()))
+	for name := range goth.GetProviders() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SocialUsesPKCE reports whether sign-ins at a provider are protected with PKCE. Only the generic
+// OpenID Connect provider sends the code verifier when it exchanges the authorization code.
+func SocialUsesPKCE(provider goth.Provider) bool {
+	_, ok := provider.(*openidConnect.Provider)
+	return ok
+}
+
+// SocialAuthURL binds a sign-in to the server that started it, by adding to the URL of the
+// authorization endpoint of a provider the nonce its ID token must carry and, if the provider
+// uses PKCE, the S256 challenge of codeVerifier.
+//
+// Returns the URL to send the user to and an error if authUrl is invalid.
+func SocialAuthURL(provider goth.Provider, authUrl, codeVerifier, nonce string) (string, error) {
+	u, err := url.Parse(authUrl)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("nonce", nonce)
+	if SocialUsesPKCE(provider) {
+		challenge := sha256.Sum256([]byte(codeVerifier))
+		query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+		query.Set("code_challenge_method", "S256")
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// SocialNonceValid reports whether the ID token a provider issued carries the nonce of the sign-in.
+//
+// Providers that only speak OAuth 2.0, like GitHub, issue no ID token, which is accepted. The ID token
+// is received from the token endpoint of the provider, so it cannot be stripped by the user.
+func SocialNonceValid(user *goth.User, nonce string) bool {
+	if user.IDToken == "" {
+		return true
+	}
+	parts := strings.Split(user.IDToken, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return false
+	}
+	var claims struct {
+		Nonce string `json:"nonce"`
+	}
+	if err = json.Unmarshal(payload, &claims); err != nil || claims.Nonce == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) == 1
+}
+
+// SocialEmailVerified reports whether an identity provider asserts that the user owns their email address.
+//
+// OpenID Connect providers send the email_verified claim and Google's profile endpoint verified_email.
+// GitHub only hands out verified addresses.
+func SocialEmailVerified(user *goth.User) bool {
+	if user.Email == "" {
+		return false
+	}
+	if user.Provider == "github" {
+		return true
+	}
+	for _, claim := range []string{"email_verified", "verified_email"} {
+		switch verified := user.RawData[claim].(type) {
+		case bool:
+			return verified
+		case string:
+			return verified == "true"
+		}
+	}
+	return false
+}
+
+// socialCallbackUrl returns the URL a provider redirects back to.
+func socialCallbackUrl(provider string) string {
+	return config.GetSocialRedirectUrl() + "/" + provider
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/drunkleen/rasta/config"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+// WebAuthnCeremonyTimeout is how long a client has to complete a registration or login ceremony.
+const WebAuthnCeremonyTimeout = 5 * time.Minute
+
+var webAuthn *webauthn.WebAuthn
+
+// InitWebAuthn configures the relying party passkey ceremonies are performed for.
+//
+// Returns an error if the relying party settings are invalid.
+func InitWebAuthn() error {
+	w, err := NewWebAuthn(config.GetWebAuthnRPID(), config.GetWebAuthnRPName(), config.GetWebAuthnRPOrigins())
+	if err != nil {
+		return err
+	}
+	webAuthn = w
+	return nil
+}
+
+// NewWebAuthn creates a relying party for passkey ceremonies.
+//
+// rpId is the domain passkeys are bound to, rpName the name shown by authenticators and
+// origins the origins ceremonies may be performed from. Tests use it to drive the
+// ceremonies with a software authenticator.
+func NewWebAuthn(rpId, rpName string, origins []string) (*webauthn.WebAuthn, error) {
+	timeout := webauthn.TimeoutConfig{Enforce: true, Timeout: WebAuthnCeremonyTimeout, TimeoutUVD: WebAuthnCeremonyTimeout}
+	return webauthn.New(&webauthn.Config{
+		RPID:          rpId,
+		RPDisplayName: rpName,
+		RPOrigins:     origins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementPreferred,
+			UserVerification: protocol.VerificationPreferred,
+		},
+		Timeouts: webauthn.TimeoutsConfig{Login: timeout, Registration: timeout},
+	})
+}
+
+// GetWebAuthn returns the relying party configured by InitWebAuthn.
+func GetWebAuthn() *webauthn.WebAuthn {
+	return webAuthn
+}
+
+// ValidateWebAuthnLogin verifies the response of an authenticator to a login ceremony.
+//
+// A ceremony started without a user is a passkey-only login, in which case the passkey must belong to user.
+// Returns the credential record with its updated signature counter, or an error if the response is invalid
+// or the counter did not increase, which means the authenticator may have been cloned.
+func ValidateWebAuthnLogin(w *webauthn.WebAuthn, user *WebAuthnUser, session webauthn.SessionData, response *protocol.ParsedCredentialAssertionData) (*webauthn.Credential, error) {
+	var credential *webauthn.Credential
+	var err error
+	if len(session.UserID) == 0 {
+		_, credential, err = w.ValidatePasskeyLogin(func(_, _ []byte) (webauthn.User, error) {
+			return user, nil
+		}, session, response)
+	} else {
+		credential, err = w.ValidateLogin(user, session, response)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if credential.Authenticator.CloneWarning {
+		return nil, errors.New("signature counter did not increase, the authenticator may have been cloned")
+	}
+	return credential, nil
+}
+
+// WebAuthnUser is a user as seen by the WebAuthn ceremonies.
+type WebAuthnUser struct {
+	Id          []byte
+	Name        string
+	DisplayName string
+	Credentials []webauthn.Credential
+}
+
+// WebAuthnID returns the user handle stored by the authenticator.
+func (u *WebAuthnUser) WebAuthnID() []byte {
+	return u.Id
+}
+
+// WebAuthnName returns the name the user signs in with.
+func (u *WebAuthnUser) WebAuthnName() string {
+	return u.Name
+}
+
+// WebAuthnDisplayName returns the name authenticators display for the user.
+func (u *WebAuthnUser) WebAuthnDisplayName() string {
+	return u.DisplayName
+}
+
+// WebAuthnCredentials returns the credentials registered by the user.
+func (u *WebAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.Credentials
+}
+
+// ExcludedCredentials lists the credentials of the user, so an authenticator is not registered twice.
+func (u *WebAuthnUser) ExcludedCredentials() []protocol.CredentialDescriptor {
+	descriptors := make([]protocol.CredentialDescriptor, len(u.Credentials))
+	for i, credential := range u.Credentials {
+		descriptors[i] = credential.Descriptor()
+	}
+	return descriptors
+}
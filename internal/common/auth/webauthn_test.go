@@ -0,0 +1,323 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+const (
+	testRPID   = "rasta.test"
+	testOrigin = "https://rasta.test"
+)
+
+// softAuthenticator is a software authenticator holding a single ES256 passkey.
+type softAuthenticator struct {
+	credentialId []byte
+	key          *ecdsa.PrivateKey
+	signCount    uint32
+	rpId         string
+	origin       string
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	credentialId := make([]byte, 16)
+	if _, err = rand.Read(credentialId); err != nil {
+		t.Fatalf("failed to generate credential ID: %v", err)
+	}
+	return &softAuthenticator{credentialId: credentialId, key: key, rpId: testRPID, origin: testOrigin}
+}
+
+// create answers navigator.credentials.create with a "none" attestation.
+func (a *softAuthenticator) create(t *testing.T, creation *protocol.CredentialCreation) []byte {
+	t.Helper()
+	clientData := a.clientData(t, "webauthn.create", creation.Response.Challenge.String())
+
+	publicKey, err := webauthncbor.Marshal(map[int]interface{}{
+		1:  2,
+		3:  -7,
+		-1: 1,
+		-2: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		-3: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatalf("failed to encode public key: %v", err)
+	}
+	authData := a.authData(0x01 | 0x04 | 0x40)
+	authData = append(authData, make([]byte, 16)...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.credentialId)))
+	authData = append(authData, a.credentialId...)
+	authData = append(authData, publicKey...)
+
+	attestation, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authData,
+	})
+	if err != nil {
+		t.Fatalf("failed to encode attestation: %v", err)
+	}
+	return a.credential(t, map[string]string{
+		"clientDataJSON":    encode(clientData),
+		"attestationObject": encode(attestation),
+	})
+}
+
+// get answers navigator.credentials.get, signing with the current counter of the authenticator.
+func (a *softAuthenticator) get(t *testing.T, assertion *protocol.CredentialAssertion, userHandle []byte) []byte {
+	t.Helper()
+	clientData := a.clientData(t, "webauthn.get", assertion.Response.Challenge.String())
+	authData := a.authData(0x01 | 0x04)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(bytes.Clone(authData), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign assertion: %v", err)
+	}
+	return a.credential(t, map[string]string{
+		"clientDataJSON":    encode(clientData),
+		"authenticatorData": encode(authData),
+		"signature":         encode(signature),
+		"userHandle":        encode(userHandle),
+	})
+}
+
+func (a *softAuthenticator) clientData(t *testing.T, ceremony, challenge string) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]string{"type": ceremony, "challenge": challenge, "origin": a.origin})
+	if err != nil {
+		t.Fatalf("failed to encode client data: %v", err)
+	}
+	return data
+}
+
+func (a *softAuthenticator) authData(flags byte) []byte {
+	rpIdHash := sha256.Sum256([]byte(a.rpId))
+	data := append(rpIdHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, a.signCount)
+}
+
+func (a *softAuthenticator) credential(t *testing.T, response map[string]string) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{
+		"id":       encode(a.credentialId),
+		"rawId":    encode(a.credentialId),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatalf("failed to encode credential: %v", err)
+	}
+	return data
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func newTestWebAuthn(t *testing.T) *webauthn.WebAuthn {
+	t.Helper()
+	w, err := NewWebAuthn(testRPID, "Rasta", []string{testOrigin})
+	if err != nil {
+		t.Fatalf("failed to create relying party: %v", err)
+	}
+	return w
+}
+
+// register performs a registration ceremony and adds the new passkey to the user.
+func register(t *testing.T, w *webauthn.WebAuthn, user *WebAuthnUser, authenticator *softAuthenticator) (*webauthn.Credential, error) {
+	t.Helper()
+	creation, session, err := w.BeginRegistration(user, webauthn.WithExclusions(user.ExcludedCredentials()))
+	if err != nil {
+		t.Fatalf("failed to begin registration: %v", err)
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(authenticator.create(t, creation))
+	if err != nil {
+		return nil, err
+	}
+	credential, err := w.CreateCredential(user, *session, parsed)
+	if err != nil {
+		return nil, err
+	}
+	user.Credentials = append(user.Credentials, *credential)
+	return credential, nil
+}
+
+// login performs a login ceremony, bound to the user when secondFactor is set, and stores the updated credential.
+func login(t *testing.T, w *webauthn.WebAuthn, user *WebAuthnUser, authenticator *softAuthenticator, secondFactor bool) (*webauthn.Credential, error) {
+	t.Helper()
+	var assertion *protocol.CredentialAssertion
+	var session *webauthn.SessionData
+	var err error
+	if secondFactor {
+		assertion, session, err = w.BeginLogin(user)
+	} else {
+		assertion, session, err = w.BeginDiscoverableLogin()
+	}
+	if err != nil {
+		t.Fatalf("failed to begin login: %v", err)
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(authenticator.get(t, assertion, user.Id))
+	if err != nil {
+		return nil, err
+	}
+	credential, err := ValidateWebAuthnLogin(w, user, *session, parsed)
+	if err != nil {
+		return nil, err
+	}
+	for i := range user.Credentials {
+		if bytes.Equal(user.Credentials[i].ID, credential.ID) {
+			user.Credentials[i] = *credential
+		}
+	}
+	return credential, nil
+}
+
+func newTestWebAuthnUser() *WebAuthnUser {
+	return &WebAuthnUser{Id: []byte("0123456789abcdef"), Name: "alice", DisplayName: "Alice"}
+}
+
+func TestWebAuthnRegistration(t *testing.T) {
+	w := newTestWebAuthn(t)
+	user := newTestWebAuthnUser()
+	authenticator := newSoftAuthenticator(t)
+
+	credential, err := register(t, w, user, authenticator)
+	if err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+	if !bytes.Equal(credential.ID, authenticator.credentialId) {
+		t.Errorf("credential ID = %x, want %x", credential.ID, authenticator.credentialId)
+	}
+	if excluded := user.ExcludedCredentials(); len(excluded) != 1 || !bytes.Equal(excluded[0].CredentialID, credential.ID) {
+		t.Errorf("excluded credentials = %v, want the registered passkey", excluded)
+	}
+}
+
+func TestWebAuthnRegistrationRejectsOtherOrigin(t *testing.T) {
+	w := newTestWebAuthn(t)
+	authenticator := newSoftAuthenticator(t)
+	authenticator.origin = "https://evil.test"
+
+	if _, err := register(t, w, newTestWebAuthnUser(), authenticator); err == nil {
+		t.Fatal("registration from another origin succeeded")
+	}
+}
+
+func TestWebAuthnRegistrationRejectsOtherRelyingParty(t *testing.T) {
+	w := newTestWebAuthn(t)
+	authenticator := newSoftAuthenticator(t)
+	authenticator.rpId = "evil.test"
+
+	if _, err := register(t, w, newTestWebAuthnUser(), authenticator); err == nil {
+		t.Fatal("registration for another relying party succeeded")
+	}
+}
+
+func TestWebAuthnLogin(t *testing.T) {
+	for _, secondFactor := range []bool{false, true} {
+		w := newTestWebAuthn(t)
+		user := newTestWebAuthnUser()
+		authenticator := newSoftAuthenticator(t)
+		if _, err := register(t, w, user, authenticator); err != nil {
+			t.Fatalf("registration failed: %v", err)
+		}
+
+		authenticator.signCount = 1
+		credential, err := login(t, w, user, authenticator, secondFactor)
+		if err != nil {
+			t.Fatalf("login failed (second factor: %v): %v", secondFactor, err)
+		}
+		if credential.Authenticator.SignCount != 1 {
+			t.Errorf("sign count = %d, want 1", credential.Authenticator.SignCount)
+		}
+	}
+}
+
+func TestWebAuthnLoginRejectsUnknownKey(t *testing.T) {
+	w := newTestWebAuthn(t)
+	user := newTestWebAuthnUser()
+	authenticator := newSoftAuthenticator(t)
+	if _, err := register(t, w, user, authenticator); err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+
+	impostor := newSoftAuthenticator(t)
+	impostor.credentialId = authenticator.credentialId
+	impostor.signCount = 1
+	if _, err := login(t, w, user, impostor, true); err == nil {
+		t.Fatal("login signed with another key succeeded")
+	}
+}
+
+func TestWebAuthnLoginRejectsReplayedResponse(t *testing.T) {
+	w := newTestWebAuthn(t)
+	user := newTestWebAuthnUser()
+	authenticator := newSoftAuthenticator(t)
+	if _, err := register(t, w, user, authenticator); err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+
+	authenticator.signCount = 1
+	assertion, _, err := w.BeginLogin(user)
+	if err != nil {
+		t.Fatalf("failed to begin login: %v", err)
+	}
+	response := authenticator.get(t, assertion, user.Id)
+	_, session, err := w.BeginLogin(user)
+	if err != nil {
+		t.Fatalf("failed to begin login: %v", err)
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if _, err = ValidateWebAuthnLogin(w, user, *session, parsed); err == nil {
+		t.Fatal("response to another challenge was accepted")
+	}
+}
+
+func TestWebAuthnLoginDetectsClonedAuthenticator(t *testing.T) {
+	w := newTestWebAuthn(t)
+	user := newTestWebAuthnUser()
+	authenticator := newSoftAuthenticator(t)
+	if _, err := register(t, w, user, authenticator); err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+
+	authenticator.signCount = 5
+	if _, err := login(t, w, user, authenticator, true); err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	clone := *authenticator
+	clone.signCount = 5
+	if _, err := login(t, w, user, &clone, true); err == nil || !strings.Contains(err.Error(), "cloned") {
+		t.Fatalf("login with a repeated signature counter: got %v, want a clone warning", err)
+	}
+	clone.signCount = 3
+	if _, err := login(t, w, user, &clone, false); err == nil || !strings.Contains(err.Error(), "cloned") {
+		t.Fatalf("login with a lower signature counter: got %v, want a clone warning", err)
+	}
+
+	authenticator.signCount = 6
+	if _, err := login(t, w, user, authenticator, true); err != nil {
+		t.Fatalf("login with an increased signature counter failed: %v", err)
+	}
+}
@@ -1,22 +1,119 @@
 package commonerrors
 
 const (
-	ErrUserNotFound          = "user not found"
-	ErrUnauthorizedToken     = "unauthorized, invalid token"
-	ErrUnauthorizedExpToken  = "unauthorized, expired token"
-	ErrForbidden             = "forbidden"
-	ErrUserNotVerified       = "user not verified"
-	ErrInvalidCredentials    = "invalid credentials"
-	ErrInvalidOAuth          = "invalid one-time password"
-	ErrInvalidUserId         = "invalid user ID"
-	ErrEmailAlreadyExists    = "email already exists"
-	ErrEmailNotExists        = "email not exists"
-	ErrInvalidEmail          = "invalid email address"
-	ErrUsernameAlreadyExists = "username already exists"
-	ErrUsernameNotExists     = "username not exists"
-	ErrInvalidUsername       = "username must be at least 4 characters long and contain only letters and numbers"
-	ErrInvalidRequestBody    = "invalid request body"
-	ErrPasswordTooWeak       = "password too weak. must be at least 8 characters long and contain at least one uppercase letter, one lowercase letter, one number, and one special character"
-	ErrPasswordsNotMatch     = "password do not match"
-	ErrInternalServer        = "internal server error"
+	ErrUserNotFound           = "user not found"
+	ErrUnauthorizedToken      = "unauthorized, invalid token"
+	ErrUnauthorizedExpToken   = "unauthorized, expired token"
+	ErrForbidden              = "forbidden"
+	ErrUserNotVerified        = "user not verified"
+	ErrInvalidCredentials     = "invalid credentials"
+	ErrInvalidOAuth           = "invalid one-time password"
+	ErrInvalidUserId          = "invalid user ID"
+	ErrEmailAlreadyExists     = "email already exists"
+	ErrEmailNotExists         = "email not exists"
+	ErrInvalidEmail           = "invalid email address"
+	ErrUsernameAlreadyExists  = "username already exists"
+	ErrUsernameNotExists      = "username not exists"
+	ErrInvalidUsername        = "username must be at least 4 characters long and contain only letters and numbers"
+	ErrInvalidRequestBody     = "invalid request body"
+	ErrPasswordTooWeak        = "password does not meet the password policy"
+	ErrPasswordsNotMatch      = "password do not match"
+	ErrInternalServer         = "internal server error"
+	ErrInvalidRefreshToken    = "invalid or expired refresh token"
+	ErrSessionRevoked         = "unauthorized, session revoked"
+	ErrTokenRevoked           = "unauthorized, token revoked"
+	ErrSessionNotFound        = "session not found"
+	ErrRoleNotFound           = "role not found"
+	ErrRoleAlreadyExists      = "role already exists"
+	ErrInvalidRoleName        = "role name must be between 2 and 64 characters long"
+	ErrInvalidPermission      = "invalid permission"
+	ErrPermissionNotHeld      = "you cannot grant a permission you do not hold"
+	ErrAccountSuspended       = "account suspended"
+	ErrInvalidSuspensionEnd   = "suspension end must be in the future"
+	ErrCannotSuspendSelf      = "you cannot suspend your own account"
+	ErrAccountLocked          = "account temporarily locked after too many failed attempts, try again later"
+	ErrTooManyAttempts        = "too many failed attempts, try again later"
+	ErrInvalidOtp             = "invalid or expired otp"
+	ErrInvalidRecoveryCode    = "invalid or already used recovery code"
+	ErrInvalidCeremony        = "invalid or expired passkey ceremony"
+	ErrInvalidPasskey         = "passkey verification failed"
+	ErrPasskeyNotFound        = "passkey not found"
+	ErrPasskeyRequired        = "passkey verification required"
+	ErrInvalidLoginLink       = "invalid, expired or already used sign-in link or code"
+	ErrEmailLoginDisabled     = "passwordless sign-in is disabled"
+	ErrSocialProviderNotFound = "identity provider not found"
+	ErrInvalidSocialState     = "invalid or expired identity provider sign-in"
+	ErrSocialSignInFailed     = "identity provider sign-in failed"
+	ErrSocialEmailRequired    = "the identity provider did not share an email address"
+	ErrSocialEmailExists      = "an account with this email address already exists, sign in and link the identity provider from your account"
+	ErrIdentityAlreadyLinked  = "this identity is already linked to another account"
+	ErrIdentityNotFound       = "identity not found"
+	ErrOidcUnavailable        = "openid connect requires JWT_SIGNING_ALG RS256 or EdDSA"
+	ErrOidcClientNotFound     = "client not found"
+	ErrInvalidClientName      = "client name must be between 2 and 64 characters long"
+	ErrInvalidRedirectUri     = "invalid or unregistered redirect uri"
+	ErrUnsupportedResponse    = "unsupported response type, only code is supported"
+	ErrInvalidScope           = "invalid scope, openid is required"
+	ErrPkceRequired           = "a S256 code challenge is required"
+	ErrInvalidClient          = "invalid client credentials"
+	ErrInvalidGrant           = "invalid, expired or already used authorization code"
+	ErrUnsupportedGrantType   = "unsupported grant type, only authorization_code is supported"
+	ErrAccessDenied           = "the user denied access"
+	ErrConsentNotFound        = "consent not found"
+	ErrInvalidApiKey          = "unauthorized, invalid or expired api key"
+	ErrApiKeyNotFound         = "api key not found"
+	ErrInvalidApiKeyName      = "api key name must be between 2 and 64 characters long"
+	ErrInvalidRateLimit       = "invalid rate limit"
+	ErrInvalidExpiry          = "expiry must not be negative"
+	ErrTooManyApiKeys         = "too many api keys, revoke one first"
+	ErrRateLimited            = "rate limit exceeded, try again later"
+	ErrServiceAccountNotFound = "service account not found"
+	ErrCannotImpersonate      = "this account cannot be impersonated"
+	ErrImpersonationForbidden = "this action is not allowed while impersonating a user"
+	ErrInvalidReason          = "reason must be between 3 and 512 characters long"
+	ErrSameEmail              = "the new email address is the current one"
+	ErrInvalidEmailChange     = "invalid or expired email change code"
+	ErrInvalidEmailCancel     = "invalid or expired email change cancellation link"
+	ErrInvalidName            = "first and last name must be between 1 and 64 characters long"
+	ErrInvalidDisplayName     = "display name must be at most 64 characters long"
+	ErrInvalidLocale          = "invalid locale, use a BCP 47 language tag such as en-US"
+	ErrInvalidTimezone        = "invalid timezone, use an IANA time zone such as Europe/Berlin"
+	ErrPasswordResetRequired  = "a password reset is required after a login was reported, use the code sent by email or request a new one"
+	ErrInvalidLoginReport     = "invalid, expired or already used login report link"
+	ErrDeviceNotFound         = "device not found"
+	ErrInvalidPhone           = "invalid phone number, use the E.164 format such as +14155550123"
+	ErrInvalidBio             = "bio must be at most 512 characters long"
+	ErrPhoneRequired          = "no phone number is set on the account"
+	ErrPhoneNotVerified       = "the phone number of the account is not verified"
+	ErrPhoneAlreadyVerified   = "the phone number of the account is already verified"
+	ErrPhoneLocked            = "disable SMS two-factor authentication before changing your phone number"
+	ErrInvalidChannel         = "invalid channel, use email or sms"
+	ErrSmsTooSoon             = "a code has just been sent by SMS, wait before requesting another one"
+	ErrSmsCodeRequired        = "a code has been sent by SMS, send it to /users/login/sms with the token to sign in"
+	ErrInvalidSmsLogin        = "invalid or expired SMS sign-in"
+	ErrSmsTwoFactorEnabled    = "SMS two-factor authentication is already enabled"
+	ErrSmsTwoFactorDisabled   = "SMS two-factor authentication is not enabled"
+	ErrEmailDomainBlocked     = "email addresses of this domain cannot be used to sign up"
+	ErrInvalidEmailDomain     = "invalid email domain"
+	ErrSignupChallengeNeeded  = "solve a challenge from /users/signup/challenge and send it along with its solution to sign up"
+	ErrInvalidSignupChallenge = "invalid, expired or already used signup challenge"
+	ErrSignupQuotaExceeded    = "too many accounts have been signed up from this address, try again later"
+	ErrInvalidRegion          = "invalid region"
+	ErrDeletionScheduled      = "account deletion already scheduled"
+	ErrDeletionNotFound       = "no account deletion is scheduled"
+	ErrCannotDeleteAccount    = "this account cannot be deleted"
+	ErrDataExportPending      = "a data export is already being prepared"
+	ErrDataExportNotFound     = "data export not found"
+	ErrDataExportNotReady     = "data export is not ready or has expired"
+	ErrOutboxEmailNotFound    = "email not found"
+	ErrOutboxEmailQueued      = "the email is already queued for delivery"
+	ErrTicketNotFound         = "ticket not found"
+	ErrInvalidTicketTitle     = "title must be between 3 and 256 characters long"
+	ErrInvalidTicketBody      = "description must be between 1 and 10000 characters long"
+	ErrInvalidTicketComment   = "comment must be between 1 and 10000 characters long"
+	ErrInvalidTicketCategory  = "invalid category, use bug report, feature request, account issue or general query"
+	ErrInvalidTicketStatus    = "invalid status, use open, in progress, resolved or closed"
+	ErrInvalidTicketPriority  = "invalid priority, use low, medium, high or urgent"
+	ErrInvalidTicketAssignee  = "tickets can only be assigned to users allowed to work on them"
+	ErrTicketClosed           = "the ticket is closed, open a new one"
 )
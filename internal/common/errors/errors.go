@@ -1,7 +1,15 @@
 package commonerrors
 
 type ErrorMap struct {
-	Status  string `json:"status"`
+	Status  string       `json:"status"`
+	Message string       `json:"message"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError explains which rule a field of the request body broke.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
 	Message string `json:"message"`
 }
 
@@ -12,6 +20,15 @@ func NewErrorMap(message string) ErrorMap {
 	}
 }
 
+// NewFieldErrorMap creates an ErrorMap listing the rules the fields of the request body broke.
+func NewFieldErrorMap(message string, errors []FieldError) ErrorMap {
+	return ErrorMap{
+		Status:  "error",
+		Message: message,
+		Errors:  errors,
+	}
+}
+
 type GenericResponseError struct {
 	Status  string      `json:"status"`
 	Error   string      `json:"error,omitempty"`
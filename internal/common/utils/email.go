@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/drunkleen/rasta/config"
+	"golang.org/x/net/idna"
+)
+
+// Length limits of an email address and its local part, in bytes, from RFC 5321.
+const (
+	maxEmailLength          = 254
+	maxEmailLocalPartLength = 64
+)
+
+// emailSpecials are the ASCII characters other than letters and digits an unquoted local part may
+// contain, the atext of RFC 5322.
+const emailSpecials = "!#$%&'*+-/=?^_`{|}~"
+
+// emailDomainProfile converts the domain of an email address to punycode, refusing empty or
+// overlong labels and characters host names cannot contain.
+var emailDomainProfile = idna.New(idna.MapForLookup(), idna.BidiRule(), idna.VerifyDNSLength(true))
+
+// EmailValidate checks if an email address is valid and normalizes it.
+//
+// Parameters:
+// - email: the email address to be checked, replaced by its normalized form when valid.
+//
+// Returns:
+// - bool: true if the email address is valid, false otherwise. Addresses with a plus tag are
+// invalid when EMAIL_PLUS_ADDRESSING is reject.
+func EmailValidate(email *string) bool {
+	normalized, ok := NormalizeEmail(*email)
+	if !ok {
+		return false
+	}
+	if config.GetEmailPlusAddressing() == "reject" && EmailHasTag(normalized) {
+		return false
+	}
+	*email = normalized
+	return true
+}
+
+// NormalizeEmail validates an email address following RFC 5322 and RFC 6531, and returns its
+// normalized form: trimmed, lowercased, with an internationalized domain converted to punycode.
+//
+// The local part is either dot-separated atoms, which may contain UTF-8 characters, or a quoted
+// string. Domain literals such as [192.0.2.1] are refused, and the domain needs at least two labels.
+// Returns the normalized address and false if it is invalid.
+func NormalizeEmail(email string) (string, bool) {
+	email = strings.TrimSpace(email)
+	if !utf8.ValidString(email) {
+		return "", false
+	}
+	at := strings.LastIndexByte(email, '@')
+	if at <= 0 || at == len(email)-1 {
+		return "", false
+	}
+	local, domain := email[:at], email[at+1:]
+	if len(local) > maxEmailLocalPartLength || !localPartValid(local) {
+		return "", false
+	}
+	domain, err := emailDomainProfile.ToASCII(domain)
+	if err != nil || !strings.Contains(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", false
+	}
+	normalized := strings.ToLower(local) + "@" + strings.ToLower(domain)
+	if len(normalized) > maxEmailLength {
+		return "", false
+	}
+	return normalized, true
+}
+
+// CanonicalEmail returns the canonical form of a normalized email address, without the plus tag
+// of its local part: "jane+news@example.com" becomes "jane@example.com". Addresses with the same
+// canonical form reach the same mailbox at most providers.
+func CanonicalEmail(email string) string {
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return strings.ToLower(email)
+	}
+	local, domain := email[:at], email[at:]
+	if plus := strings.IndexByte(local, '+'); plus > 0 && !strings.HasPrefix(local, `"`) {
+		local = local[:plus]
+	}
+	return strings.ToLower(local + domain)
+}
+
+// EmailHasTag reports whether a normalized email address has a plus tag.
+func EmailHasTag(email string) bool {
+	return CanonicalEmail(email) != strings.ToLower(email)
+}
+
+// EmailDuplicateKey returns the canonical form two email addresses must not share to belong to
+// different accounts, or an empty string if only identical addresses are duplicates, which
+// EMAIL_PLUS_ADDRESSING decides.
+func EmailDuplicateKey(email string) string {
+	if config.GetEmailPlusAddressing() == "allow" {
+		return ""
+	}
+	return CanonicalEmail(email)
+}
+
+// localPartValid checks the local part of an email address, a dot-atom or a quoted string.
+func localPartValid(local string) bool {
+	if strings.HasPrefix(local, `"`) {
+		return quotedLocalPartValid(local)
+	}
+	for _, atom := range strings.Split(local, ".") {
+		if atom == "" {
+			return false
+		}
+		for _, r := range atom {
+			if r < utf8.RuneSelf {
+				if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' ||
+					strings.ContainsRune(emailSpecials, r)) {
+					return false
+				}
+			} else if !unicode.IsGraphic(r) || unicode.IsSpace(r) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// quotedLocalPartValid checks a local part made of a quoted string, in which a backslash escapes
+// the character following it.
+func quotedLocalPartValid(local string) bool {
+	if len(local) < 2 || !strings.HasSuffix(local, `"`) {
+		return false
+	}
+	escaped := false
+	for _, r := range local[1 : len(local)-1] {
+		switch {
+		case escaped:
+			if r < ' ' || r == utf8.RuneError {
+				return false
+			}
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			return false
+		case r < utf8.RuneSelf:
+			if r < ' ' || r > '~' {
+				return false
+			}
+		case !unicode.IsGraphic(r):
+			return false
+		}
+	}
+	return !escaped
+}
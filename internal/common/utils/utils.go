@@ -1,77 +1,132 @@
 package utils
 
 import (
-	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/text/language"
+	"net/url"
+	"regexp"
 	"strings"
+	"time"
+	// Embeds the IANA time zone database so TimezoneValid does not depend on the host.
+	_ "time/tzdata"
 )
 
-func HashString(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	return string(bytes), err
-}
-
-func CompareHashWithString(plainString, hashString string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hashString), []byte(plainString))
-	return err == nil
-}
-
-func PasswordValid(password string) bool {
+var (
+	phonePattern          = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+	domainLabelPattern    = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+	topLevelDomainPattern = regexp.MustCompile(`^(?i)([a-z]{2,63}|xn--[a-z0-9-]{1,59})$`)
+)
 
-	if len(password) < 8 {
+func UsernameValid(username string) bool {
+	if len(username) < 4 {
 		return false
 	}
-	if !strings.ContainsAny(password, "!@#$%^&*()_+`-=[]{}|;':\",./<>?") {
+	if strings.ContainsAny(username, "!@#$%^&*()+`-=[]{}|;':\",./<>?") {
 		return false
 	}
-
-	if !strings.ContainsAny(password, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") && !strings.ContainsAny(password, "abcdefghijklmnopqrstuvwxyz") {
+	if !strings.ContainsAny(username, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") && !strings.ContainsAny(username, "abcdefghijklmnopqrstuvwxyz") {
 		return false
 	}
-	if strings.Contains(password, " ") || strings.Contains(password, "\t") {
+	if strings.Contains(username, " ") || strings.Contains(username, "\t") {
 		return false
 	}
 	return true
 }
 
-// EmailValidate checks if an email address is valid.
-//
-// Parameters:
-// - email: the email address to be checked.
+// LocaleValid checks if locale is a well-formed BCP 47 language tag, such as "en-US".
 //
-// Returns:
-// - bool: true if the email address is valid, false otherwise.
-func EmailValidate(email *string) bool {
-	if *email == "" {
-		return false
-	}
-	if strings.Contains(*email, "+") {
-		return false
+// Returns the canonical form of the tag and true if it is valid, false otherwise.
+func LocaleValid(locale string) (string, bool) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return "", false
 	}
-	if !strings.Contains(*email, "@") {
+	return tag.String(), true
+}
+
+// TimezoneValid checks if timezone is the name of an IANA time zone, such as "Europe/Berlin".
+func TimezoneValid(timezone string) bool {
+	if timezone == "" || timezone == "Local" {
 		return false
 	}
-	if !strings.Contains(strings.Split(*email, "@")[1], ".") {
-		return false
+	_, err := time.LoadLocation(timezone)
+	return err == nil
+}
+
+// WithTokenQuery returns the URL base with the token set as its token query parameter, keeping
+// the other parameters of base. If base cannot be parsed, the parameter is appended to it as is.
+func WithTokenQuery(base, token string) string {
+	link, err := url.Parse(base)
+	if err != nil {
+		return base + "?token=" + url.QueryEscape(token)
 	}
-	parts := strings.SplitN(*email, "@", 2)
-	localPart := strings.ToLower(parts[0])
-	lowerEmail := localPart + "@" + parts[1]
-	*email = lowerEmail
-	return true
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
 }
 
-func UsernameValid(username string) bool {
-	if len(username) < 4 {
-		return false
+// PhoneValid checks if phone is a phone number in the E.164 format, such as "+14155550123".
+func PhoneValid(phone string) bool {
+	return phonePattern.MatchString(phone)
+}
+
+// DeviceFromUserAgent returns a short, human readable description of the
+// device a User-Agent header was sent from, such as "Chrome on Windows".
+func DeviceFromUserAgent(userAgent string) string {
+	browser := ""
+	switch {
+	case strings.Contains(userAgent, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(userAgent, "OPR/"):
+		browser = "Opera"
+	case strings.Contains(userAgent, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(userAgent, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(userAgent, "Safari/"):
+		browser = "Safari"
 	}
-	if strings.ContainsAny(username, "!@#$%^&*()+`-=[]{}|;':\",./<>?") {
-		return false
+
+	platform := ""
+	switch {
+	case strings.Contains(userAgent, "Android"):
+		platform = "Android"
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"):
+		platform = "iOS"
+	case strings.Contains(userAgent, "Windows"):
+		platform = "Windows"
+	case strings.Contains(userAgent, "Mac OS X"):
+		platform = "macOS"
+	case strings.Contains(userAgent, "Linux"):
+		platform = "Linux"
 	}
-	if !strings.ContainsAny(username, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") && !strings.ContainsAny(username, "abcdefghijklmnopqrstuvwxyz") {
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	}
+	return "Unknown device"
+}
+
+// DomainValid checks if domain is a well-formed host name with a top-level domain, such as
+// "example.com", without looking it up. Labels are made of letters, digits and inner hyphens,
+// so internationalized domains must be given in their ASCII "xn--" form.
+func DomainValid(domain string) bool {
+	if len(domain) > 253 {
 		return false
 	}
-	if strings.Contains(username, " ") || strings.Contains(username, "\t") {
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
 		return false
 	}
-	return true
+	for _, label := range labels {
+		if !domainLabelPattern.MatchString(label) {
+			return false
+		}
+	}
+	return topLevelDomainPattern.MatchString(labels[len(labels)-1])
 }
@@ -0,0 +1,97 @@
+package auditcontroller
+
+import (
+	auditDTO "github.com/drunkleen/rasta/internal/DTO/audit"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	auditmodel "github.com/drunkleen/rasta/internal/models/audit"
+	auditrepository "github.com/drunkleen/rasta/internal/repository/audit"
+	auditservice "github.com/drunkleen/rasta/internal/service/audit"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+)
+
+type AuditController struct {
+	AuditService *auditservice.AuditService
+}
+
+// NewAuditController creates a new instance of the AuditController.
+//
+// It takes a pointer to the AuditService as a parameter and returns a pointer to the AuditController.
+func NewAuditController(auditService *auditservice.AuditService) *AuditController {
+	return &AuditController{AuditService: auditService}
+}
+
+// GetAuditLogs godoc
+// @Summary List audit log entries
+// @Description Lists the audit log, most recent entries first, such as every request made by an admin while impersonating a user. Entries can be filtered by action, actor, user and session.
+// @Tags Audit
+// @Security BearerAuth
+// @Produce  json
+// @Param action query string false "Action, e.g. impersonation.start or impersonation.request"
+// @Param actor_id query string false "ID of the account that performed the action"
+// @Param user_id query string false "ID of the account the action was performed on"
+// @Param session_id query string false "ID of the session the action was performed in"
+// @Param limit query int false "Number of entries per page, at most 100" default(10)
+// @Param page query int false "Page number" default(1)
+// @Success 200 {object} auditDTO.GenericResponse{data=auditDTO.AuditLogPage}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/audit-logs [get]
+func (c *AuditController) GetAuditLogs(ctx *gin.Context) {
+	filter := auditrepository.Filter{Action: auditmodel.Action(ctx.Query("action"))}
+	var ok bool
+	if filter.ActorId, ok = queryUUID(ctx, "actor_id"); !ok {
+		return
+	}
+	if filter.UserId, ok = queryUUID(ctx, "user_id"); !ok {
+		return
+	}
+	if filter.SessionId, ok = queryUUID(ctx, "session_id"); !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	page, _ := strconv.Atoi(ctx.Query("page"))
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > auditservice.MaxPageSize {
+		limit = auditservice.MaxPageSize
+	}
+	if page <= 0 {
+		page = 1
+	}
+	entries, total, err := c.AuditService.Find(filter, limit, page)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, auditDTO.GenericResponse{
+		Status: "success",
+		Data: auditDTO.AuditLogPage{
+			Entries: auditDTO.FromModelsToAuditLogResponse(entries),
+			Total:   total,
+			Page:    page,
+			Limit:   limit,
+		},
+	})
+}
+
+// queryUUID parses an optional UUID query parameter.
+//
+// If the parameter is not a valid UUID, a 400 response is written and false is returned.
+func queryUUID(ctx *gin.Context, key string) (*uuid.UUID, bool) {
+	value := ctx.Query(key)
+	if value == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return nil, false
+	}
+	return &id, true
+}
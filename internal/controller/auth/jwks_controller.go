@@ -0,0 +1,25 @@
+package authcontroller
+
+import (
+	"github.com/drunkleen/rasta/internal/common/auth"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type JWKSController struct{}
+
+// NewJWKSController creates a new instance of the JWKSController.
+//
+// It returns a pointer to the JWKSController.
+func NewJWKSController() *JWKSController {
+	return &JWKSController{}
+}
+
+// GetJWKS serves the public keys access tokens are signed with as a JSON Web Key Set,
+// so other services can verify Rasta tokens without sharing a secret.
+//
+// The set includes retired keys that are still within their grace window.
+func (c *JWKSController) GetJWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, auth.GetJWKS())
+}
@@ -0,0 +1,55 @@
+package authcontroller
+
+import (
+	"github.com/drunkleen/rasta/config"
+	oidcDTO "github.com/drunkleen/rasta/internal/DTO/oidc"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	oidcmodel "github.com/drunkleen/rasta/internal/models/oidc"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// oidcBasePath is where the OpenID Connect endpoints are served below the issuer URL.
+const oidcBasePath = "/api/v1/oidc"
+
+type OidcDiscoveryController struct{}
+
+// NewOidcDiscoveryController creates a new instance of the OidcDiscoveryController.
+//
+// It returns a pointer to the OidcDiscoveryController.
+func NewOidcDiscoveryController() *OidcDiscoveryController {
+	return &OidcDiscoveryController{}
+}
+
+// GetConfiguration serves the OpenID Connect discovery document partner apps configure themselves from.
+//
+// It answers 404 Not Found when tokens are signed with HS256, since partner apps could not verify ID tokens.
+func (c *OidcDiscoveryController) GetConfiguration(ctx *gin.Context) {
+	if !auth.OidcAvailable() {
+		ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(commonerrors.ErrOidcUnavailable))
+		return
+	}
+	issuer := config.GetOidcIssuerUrl()
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, oidcDTO.Discovery{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + oidcBasePath + "/authorize",
+		TokenEndpoint:                     issuer + oidcBasePath + "/token",
+		UserinfoEndpoint:                  issuer + oidcBasePath + "/userinfo",
+		JwksUri:                           issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   oidcmodel.Scopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code"},
+		SubjectTypesSupported:             []string{"public"},
+		IdTokenSigningAlgValuesSupported:  []string{auth.OidcSigningAlg()},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported: []string{
+			"sub", "iss", "aud", "exp", "iat", "nonce",
+			"username", "account", "region",
+			"name", "given_name", "family_name", "preferred_username", "updated_at",
+			"email", "email_verified",
+		},
+	})
+}
@@ -0,0 +1,153 @@
+package emailcontroller
+
+import (
+	emailDTO "github.com/drunkleen/rasta/internal/DTO/email"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
+	emailrepository "github.com/drunkleen/rasta/internal/repository/email"
+	emailservice "github.com/drunkleen/rasta/internal/service/email"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type OutboxController struct {
+	OutboxService *emailservice.OutboxService
+}
+
+// NewOutboxController creates a new instance of the OutboxController.
+//
+// It takes a pointer to the OutboxService as a parameter and returns a pointer to the OutboxController.
+func NewOutboxController(outboxService *emailservice.OutboxService) *OutboxController {
+	return &OutboxController{OutboxService: outboxService}
+}
+
+// GetEmails godoc
+// @Summary List outbox emails
+// @Description Lists the emails of the outbox, most recent first, with their delivery status: queued, sent, failed after too many attempts, or bounced. Bodies are never returned. Emails can be filtered by status, kind, recipient and user.
+// @Tags Emails
+// @Security BearerAuth
+// @Produce  json
+// @Param status query string false "Status: queued, sent, failed or bounced"
+// @Param kind query string false "Kind, e.g. verify_email, reset_password, login_link, email_change_code, new_login, account_locked or newsletter"
+// @Param recipient query string false "Email address of the recipient"
+// @Param user_id query string false "ID of the user the email was sent to"
+// @Param limit query int false "Number of emails per page, at most 100" default(10)
+// @Param page query int false "Page number" default(1)
+// @Success 200 {object} emailDTO.GenericResponse{data=emailDTO.OutboxEmailPage}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/emails [get]
+func (c *OutboxController) GetEmails(ctx *gin.Context) {
+	filter := emailrepository.Filter{
+		Status:    emailmodel.OutboxStatus(ctx.Query("status")),
+		Kind:      ctx.Query("kind"),
+		Recipient: strings.ToLower(strings.TrimSpace(ctx.Query("recipient"))),
+	}
+	if userId := ctx.Query("user_id"); userId != "" {
+		id, err := uuid.Parse(userId)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+			return
+		}
+		filter.UserId = &id
+	}
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	page, _ := strconv.Atoi(ctx.Query("page"))
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > emailservice.MaxPageSize {
+		limit = emailservice.MaxPageSize
+	}
+	if page <= 0 {
+		page = 1
+	}
+	emails, total, err := c.OutboxService.Find(filter, limit, page)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, emailDTO.GenericResponse{
+		Status: "success",
+		Data: emailDTO.OutboxEmailPage{
+			Emails: emailDTO.FromModelsToOutboxEmailResponse(emails),
+			Total:  total,
+			Page:   page,
+			Limit:  limit,
+		},
+	})
+}
+
+// GetEmail godoc
+// @Summary Get an outbox email
+// @Description Returns an email of the outbox with its delivery status and the error of its last failed attempt, if any. The body is never returned.
+// @Tags Emails
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "Email ID"
+// @Success 200 {object} emailDTO.GenericResponse{data=emailDTO.OutboxEmail}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 404 {object} commonerrors.ErrorMap "Email not found"
+// @Router /admin/emails/{id} [get]
+func (c *OutboxController) GetEmail(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	email, err := c.OutboxService.FindById(id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, emailDTO.GenericResponse{
+		Status: "success",
+		Data:   emailDTO.FromModelToOutboxEmailResponse(email),
+	})
+}
+
+// ResendEmail godoc
+// @Summary Resend an outbox email
+// @Description Queues an email that was sent, failed or bounced to be delivered again, with a fresh count of attempts. Codes and links it holds may have expired since it was first sent.
+// @Tags Emails
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "Email ID"
+// @Success 200 {object} emailDTO.GenericResponse{data=emailDTO.OutboxEmail}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 404 {object} commonerrors.ErrorMap "Email not found"
+// @Failure 409 {object} commonerrors.ErrorMap "Email already queued"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/emails/{id}/resend [post]
+func (c *OutboxController) ResendEmail(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	email, err := c.OutboxService.Resend(id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case commonerrors.ErrOutboxEmailNotFound:
+			status = http.StatusNotFound
+		case commonerrors.ErrOutboxEmailQueued:
+			status = http.StatusConflict
+		}
+		ctx.JSON(status, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, emailDTO.GenericResponse{
+		Status: "success",
+		Data:   emailDTO.FromModelToOutboxEmailResponse(email),
+	})
+}
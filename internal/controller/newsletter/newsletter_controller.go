@@ -57,6 +57,7 @@ func (c *NewsletterController) Subscribe(ctx *gin.Context) {
 			Status:  "success",
 			Message: "Successfully subscribed for newsletter",
 		})
+		return
 	}
 
 	if (*subscriber).IsActive {
@@ -226,7 +227,7 @@ func (c *NewsletterController) GetUnsubscribedCount(ctx *gin.Context) {
 
 // SendNewsletterToEveryActiveParticipants godoc
 // @Summary Send Newsletter to Active Subscribers
-// @Description Sends the newsletter email to all active subscribers.
+// @Description Queues the newsletter email to all active subscribers in the email outbox, from which it is delivered in the background.
 // @Tags Newsletter
 // @Accept  json
 // @Produce  json
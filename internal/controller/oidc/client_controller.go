@@ -0,0 +1,179 @@
+package oidccontroller
+
+import (
+	oidcDTO "github.com/drunkleen/rasta/internal/DTO/oidc"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	oidcservice "github.com/drunkleen/rasta/internal/service/oidc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type ClientController struct {
+	OidcService *oidcservice.OidcService
+}
+
+// NewClientController creates a new instance of the ClientController.
+//
+// It takes a pointer to the OidcService as a parameter to initialize the ClientController.
+// It returns a pointer to the ClientController.
+func NewClientController(oidcService *oidcservice.OidcService) *ClientController {
+	return &ClientController{OidcService: oidcService}
+}
+
+// GetClients godoc
+// @Summary List partner apps
+// @Description Lists every partner app allowed to sign users in with their Rasta account.
+// @Tags OpenID Connect Clients
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} oidcDTO.GenericResponse{data=[]oidcDTO.Client}
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/oidc/clients [get]
+func (c *ClientController) GetClients(ctx *gin.Context) {
+	clients, err := c.OidcService.FindClients()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, oidcDTO.GenericResponse{
+		Status: "success",
+		Data:   oidcDTO.FromModelsToClientResponse(clients),
+	})
+}
+
+// CreateClient godoc
+// @Summary Register a partner app
+// @Description Registers a partner app. Confidential clients get a client secret, which is only returned in this response; public clients, such as single-page and mobile apps, get none and rely on PKCE alone.
+// @Tags OpenID Connect Clients
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param client body oidcDTO.ClientRequest true "Client payload"
+// @Success 201 {object} oidcDTO.GenericResponse{data=oidcDTO.ClientSecret}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/oidc/clients [post]
+func (c *ClientController) CreateClient(ctx *gin.Context) {
+	var reqBody oidcDTO.ClientRequest
+	if err := ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	client, secret, err := c.OidcService.CreateClient(reqBody.Name, reqBody.RedirectUris, reqBody.Public)
+	if err != nil {
+		ctx.JSON(oidcErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusCreated, oidcDTO.GenericResponse{
+		Status: "success",
+		Data:   oidcDTO.ClientSecret{Client: *oidcDTO.FromModelToClientResponse(client), ClientSecret: secret},
+	})
+}
+
+// UpdateClient godoc
+// @Summary Update a partner app
+// @Description Renames a partner app and replaces its redirect URIs.
+// @Tags OpenID Connect Clients
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Client ID"
+// @Param client body oidcDTO.ClientUpdate true "Client payload"
+// @Success 200 {object} oidcDTO.GenericResponse{data=oidcDTO.Client}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 404 {object} commonerrors.ErrorMap "Client not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/oidc/clients/{id} [put]
+func (c *ClientController) UpdateClient(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	var reqBody oidcDTO.ClientUpdate
+	if err = ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	client, err := c.OidcService.UpdateClient(id, reqBody.Name, reqBody.RedirectUris)
+	if err != nil {
+		ctx.JSON(oidcErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, oidcDTO.GenericResponse{
+		Status: "success",
+		Data:   oidcDTO.FromModelToClientResponse(client),
+	})
+}
+
+// RotateSecret godoc
+// @Summary Rotate the secret of a partner app
+// @Description Issues a new client secret, which is only returned in this response. The previous secret stops working at once, and a public client becomes confidential.
+// @Tags OpenID Connect Clients
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "Client ID"
+// @Success 200 {object} oidcDTO.GenericResponse{data=oidcDTO.ClientSecret}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 404 {object} commonerrors.ErrorMap "Client not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/oidc/clients/{id}/secret [post]
+func (c *ClientController) RotateSecret(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	client, secret, err := c.OidcService.RotateSecret(id)
+	if err != nil {
+		ctx.JSON(oidcErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, oidcDTO.GenericResponse{
+		Status: "success",
+		Data:   oidcDTO.ClientSecret{Client: *oidcDTO.FromModelToClientResponse(client), ClientSecret: secret},
+	})
+}
+
+// DeleteClient godoc
+// @Summary Delete a partner app
+// @Description Deletes a partner app together with the consents users gave it. Tokens already issued to the app stay valid until they expire.
+// @Tags OpenID Connect Clients
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "Client ID"
+// @Success 200 {object} oidcDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 404 {object} commonerrors.ErrorMap "Client not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/oidc/clients/{id} [delete]
+func (c *ClientController) DeleteClient(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	if err = c.OidcService.DeleteClient(id); err != nil {
+		ctx.JSON(oidcErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, oidcDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "client deleted successfully",
+		},
+	})
+}
@@ -0,0 +1,371 @@
+package oidccontroller
+
+import (
+	"github.com/drunkleen/rasta/config"
+	oidcDTO "github.com/drunkleen/rasta/internal/DTO/oidc"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	oidcservice "github.com/drunkleen/rasta/internal/service/oidc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type OidcController struct {
+	OidcService *oidcservice.OidcService
+}
+
+// NewOidcController creates a new instance of the OidcController.
+//
+// It takes a pointer to the OidcService as a parameter to initialize the OidcController.
+// It returns a pointer to the OidcController.
+func NewOidcController(oidcService *oidcservice.OidcService) *OidcController {
+	return &OidcController{OidcService: oidcService}
+}
+
+// Authorize godoc
+// @Summary OpenID Connect authorization endpoint
+// @Description Partner apps send users here to sign in with their Rasta account, using the authorization code flow with a S256 PKCE challenge. A valid request is redirected to OIDC_CONSENT_URL with the same query string, where the user signs in and approves access. Invalid requests are redirected back to the app with an error, unless the client or the redirect URI is invalid.
+// @Tags OpenID Connect
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "Registered redirect URI"
+// @Param response_type query string true "Must be code"
+// @Param scope query string true "Space-separated scopes, including openid"
+// @Param state query string false "Opaque value sent back to the app"
+// @Param nonce query string false "Value copied into the ID token"
+// @Param code_challenge query string true "S256 PKCE code challenge"
+// @Param code_challenge_method query string true "Must be S256"
+// @Success 302 "Redirect to the consent page"
+// @Failure 400 {object} commonerrors.ErrorMap "Invalid redirect URI"
+// @Failure 404 {object} commonerrors.ErrorMap "Client not found"
+// @Router /oidc/authorize [get]
+func (c *OidcController) Authorize(ctx *gin.Context) {
+	var req oidcDTO.AuthorizationRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	_, _, err := c.OidcService.ValidateAuthorization(req.ClientId, req.RedirectUri, req.ResponseType, req.Scope, req.CodeChallenge, req.CodeChallengeMethod)
+	if err != nil {
+		if status, redirectable := authorizationErrorStatus(err); !redirectable {
+			ctx.JSON(status, commonerrors.NewErrorMap(err.Error()))
+			return
+		}
+		ctx.Redirect(http.StatusFound, redirectUrl(req.RedirectUri, url.Values{
+			"error":             {authorizationErrorCode(err)},
+			"error_description": {err.Error()},
+			"state":             {req.State},
+		}))
+		return
+	}
+	consentUrl := config.GetOidcConsentUrl()
+	separator := "?"
+	if strings.Contains(consentUrl, "?") {
+		separator = "&"
+	}
+	ctx.Redirect(http.StatusFound, consentUrl+separator+ctx.Request.URL.RawQuery)
+}
+
+// GetConsent godoc
+// @Summary Describe an authorization request
+// @Description Returns what the consent page shows the authenticated user for an authorization request: the partner app and the scopes it requests, and whether the user already allowed them, in which case the page may approve the request right away.
+// @Tags OpenID Connect
+// @Security BearerAuth
+// @Produce  json
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "Registered redirect URI"
+// @Param response_type query string true "Must be code"
+// @Param scope query string true "Space-separated scopes, including openid"
+// @Param code_challenge query string true "S256 PKCE code challenge"
+// @Param code_challenge_method query string true "Must be S256"
+// @Success 200 {object} oidcDTO.GenericResponse{data=oidcDTO.ConsentPrompt}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 404 {object} commonerrors.ErrorMap "Client not found"
+// @Router /oidc/consent [get]
+func (c *OidcController) GetConsent(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	var req oidcDTO.AuthorizationRequest
+	if err = ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	client, scopes, err := c.OidcService.ValidateAuthorization(req.ClientId, req.RedirectUri, req.ResponseType, req.Scope, req.CodeChallenge, req.CodeChallengeMethod)
+	if err != nil {
+		status, _ := authorizationErrorStatus(err)
+		ctx.JSON(status, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, oidcDTO.GenericResponse{
+		Status: "success",
+		Data: oidcDTO.ConsentPrompt{
+			ClientId:   client.Id,
+			ClientName: client.Name,
+			Scopes:     scopes,
+			Granted:    c.OidcService.HasConsent(userId, client.Id, scopes),
+		},
+	})
+}
+
+// Consent godoc
+// @Summary Approve or deny an authorization request
+// @Description Records the decision of the authenticated user on an authorization request and returns the URL of the partner app to send the user to, carrying an authorization code and the state, or an access_denied error. The code must be exchanged at /oidc/token within a minute.
+// @Tags OpenID Connect
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param decision body oidcDTO.ConsentDecision true "Authorization request and decision"
+// @Success 200 {object} oidcDTO.GenericResponse{data=oidcDTO.ConsentRedirect}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 404 {object} commonerrors.ErrorMap "Client not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /oidc/consent [post]
+func (c *OidcController) Consent(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	var reqBody oidcDTO.ConsentDecision
+	if err = ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	req := reqBody.AuthorizationRequest
+	client, scopes, err := c.OidcService.ValidateAuthorization(req.ClientId, req.RedirectUri, req.ResponseType, req.Scope, req.CodeChallenge, req.CodeChallengeMethod)
+	if err != nil {
+		status, _ := authorizationErrorStatus(err)
+		ctx.JSON(status, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	params := url.Values{"state": {req.State}}
+	if reqBody.Approve {
+		code, err := c.OidcService.Approve(userId, client, scopes, req.RedirectUri, req.Nonce, req.CodeChallenge)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+			return
+		}
+		params.Set("code", code)
+	} else {
+		params.Set("error", "access_denied")
+		params.Set("error_description", commonerrors.ErrAccessDenied)
+	}
+	ctx.JSON(http.StatusOK, oidcDTO.GenericResponse{
+		Status: "success",
+		Data:   oidcDTO.ConsentRedirect{RedirectUrl: redirectUrl(req.RedirectUri, params)},
+	})
+}
+
+// Token godoc
+// @Summary OpenID Connect token endpoint
+// @Description Exchanges an authorization code for an access token and an ID token carrying the username, account type and region of the user. Confidential clients authenticate with HTTP Basic or client_secret; public clients send their client_id. The code_verifier must match the PKCE challenge of the authorization request. Errors follow RFC 6749.
+// @Tags OpenID Connect
+// @Accept  x-www-form-urlencoded
+// @Produce  json
+// @Param grant_type formData string true "Must be authorization_code"
+// @Param code formData string true "Authorization code"
+// @Param redirect_uri formData string true "Redirect URI of the authorization request"
+// @Param code_verifier formData string true "PKCE code verifier"
+// @Param client_id formData string false "Client ID, unless sent with HTTP Basic"
+// @Param client_secret formData string false "Client secret, unless sent with HTTP Basic"
+// @Success 200 {object} oidcDTO.Token
+// @Failure 400 {object} oidcDTO.TokenError "Invalid request or grant"
+// @Failure 401 {object} oidcDTO.TokenError "Invalid client"
+// @Failure 500 {object} oidcDTO.TokenError "Internal Server Error"
+// @Router /oidc/token [post]
+func (c *OidcController) Token(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Pragma", "no-cache")
+	var req oidcDTO.TokenRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, oidcDTO.TokenError{Error: "invalid_request", ErrorDescription: commonerrors.ErrInvalidRequestBody})
+		return
+	}
+	if req.GrantType != "authorization_code" {
+		ctx.JSON(http.StatusBadRequest, oidcDTO.TokenError{Error: "unsupported_grant_type", ErrorDescription: commonerrors.ErrUnsupportedGrantType})
+		return
+	}
+	clientId, clientSecret := req.ClientId, req.ClientSecret
+	if username, password, ok := ctx.Request.BasicAuth(); ok {
+		clientId, _ = url.QueryUnescape(username)
+		clientSecret, _ = url.QueryUnescape(password)
+	}
+	accessToken, idToken, scope, err := c.OidcService.Exchange(clientId, clientSecret, req.Code, req.RedirectUri, req.CodeVerifier)
+	if err != nil {
+		status, code := tokenErrorCode(err)
+		if status == http.StatusUnauthorized {
+			ctx.Header("WWW-Authenticate", `Basic realm="oidc"`)
+		}
+		ctx.JSON(status, oidcDTO.TokenError{Error: code, ErrorDescription: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, oidcDTO.Token{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   config.GetJwtExpiry(),
+		IdToken:     idToken,
+		Scope:       scope,
+	})
+}
+
+// UserInfo godoc
+// @Summary OpenID Connect userinfo endpoint
+// @Description Returns the claims about the user a partner app was granted, for an access token issued by /oidc/token and sent as a Bearer token.
+// @Tags OpenID Connect
+// @Produce  json
+// @Param Authorization header string true "Bearer access token"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} oidcDTO.TokenError "Invalid token"
+// @Router /oidc/userinfo [get]
+func (c *OidcController) UserInfo(ctx *gin.Context) {
+	token := strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	info, err := c.OidcService.UserInfo(token)
+	if err != nil {
+		ctx.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		ctx.JSON(http.StatusUnauthorized, oidcDTO.TokenError{Error: "invalid_token", ErrorDescription: err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, info)
+}
+
+// GetConsents godoc
+// @Summary List partner app consents
+// @Description Lists the partner apps the authenticated user allowed to access their account, and the scopes they were granted.
+// @Tags OpenID Connect
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} oidcDTO.GenericResponse{data=[]oidcDTO.Consent}
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /oidc/consents [get]
+func (c *OidcController) GetConsents(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	consents, err := c.OidcService.FindConsents(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, oidcDTO.GenericResponse{
+		Status: "success",
+		Data:   oidcDTO.FromModelsToConsentResponse(consents),
+	})
+}
+
+// RevokeConsent godoc
+// @Summary Revoke a partner app consent
+// @Description Revokes the consent the authenticated user gave to a partner app, so the user is asked again on the next sign-in. Tokens already issued to the app stay valid until they expire.
+// @Tags OpenID Connect
+// @Security BearerAuth
+// @Produce  json
+// @Param clientId path string true "Client ID"
+// @Success 200 {object} oidcDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 404 {object} commonerrors.ErrorMap "Consent not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /oidc/consents/{clientId} [delete]
+func (c *OidcController) RevokeConsent(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	clientId, err := uuid.Parse(ctx.Param("clientId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	if err = c.OidcService.RevokeConsent(userId, clientId); err != nil {
+		ctx.JSON(oidcErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, oidcDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "consent revoked successfully",
+		},
+	})
+}
+
+// redirectUrl appends the given parameters to the query string of a redirect URI, dropping empty ones.
+func redirectUrl(redirectUri string, params url.Values) string {
+	parsed, err := url.Parse(redirectUri)
+	if err != nil {
+		return redirectUri
+	}
+	query := parsed.Query()
+	for key, values := range params {
+		if len(values) > 0 && values[0] != "" {
+			query.Set(key, values[0])
+		}
+	}
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
+
+// authorizationErrorStatus maps an error returned by OidcService.ValidateAuthorization to an HTTP status code,
+// and reports whether the error may be sent back to the redirect URI of the request.
+func authorizationErrorStatus(err error) (int, bool) {
+	switch err.Error() {
+	case commonerrors.ErrOidcUnavailable, commonerrors.ErrOidcClientNotFound:
+		return http.StatusNotFound, false
+	case commonerrors.ErrInvalidRedirectUri:
+		return http.StatusBadRequest, false
+	case commonerrors.ErrInternalServer:
+		return http.StatusInternalServerError, true
+	default:
+		return http.StatusBadRequest, true
+	}
+}
+
+// authorizationErrorCode maps an error returned by OidcService.ValidateAuthorization to an RFC 6749 error code.
+func authorizationErrorCode(err error) string {
+	switch err.Error() {
+	case commonerrors.ErrUnsupportedResponse:
+		return "unsupported_response_type"
+	case commonerrors.ErrInvalidScope:
+		return "invalid_scope"
+	case commonerrors.ErrInternalServer:
+		return "server_error"
+	default:
+		return "invalid_request"
+	}
+}
+
+// tokenErrorCode maps an error returned by OidcService.Exchange to an HTTP status code and an RFC 6749 error code.
+func tokenErrorCode(err error) (int, string) {
+	switch err.Error() {
+	case commonerrors.ErrInvalidClient:
+		return http.StatusUnauthorized, "invalid_client"
+	case commonerrors.ErrInvalidGrant, commonerrors.ErrAccountSuspended, commonerrors.ErrUserNotFound:
+		return http.StatusBadRequest, "invalid_grant"
+	default:
+		return http.StatusInternalServerError, "server_error"
+	}
+}
+
+// oidcErrorStatus maps an error returned by the OidcService to an HTTP status code.
+//
+// Invalid redirect URIs are reported together with the offending URI.
+func oidcErrorStatus(err error) int {
+	switch {
+	case err.Error() == commonerrors.ErrOidcClientNotFound, err.Error() == commonerrors.ErrConsentNotFound:
+		return http.StatusNotFound
+	case err.Error() == commonerrors.ErrInvalidClientName, strings.HasPrefix(err.Error(), commonerrors.ErrInvalidRedirectUri):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
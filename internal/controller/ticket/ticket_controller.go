@@ -1 +1,409 @@
 package ticketcontroller
+
+import (
+	ticketDTO "github.com/drunkleen/rasta/internal/DTO/ticket"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	ticketmodel "github.com/drunkleen/rasta/internal/models/ticket"
+	ticketrepository "github.com/drunkleen/rasta/internal/repository/ticket"
+	ticketservice "github.com/drunkleen/rasta/internal/service/ticket"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+)
+
+type TicketController struct {
+	TicketService *ticketservice.TicketService
+}
+
+// NewTicketController creates a new instance of the TicketController.
+//
+// It takes a pointer to the TicketService as a parameter and returns a pointer to the TicketController.
+func NewTicketController(ticketService *ticketservice.TicketService) *TicketController {
+	return &TicketController{TicketService: ticketService}
+}
+
+// CreateTicket godoc
+// @Summary Open a support ticket
+// @Description Opens a support ticket on behalf of the authenticated user. The category is one of bug report, feature request, account issue or general query, and the priority one of low, medium, high or urgent, medium by default.
+// @Tags Tickets
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param ticket body ticketDTO.CreateTicketRequest true "Ticket payload"
+// @Success 201 {object} ticketDTO.GenericResponse{data=ticketDTO.Ticket}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden while impersonating a user"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /tickets [post]
+func (c *TicketController) CreateTicket(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	var reqBody ticketDTO.CreateTicketRequest
+	if err = ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	ticket, err := c.TicketService.Create(userId, reqBody.Title, reqBody.Description, reqBody.Category, reqBody.Priority)
+	if err != nil {
+		ctx.JSON(ticketErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusCreated, ticketDTO.GenericResponse{
+		Status: "success",
+		Data:   ticketDTO.FromModelToTicketResponse(ticket),
+	})
+}
+
+// GetMyTickets godoc
+// @Summary List my support tickets
+// @Description Lists the support tickets the authenticated user opened, most recent first, without their comments.
+// @Tags Tickets
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} ticketDTO.GenericResponse{data=[]ticketDTO.Ticket}
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /tickets [get]
+func (c *TicketController) GetMyTickets(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	tickets, err := c.TicketService.FindByUserId(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, ticketDTO.GenericResponse{
+		Status: "success",
+		Data:   ticketDTO.FromModelsToTicketResponse(tickets),
+	})
+}
+
+// GetMyTicket godoc
+// @Summary Get one of my support tickets
+// @Description Returns a support ticket the authenticated user opened, with its comments, oldest first.
+// @Tags Tickets
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "Ticket ID"
+// @Success 200 {object} ticketDTO.GenericResponse{data=ticketDTO.Ticket}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 404 {object} commonerrors.ErrorMap "Ticket not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /tickets/{id} [get]
+func (c *TicketController) GetMyTicket(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	ticket, err := c.TicketService.FindOwnedById(userId, id)
+	if err != nil {
+		ctx.JSON(ticketErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, ticketDTO.GenericResponse{
+		Status: "success",
+		Data:   ticketDTO.FromModelToTicketResponse(ticket),
+	})
+}
+
+// CommentMyTicket godoc
+// @Summary Comment on one of my support tickets
+// @Description Adds a comment of the authenticated user to a support ticket they opened. Closed tickets no longer take comments; open a new ticket instead.
+// @Tags Tickets
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Ticket ID"
+// @Param comment body ticketDTO.CommentRequest true "Comment payload"
+// @Success 201 {object} ticketDTO.GenericResponse{data=ticketDTO.Comment}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden while impersonating a user"
+// @Failure 404 {object} commonerrors.ErrorMap "Ticket not found"
+// @Failure 409 {object} commonerrors.ErrorMap "Ticket closed"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /tickets/{id}/comments [post]
+func (c *TicketController) CommentMyTicket(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	var reqBody ticketDTO.CommentRequest
+	if err = ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	comment, err := c.TicketService.Comment(userId, id, reqBody.Comment)
+	if err != nil {
+		ctx.JSON(ticketErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusCreated, ticketDTO.GenericResponse{
+		Status: "success",
+		Data:   ticketDTO.FromModelToCommentResponse(comment),
+	})
+}
+
+// GetTickets godoc
+// @Summary List support tickets
+// @Description Lists the support tickets of every user, most recently updated first, without their comments. Tickets can be filtered by status, priority, category, author and assignee; set unassigned to list the tickets no one handles yet.
+// @Tags Tickets
+// @Security BearerAuth
+// @Produce  json
+// @Param status query string false "Status: open, in progress, resolved or closed"
+// @Param priority query string false "Priority: low, medium, high or urgent"
+// @Param category query string false "Category: bug report, feature request, account issue or general query"
+// @Param user_id query string false "ID of the user who opened the ticket"
+// @Param assigned_to query string false "ID of the member of staff the ticket is assigned to"
+// @Param unassigned query bool false "Only list the tickets assigned to no one"
+// @Param limit query int false "Number of tickets per page, at most 100" default(10)
+// @Param page query int false "Page number" default(1)
+// @Success 200 {object} ticketDTO.GenericResponse{data=ticketDTO.TicketPage}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/tickets [get]
+func (c *TicketController) GetTickets(ctx *gin.Context) {
+	filter := ticketrepository.Filter{
+		Status:     ticketmodel.TicketStatus(ctx.Query("status")),
+		Priority:   ticketmodel.TicketPriority(ctx.Query("priority")),
+		Category:   ticketmodel.TicketCategory(ctx.Query("category")),
+		Unassigned: ctx.Query("unassigned") == "true",
+	}
+	var ok bool
+	if filter.UserId, ok = queryUUID(ctx, "user_id"); !ok {
+		return
+	}
+	if filter.AssignedTo, ok = queryUUID(ctx, "assigned_to"); !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	page, _ := strconv.Atoi(ctx.Query("page"))
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > ticketservice.MaxPageSize {
+		limit = ticketservice.MaxPageSize
+	}
+	if page <= 0 {
+		page = 1
+	}
+	tickets, total, err := c.TicketService.Find(filter, limit, page)
+	if err != nil {
+		ctx.JSON(ticketErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, ticketDTO.GenericResponse{
+		Status: "success",
+		Data: ticketDTO.TicketPage{
+			Tickets: ticketDTO.FromModelsToTicketResponse(tickets),
+			Total:   total,
+			Page:    page,
+			Limit:   limit,
+		},
+	})
+}
+
+// GetTicket godoc
+// @Summary Get a support ticket
+// @Description Returns any support ticket with its comments, oldest first.
+// @Tags Tickets
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "Ticket ID"
+// @Success 200 {object} ticketDTO.GenericResponse{data=ticketDTO.Ticket}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 404 {object} commonerrors.ErrorMap "Ticket not found"
+// @Router /admin/tickets/{id} [get]
+func (c *TicketController) GetTicket(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	ticket, err := c.TicketService.FindById(id)
+	if err != nil {
+		ctx.JSON(ticketErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, ticketDTO.GenericResponse{
+		Status: "success",
+		Data:   ticketDTO.FromModelToTicketResponse(ticket),
+	})
+}
+
+// CommentTicket godoc
+// @Summary Reply to a support ticket
+// @Description Adds a comment of the authenticated member of staff to any support ticket, closed or not.
+// @Tags Tickets
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Ticket ID"
+// @Param comment body ticketDTO.CommentRequest true "Comment payload"
+// @Success 201 {object} ticketDTO.GenericResponse{data=ticketDTO.Comment}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 404 {object} commonerrors.ErrorMap "Ticket not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/tickets/{id}/comments [post]
+func (c *TicketController) CommentTicket(ctx *gin.Context) {
+	staffId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	var reqBody ticketDTO.CommentRequest
+	if err = ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	comment, err := c.TicketService.StaffComment(staffId, id, reqBody.Comment)
+	if err != nil {
+		ctx.JSON(ticketErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusCreated, ticketDTO.GenericResponse{
+		Status: "success",
+		Data:   ticketDTO.FromModelToCommentResponse(comment),
+	})
+}
+
+// UpdateTicket godoc
+// @Summary Update a support ticket
+// @Description Changes the status and the priority of a support ticket. Fields left out are unchanged.
+// @Tags Tickets
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Ticket ID"
+// @Param ticket body ticketDTO.UpdateTicketRequest true "Ticket payload"
+// @Success 200 {object} ticketDTO.GenericResponse{data=ticketDTO.Ticket}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 404 {object} commonerrors.ErrorMap "Ticket not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/tickets/{id} [patch]
+func (c *TicketController) UpdateTicket(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	var reqBody ticketDTO.UpdateTicketRequest
+	if err = ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	ticket, err := c.TicketService.Update(id, reqBody.Status, reqBody.Priority)
+	if err != nil {
+		ctx.JSON(ticketErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, ticketDTO.GenericResponse{
+		Status: "success",
+		Data:   ticketDTO.FromModelToTicketResponse(ticket),
+	})
+}
+
+// AssignTicket godoc
+// @Summary Assign a support ticket
+// @Description Assigns a support ticket to a member of staff, who must hold the tickets.write permission, or unassigns it when assignee_id is left out.
+// @Tags Tickets
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Ticket ID"
+// @Param assignee body ticketDTO.AssignTicketRequest true "Assignee payload"
+// @Success 200 {object} ticketDTO.GenericResponse{data=ticketDTO.Ticket}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 404 {object} commonerrors.ErrorMap "Ticket not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/tickets/{id}/assignee [put]
+func (c *TicketController) AssignTicket(ctx *gin.Context) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	var reqBody ticketDTO.AssignTicketRequest
+	if err = ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	ticket, err := c.TicketService.Assign(id, reqBody.AssigneeId)
+	if err != nil {
+		ctx.JSON(ticketErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, ticketDTO.GenericResponse{
+		Status: "success",
+		Data:   ticketDTO.FromModelToTicketResponse(ticket),
+	})
+}
+
+// queryUUID parses an optional UUID query parameter.
+//
+// If the parameter is not a valid UUID, a 400 response is written and false is returned.
+func queryUUID(ctx *gin.Context, key string) (*uuid.UUID, bool) {
+	value := ctx.Query(key)
+	if value == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return nil, false
+	}
+	return &id, true
+}
+
+// ticketErrorStatus maps the errors of the TicketService to HTTP status codes.
+func ticketErrorStatus(err error) int {
+	switch {
+	case err.Error() == commonerrors.ErrTicketNotFound:
+		return http.StatusNotFound
+	case err.Error() == commonerrors.ErrTicketClosed:
+		return http.StatusConflict
+	case err.Error() == commonerrors.ErrInvalidTicketTitle, err.Error() == commonerrors.ErrInvalidTicketBody,
+		err.Error() == commonerrors.ErrInvalidTicketComment, err.Error() == commonerrors.ErrInvalidTicketCategory,
+		err.Error() == commonerrors.ErrInvalidTicketStatus, err.Error() == commonerrors.ErrInvalidTicketPriority,
+		err.Error() == commonerrors.ErrInvalidTicketAssignee:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,225 @@
+package usercontroller
+
+import (
+	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	userservice "github.com/drunkleen/rasta/internal/service/user"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type AccountDeletionController struct {
+	AccountDeletionService *userservice.AccountDeletionService
+	UserService            *userservice.UserService
+	OAuthService           *userservice.OAuthService
+	LockoutService         *userservice.LockoutService
+}
+
+// NewAccountDeletionController creates a new instance of the AccountDeletionController.
+//
+// accountDeletionService is the AccountDeletionService instance to be used by the AccountDeletionController.
+// userService is the UserService instance to be used by the AccountDeletionController.
+// oauthService is the OAuthService instance to be used by the AccountDeletionController.
+// lockoutService is the LockoutService instance to be used by the AccountDeletionController.
+// Returns a pointer to the newly created AccountDeletionController instance.
+func NewAccountDeletionController(
+	accountDeletionService *userservice.AccountDeletionService,
+	userService *userservice.UserService,
+	oauthService *userservice.OAuthService,
+	lockoutService *userservice.LockoutService,
+) *AccountDeletionController {
+	return &AccountDeletionController{
+		AccountDeletionService: accountDeletionService,
+		UserService:            userService,
+		OAuthService:           oauthService,
+		LockoutService:         lockoutService,
+	}
+}
+
+// ScheduleDeletion godoc
+// @Summary Delete the authenticated user
+// @Description Schedules the deletion of the account of the authenticated user after ACCOUNT_DELETION_GRACE seconds. The current password is required, and the TOTP code or a recovery code when two-factor authentication is enabled. The account stays usable until then and the deletion can be canceled. The account is then anonymized: its personal data is erased, the tickets and comments the user wrote are redacted and its sessions, credentials, subscriptions and exports are deleted. Admins cannot delete their own account.
+// @Tags Users
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param deletion body userDTO.AccountDeletionRequest true "Account deletion payload"
+// @Success 202 {object} userDTO.GenericResponse{data=userDTO.AccountDeletion}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Invalid password, otp or recovery code"
+// @Failure 403 {object} commonerrors.ErrorMap "This account cannot be deleted"
+// @Failure 409 {object} commonerrors.ErrorMap "Deletion already scheduled"
+// @Failure 429 {object} commonerrors.ErrorMap "Too many failed attempts"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/me [delete]
+func (c *AccountDeletionController) ScheduleDeletion(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	var reqBody userDTO.AccountDeletionRequest
+	if err = ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	user, err := c.UserService.FindById(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(commonerrors.ErrInternalServer))
+		return
+	}
+	ipAddress := ctx.ClientIP()
+	if retryAfter, err := c.LockoutService.Check(user, ipAddress); err != nil {
+		respondTooManyAttempts(ctx, retryAfter, err)
+		return
+	}
+	if user.OAuth.Enabled {
+		if reqBody.RecoveryCode != "" {
+			err = c.OAuthService.UseRecoveryCode(user, reqBody.RecoveryCode)
+		} else {
+			err = c.OAuthService.OAuthValidate(user, reqBody.OTP)
+		}
+		if err != nil {
+			if err.Error() != commonerrors.ErrInternalServer {
+				c.LockoutService.RegisterFailure(user, ipAddress)
+			}
+			ctx.JSON(accountDeletionErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+			return
+		}
+	}
+	deletion, err := c.AccountDeletionService.Schedule(user, reqBody.Password)
+	if err != nil {
+		if err.Error() == commonerrors.ErrInvalidCredentials {
+			c.LockoutService.RegisterFailure(user, ipAddress)
+		}
+		ctx.JSON(accountDeletionErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusAccepted, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelToAccountDeletionResponse(deletion),
+	})
+}
+
+// GetDeletion godoc
+// @Summary Get the scheduled deletion of the authenticated user
+// @Description Retrieves when the account of the authenticated user is scheduled to be deleted.
+// @Tags Users
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse{data=userDTO.AccountDeletion}
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 404 {object} commonerrors.ErrorMap "No deletion is scheduled"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/me/deletion [get]
+func (c *AccountDeletionController) GetDeletion(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	deletion, err := c.AccountDeletionService.FindPending(userId)
+	if err != nil {
+		ctx.JSON(accountDeletionErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelToAccountDeletionResponse(deletion),
+	})
+}
+
+// CancelDeletion godoc
+// @Summary Cancel the deletion of the authenticated user
+// @Description Cancels the scheduled deletion of the account of the authenticated user.
+// @Tags Users
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 404 {object} commonerrors.ErrorMap "No deletion is scheduled"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/me/deletion [delete]
+func (c *AccountDeletionController) CancelDeletion(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	if err = c.AccountDeletionService.Cancel(userId); err != nil {
+		ctx.JSON(accountDeletionErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "account deletion canceled successfully",
+		},
+	})
+}
+
+// DeleteUser godoc
+// @Summary Delete a user
+// @Description Deletes a user account at once, without grace period, anonymizing it like a deletion requested by the user, and notifies the user by email. Admins and service accounts cannot be deleted this way.
+// @Tags Users
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "User ID"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden or the account cannot be deleted"
+// @Failure 404 {object} commonerrors.ErrorMap "User not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/users/id/{id} [delete]
+func (c *AccountDeletionController) DeleteUser(ctx *gin.Context) {
+	userId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(commonerrors.ErrUserNotFound))
+		return
+	}
+	if actor, ok := ctx.Get("userModel"); ok {
+		if actorModel, isUser := actor.(*usermodel.User); isUser && actorModel.Id == userId {
+			ctx.JSON(http.StatusForbidden, commonerrors.NewErrorMap(commonerrors.ErrCannotDeleteAccount))
+			return
+		}
+	}
+	user, err := c.UserService.FindById(userId)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(commonerrors.ErrUserNotFound))
+		return
+	}
+	if err = c.AccountDeletionService.DeleteNow(user); err != nil {
+		ctx.JSON(accountDeletionErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "user deleted successfully",
+		},
+	})
+}
+
+// accountDeletionErrorStatus maps an error returned by the AccountDeletionService to an HTTP status code.
+func accountDeletionErrorStatus(err error) int {
+	switch err.Error() {
+	case commonerrors.ErrInvalidCredentials, commonerrors.ErrInvalidOtp, commonerrors.ErrInvalidRecoveryCode:
+		return http.StatusUnauthorized
+	case commonerrors.ErrCannotDeleteAccount:
+		return http.StatusForbidden
+	case commonerrors.ErrDeletionNotFound:
+		return http.StatusNotFound
+	case commonerrors.ErrDeletionScheduled:
+		return http.StatusConflict
+	case commonerrors.ErrInternalServer:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
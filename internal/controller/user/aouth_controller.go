@@ -69,7 +69,7 @@ func (c *OAuthController) GenerateOAuth(ctx *gin.Context) {
 
 // VerifyAndEnableOAuth godoc
 // @Summary Verify and Enable OAuth
-// @Description Verifies the OAuth code provided by the user and enables OAuth for the account.
+// @Description Verifies the OAuth code provided by the user and enables OAuth for the account. The response carries a set of one-time recovery codes, which are shown only once.
 // @Tags OAuth
 // @Security BearerAuth
 // @Accept  json
@@ -119,11 +119,18 @@ func (c *OAuthController) VerifyAndEnableOAuth(ctx *gin.Context) {
 		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap("Invalid OAuth code"))
 		return
 	}
+	recoveryCodes, err := c.OAuthService.GenerateRecoveryCodes(user.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(commonerrors.ErrInternalServer))
+		return
+	}
 	if err = c.OAuthService.UpdateOAuthEnabled(user.Id, true); err != nil {
 		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(commonerrors.ErrInternalServer))
 		return
 	}
-	ctx.JSON(http.StatusOK, oauthDTO.ToOAuthResponse("Otp enabled", "", "", true))
+	resp := oauthDTO.ToOAuthResponse("Otp enabled", "", "", true)
+	resp.RecoveryCodes = recoveryCodes
+	ctx.JSON(http.StatusOK, resp)
 }
 
 // DisableOAuth godoc
@@ -184,3 +191,89 @@ func (c *OAuthController) DisableOAuth(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, oauthDTO.ToOAuthResponse("OAuth disabled", "", "", false))
 }
+
+// GetRecoveryCodesCount godoc
+// @Summary Count remaining recovery codes
+// @Description Returns the number of unused recovery codes of the authenticated user.
+// @Tags OAuth
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} oauthDTO.RecoveryCodesCount
+// @Failure 400 {object} commonerrors.ErrorMap "OAuth is not enabled"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/oauth/recovery-codes [get]
+func (c *OAuthController) GetRecoveryCodesCount(ctx *gin.Context) {
+	id, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	user, err := c.UserService.FindById(id)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	if !user.OAuth.Enabled {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap("OAuth is not enabled"))
+		return
+	}
+	count, err := c.OAuthService.CountRecoveryCodes(user.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, oauthDTO.RecoveryCodesCount{Status: "success", Remaining: count})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replaces the recovery codes of the authenticated user after verifying a TOTP code. The previous codes stop working and the new codes are shown only once.
+// @Tags OAuth
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param oauth body map[string]string true "OAuth code"
+// @Success 200 {object} oauthDTO.Response "New recovery codes"
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/oauth/recovery-codes [post]
+func (c *OAuthController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	id, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	var reqBody map[string]string
+	if err = ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	otp, exists := reqBody["oauth"]
+	if !exists || otp == "" {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	user, err := c.UserService.FindById(id)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	if !user.OAuth.Enabled {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap("OAuth is not enabled"))
+		return
+	}
+	if err = c.OAuthService.OAuthValidate(user, otp); err != nil {
+		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap("Invalid OAuth code"))
+		return
+	}
+	recoveryCodes, err := c.OAuthService.GenerateRecoveryCodes(user.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	resp := oauthDTO.ToOAuthResponse("Recovery codes regenerated", "", "", true)
+	resp.RecoveryCodes = recoveryCodes
+	ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,151 @@
+package usercontroller
+
+import (
+	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	userservice "github.com/drunkleen/rasta/internal/service/user"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type ApiKeyController struct {
+	ApiKeyService *userservice.ApiKeyService
+	UserService   *userservice.UserService
+	RoleService   *userservice.RoleService
+}
+
+// NewApiKeyController creates a new instance of the ApiKeyController.
+//
+// apiKeyService is the ApiKeyService instance to be used by the ApiKeyController.
+// userService is the UserService instance to be used by the ApiKeyController.
+// roleService is the RoleService instance to be used by the ApiKeyController.
+// Returns a pointer to the newly created ApiKeyController instance.
+func NewApiKeyController(apiKeyService *userservice.ApiKeyService, userService *userservice.UserService, roleService *userservice.RoleService) *ApiKeyController {
+	return &ApiKeyController{ApiKeyService: apiKeyService, UserService: userService, RoleService: roleService}
+}
+
+// CreateApiKey godoc
+// @Summary Create an API key
+// @Description Issues an API key to the authenticated user, for integrations to send as "Authorization: ApiKey <key>". The key is only returned in this response. It grants the permissions in scopes that the user holds, may make rate_limit requests per minute (API_KEY_RATE_LIMIT by default) and expires after expires_in_days days, or never when 0.
+// @Tags API Keys
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param key body userDTO.ApiKeyCreate true "API key payload"
+// @Success 201 {object} userDTO.GenericResponse{data=userDTO.ApiKeyCreated}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Scopes not held by the user"
+// @Failure 409 {object} commonerrors.ErrorMap "Too many API keys"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/api-keys [post]
+func (c *ApiKeyController) CreateApiKey(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	var reqBody userDTO.ApiKeyCreate
+	if err = ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	user, err := c.UserService.FindById(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(commonerrors.ErrInternalServer))
+		return
+	}
+	if len(reqBody.Scopes) > 0 && !c.RoleService.HasPermissions(user, reqBody.Scopes...) {
+		ctx.JSON(http.StatusForbidden, commonerrors.NewErrorMap(commonerrors.ErrForbidden))
+		return
+	}
+	key, secret, err := c.ApiKeyService.Create(userId, reqBody.Name, reqBody.Scopes, reqBody.RateLimit, reqBody.ExpiresInDays)
+	if err != nil {
+		ctx.JSON(apiKeyErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusCreated, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.ApiKeyCreated{ApiKey: *userDTO.FromModelToApiKeyResponse(key), Key: secret},
+	})
+}
+
+// GetApiKeys godoc
+// @Summary List API keys
+// @Description Lists the API keys of the authenticated user, with when and from where each was last used.
+// @Tags API Keys
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse{data=[]userDTO.ApiKey}
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/api-keys [get]
+func (c *ApiKeyController) GetApiKeys(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	keys, err := c.ApiKeyService.FindByUserId(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelsToApiKeyResponse(keys),
+	})
+}
+
+// RevokeApiKey godoc
+// @Summary Revoke an API key
+// @Description Revokes one of the API keys of the authenticated user. Requests made with it are rejected at once.
+// @Tags API Keys
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "API key ID"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 404 {object} commonerrors.ErrorMap "API key not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/api-keys/{id} [delete]
+func (c *ApiKeyController) RevokeApiKey(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	if err = c.ApiKeyService.Revoke(id, userId); err != nil {
+		ctx.JSON(apiKeyErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "api key revoked successfully",
+		},
+	})
+}
+
+// apiKeyErrorStatus maps an error returned by the ApiKeyService to an HTTP status code.
+func apiKeyErrorStatus(err error) int {
+	switch {
+	case err.Error() == commonerrors.ErrApiKeyNotFound, err.Error() == commonerrors.ErrServiceAccountNotFound:
+		return http.StatusNotFound
+	case err.Error() == commonerrors.ErrTooManyApiKeys, err.Error() == commonerrors.ErrUsernameAlreadyExists:
+		return http.StatusConflict
+	case err.Error() == commonerrors.ErrInternalServer:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
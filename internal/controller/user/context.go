@@ -0,0 +1,41 @@
+package usercontroller
+
+import (
+	"errors"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"time"
+)
+
+// contextUUID reads a UUID stored as a string in the gin context by the auth middlewares.
+//
+// Returns an internal server error if the key is missing or does not hold a valid UUID.
+func contextUUID(ctx *gin.Context, key string) (uuid.UUID, error) {
+	value, exists := ctx.Get(key)
+	if !exists {
+		return uuid.Nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	valueStr, ok := value.(string)
+	if !ok {
+		return uuid.Nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	id, err := uuid.Parse(valueStr)
+	if err != nil {
+		return uuid.Nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return id, nil
+}
+
+// contextTokenExpiry reads the expiry of the access token the request was made with.
+func contextTokenExpiry(ctx *gin.Context) time.Time {
+	value, exists := ctx.Get("tokenExpiresAt")
+	if !exists {
+		return time.Now()
+	}
+	expiresAt, ok := value.(time.Time)
+	if !ok {
+		return time.Now()
+	}
+	return expiresAt
+}
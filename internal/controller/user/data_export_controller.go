@@ -0,0 +1,129 @@
+package usercontroller
+
+import (
+	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	userservice "github.com/drunkleen/rasta/internal/service/user"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type DataExportController struct {
+	DataExportService *userservice.DataExportService
+	UserService       *userservice.UserService
+}
+
+// NewDataExportController creates a new instance of the DataExportController.
+//
+// dataExportService is the DataExportService instance to be used by the DataExportController.
+// userService is the UserService instance to be used by the DataExportController.
+// Returns a pointer to the newly created DataExportController instance.
+func NewDataExportController(dataExportService *userservice.DataExportService, userService *userservice.UserService) *DataExportController {
+	return &DataExportController{DataExportService: dataExportService, UserService: userService}
+}
+
+// RequestExport godoc
+// @Summary Export the data of the authenticated user
+// @Description Starts assembling a copy of the data held about the authenticated user: profile, tickets, comments, newsletter subscriptions, sessions, known devices, linked identities, passkeys, API keys, consents given to partner apps and email changes. The export is prepared in the background as a ZIP archive of JSON files, and can be downloaded for DATA_EXPORT_EXPIRY seconds once its status is ready.
+// @Tags Users
+// @Security BearerAuth
+// @Produce  json
+// @Success 202 {object} userDTO.GenericResponse{data=userDTO.DataExport}
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 409 {object} commonerrors.ErrorMap "An export is already being prepared"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/me/exports [post]
+func (c *DataExportController) RequestExport(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	user, err := c.UserService.FindById(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(commonerrors.ErrInternalServer))
+		return
+	}
+	export, err := c.DataExportService.Request(user)
+	if err != nil {
+		ctx.JSON(dataExportErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusAccepted, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelToDataExportResponse(export),
+	})
+}
+
+// GetExports godoc
+// @Summary List the data exports of the authenticated user
+// @Description Lists the data exports of the authenticated user, newest first, with their status.
+// @Tags Users
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse{data=[]userDTO.DataExport}
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/me/exports [get]
+func (c *DataExportController) GetExports(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	exports, err := c.DataExportService.FindByUserId(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelsToDataExportResponse(exports),
+	})
+}
+
+// DownloadExport godoc
+// @Summary Download a data export
+// @Description Downloads the ZIP archive of a data export of the authenticated user once it is ready.
+// @Tags Users
+// @Security BearerAuth
+// @Produce  application/zip
+// @Param id path string true "Data export ID"
+// @Success 200 {file} file "ZIP archive of JSON files"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 404 {object} commonerrors.ErrorMap "Data export not found"
+// @Failure 409 {object} commonerrors.ErrorMap "Data export not ready or expired"
+// @Router /users/me/exports/{id}/download [get]
+func (c *DataExportController) DownloadExport(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(commonerrors.ErrDataExportNotFound))
+		return
+	}
+	export, err := c.DataExportService.Download(id, userId)
+	if err != nil {
+		ctx.JSON(dataExportErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.Header("Content-Disposition", `attachment; filename="data-export-`+export.Id.String()+`.zip"`)
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Data(http.StatusOK, "application/zip", export.Archive)
+}
+
+// dataExportErrorStatus maps an error returned by the DataExportService to an HTTP status code.
+func dataExportErrorStatus(err error) int {
+	switch err.Error() {
+	case commonerrors.ErrDataExportNotFound:
+		return http.StatusNotFound
+	case commonerrors.ErrDataExportPending, commonerrors.ErrDataExportNotReady:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,197 @@
+package usercontroller
+
+import (
+	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	userservice "github.com/drunkleen/rasta/internal/service/user"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type EmailChangeController struct {
+	EmailChangeService *userservice.EmailChangeService
+	UserService        *userservice.UserService
+	OAuthService       *userservice.OAuthService
+	SessionService     *userservice.SessionService
+	LockoutService     *userservice.LockoutService
+}
+
+// NewEmailChangeController creates a new instance of the EmailChangeController.
+//
+// emailChangeService is the EmailChangeService instance to be used by the EmailChangeController.
+// userService is the UserService instance to be used by the EmailChangeController.
+// oauthService is the OAuthService instance to be used by the EmailChangeController.
+// sessionService is the SessionService instance to be used by the EmailChangeController.
+// lockoutService is the LockoutService instance to be used by the EmailChangeController.
+// Returns a pointer to the newly created EmailChangeController instance.
+func NewEmailChangeController(
+	emailChangeService *userservice.EmailChangeService,
+	userService *userservice.UserService,
+	oauthService *userservice.OAuthService,
+	sessionService *userservice.SessionService,
+	lockoutService *userservice.LockoutService,
+) *EmailChangeController {
+	return &EmailChangeController{
+		EmailChangeService: emailChangeService,
+		UserService:        userService,
+		OAuthService:       oauthService,
+		SessionService:     sessionService,
+		LockoutService:     lockoutService,
+	}
+}
+
+// RequestEmailChange godoc
+// @Summary Change the email address
+// @Description Starts changing the email address of the authenticated user. The current password is required, and the TOTP code or a recovery code when two-factor authentication is enabled. A code is sent to the new address, which completes the change, and a notice to the current address with a link cancelling it. The address only changes once the code has been verified.
+// @Tags Users
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param change body userDTO.EmailChangeRequest true "Email change payload"
+// @Success 202 {object} userDTO.GenericResponse{data=userDTO.EmailChange}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Invalid password, otp or recovery code"
+// @Failure 409 {object} commonerrors.ErrorMap "Email already exists"
+// @Failure 429 {object} commonerrors.ErrorMap "Too many failed attempts"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/me/email [post]
+func (c *EmailChangeController) RequestEmailChange(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	var reqBody userDTO.EmailChangeRequest
+	if err = ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	user, err := c.UserService.FindById(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(commonerrors.ErrInternalServer))
+		return
+	}
+	ipAddress := ctx.ClientIP()
+	if retryAfter, err := c.LockoutService.Check(user, ipAddress); err != nil {
+		respondTooManyAttempts(ctx, retryAfter, err)
+		return
+	}
+	if user.OAuth.Enabled {
+		if reqBody.RecoveryCode != "" {
+			err = c.OAuthService.UseRecoveryCode(user, reqBody.RecoveryCode)
+		} else {
+			err = c.OAuthService.OAuthValidate(user, reqBody.OTP)
+		}
+		if err != nil {
+			if err.Error() != commonerrors.ErrInternalServer {
+				c.LockoutService.RegisterFailure(user, ipAddress)
+			}
+			ctx.JSON(emailChangeErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+			return
+		}
+	}
+	change, err := c.EmailChangeService.Request(user, reqBody.Password, reqBody.NewEmail)
+	if err != nil {
+		if err.Error() == commonerrors.ErrInvalidCredentials {
+			c.LockoutService.RegisterFailure(user, ipAddress)
+		}
+		ctx.JSON(emailChangeErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusAccepted, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelToEmailChangeResponse(change),
+	})
+}
+
+// VerifyEmailChange godoc
+// @Summary Confirm the new email address
+// @Description Completes the pending email change of the authenticated user with the code sent to the new address. The old address can still cancel the change until cancelable_until.
+// @Tags Users
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param code body userDTO.EmailChangeVerify true "Code sent to the new address"
+// @Success 200 {object} userDTO.GenericResponse{data=userDTO.EmailChange}
+// @Failure 400 {object} commonerrors.ErrorMap "Invalid or expired code"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 409 {object} commonerrors.ErrorMap "Email already exists"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/me/email/verify [post]
+func (c *EmailChangeController) VerifyEmailChange(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	var reqBody userDTO.EmailChangeVerify
+	if err = ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	user, err := c.UserService.FindById(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(commonerrors.ErrInternalServer))
+		return
+	}
+	change, err := c.EmailChangeService.Verify(user, reqBody.Code)
+	if err != nil {
+		ctx.JSON(emailChangeErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelToEmailChangeResponse(change),
+	})
+}
+
+// CancelEmailChange godoc
+// @Summary Cancel an email change
+// @Description Cancels an email change with the token of the link sent to the old address, or reverts it if it has already been completed. Every session of the user is revoked, since the change may not have been made by the user.
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param token body userDTO.EmailChangeCancel true "Token of the cancellation link"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Invalid or expired link"
+// @Failure 409 {object} commonerrors.ErrorMap "The old address is now used by another account"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/email/cancel [post]
+func (c *EmailChangeController) CancelEmailChange(ctx *gin.Context) {
+	var reqBody userDTO.EmailChangeCancel
+	if err := ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	change, err := c.EmailChangeService.Cancel(reqBody.Token)
+	if err != nil {
+		ctx.JSON(emailChangeErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	if err = c.SessionService.RevokeAll(change.UserId); err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "email change canceled successfully",
+		},
+	})
+}
+
+// emailChangeErrorStatus maps an error returned while changing an email address to an HTTP status code.
+func emailChangeErrorStatus(err error) int {
+	switch err.Error() {
+	case commonerrors.ErrInvalidCredentials, commonerrors.ErrInvalidOtp, commonerrors.ErrInvalidRecoveryCode:
+		return http.StatusUnauthorized
+	case commonerrors.ErrEmailAlreadyExists:
+		return http.StatusConflict
+	case commonerrors.ErrInternalServer:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,118 @@
+package usercontroller
+
+import (
+	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	auditmodel "github.com/drunkleen/rasta/internal/models/audit"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	auditservice "github.com/drunkleen/rasta/internal/service/audit"
+	userservice "github.com/drunkleen/rasta/internal/service/user"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+type ImpersonationController struct {
+	SessionService *userservice.SessionService
+	UserService    *userservice.UserService
+	AuditService   *auditservice.AuditService
+}
+
+// NewImpersonationController creates a new instance of the ImpersonationController.
+//
+// sessionService is the SessionService instance to be used by the ImpersonationController.
+// userService is the UserService instance to be used by the ImpersonationController.
+// auditService is the AuditService instance to be used by the ImpersonationController.
+// Returns a pointer to the newly created ImpersonationController instance.
+func NewImpersonationController(sessionService *userservice.SessionService, userService *userservice.UserService, auditService *auditservice.AuditService) *ImpersonationController {
+	return &ImpersonationController{SessionService: sessionService, UserService: userService, AuditService: auditService}
+}
+
+// Impersonate godoc
+// @Summary Impersonate a user
+// @Description Issues the admin a token to act as a user, for instance to see what a buyer or seller sees. The token carries the admin in its act claim, expires after IMPERSONATION_EXPIRY seconds and cannot be refreshed. Responses to requests made with it carry the X-Impersonated-By header, and every request is recorded in the audit log together with the reason given here. The token cannot be used to change the password, second factors or other credentials of the user, nor on admin routes. Admins, service accounts and suspended users cannot be impersonated.
+// @Tags Users
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path string true "User ID"
+// @Param impersonation body userDTO.ImpersonationRequest true "Reason for the impersonation"
+// @Success 201 {object} userDTO.GenericResponse{data=userDTO.Impersonation}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden or the user cannot be impersonated"
+// @Failure 404 {object} commonerrors.ErrorMap "User not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/users/id/{id}/impersonate [post]
+func (c *ImpersonationController) Impersonate(ctx *gin.Context) {
+	actor, ok := ctx.Get("userModel")
+	actorModel, isUser := actor.(*usermodel.User)
+	if !ok || !isUser {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(commonerrors.ErrInternalServer))
+		return
+	}
+	userId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(commonerrors.ErrUserNotFound))
+		return
+	}
+	var reqBody userDTO.ImpersonationRequest
+	if err = ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	reason := strings.TrimSpace(reqBody.Reason)
+	if length := utf8.RuneCountInString(reason); length < 3 || length > 512 {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidReason))
+		return
+	}
+	user, err := c.UserService.FindById(userId)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(commonerrors.ErrUserNotFound))
+		return
+	}
+	session, accessToken, err := c.SessionService.Impersonate(actorModel, user, ctx.Request.UserAgent(), ctx.ClientIP())
+	if err != nil {
+		ctx.JSON(impersonationErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	err = c.AuditService.Record(&auditmodel.AuditLog{
+		Action:    auditmodel.ActionImpersonationStart,
+		ActorId:   &actorModel.Id,
+		UserId:    &user.Id,
+		SessionId: &session.Id,
+		Method:    ctx.Request.Method,
+		Path:      ctx.Request.URL.Path,
+		Status:    http.StatusCreated,
+		IpAddress: ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		Detail:    reason,
+	})
+	if err != nil {
+		// An impersonation that could not be audited must not be usable.
+		_ = c.SessionService.Revoke(session.Id)
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(commonerrors.ErrInternalServer))
+		return
+	}
+	ctx.JSON(http.StatusCreated, userDTO.GenericResponse{
+		Status: "success",
+		Data: userDTO.Impersonation{
+			AccessToken: accessToken,
+			SessionId:   session.Id,
+			UserId:      user.Id,
+			ExpiresAt:   session.ExpiresAt,
+		},
+	})
+}
+
+// impersonationErrorStatus maps an error returned by SessionService.Impersonate to an HTTP status code.
+func impersonationErrorStatus(err error) int {
+	switch err.Error() {
+	case commonerrors.ErrCannotImpersonate, commonerrors.ErrAccountSuspended:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
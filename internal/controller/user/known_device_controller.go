@@ -0,0 +1,162 @@
+package usercontroller
+
+import (
+	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	userservice "github.com/drunkleen/rasta/internal/service/user"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log"
+	"net/http"
+)
+
+type KnownDeviceController struct {
+	KnownDeviceService *userservice.KnownDeviceService
+	UserService        *userservice.UserService
+	SessionService     *userservice.SessionService
+	ResetPwdService    *userservice.ResetPwdService
+}
+
+// NewKnownDeviceController creates a new instance of the KnownDeviceController.
+//
+// knownDeviceService is the KnownDeviceService instance to be used by the KnownDeviceController.
+// userService is the UserService instance to be used by the KnownDeviceController.
+// sessionService is the SessionService instance to be used by the KnownDeviceController.
+// resetPwdService is the ResetPwdService instance to be used by the KnownDeviceController.
+// Returns a pointer to the newly created KnownDeviceController instance.
+func NewKnownDeviceController(
+	knownDeviceService *userservice.KnownDeviceService,
+	userService *userservice.UserService,
+	sessionService *userservice.SessionService,
+	resetPwdService *userservice.ResetPwdService,
+) *KnownDeviceController {
+	return &KnownDeviceController{
+		KnownDeviceService: knownDeviceService,
+		UserService:        userService,
+		SessionService:     sessionService,
+		ResetPwdService:    resetPwdService,
+	}
+}
+
+// GetDevices godoc
+// @Summary List known devices
+// @Description Lists the devices the authenticated user has signed in from with a password. Signing in from a device not in the list is notified by email.
+// @Tags Sessions
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse{data=[]userDTO.KnownDevice}
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/me/devices [get]
+func (c *KnownDeviceController) GetDevices(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	devices, err := c.KnownDeviceService.FindByUserId(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	respDevices := make([]userDTO.KnownDevice, len(devices))
+	for i, device := range devices {
+		respDevices[i] = *userDTO.FromModelToKnownDeviceResponse(&device)
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   respDevices,
+	})
+}
+
+// DeleteDevice godoc
+// @Summary Forget a known device
+// @Description Forgets one of the known devices of the authenticated user, so the next sign-in from it is notified again. Its sessions are not revoked.
+// @Tags Sessions
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "Device ID"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 404 {object} commonerrors.ErrorMap "Device not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/me/devices/{id} [delete]
+func (c *KnownDeviceController) DeleteDevice(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	deviceId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrDeviceNotFound))
+		return
+	}
+	if err = c.KnownDeviceService.Delete(userId, deviceId); err != nil {
+		if err.Error() == commonerrors.ErrDeviceNotFound {
+			ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "device forgotten successfully",
+		},
+	})
+}
+
+// ReportLogin godoc
+// @Summary Report a login that was not made by the user
+// @Description Reports the sign-in from a new device with the token of the "this wasn't me" link sent by email. Every session of the user is revoked, as are the passkeys, linked social accounts, API keys and partner app consents added since that sign-in, along with pending sign-in links and email changes. Signing in with the password is refused until it has been reset, and a password reset code is sent by email.
+// @Tags Sessions
+// @Accept  json
+// @Produce  json
+// @Param token body userDTO.LoginReport true "Token of the link"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Invalid, expired or already used link"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/login/report [post]
+func (c *KnownDeviceController) ReportLogin(ctx *gin.Context) {
+	var reqBody userDTO.LoginReport
+	if err := ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	device, err := c.KnownDeviceService.Report(reqBody.Token)
+	if err != nil {
+		if err.Error() == commonerrors.ErrInternalServer {
+			ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	if err = c.SessionService.RevokeAll(device.UserId); err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	user, err := c.UserService.FindById(device.UserId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(commonerrors.ErrInternalServer))
+		return
+	}
+	// The sessions are revoked and password sign-in is blocked already; the user can
+	// still request a reset code themselves if this one does not arrive.
+	if err = c.ResetPwdService.GenerateResetPwdAndSendEmail(user, user.Id); err != nil {
+		log.Printf("failed to send password reset after a login report: %v", err)
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "login reported, every session has been revoked and a password reset code has been sent",
+		},
+	})
+}
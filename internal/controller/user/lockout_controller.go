@@ -0,0 +1,105 @@
+package usercontroller
+
+import (
+	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	userservice "github.com/drunkleen/rasta/internal/service/user"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type LockoutController struct {
+	LockoutService *userservice.LockoutService
+	UserService    *userservice.UserService
+}
+
+// NewLockoutController creates a new instance of the LockoutController.
+//
+// It takes a pointer to the LockoutService and a pointer to the UserService as parameters to initialize the LockoutController.
+// It returns a pointer to the LockoutController.
+func NewLockoutController(lockoutService *userservice.LockoutService, userService *userservice.UserService) *LockoutController {
+	return &LockoutController{LockoutService: lockoutService, UserService: userService}
+}
+
+// GetLockedAccounts godoc
+// @Summary List locked accounts
+// @Description Lists the accounts currently locked after too many failed logins.
+// @Tags Users
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/users/locked [get]
+func (c *LockoutController) GetLockedAccounts(ctx *gin.Context) {
+	lockouts, err := c.LockoutService.FindLockedAccounts()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	respAccounts := make([]userDTO.LockedAccount, 0, len(lockouts))
+	for i := range lockouts {
+		userId, err := uuid.Parse(lockouts[i].Subject)
+		if err != nil {
+			continue
+		}
+		user, err := c.UserService.FindById(userId)
+		if err != nil {
+			continue
+		}
+		respAccounts = append(respAccounts, *userDTO.FromModelToLockedAccountResponse(user, &lockouts[i]))
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   respAccounts,
+	})
+}
+
+// UnlockAccount godoc
+// @Summary Unlock an account
+// @Description Clears the failed logins of an account, lifting its lockout.
+// @Tags Users
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "User ID"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 404 {object} commonerrors.ErrorMap "User not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/users/id/{id}/lockout [delete]
+func (c *LockoutController) UnlockAccount(ctx *gin.Context) {
+	userId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(commonerrors.ErrUserNotFound))
+		return
+	}
+	if _, err = c.UserService.FindById(userId); err != nil {
+		ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(commonerrors.ErrUserNotFound))
+		return
+	}
+	if err = c.LockoutService.Reset(userId); err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "account unlocked successfully",
+		},
+	})
+}
+
+// respondTooManyAttempts answers an attempt refused by the LockoutService with
+// 429 Too Many Requests and a Retry-After header.
+func respondTooManyAttempts(ctx *gin.Context, retryAfter time.Duration, err error) {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	ctx.JSON(http.StatusTooManyRequests, commonerrors.NewErrorMap(err.Error()))
+}
@@ -0,0 +1,180 @@
+package usercontroller
+
+import (
+	"github.com/drunkleen/rasta/config"
+	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	"github.com/drunkleen/rasta/internal/common/utils"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	userservice "github.com/drunkleen/rasta/internal/service/user"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type LoginLinkController struct {
+	LoginLinkService *userservice.LoginLinkService
+	UserService      *userservice.UserService
+	OAuthService     *userservice.OAuthService
+	SessionService   *userservice.SessionService
+	LockoutService   *userservice.LockoutService
+	WebAuthnService  *userservice.WebAuthnService
+	SmsService       *userservice.SmsService
+}
+
+// NewLoginLinkController creates a new instance of the LoginLinkController.
+//
+// loginLinkService is the LoginLinkService instance to be used by the LoginLinkController.
+// userService is the UserService instance to be used by the LoginLinkController.
+// oauthService is the OAuthService instance to be used by the LoginLinkController.
+// sessionService is the SessionService instance to be used by the LoginLinkController.
+// lockoutService is the LockoutService instance to be used by the LoginLinkController.
+// webAuthnService is the WebAuthnService instance to be used by the LoginLinkController.
+// smsService is the SmsService instance to be used by the LoginLinkController.
+// Returns a pointer to the newly created LoginLinkController instance.
+func NewLoginLinkController(
+	loginLinkService *userservice.LoginLinkService,
+	userService *userservice.UserService,
+	oauthService *userservice.OAuthService,
+	sessionService *userservice.SessionService,
+	lockoutService *userservice.LockoutService,
+	webAuthnService *userservice.WebAuthnService,
+	smsService *userservice.SmsService,
+) *LoginLinkController {
+	return &LoginLinkController{
+		LoginLinkService: loginLinkService,
+		UserService:      userService,
+		OAuthService:     oauthService,
+		SessionService:   sessionService,
+		LockoutService:   lockoutService,
+		WebAuthnService:  webAuthnService,
+		SmsService:       smsService,
+	}
+}
+
+// Send godoc
+// @Summary Send a sign-in link
+// @Description Sends a single-use sign-in link and code to the email address of a verified account, valid for EMAIL_OTP_EXPIRY seconds. The response is the same whether or not an account matches, and the returned ID is needed to sign in with the code. Only available when EMAIL_LOGIN_ENABLED is set.
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param email body userDTO.LoginLinkSend true "User email"
+// @Success 200 {object} userDTO.GenericResponse{data=userDTO.LoginLinkSent}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 403 {object} commonerrors.ErrorMap "Passwordless sign-in disabled"
+// @Failure 429 {object} commonerrors.ErrorMap "Too many failed attempts"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/login/email [post]
+func (c *LoginLinkController) Send(ctx *gin.Context) {
+	if !config.GetEmailLoginEnabled() {
+		ctx.JSON(http.StatusForbidden, commonerrors.NewErrorMap(commonerrors.ErrEmailLoginDisabled))
+		return
+	}
+	var reqBody userDTO.LoginLinkSend
+	if err := ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	email, ok := utils.NormalizeEmail(reqBody.Email)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	if retryAfter, err := c.LockoutService.Check(nil, ctx.ClientIP()); err != nil {
+		respondTooManyAttempts(ctx, retryAfter, err)
+		return
+	}
+	id := uuid.New()
+	user, err := c.UserService.FindByEmail(email)
+	if err == nil && user.IsVerified && !user.IsSuspended() {
+		id, err = c.LoginLinkService.GenerateAndSendEmail(&user)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+			return
+		}
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: userDTO.LoginLinkSent{
+			Message: "if an account matches, a sign-in link has been sent to your email",
+			Id:      id,
+		},
+	})
+}
+
+// Verify godoc
+// @Summary Sign in with a link or code
+// @Description Signs in with the token of a sign-in link, or with the ID returned by /users/login/email and the emailed code, and returns a JWT access token and a refresh token. The link is used up by the first attempt that matches it and passes the second factor, if any, so a mistyped TOTP code can be retried with the same link. Users with two-factor authentication enabled also send their TOTP code as otp or a recovery code as recovery_code; users with a registered passkey who send neither get a 401 with status passkey_required and a WebAuthn ceremony to complete at /users/webauthn/login/finish, and users with SMS two-factor authentication enabled get a 401 with status sms_code_required and a token to send to /users/login/sms along with the code texted to them.
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param login body userDTO.LoginLinkVerify true "Token, or ID and code"
+// @Success 202 {object} userDTO.LoginResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} userDTO.GenericResponse{data=userDTO.WebAuthnCeremony} "Invalid link or code, or passkey required"
+// @Failure 401 {object} userDTO.GenericResponse{data=userDTO.SmsChallenge} "SMS code required"
+// @Failure 403 {object} commonerrors.ErrorMap "Account suspended, or passwordless sign-in disabled"
+// @Failure 429 {object} commonerrors.ErrorMap "Too many failed attempts"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/login/email/verify [post]
+func (c *LoginLinkController) Verify(ctx *gin.Context) {
+	if !config.GetEmailLoginEnabled() {
+		ctx.JSON(http.StatusForbidden, commonerrors.NewErrorMap(commonerrors.ErrEmailLoginDisabled))
+		return
+	}
+	var reqBody userDTO.LoginLinkVerify
+	if err := ctx.ShouldBindJSON(&reqBody); err != nil || (reqBody.Token == "" && (reqBody.Id == uuid.Nil || reqBody.Code == "")) {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	ipAddress := ctx.ClientIP()
+	if retryAfter, err := c.LockoutService.Check(nil, ipAddress); err != nil {
+		respondTooManyAttempts(ctx, retryAfter, err)
+		return
+	}
+	var (
+		link *usermodel.LoginLink
+		user *usermodel.User
+		err  error
+	)
+	if reqBody.Token != "" {
+		link, user, err = c.LoginLinkService.VerifyToken(reqBody.Token)
+	} else {
+		link, user, err = c.LoginLinkService.VerifyCode(reqBody.Id, reqBody.Code)
+	}
+	if err != nil {
+		c.LockoutService.RegisterFailure(nil, ipAddress)
+		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	if retryAfter, err := c.LockoutService.Check(user, ipAddress); err != nil {
+		respondTooManyAttempts(ctx, retryAfter, err)
+		return
+	}
+	if user.IsSuspended() {
+		ctx.JSON(http.StatusForbidden, commonerrors.NewErrorMap(commonerrors.ErrAccountSuspended))
+		return
+	}
+	// The link is only used up once the second factor passed, so that a mistyped code can be retried.
+	if !verifySecondFactor(ctx, c.OAuthService, c.WebAuthnService, c.SmsService, c.LockoutService, user, reqBody.OTP, reqBody.RecoveryCode) {
+		return
+	}
+	if err = c.LoginLinkService.Use(link); err != nil {
+		if err.Error() == commonerrors.ErrInternalServer {
+			ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	if err = c.LockoutService.Reset(user.Id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	jwtToken, refreshToken, err := c.SessionService.Create(user, ctx.Request.UserAgent(), ipAddress)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusAccepted, userDTO.FromModelToUserLoginResponse(user, jwtToken, refreshToken))
+}
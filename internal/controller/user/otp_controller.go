@@ -1,19 +1,23 @@
 package usercontroller
 
 import (
+	"errors"
 	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
+	"github.com/drunkleen/rasta/internal/common/auth"
 	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
 	"github.com/drunkleen/rasta/internal/common/utils"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
 	"github.com/drunkleen/rasta/internal/service/user"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"net/http"
-	"time"
 )
 
 type OtpController struct {
-	OtpService  *userservice.OtpService
-	UserService *userservice.UserService
+	OtpService     *userservice.OtpService
+	UserService    *userservice.UserService
+	LockoutService *userservice.LockoutService
+	SmsService     *userservice.SmsService
 }
 
 // NewOtpController returns a new instance of the OtpController struct.
@@ -21,23 +25,36 @@ type OtpController struct {
 // Parameters:
 // - otpService: a pointer to the userservice.OtpService object.
 // - userService: a pointer to the userservice.UserService object.
+// - lockoutService: a pointer to the userservice.LockoutService object.
+// - smsService: a pointer to the userservice.SmsService object.
 //
 // Returns a pointer to the OtpController struct.
-func NewOtpController(otpService *userservice.OtpService, userService *userservice.UserService) *OtpController {
-	return &OtpController{OtpService: otpService, UserService: userService}
+func NewOtpController(
+	otpService *userservice.OtpService,
+	userService *userservice.UserService,
+	lockoutService *userservice.LockoutService,
+	smsService *userservice.SmsService,
+) *OtpController {
+	return &OtpController{
+		OtpService:     otpService,
+		UserService:    userService,
+		LockoutService: lockoutService,
+		SmsService:     smsService,
+	}
 }
 
 // VerifyEmail godoc
 // @Summary Verify Email with OTP
-// @Description Verifies the user's email using the provided OTP. If successful, marks the email as verified and deletes the OTP.
+// @Description Verifies the user's email using the provided OTP. If successful, marks the email as verified and deletes the OTP. The OTP is invalidated after too many wrong guesses. With channel set to sms, the code texted by /users/otp/resend is verified instead, which verifies the phone number of the user as well.
 // @Tags OTP
 // @Accept  json
 // @Produce  json
 // @Param id path string true "User ID"
-// @Param otp body map[string]string true "OTP code"
+// @Param otp body map[string]string true "OTP code, and channel: email (default) or sms"
 // @Success 200 {object} userDTO.GenericResponse "Email verified successfully"
 // @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
 // @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 429 {object} commonerrors.ErrorMap "Too many failed attempts"
 // @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
 // @Router /users/otp/{id}/verify [post]
 func (c *OtpController) VerifyEmail(ctx *gin.Context) {
@@ -46,19 +63,54 @@ func (c *OtpController) VerifyEmail(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
 		return
 	}
+	channel, err := otpChannel(reqBody["channel"])
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	codeLength := 8
+	if channel == "sms" {
+		codeLength = auth.SmsCodeLength
+	}
 	otp, otpExists := reqBody["otp"]
-	if !otpExists || otp == "" || len(otp) != 8 {
+	if !otpExists || otp == "" || len(otp) != codeLength {
 		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
 		return
 	}
+	ipAddress := ctx.ClientIP()
+	if retryAfter, err := c.LockoutService.Check(nil, ipAddress); err != nil {
+		respondTooManyAttempts(ctx, retryAfter, err)
+		return
+	}
 	userId := uuid.MustParse(ctx.Param("id"))
 	user, err := c.OtpService.FindByUserIdIncludingOtp(&userId)
 	if err != nil || user.IsVerified {
 		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidUserId))
 		return
 	}
-	if !utils.CompareHashWithString(otp, user.OtpEmail.Code) || time.Now().After(user.OtpEmail.Expiry) {
-		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap("invalid or expired otp"))
+	if channel == "sms" {
+		if err = c.SmsService.VerifyAccount(user, otp); err != nil {
+			if err.Error() == commonerrors.ErrInternalServer {
+				ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+				return
+			}
+			c.LockoutService.RegisterFailure(nil, ipAddress)
+			ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+			Status: "success",
+			Data: struct {
+				Message string `json:"message"`
+			}{
+				Message: "Account verified successfully",
+			},
+		})
+		return
+	}
+	if err = c.OtpService.Verify(user, otp); err != nil {
+		c.LockoutService.RegisterFailure(nil, ipAddress)
+		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap(err.Error()))
 		return
 	}
 	err = c.UserService.MarkEmailAsVerified(userId)
@@ -84,13 +136,14 @@ func (c *OtpController) VerifyEmail(ctx *gin.Context) {
 
 // ResendOtp godoc
 // @Summary Resend OTP to Email
-// @Description Resends the OTP to the user's email for verification purposes.
+// @Description Resends the OTP to the user's email for verification purposes. With channel set to sms, a code is texted to the phone number given at sign-up instead; another one cannot be sent before SMS_RESEND_INTERVAL seconds have passed.
 // @Tags OTP
 // @Accept  json
 // @Produce  json
-// @Param email body map[string]string true "User email"
+// @Param email body map[string]string true "User email, and channel: email (default) or sms"
 // @Success 200 {object} userDTO.GenericResponse "OTP sent successfully"
 // @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 429 {object} commonerrors.ErrorMap "Code sent too recently"
 // @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
 // @Router /users/otp/resend [post]
 func (c *OtpController) ResendOtp(ctx *gin.Context) {
@@ -99,11 +152,17 @@ func (c *OtpController) ResendOtp(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
 		return
 	}
-	email := reqBody["email"]
-	if !utils.EmailValidate(&email) {
+	// Addresses refused at signup may still belong to existing accounts, so they are only normalized.
+	email, ok := utils.NormalizeEmail(reqBody["email"])
+	if !ok {
 		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
 		return
 	}
+	channel, err := otpChannel(reqBody["channel"])
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
 	user, err := c.OtpService.FindByUserEmailIncludingOtp(&email)
 	if err != nil || user.IsVerified {
 		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidUserId))
@@ -113,6 +172,23 @@ func (c *OtpController) ResendOtp(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap("user already verified"))
 		return
 	}
+	if channel == "sms" {
+		if err = c.SmsService.SendCode(user, usermodel.SmsPurposeVerify); err != nil {
+			ctx.JSON(smsErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+			Status: "success",
+			Data: struct {
+				Message string    `json:"message"`
+				Id      uuid.UUID `json:"id"`
+			}{
+				Message: "otp sent successfully to your phone",
+				Id:      user.Id,
+			},
+		})
+		return
+	}
 	if err = c.OtpService.GenerateOtpAndSendEmail(user, user.Id); err != nil {
 		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap("failed to generate otp"))
 		return
@@ -128,3 +204,15 @@ func (c *OtpController) ResendOtp(ctx *gin.Context) {
 		},
 	})
 }
+
+// otpChannel validates the channel a one-time code is sent through, email by default.
+func otpChannel(channel string) (string, error) {
+	switch channel {
+	case "", "email":
+		return "email", nil
+	case "sms":
+		return "sms", nil
+	default:
+		return "", errors.New(commonerrors.ErrInvalidChannel)
+	}
+}
@@ -2,31 +2,48 @@ package usercontroller
 
 import (
 	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
+	"github.com/drunkleen/rasta/internal/common/auth"
 	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
 	"github.com/drunkleen/rasta/internal/common/utils"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
 	userservice "github.com/drunkleen/rasta/internal/service/user"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"net/http"
-	"time"
 )
 
 type ResetPwdController struct {
 	ResetPwdService *userservice.ResetPwdService
 	UserService     *userservice.UserService
+	SessionService  *userservice.SessionService
+	LockoutService  *userservice.LockoutService
+	SmsService      *userservice.SmsService
 }
 
 // NewResetPwdController returns a new instance of ResetPwdController.
 //
-// It takes two parameters: resetPwdService and userService, both pointers to services used for password reset and user management respectively.
+// It takes five parameters: resetPwdService, userService, sessionService, lockoutService and smsService, pointers to
+// services used for password reset, user management, session management, brute-force protection and SMS codes respectively.
 // Returns a pointer to a ResetPwdController.
-func NewResetPwdController(resetPwdService *userservice.ResetPwdService, userService *userservice.UserService) *ResetPwdController {
-	return &ResetPwdController{ResetPwdService: resetPwdService, UserService: userService}
+func NewResetPwdController(
+	resetPwdService *userservice.ResetPwdService,
+	userService *userservice.UserService,
+	sessionService *userservice.SessionService,
+	lockoutService *userservice.LockoutService,
+	smsService *userservice.SmsService,
+) *ResetPwdController {
+	return &ResetPwdController{
+		ResetPwdService: resetPwdService,
+		UserService:     userService,
+		SessionService:  sessionService,
+		LockoutService:  lockoutService,
+		SmsService:      smsService,
+	}
 }
 
 // VerifyAndResetPassword godoc
 // @Summary Verify OTP and Reset Password
-// @Description Verifies the provided OTP and, if valid, allows the user to reset their password.
+// @Description Verifies the provided OTP and, if valid, allows the user to reset their password. Every session of the user is revoked afterwards. A password that breaks the password policy or is one of the most recent passwords of the user is refused with the rules it breaks in errors. Set channel to sms to reset it with a code texted by /users/reset-password instead.
 // @Tags Password Reset
 // @Accept  json
 // @Produce  json
@@ -36,6 +53,7 @@ func NewResetPwdController(resetPwdService *userservice.ResetPwdService, userSer
 // @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
 // @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
 // @Failure 406 {object} commonerrors.ErrorMap "Not Acceptable"
+// @Failure 429 {object} commonerrors.ErrorMap "Too many failed attempts"
 // @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
 // @Router /users/reset-password/{id}/verify [post]
 func (c *ResetPwdController) VerifyAndResetPassword(ctx *gin.Context) {
@@ -46,9 +64,19 @@ func (c *ResetPwdController) VerifyAndResetPassword(ctx *gin.Context) {
 	}
 	if err := ResetPassword.Validate(); err != nil {
 		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrPasswordsNotMatch))
+		return
+	}
+	if respondPasswordRejected(ctx, "new_password1", auth.CheckPassword(ResetPassword.NewPassword1)) {
+		return
 	}
-	if !utils.PasswordValid(ResetPassword.NewPassword1) {
-		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrPasswordTooWeak))
+	ipAddress := ctx.ClientIP()
+	if retryAfter, err := c.LockoutService.Check(nil, ipAddress); err != nil {
+		respondTooManyAttempts(ctx, retryAfter, err)
+		return
+	}
+	channel, err := otpChannel(ResetPassword.Channel)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(err.Error()))
 		return
 	}
 	userId := uuid.MustParse(ctx.Param("id"))
@@ -57,16 +85,34 @@ func (c *ResetPwdController) VerifyAndResetPassword(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidUserId))
 		return
 	}
-	if !utils.CompareHashWithString(ResetPassword.Otp, user.ResetPwd.Code) || time.Now().After(user.ResetPwd.Expiry) {
-		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap("invalid or expired otp"))
+	if channel == "sms" {
+		err = c.SmsService.Verify(user, usermodel.SmsPurposeResetPassword, ResetPassword.Otp)
+	} else {
+		err = c.ResetPwdService.Verify(user, ResetPassword.Otp)
+	}
+	if err != nil {
+		c.LockoutService.RegisterFailure(nil, ipAddress)
+		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap(err.Error()))
 		return
 	}
 	err = c.UserService.ResetPassword(userId, ResetPassword.NewPassword1)
+	if respondPasswordRejected(ctx, "new_password1", err) {
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusNotAcceptable, commonerrors.NewErrorMap(err.Error()))
 		return
 	}
-	err = c.ResetPwdService.Delete(userId)
+	if channel == "sms" {
+		err = c.SmsService.Delete(userId, usermodel.SmsPurposeResetPassword)
+	} else {
+		err = c.ResetPwdService.Delete(userId)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	err = c.SessionService.RevokeAll(userId)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
 		return
@@ -83,13 +129,14 @@ func (c *ResetPwdController) VerifyAndResetPassword(ctx *gin.Context) {
 
 // Send godoc
 // @Summary Send Password Reset Code
-// @Description Generates a password reset code and sends it to the user's email.
+// @Description Generates a password reset code and sends it to the user's email. With channel set to sms, the code is texted to the verified phone number of the user instead; another one cannot be sent before SMS_RESEND_INTERVAL seconds have passed.
 // @Tags Password Reset
 // @Accept  json
 // @Produce  json
-// @Param email body map[string]string true "User email"
+// @Param email body map[string]string true "User email, and channel: email (default) or sms"
 // @Success 200 {object} userDTO.GenericResponse "Password reset code sent successfully"
-// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request, or no verified phone number"
+// @Failure 429 {object} commonerrors.ErrorMap "Code sent too recently"
 // @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
 // @Router /users/reset-password [get]
 func (c *ResetPwdController) Send(ctx *gin.Context) {
@@ -98,16 +145,39 @@ func (c *ResetPwdController) Send(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
 		return
 	}
-	userEmail := reqBody["email"]
-	if !utils.EmailValidate(&userEmail) {
+	// Addresses refused at signup may still belong to existing accounts, so they are only normalized.
+	userEmail, ok := utils.NormalizeEmail(reqBody["email"])
+	if !ok {
 		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
 		return
 	}
+	channel, err := otpChannel(reqBody["channel"])
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
 	user, err := c.ResetPwdService.FindByUserEmailIncludingResetPwd(&userEmail)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
 		return
 	}
+	if channel == "sms" {
+		if err = c.SmsService.SendCode(user, usermodel.SmsPurposeResetPassword); err != nil {
+			ctx.JSON(smsErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+			Status: "success",
+			Data: struct {
+				Message string    `json:"message"`
+				Id      uuid.UUID `json:"id"`
+			}{
+				Message: "password reset code sent successfully to your phone",
+				Id:      user.Id,
+			},
+		})
+		return
+	}
 	if err = c.ResetPwdService.GenerateResetPwdAndSendEmail(user, user.Id); err != nil {
 		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap("Failed to generate password reset code"))
 		return
@@ -0,0 +1,345 @@
+package usercontroller
+
+import (
+	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	userservice "github.com/drunkleen/rasta/internal/service/user"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"strings"
+)
+
+type RoleController struct {
+	RoleService *userservice.RoleService
+	UserService *userservice.UserService
+}
+
+// NewRoleController creates a new instance of the RoleController.
+//
+// It takes a pointer to the RoleService and a pointer to the UserService as parameters to initialize the RoleController.
+// It returns a pointer to the RoleController.
+func NewRoleController(roleService *userservice.RoleService, userService *userservice.UserService) *RoleController {
+	return &RoleController{RoleService: roleService, UserService: userService}
+}
+
+// GetRoles godoc
+// @Summary List roles
+// @Description Lists every role together with the permissions it grants.
+// @Tags Roles
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/roles [get]
+func (c *RoleController) GetRoles(ctx *gin.Context) {
+	roles, err := c.RoleService.FindAll()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelsToRoleResponse(roles),
+	})
+}
+
+// GetPermissions godoc
+// @Summary List permissions
+// @Description Lists every permission that can be granted through a role.
+// @Tags Roles
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Router /admin/roles/permissions [get]
+func (c *RoleController) GetPermissions(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   usermodel.Permissions,
+	})
+}
+
+// CreateRole godoc
+// @Summary Create a role
+// @Description Creates a role granting the given permissions. Only permissions held by the caller, and granted to the API key the request is made with if any, may be granted.
+// @Tags Roles
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param role body userDTO.RoleRequest true "Role payload"
+// @Success 201 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden or a permission not held by the caller"
+// @Failure 409 {object} commonerrors.ErrorMap "Role already exists"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/roles [post]
+func (c *RoleController) CreateRole(ctx *gin.Context) {
+	var reqBody userDTO.RoleRequest
+	if err := ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	actor, apiKey, ok := actingUser(ctx)
+	if !ok {
+		return
+	}
+	role, err := c.RoleService.Create(actor, apiKey, reqBody.Name, reqBody.Description, reqBody.Permissions)
+	if err != nil {
+		ctx.JSON(roleErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusCreated, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelToRoleResponse(role),
+	})
+}
+
+// UpdateRole godoc
+// @Summary Update a role
+// @Description Updates the name and description of a role and replaces the permissions it grants. The caller must hold every permission the role grants, before and after the update, and so must the API key the request is made with if any.
+// @Tags Roles
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Role ID"
+// @Param role body userDTO.RoleRequest true "Role payload"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden or a permission not held by the caller"
+// @Failure 404 {object} commonerrors.ErrorMap "Role not found"
+// @Failure 409 {object} commonerrors.ErrorMap "Role already exists"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/roles/{id} [put]
+func (c *RoleController) UpdateRole(ctx *gin.Context) {
+	roleId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(commonerrors.ErrRoleNotFound))
+		return
+	}
+	var reqBody userDTO.RoleRequest
+	if err = ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	actor, apiKey, ok := actingUser(ctx)
+	if !ok {
+		return
+	}
+	role, err := c.RoleService.Update(actor, apiKey, roleId, reqBody.Name, reqBody.Description, reqBody.Permissions)
+	if err != nil {
+		ctx.JSON(roleErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelToRoleResponse(role),
+	})
+}
+
+// DeleteRole godoc
+// @Summary Delete a role
+// @Description Deletes a role and removes it from every user it is assigned to. The caller must hold every permission of the role, and so must the API key the request is made with if any.
+// @Tags Roles
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "Role ID"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden or a permission not held by the caller"
+// @Failure 404 {object} commonerrors.ErrorMap "Role not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/roles/{id} [delete]
+func (c *RoleController) DeleteRole(ctx *gin.Context) {
+	roleId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(commonerrors.ErrRoleNotFound))
+		return
+	}
+	actor, apiKey, ok := actingUser(ctx)
+	if !ok {
+		return
+	}
+	if err = c.RoleService.Delete(actor, apiKey, roleId); err != nil {
+		ctx.JSON(roleErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "role deleted successfully",
+		},
+	})
+}
+
+// GetUserRoles godoc
+// @Summary List the roles of a user
+// @Description Lists the roles assigned to a user.
+// @Tags Roles
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "User ID"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 404 {object} commonerrors.ErrorMap "User not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/users/id/{id}/roles [get]
+func (c *RoleController) GetUserRoles(ctx *gin.Context) {
+	userId, ok := c.findUserId(ctx)
+	if !ok {
+		return
+	}
+	roles, err := c.RoleService.FindByUserId(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelsToRoleResponse(roles),
+	})
+}
+
+// AssignUserRole godoc
+// @Summary Assign a role to a user
+// @Description Assigns a role to a user, granting the user every permission of the role. The caller must hold every permission of the role, and so must the API key the request is made with if any.
+// @Tags Roles
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path string true "User ID"
+// @Param role body userDTO.AssignRoleRequest true "Role assignment payload"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden or a permission not held by the caller"
+// @Failure 404 {object} commonerrors.ErrorMap "User or role not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/users/id/{id}/roles [post]
+func (c *RoleController) AssignUserRole(ctx *gin.Context) {
+	userId, ok := c.findUserId(ctx)
+	if !ok {
+		return
+	}
+	var reqBody userDTO.AssignRoleRequest
+	if err := ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	actor, apiKey, ok := actingUser(ctx)
+	if !ok {
+		return
+	}
+	if err := c.RoleService.AssignToUser(actor, apiKey, userId, reqBody.RoleId); err != nil {
+		ctx.JSON(roleErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "role assigned successfully",
+		},
+	})
+}
+
+// RemoveUserRole godoc
+// @Summary Remove a role from a user
+// @Description Removes a role from a user, revoking the permissions granted through it. The caller must hold every permission of the role, and so must the API key the request is made with if any.
+// @Tags Roles
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "User ID"
+// @Param roleId path string true "Role ID"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden or a permission not held by the caller"
+// @Failure 404 {object} commonerrors.ErrorMap "User or role not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/users/id/{id}/roles/{roleId} [delete]
+func (c *RoleController) RemoveUserRole(ctx *gin.Context) {
+	userId, ok := c.findUserId(ctx)
+	if !ok {
+		return
+	}
+	roleId, err := uuid.Parse(ctx.Param("roleId"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(commonerrors.ErrRoleNotFound))
+		return
+	}
+	actor, apiKey, ok := actingUser(ctx)
+	if !ok {
+		return
+	}
+	if err = c.RoleService.RemoveFromUser(actor, apiKey, userId, roleId); err != nil {
+		ctx.JSON(roleErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "role removed successfully",
+		},
+	})
+}
+
+// findUserId parses the user ID path parameter and checks that the user exists.
+//
+// If the user cannot be found, a 404 response is written and false is returned.
+func (c *RoleController) findUserId(ctx *gin.Context) (uuid.UUID, bool) {
+	userId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(commonerrors.ErrUserNotFound))
+		return uuid.Nil, false
+	}
+	if _, err = c.UserService.FindById(userId); err != nil {
+		ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(commonerrors.ErrUserNotFound))
+		return uuid.Nil, false
+	}
+	return userId, true
+}
+
+// actingUser returns the user making the request, and the API key it was made with if any, as set
+// by RequirePermission.
+//
+// If the user is missing from the context, a 500 response is written and false is returned.
+func actingUser(ctx *gin.Context) (*usermodel.User, *usermodel.ApiKey, bool) {
+	value, _ := ctx.Get("userModel")
+	actor, ok := value.(*usermodel.User)
+	if !ok {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(commonerrors.ErrInternalServer))
+		return nil, nil, false
+	}
+	value, _ = ctx.Get("apiKey")
+	apiKey, _ := value.(*usermodel.ApiKey)
+	return actor, apiKey, true
+}
+
+// roleErrorStatus maps an error returned by the RoleService to an HTTP status code.
+func roleErrorStatus(err error) int {
+	switch {
+	case err.Error() == commonerrors.ErrRoleNotFound:
+		return http.StatusNotFound
+	case err.Error() == commonerrors.ErrRoleAlreadyExists:
+		return http.StatusConflict
+	case err.Error() == commonerrors.ErrPermissionNotHeld:
+		return http.StatusForbidden
+	case err.Error() == commonerrors.ErrInvalidRoleName,
+		strings.HasPrefix(err.Error(), commonerrors.ErrInvalidPermission):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,236 @@
+package usercontroller
+
+import (
+	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	userservice "github.com/drunkleen/rasta/internal/service/user"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type ServiceAccountController struct {
+	ApiKeyService *userservice.ApiKeyService
+}
+
+// NewServiceAccountController creates a new instance of the ServiceAccountController.
+//
+// It takes a pointer to the ApiKeyService as a parameter and returns a pointer to the ServiceAccountController.
+func NewServiceAccountController(apiKeyService *userservice.ApiKeyService) *ServiceAccountController {
+	return &ServiceAccountController{ApiKeyService: apiKeyService}
+}
+
+// GetServiceAccounts godoc
+// @Summary List service accounts
+// @Description Lists every service account.
+// @Tags Service Accounts
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse{data=[]userDTO.User}
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/service-accounts [get]
+func (c *ServiceAccountController) GetServiceAccounts(ctx *gin.Context) {
+	users, err := c.ApiKeyService.FindServiceAccounts()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	respUsers := make([]userDTO.User, len(users))
+	for i := range users {
+		respUsers[i] = *userDTO.FromModelToUserResponseForAdmins(&users[i])
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   respUsers,
+	})
+}
+
+// CreateServiceAccount godoc
+// @Summary Create a service account
+// @Description Creates a non-human account for an integration. It cannot sign in and authenticates with the API keys issued to it; its permissions are granted by assigning it roles.
+// @Tags Service Accounts
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param account body userDTO.ServiceAccountCreate true "Service account payload"
+// @Success 201 {object} userDTO.GenericResponse{data=userDTO.User}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 409 {object} commonerrors.ErrorMap "Username already exists"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/service-accounts [post]
+func (c *ServiceAccountController) CreateServiceAccount(ctx *gin.Context) {
+	var reqBody userDTO.ServiceAccountCreate
+	if err := ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	user, err := c.ApiKeyService.CreateServiceAccount(reqBody.Username, reqBody.Name, reqBody.Region)
+	if err != nil {
+		ctx.JSON(apiKeyErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusCreated, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelToUserResponseForAdmins(user),
+	})
+}
+
+// DeleteServiceAccount godoc
+// @Summary Delete a service account
+// @Description Deletes a service account and revokes every API key issued to it.
+// @Tags Service Accounts
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "Service account ID"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 404 {object} commonerrors.ErrorMap "Service account not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/service-accounts/{id} [delete]
+func (c *ServiceAccountController) DeleteServiceAccount(ctx *gin.Context) {
+	id, ok := c.findServiceAccountId(ctx)
+	if !ok {
+		return
+	}
+	if err := c.ApiKeyService.DeleteServiceAccount(id); err != nil {
+		ctx.JSON(apiKeyErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "service account deleted successfully",
+		},
+	})
+}
+
+// GetApiKeys godoc
+// @Summary List the API keys of a service account
+// @Description Lists the API keys issued to a service account, with when and from where each was last used.
+// @Tags Service Accounts
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "Service account ID"
+// @Success 200 {object} userDTO.GenericResponse{data=[]userDTO.ApiKey}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 404 {object} commonerrors.ErrorMap "Service account not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/service-accounts/{id}/api-keys [get]
+func (c *ServiceAccountController) GetApiKeys(ctx *gin.Context) {
+	id, ok := c.findServiceAccountId(ctx)
+	if !ok {
+		return
+	}
+	keys, err := c.ApiKeyService.FindByUserId(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelsToApiKeyResponse(keys),
+	})
+}
+
+// CreateApiKey godoc
+// @Summary Issue an API key to a service account
+// @Description Issues an API key to a service account. The key is only returned in this response. It grants the permissions in scopes as far as the roles of the service account hold them.
+// @Tags Service Accounts
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Service account ID"
+// @Param key body userDTO.ApiKeyCreate true "API key payload"
+// @Success 201 {object} userDTO.GenericResponse{data=userDTO.ApiKeyCreated}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 404 {object} commonerrors.ErrorMap "Service account not found"
+// @Failure 409 {object} commonerrors.ErrorMap "Too many API keys"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/service-accounts/{id}/api-keys [post]
+func (c *ServiceAccountController) CreateApiKey(ctx *gin.Context) {
+	id, ok := c.findServiceAccountId(ctx)
+	if !ok {
+		return
+	}
+	var reqBody userDTO.ApiKeyCreate
+	if err := ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	key, secret, err := c.ApiKeyService.Create(id, reqBody.Name, reqBody.Scopes, reqBody.RateLimit, reqBody.ExpiresInDays)
+	if err != nil {
+		ctx.JSON(apiKeyErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusCreated, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.ApiKeyCreated{ApiKey: *userDTO.FromModelToApiKeyResponse(key), Key: secret},
+	})
+}
+
+// RevokeApiKey godoc
+// @Summary Revoke an API key of a service account
+// @Description Revokes an API key issued to a service account. Requests made with it are rejected at once.
+// @Tags Service Accounts
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "Service account ID"
+// @Param keyId path string true "API key ID"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 404 {object} commonerrors.ErrorMap "Service account or API key not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/service-accounts/{id}/api-keys/{keyId} [delete]
+func (c *ServiceAccountController) RevokeApiKey(ctx *gin.Context) {
+	id, ok := c.findServiceAccountId(ctx)
+	if !ok {
+		return
+	}
+	keyId, err := uuid.Parse(ctx.Param("keyId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	if err = c.ApiKeyService.Revoke(keyId, id); err != nil {
+		ctx.JSON(apiKeyErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "api key revoked successfully",
+		},
+	})
+}
+
+// findServiceAccountId parses the service account ID path parameter and checks that the account exists.
+//
+// If the account cannot be found, a 404 response is written and false is returned.
+func (c *ServiceAccountController) findServiceAccountId(ctx *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(commonerrors.ErrServiceAccountNotFound))
+		return uuid.Nil, false
+	}
+	if _, err = c.ApiKeyService.FindServiceAccountById(id); err != nil {
+		ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(commonerrors.ErrServiceAccountNotFound))
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,196 @@
+package usercontroller
+
+import (
+	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	userservice "github.com/drunkleen/rasta/internal/service/user"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type SessionController struct {
+	SessionService *userservice.SessionService
+}
+
+// NewSessionController creates a new instance of the SessionController.
+//
+// It takes a pointer to the SessionService as a parameter to initialize the SessionController.
+// It returns a pointer to the SessionController.
+func NewSessionController(sessionService *userservice.SessionService) *SessionController {
+	return &SessionController{SessionService: sessionService}
+}
+
+// RefreshToken godoc
+// @Summary Refresh access token
+// @Description Exchanges a refresh token for a new access token and a new refresh token. Each refresh token can be used only once; reusing one revokes the whole session.
+// @Tags Sessions
+// @Accept  json
+// @Produce  json
+// @Param refreshToken body userDTO.RefreshTokenRequest true "Refresh token payload"
+// @Success 200 {object} userDTO.LoginResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Account suspended"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/token/refresh [post]
+func (c *SessionController) RefreshToken(ctx *gin.Context) {
+	var reqBody userDTO.RefreshTokenRequest
+	if err := ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	user, jwtToken, refreshToken, err := c.SessionService.Refresh(reqBody.RefreshToken, ctx.Request.UserAgent(), ctx.ClientIP())
+	if err != nil {
+		if err.Error() == commonerrors.ErrInternalServer {
+			ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+			return
+		}
+		if err.Error() == commonerrors.ErrAccountSuspended {
+			ctx.JSON(http.StatusForbidden, commonerrors.NewErrorMap(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.FromModelToUserLoginResponse(user, jwtToken, refreshToken))
+}
+
+// GetSessions godoc
+// @Summary List active sessions
+// @Description Lists the active sessions of the authenticated user, with device, IP address, user agent and last activity.
+// @Tags Sessions
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/sessions [get]
+func (c *SessionController) GetSessions(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	currentSessionId, err := contextUUID(ctx, "sessionId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	sessions, err := c.SessionService.FindActiveByUserId(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	respSessions := make([]userDTO.Session, len(sessions))
+	for i, session := range sessions {
+		respSessions[i] = *userDTO.FromModelToSessionResponse(&session, currentSessionId)
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   respSessions,
+	})
+}
+
+// RevokeSession godoc
+// @Summary Revoke a session
+// @Description Revokes one of the authenticated user's sessions, logging that device out.
+// @Tags Sessions
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "Session ID"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 404 {object} commonerrors.ErrorMap "Session not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/sessions/{id} [delete]
+func (c *SessionController) RevokeSession(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	sessionId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrSessionNotFound))
+		return
+	}
+	if err = c.SessionService.RevokeForUser(sessionId, userId); err != nil {
+		if err.Error() == commonerrors.ErrSessionNotFound {
+			ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "session revoked successfully",
+		},
+	})
+}
+
+// RevokeAllSessions godoc
+// @Summary Log out everywhere
+// @Description Revokes every session of the authenticated user, including the current one.
+// @Tags Sessions
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/sessions [delete]
+func (c *SessionController) RevokeAllSessions(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	if err = c.SessionService.RevokeAll(userId); err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "logged out from every session",
+		},
+	})
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revokes the access token the request was made with and the session it belongs to.
+// @Tags Sessions
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/logout [post]
+func (c *SessionController) Logout(ctx *gin.Context) {
+	sessionId, err := contextUUID(ctx, "sessionId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	tokenId := ctx.GetString("tokenId")
+	if err = c.SessionService.Logout(sessionId, tokenId, contextTokenExpiry(ctx)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "logged out successfully",
+		},
+	})
+}
@@ -0,0 +1,272 @@
+package usercontroller
+
+import (
+	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	userservice "github.com/drunkleen/rasta/internal/service/user"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type SmsController struct {
+	SmsService         *userservice.SmsService
+	UserService        *userservice.UserService
+	SessionService     *userservice.SessionService
+	LockoutService     *userservice.LockoutService
+	KnownDeviceService *userservice.KnownDeviceService
+}
+
+// NewSmsController creates a new instance of the SmsController.
+//
+// smsService is the SmsService instance to be used by the SmsController.
+// userService is the UserService instance to be used by the SmsController.
+// sessionService is the SessionService instance to be used by the SmsController.
+// lockoutService is the LockoutService instance to be used by the SmsController.
+// knownDeviceService is the KnownDeviceService instance to be used by the SmsController.
+// Returns a pointer to the newly created SmsController instance.
+func NewSmsController(
+	smsService *userservice.SmsService,
+	userService *userservice.UserService,
+	sessionService *userservice.SessionService,
+	lockoutService *userservice.LockoutService,
+	knownDeviceService *userservice.KnownDeviceService,
+) *SmsController {
+	return &SmsController{
+		SmsService:         smsService,
+		UserService:        userService,
+		SessionService:     sessionService,
+		LockoutService:     lockoutService,
+		KnownDeviceService: knownDeviceService,
+	}
+}
+
+// FinishLogin godoc
+// @Summary Finish sign-in with an SMS code
+// @Description Finishes a sign-in of a user with SMS two-factor authentication enabled with the token returned in the sms_code_required response and the code texted to them, and returns a JWT access token and a refresh token. The code is invalidated after too many wrong guesses.
+// @Tags SMS
+// @Accept  json
+// @Produce  json
+// @Param login body userDTO.SmsLogin true "Sign-in token and SMS code"
+// @Success 202 {object} userDTO.LoginResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Invalid or expired code"
+// @Failure 403 {object} commonerrors.ErrorMap "Account suspended"
+// @Failure 429 {object} commonerrors.ErrorMap "Too many failed attempts"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/login/sms [post]
+func (c *SmsController) FinishLogin(ctx *gin.Context) {
+	var reqBody userDTO.SmsLogin
+	if err := ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	ipAddress := ctx.ClientIP()
+	if retryAfter, err := c.LockoutService.Check(nil, ipAddress); err != nil {
+		respondTooManyAttempts(ctx, retryAfter, err)
+		return
+	}
+	userId, err := c.SmsService.FindLogin(reqBody.Token)
+	if err != nil {
+		c.LockoutService.RegisterFailure(nil, ipAddress)
+		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	user, err := c.UserService.FindById(userId)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap(commonerrors.ErrInvalidSmsLogin))
+		return
+	}
+	if user.IsSuspended() {
+		ctx.JSON(http.StatusForbidden, commonerrors.NewErrorMap(commonerrors.ErrAccountSuspended))
+		return
+	}
+	if retryAfter, err := c.LockoutService.Check(user, ipAddress); err != nil {
+		respondTooManyAttempts(ctx, retryAfter, err)
+		return
+	}
+	if err = c.SmsService.FinishLogin(user, reqBody.Code); err != nil {
+		if err.Error() == commonerrors.ErrInternalServer {
+			ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+			return
+		}
+		c.LockoutService.RegisterFailure(user, ipAddress)
+		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	if err = c.LockoutService.Reset(user.Id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	jwtToken, refreshToken, err := c.SessionService.Create(user, ctx.Request.UserAgent(), ipAddress)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	c.KnownDeviceService.Recognize(user, ctx.Request.UserAgent(), ipAddress)
+	ctx.JSON(http.StatusAccepted, userDTO.FromModelToUserLoginResponse(user, jwtToken, refreshToken))
+}
+
+// SendPhoneCode godoc
+// @Summary Send a code to the phone number
+// @Description Texts a code to the phone number of the authenticated user, valid for SMS_CODE_EXPIRY seconds, to verify the phone number at /users/me/phone/verify or to disable SMS two-factor authentication. Another code cannot be sent before SMS_RESEND_INTERVAL seconds have passed.
+// @Tags SMS
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "No phone number"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 429 {object} commonerrors.ErrorMap "Code sent too recently"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/me/phone/code [post]
+func (c *SmsController) SendPhoneCode(ctx *gin.Context) {
+	user, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+	if err := c.SmsService.SendCode(user, usermodel.SmsPurposePhone); err != nil {
+		ctx.JSON(smsErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "code sent successfully to your phone",
+		},
+	})
+}
+
+// VerifyPhone godoc
+// @Summary Verify the phone number
+// @Description Verifies the phone number of the authenticated user with the code sent by /users/me/phone/code. A verified phone number can receive password reset codes and be used for two-factor authentication.
+// @Tags SMS
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param code body userDTO.SmsCode true "SMS code"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request, or phone number already verified"
+// @Failure 401 {object} commonerrors.ErrorMap "Invalid or expired code"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/me/phone/verify [post]
+func (c *SmsController) VerifyPhone(ctx *gin.Context) {
+	var reqBody userDTO.SmsCode
+	if err := ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	user, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+	if err := c.SmsService.VerifyPhone(user, reqBody.Code); err != nil {
+		ctx.JSON(smsErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "phone number verified successfully",
+		},
+	})
+}
+
+// EnableTwoFactor godoc
+// @Summary Enable SMS two-factor authentication
+// @Description Enables SMS two-factor authentication for the authenticated user, whose phone number must be verified. Signing in then texts a code to finish the sign-in with at /users/login/sms, unless a TOTP code is sent.
+// @Tags SMS
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Phone number not verified, or already enabled"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/me/phone/2fa [post]
+func (c *SmsController) EnableTwoFactor(ctx *gin.Context) {
+	user, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+	if err := c.SmsService.EnableTwoFactor(user); err != nil {
+		ctx.JSON(smsErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "sms two-factor authentication enabled successfully",
+		},
+	})
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable SMS two-factor authentication
+// @Description Disables SMS two-factor authentication for the authenticated user with a code sent by /users/me/phone/code.
+// @Tags SMS
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param code body userDTO.SmsCode true "SMS code"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request, or not enabled"
+// @Failure 401 {object} commonerrors.ErrorMap "Invalid or expired code"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/me/phone/2fa [delete]
+func (c *SmsController) DisableTwoFactor(ctx *gin.Context) {
+	var reqBody userDTO.SmsCode
+	if err := ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	user, ok := c.currentUser(ctx)
+	if !ok {
+		return
+	}
+	if err := c.SmsService.DisableTwoFactor(user, reqBody.Code); err != nil {
+		ctx.JSON(smsErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "sms two-factor authentication disabled successfully",
+		},
+	})
+}
+
+// currentUser loads the authenticated user, writing the response and returning false if it fails.
+func (c *SmsController) currentUser(ctx *gin.Context) (*usermodel.User, bool) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return nil, false
+	}
+	user, err := c.UserService.FindById(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(commonerrors.ErrInternalServer))
+		return nil, false
+	}
+	return user, true
+}
+
+// smsErrorStatus maps an error returned by the SmsService to an HTTP status code.
+func smsErrorStatus(err error) int {
+	switch err.Error() {
+	case commonerrors.ErrInternalServer:
+		return http.StatusInternalServerError
+	case commonerrors.ErrSmsTooSoon:
+		return http.StatusTooManyRequests
+	case commonerrors.ErrInvalidOtp:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusBadRequest
+	}
+}
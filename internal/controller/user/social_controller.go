@@ -0,0 +1,314 @@
+package usercontroller
+
+import (
+	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	userservice "github.com/drunkleen/rasta/internal/service/user"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type SocialController struct {
+	SocialService   *userservice.SocialService
+	OtpService      *userservice.OtpService
+	OAuthService    *userservice.OAuthService
+	SessionService  *userservice.SessionService
+	LockoutService  *userservice.LockoutService
+	WebAuthnService *userservice.WebAuthnService
+	SmsService      *userservice.SmsService
+}
+
+// NewSocialController creates a new instance of the SocialController.
+//
+// socialService is the SocialService instance to be used by the SocialController.
+// otpService is the OtpService instance to be used by the SocialController.
+// oauthService is the OAuthService instance to be used by the SocialController.
+// sessionService is the SessionService instance to be used by the SocialController.
+// lockoutService is the LockoutService instance to be used by the SocialController.
+// webAuthnService is the WebAuthnService instance to be used by the SocialController.
+// smsService is the SmsService instance to be used by the SocialController.
+// Returns a pointer to the newly created SocialController instance.
+func NewSocialController(
+	socialService *userservice.SocialService,
+	otpService *userservice.OtpService,
+	oauthService *userservice.OAuthService,
+	sessionService *userservice.SessionService,
+	lockoutService *userservice.LockoutService,
+	webAuthnService *userservice.WebAuthnService,
+	smsService *userservice.SmsService,
+) *SocialController {
+	return &SocialController{
+		SocialService:   socialService,
+		OtpService:      otpService,
+		OAuthService:    oauthService,
+		SessionService:  sessionService,
+		LockoutService:  lockoutService,
+		WebAuthnService: webAuthnService,
+		SmsService:      smsService,
+	}
+}
+
+// GetProviders godoc
+// @Summary List identity providers
+// @Description Lists the external identity providers users may sign in with.
+// @Tags Social
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse{data=[]string}
+// @Router /users/social/providers [get]
+func (c *SocialController) GetProviders(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   c.SocialService.Providers(),
+	})
+}
+
+// Begin godoc
+// @Summary Begin sign-in with an identity provider
+// @Description Starts a sign-in with an external identity provider. Send the user to the returned URL; the provider redirects back to SOCIAL_REDIRECT_URL/{provider} with a code and a state to send to /users/social/{provider}/callback within ten minutes.
+// @Tags Social
+// @Produce  json
+// @Param provider path string true "Provider name"
+// @Success 200 {object} userDTO.GenericResponse{data=userDTO.SocialAuthorization}
+// @Failure 404 {object} commonerrors.ErrorMap "Provider not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/social/{provider}/begin [post]
+func (c *SocialController) Begin(ctx *gin.Context) {
+	provider := ctx.Param("provider")
+	authUrl, err := c.SocialService.Begin(provider, nil)
+	if err != nil {
+		ctx.JSON(socialErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.SocialAuthorization{Provider: provider, AuthUrl: authUrl},
+	})
+}
+
+// Callback godoc
+// @Summary Finish sign-in with an identity provider
+// @Description Completes a sign-in started at /users/social/{provider}/begin with the code and state the provider redirected back with, and returns a JWT access token and a refresh token. An identity not linked to any account signs up a new user, whose email address is verified if the provider asserts it; if another account already uses the email address, sign in to it and link the provider instead. Users with two-factor authentication enabled also send their TOTP code as otp or a recovery code as recovery_code, and may retry with the same state; users with a registered passkey who send neither get a 401 with status passkey_required and a WebAuthn ceremony to complete at /users/webauthn/login/finish, and users with SMS two-factor authentication enabled get a 401 with status sms_code_required and a token to send to /users/login/sms along with the code texted to them.
+// @Tags Social
+// @Accept  json
+// @Produce  json
+// @Param provider path string true "Provider name"
+// @Param callback body userDTO.SocialCallback true "Code and state sent back by the provider"
+// @Success 202 {object} userDTO.LoginResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} userDTO.GenericResponse{data=userDTO.WebAuthnCeremony} "Sign-in failed, or passkey required"
+// @Failure 401 {object} userDTO.GenericResponse{data=userDTO.SmsChallenge} "SMS code required"
+// @Failure 403 {object} commonerrors.ErrorMap "Account suspended"
+// @Failure 404 {object} commonerrors.ErrorMap "Provider not found"
+// @Failure 409 {object} commonerrors.ErrorMap "Email address already used by another account"
+// @Failure 429 {object} commonerrors.ErrorMap "Too many failed attempts"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/social/{provider}/callback [post]
+func (c *SocialController) Callback(ctx *gin.Context) {
+	var reqBody userDTO.SocialCallback
+	if err := ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	ipAddress := ctx.ClientIP()
+	if retryAfter, err := c.LockoutService.Check(nil, ipAddress); err != nil {
+		respondTooManyAttempts(ctx, retryAfter, err)
+		return
+	}
+	state, user, created, err := c.SocialService.SignIn(ctx.Param("provider"), reqBody.State, reqBody.Code)
+	if err != nil {
+		status := socialErrorStatus(err)
+		if status == http.StatusUnauthorized {
+			c.LockoutService.RegisterFailure(nil, ipAddress)
+		}
+		ctx.JSON(status, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	if !user.IsVerified {
+		c.SocialService.Finish(state)
+		if created {
+			if err = c.OtpService.GenerateOtpAndSendEmail(user, user.Id); err != nil {
+				ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+				return
+			}
+		}
+		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap(commonerrors.ErrUserNotVerified))
+		return
+	}
+	if user.IsSuspended() {
+		c.SocialService.Finish(state)
+		ctx.JSON(http.StatusForbidden, commonerrors.NewErrorMap(commonerrors.ErrAccountSuspended))
+		return
+	}
+	if retryAfter, err := c.LockoutService.Check(user, ipAddress); err != nil {
+		respondTooManyAttempts(ctx, retryAfter, err)
+		return
+	}
+	if !verifySecondFactor(ctx, c.OAuthService, c.WebAuthnService, c.SmsService, c.LockoutService, user, reqBody.OTP, reqBody.RecoveryCode) {
+		return
+	}
+	c.SocialService.Finish(state)
+	if err = c.LockoutService.Reset(user.Id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	jwtToken, refreshToken, err := c.SessionService.Create(user, ctx.Request.UserAgent(), ipAddress)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusAccepted, userDTO.FromModelToUserLoginResponse(user, jwtToken, refreshToken))
+}
+
+// BeginLink godoc
+// @Summary Begin linking an identity provider
+// @Description Starts linking an account at an external identity provider to the authenticated user. Send the user to the returned URL; the provider redirects back with a code and a state to send to /users/social/{provider}/link/callback within ten minutes.
+// @Tags Social
+// @Security BearerAuth
+// @Produce  json
+// @Param provider path string true "Provider name"
+// @Success 200 {object} userDTO.GenericResponse{data=userDTO.SocialAuthorization}
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 404 {object} commonerrors.ErrorMap "Provider not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/social/{provider}/link [post]
+func (c *SocialController) BeginLink(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	provider := ctx.Param("provider")
+	authUrl, err := c.SocialService.Begin(provider, &userId)
+	if err != nil {
+		ctx.JSON(socialErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.SocialAuthorization{Provider: provider, AuthUrl: authUrl},
+	})
+}
+
+// FinishLink godoc
+// @Summary Finish linking an identity provider
+// @Description Completes linking started at /users/social/{provider}/link with the code and state the provider redirected back with. The user can then sign in with the provider, and their email address is verified if the provider asserts it.
+// @Tags Social
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param provider path string true "Provider name"
+// @Param callback body userDTO.SocialCallback true "Code and state sent back by the provider"
+// @Success 201 {object} userDTO.GenericResponse{data=userDTO.Identity}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 404 {object} commonerrors.ErrorMap "Provider not found"
+// @Failure 409 {object} commonerrors.ErrorMap "Identity linked to another account"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/social/{provider}/link/callback [post]
+func (c *SocialController) FinishLink(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	var reqBody userDTO.SocialCallback
+	if err = ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	identity, err := c.SocialService.Link(ctx.Param("provider"), reqBody.State, reqBody.Code, userId)
+	if err != nil {
+		ctx.JSON(socialErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusCreated, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelToIdentityResponse(identity),
+	})
+}
+
+// GetIdentities godoc
+// @Summary List linked identity providers
+// @Description Lists the external identities linked to the authenticated user.
+// @Tags Social
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse{data=[]userDTO.Identity}
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/social/identities [get]
+func (c *SocialController) GetIdentities(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	identities, err := c.SocialService.FindByUserId(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	respIdentities := make([]userDTO.Identity, len(identities))
+	for i, identity := range identities {
+		respIdentities[i] = *userDTO.FromModelToIdentityResponse(&identity)
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   respIdentities,
+	})
+}
+
+// Unlink godoc
+// @Summary Unlink an identity provider
+// @Description Unlinks one of the external identities of the authenticated user, who can no longer sign in with it.
+// @Tags Social
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "Identity ID"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 404 {object} commonerrors.ErrorMap "Identity not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/social/identities/{id} [delete]
+func (c *SocialController) Unlink(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	if err = c.SocialService.Unlink(id, userId); err != nil {
+		ctx.JSON(socialErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "identity unlinked successfully",
+		},
+	})
+}
+
+// socialErrorStatus maps an error returned by the SocialService to an HTTP status code.
+func socialErrorStatus(err error) int {
+	switch err.Error() {
+	case commonerrors.ErrInternalServer:
+		return http.StatusInternalServerError
+	case commonerrors.ErrSocialProviderNotFound, commonerrors.ErrIdentityNotFound:
+		return http.StatusNotFound
+	case commonerrors.ErrSocialEmailExists, commonerrors.ErrIdentityAlreadyLinked:
+		return http.StatusConflict
+	case commonerrors.ErrSocialEmailRequired, commonerrors.ErrInvalidEmail:
+		return http.StatusBadRequest
+	default:
+		return http.StatusUnauthorized
+	}
+}
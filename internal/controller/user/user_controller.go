@@ -1,31 +1,66 @@
 package usercontroller
 
 import (
-	"fmt"
+	"errors"
+	"github.com/drunkleen/rasta/config"
 	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
 	"github.com/drunkleen/rasta/internal/common/auth"
 	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
 	"github.com/drunkleen/rasta/internal/service/user"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"log"
 	"net/http"
 	"strconv"
 )
 
 type UserController struct {
-	UserService  *userservice.UserService
-	OAuthService *userservice.OAuthService
-	OtpService   *userservice.OtpService
+	UserService    *userservice.UserService
+	OAuthService   *userservice.OAuthService
+	OtpService     *userservice.OtpService
+	SessionService *userservice.SessionService
+	LockoutService *userservice.LockoutService
+
+	WebAuthnService    *userservice.WebAuthnService
+	KnownDeviceService *userservice.KnownDeviceService
+	SmsService         *userservice.SmsService
+	SignupGuardService *userservice.SignupGuardService
 }
 
 // NewUserController creates a new instance of the UserController.
 //
 // userService is the UserService instance to be used by the UserController.
 // otpService is the OtpService instance to be used by the UserController.
+// oauthService is the OAuthService instance to be used by the UserController.
+// sessionService is the SessionService instance to be used by the UserController.
+// lockoutService is the LockoutService instance to be used by the UserController.
+// webAuthnService is the WebAuthnService instance to be used by the UserController.
+// knownDeviceService is the KnownDeviceService instance to be used by the UserController.
+// smsService is the SmsService instance to be used by the UserController.
+// signupGuardService is the SignupGuardService instance to be used by the UserController.
 // Returns a pointer to the newly created UserController instance.
-func NewUserController(userService *userservice.UserService, otpService *userservice.OtpService) *UserController {
-	return &UserController{UserService: userService, OtpService: otpService}
+func NewUserController(
+	userService *userservice.UserService,
+	otpService *userservice.OtpService,
+	oauthService *userservice.OAuthService,
+	sessionService *userservice.SessionService,
+	lockoutService *userservice.LockoutService,
+	webAuthnService *userservice.WebAuthnService,
+	knownDeviceService *userservice.KnownDeviceService,
+	smsService *userservice.SmsService,
+	signupGuardService *userservice.SignupGuardService,
+) *UserController {
+	return &UserController{
+		UserService:        userService,
+		OtpService:         otpService,
+		OAuthService:       oauthService,
+		SessionService:     sessionService,
+		LockoutService:     lockoutService,
+		WebAuthnService:    webAuthnService,
+		KnownDeviceService: knownDeviceService,
+		SmsService:         smsService,
+		SignupGuardService: signupGuardService,
+	}
 }
 
 // GetWithPagination godoc
@@ -103,10 +138,15 @@ func (c *UserController) GetAllUsersCount(ctx *gin.Context) {
 // @Produce  json
 // @Param id path string true "User ID"
 // @Success 200 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Invalid user ID"
 // @Failure 404 {object} userDTO.GenericResponse
 // @Router /admin/users/id/{id} [get]
 func (c *UserController) FindUserByID(ctx *gin.Context) {
-	userId := uuid.MustParse(ctx.Param("id"))
+	userId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidUserId))
+		return
+	}
 	user, err := c.UserService.FindById(userId)
 	if err != nil {
 		ctx.JSON(http.StatusNotFound,
@@ -116,13 +156,13 @@ func (c *UserController) FindUserByID(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
 		Status: "success",
-		Data:   user,
+		Data:   userDTO.FromModelToUserResponseForAdmins(user),
 	})
 }
 
 // FindUserByUsername godoc
 // @Summary Get user by username
-// @Description Retrieve user details by their username
+// @Description Retrieve user details by their username. Accepts a bearer token or an API key sent as "Authorization: ApiKey <key>".
 // @Tags Users
 // @Accept  json
 // @Produce  json
@@ -145,15 +185,79 @@ func (c *UserController) FindUserByUsername(ctx *gin.Context) {
 	})
 }
 
+// GetProfile godoc
+// @Summary Get the authenticated user
+// @Description Retrieves the profile of the authenticated user, including the email address, phone number, locale and timezone that are not shown to other users.
+// @Tags Users
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse{data=userDTO.User}
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/me [get]
+func (c *UserController) GetProfile(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	user, err := c.UserService.FindById(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(commonerrors.ErrInternalServer))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelToProfileResponse(user),
+	})
+}
+
+// UpdateProfile godoc
+// @Summary Update the authenticated user
+// @Description Updates the profile of the authenticated user. Only the fields sent are changed; display_name, locale, timezone, phone and bio are cleared when sent empty. The locale is a BCP 47 language tag such as en-US, the timezone an IANA time zone such as Europe/Berlin and the phone number in the E.164 format such as +14155550123. The email address and password are changed through their own endpoints.
+// @Tags Users
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param profile body userDTO.ProfileUpdate true "Profile fields to update"
+// @Success 200 {object} userDTO.GenericResponse{data=userDTO.User}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 409 {object} commonerrors.ErrorMap "Username already exists"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/me [patch]
+func (c *UserController) UpdateProfile(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	var reqBody userDTO.ProfileUpdate
+	if err = ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	user, err := c.UserService.UpdateProfile(userId, &reqBody)
+	if err != nil {
+		ctx.JSON(profileErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelToProfileResponse(user),
+	})
+}
+
 // Create godoc
 // @Summary Create a new user
-// @Description Create a new user account and send a verification OTP email
+// @Description Create a new user account and send a verification OTP email. A password that breaks the password policy is refused with the rules it breaks in errors. Unless signup challenges are disabled, a challenge from /users/signup/challenge must be solved and sent as challenge along with its solution; it is used up by the attempt. Email addresses of blocked domains are refused, and only SIGNUP_IP_QUOTA accounts may be signed up from an IP address every SIGNUP_IP_QUOTA_WINDOW seconds.
 // @Tags Users
 // @Accept  json
 // @Produce  json
 // @Param user body userDTO.UserCreate true "User creation payload"
 // @Success 200 {object} userDTO.GenericResponse
-// @Failure 400 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request, password refused by the password policy, blocked email domain or invalid challenge"
+// @Failure 429 {object} commonerrors.ErrorMap "Too many signups from this IP address"
 // @Failure 500 {object} userDTO.GenericResponse
 // @Router /users/signup [post]
 func (c *UserController) Create(ctx *gin.Context) {
@@ -164,67 +268,58 @@ func (c *UserController) Create(ctx *gin.Context) {
 		)
 		return
 	}
+	ipAddress := ctx.ClientIP()
+	if retryAfter, err := c.SignupGuardService.Check(user.Email, ipAddress, user.Challenge, user.Solution); err != nil {
+		switch err.Error() {
+		case commonerrors.ErrSignupQuotaExceeded:
+			respondTooManyAttempts(ctx, retryAfter, err)
+		case commonerrors.ErrInternalServer:
+			ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		default:
+			ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(err.Error()))
+		}
+		return
+	}
 	newUser, err := c.UserService.Create(&user)
+	if respondPasswordRejected(ctx, "password", err) {
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(err.Error()))
 		return
 	}
+	c.SignupGuardService.Record(ipAddress)
 	err = c.OtpService.GenerateOtpAndSendEmail(newUser, newUser.Id)
 	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
 		return
 	}
-	jwtToken, err := auth.GenerateJWTToken(newUser.Email, fmt.Sprintf("%v", newUser.Id))
+	jwtToken, refreshToken, err := c.SessionService.Create(newUser, ctx.Request.UserAgent(), ipAddress)
 	if err != nil {
-		log.Printf("failed to generate JWT token: %v", err)
 		ctx.JSON(http.StatusInternalServerError,
 			commonerrors.NewErrorMap(commonerrors.ErrInternalServer),
 		)
 		return
 	}
+	c.KnownDeviceService.Recognize(newUser, ctx.Request.UserAgent(), ipAddress)
 	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
 		Status: "success",
-		Data:   userDTO.FromModelToUserLoginResponse(newUser, jwtToken),
-	})
-}
-
-// Delete godoc
-// @Summary Delete a user
-// @Description Delete a user by their ID
-// @Tags Users
-// @Accept  json
-// @Produce  json
-// @Param id path string true "User ID"
-// @Success 200 {object} userDTO.GenericResponse
-// @Failure 500 {object} userDTO.GenericResponse
-// @Router /admin/users/id/{id} [delete]
-func (c *UserController) Delete(ctx *gin.Context) {
-	userId := uuid.MustParse(ctx.Param("id"))
-	err := c.UserService.Delete(userId)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError,
-			commonerrors.NewErrorMap(err.Error()),
-		)
-		return
-	}
-	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
-		Status: "success",
-		Data: struct {
-			Message string `json:"message"`
-		}{
-			Message: "user deleted successfully",
-		},
+		Data:   userDTO.FromModelToUserLoginResponse(newUser, jwtToken, refreshToken),
 	})
 }
 
 // Login godoc
 // @Summary User login
-// @Description Authenticates a user and returns a JWT token
+// @Description Authenticates a user and returns a short-lived JWT access token and a refresh token. Users with two-factor authentication enabled send either their TOTP code as otp or one of their recovery codes as recovery_code. Users with a registered passkey who do not send a TOTP code get a 401 with status passkey_required and a WebAuthn ceremony to complete at /users/webauthn/login/finish. Users with SMS two-factor authentication enabled who do not send a TOTP code get a 401 with status sms_code_required and a token to send to /users/login/sms along with the code texted to them. Repeated failures delay further attempts and temporarily lock the account and the client IP address.
 // @Tags Users
 // @Accept  json
 // @Produce  json
 // @Param user body userDTO.UserLogin true "User login payload"
 // @Success 202 {object} userDTO.LoginResponse
-// @Failure 401 {object} userDTO.GenericResponse
+// @Failure 401 {object} userDTO.GenericResponse{data=userDTO.WebAuthnCeremony} "Invalid credentials, or passkey required"
+// @Failure 401 {object} userDTO.GenericResponse{data=userDTO.SmsChallenge} "SMS code required"
+// @Failure 403 {object} userDTO.GenericResponse "Account suspended, or password reset required after a reported login"
+// @Failure 429 {object} userDTO.GenericResponse "Too many failed attempts, or SMS code sent too recently"
 // @Failure 500 {object} userDTO.GenericResponse
 // @Router /users/login [post]
 func (c *UserController) Login(ctx *gin.Context) {
@@ -235,8 +330,21 @@ func (c *UserController) Login(ctx *gin.Context) {
 		)
 		return
 	}
+	ipAddress := ctx.ClientIP()
+	target, _ := c.UserService.FindByLogin(user.Username)
+	if retryAfter, err := c.LockoutService.Check(target, ipAddress); err != nil {
+		respondTooManyAttempts(ctx, retryAfter, err)
+		return
+	}
 	dbUser, err := c.UserService.Login(user.Username, user.Password)
 	if err != nil {
+		if err.Error() == commonerrors.ErrAccountSuspended || err.Error() == commonerrors.ErrPasswordResetRequired {
+			ctx.JSON(http.StatusForbidden,
+				commonerrors.NewErrorMap(err.Error()),
+			)
+			return
+		}
+		c.LockoutService.RegisterFailure(target, ipAddress)
 		ctx.JSON(http.StatusUnauthorized,
 			commonerrors.NewErrorMap(err.Error()),
 		)
@@ -248,65 +356,154 @@ func (c *UserController) Login(ctx *gin.Context) {
 		)
 		return
 	}
-	if dbUser.OAuth.Enabled {
-		if err = c.OAuthService.OAuthValidate(&dbUser, user.OTP); err != nil {
-			ctx.JSON(http.StatusUnauthorized,
-				commonerrors.NewErrorMap(err.Error()),
-			)
-			return
-		}
+	if !verifySecondFactor(ctx, c.OAuthService, c.WebAuthnService, c.SmsService, c.LockoutService, &dbUser, user.OTP, user.RecoveryCode) {
+		return
 	}
-	jwtToken, err := auth.GenerateJWTToken(dbUser.Email, fmt.Sprintf("%v", dbUser.Id))
+	if err = c.LockoutService.Reset(dbUser.Id); err != nil {
+		ctx.JSON(http.StatusInternalServerError,
+			commonerrors.NewErrorMap(commonerrors.ErrInternalServer),
+		)
+		return
+	}
+	jwtToken, refreshToken, err := c.SessionService.Create(&dbUser, ctx.Request.UserAgent(), ctx.ClientIP())
 	if err != nil {
-		log.Printf("failed to generate JWT token: %v", err)
 		ctx.JSON(http.StatusInternalServerError,
 			commonerrors.NewErrorMap(commonerrors.ErrInternalServer),
 		)
 		return
 	}
-	ctx.JSON(http.StatusAccepted, userDTO.FromModelToUserLoginResponse(&dbUser, jwtToken))
+	c.KnownDeviceService.Recognize(&dbUser, ctx.Request.UserAgent(), ctx.ClientIP())
+	ctx.JSON(http.StatusAccepted, userDTO.FromModelToUserLoginResponse(&dbUser, jwtToken, refreshToken))
+}
+
+// verifySecondFactor checks the second factor of a user whose first factor has been checked.
+//
+// Users with two-factor authentication enabled must send their TOTP code as otp or one of their recovery codes.
+// Users with a registered passkey who send neither are asked to complete a passkey ceremony instead,
+// and users with SMS two-factor authentication enabled are sent a code to finish the sign-in with.
+// Writes the response and returns false if the login may not proceed.
+func verifySecondFactor(
+	ctx *gin.Context,
+	oauthService *userservice.OAuthService,
+	webAuthnService *userservice.WebAuthnService,
+	smsService *userservice.SmsService,
+	lockoutService *userservice.LockoutService,
+	user *usermodel.User,
+	otp, recoveryCode string,
+) bool {
+	totpProvided := user.OAuth.Enabled && (otp != "" || recoveryCode != "")
+	if !totpProvided && webAuthnService.HasCredentials(user.Id) {
+		ceremonyId, options, err := webAuthnService.BeginSecondFactor(user)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError,
+				commonerrors.NewErrorMap(commonerrors.ErrInternalServer),
+			)
+			return false
+		}
+		ctx.JSON(http.StatusUnauthorized, userDTO.GenericResponse{
+			Status: "passkey_required",
+			Data:   userDTO.WebAuthnCeremony{CeremonyId: ceremonyId, Options: options},
+			Error:  commonerrors.ErrPasskeyRequired,
+		})
+		return false
+	}
+	if !totpProvided && user.SmsTwoFactorEnabled {
+		token, err := smsService.BeginLogin(user)
+		if err != nil {
+			ctx.JSON(smsErrorStatus(err),
+				commonerrors.NewErrorMap(err.Error()),
+			)
+			return false
+		}
+		ctx.JSON(http.StatusUnauthorized, userDTO.GenericResponse{
+			Status: "sms_code_required",
+			Data:   userDTO.NewSmsChallenge(token, user.Phone, config.GetSmsCodeExpiry()),
+			Error:  commonerrors.ErrSmsCodeRequired,
+		})
+		return false
+	}
+	if !user.OAuth.Enabled {
+		return true
+	}
+	var err error
+	if recoveryCode != "" {
+		err = oauthService.UseRecoveryCode(user, recoveryCode)
+	} else {
+		err = oauthService.OAuthValidate(user, otp)
+	}
+	if err != nil {
+		if err.Error() == commonerrors.ErrInternalServer {
+			ctx.JSON(http.StatusInternalServerError,
+				commonerrors.NewErrorMap(err.Error()),
+			)
+			return false
+		}
+		lockoutService.RegisterFailure(user, ctx.ClientIP())
+		ctx.JSON(http.StatusUnauthorized,
+			commonerrors.NewErrorMap(err.Error()),
+		)
+		return false
+	}
+	return true
 }
 
 // UpdatePassword godoc
 // @Summary Update user password
-// @Description Updates the password for the currently authenticated user
+// @Description Updates the password for the currently authenticated user and logs the user out of every session. The current password is checked first, and a wrong one counts as a failed login attempt. A password that breaks the password policy or is one of the most recent passwords of the user is refused with the rules it breaks in errors.
 // @Tags Users
 // @Accept  json
 // @Produce  json
 // @Param updatePassword body userDTO.UpdatePassword true "Password update payload"
 // @Success 200 {object} userDTO.GenericResponse
-// @Failure 400 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request, or password refused by the password policy"
+// @Failure 401 {object} commonerrors.ErrorMap "Wrong current password"
+// @Failure 429 {object} commonerrors.ErrorMap "Too many failed attempts"
 // @Failure 500 {object} userDTO.GenericResponse
-// @Router /users/{username}/update-password [put]
+// @Security BearerAuth
+// @Router /users/me/password [put]
 func (c *UserController) UpdatePassword(ctx *gin.Context) {
 	var updatePassword userDTO.UpdatePassword
 	if err := ctx.ShouldBindJSON(&updatePassword); err != nil {
 		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
 	}
 	if err := updatePassword.Validate(); err != nil {
 		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrPasswordsNotMatch))
+		return
 	}
 
-	userId, exists := ctx.Get("userId")
-	if !exists {
-		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(commonerrors.ErrInternalServer))
+	id, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
 		return
 	}
-	userIdStr, ok := userId.(string)
-	if !ok {
+	user, err := c.UserService.FindById(id)
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(commonerrors.ErrInternalServer))
 		return
 	}
-	id, err := uuid.Parse(userIdStr)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(commonerrors.ErrInternalServer))
+	ipAddress := ctx.ClientIP()
+	if retryAfter, err := c.LockoutService.Check(user, ipAddress); err != nil {
+		respondTooManyAttempts(ctx, retryAfter, err)
+		return
+	}
+	err = c.UserService.UpdatePassword(id, updatePassword.OldPassword, updatePassword.NewPassword1)
+	if err != nil && err.Error() == commonerrors.ErrInvalidCredentials {
+		c.LockoutService.RegisterFailure(user, ipAddress)
+		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	if respondPasswordRejected(ctx, "new_password1", err) {
 		return
 	}
-	err = c.UserService.UpdatePassword(id, updatePassword.NewPassword1)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
 		return
 	}
+	if err = c.SessionService.RevokeAll(id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
 	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
 		Status: "success",
 		Data: struct {
@@ -317,3 +514,161 @@ func (c *UserController) UpdatePassword(ctx *gin.Context) {
 	})
 
 }
+
+// SuspendUser godoc
+// @Summary Suspend a user
+// @Description Suspends a user account with a reason and an optional end date, logs the user out of every session and notifies the user by email. Suspended users cannot log in and their tokens are rejected.
+// @Tags Users
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param id path string true "User ID"
+// @Param suspension body userDTO.SuspendUser true "Suspension payload"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 404 {object} commonerrors.ErrorMap "User not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/users/id/{id}/suspend [post]
+func (c *UserController) SuspendUser(ctx *gin.Context) {
+	userId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(commonerrors.ErrUserNotFound))
+		return
+	}
+	var reqBody userDTO.SuspendUser
+	if err = ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	if adminId, err := contextUUID(ctx, "userId"); err == nil && adminId == userId {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrCannotSuspendSelf))
+		return
+	}
+	user, err := c.UserService.Suspend(userId, reqBody.Reason, reqBody.Until)
+	if err != nil {
+		ctx.JSON(suspensionErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	if err = c.SessionService.RevokeAll(userId); err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelToUserResponseForAdmins(user),
+	})
+}
+
+// UnsuspendUser godoc
+// @Summary Unsuspend a user
+// @Description Lifts the suspension of a user account and notifies the user by email.
+// @Tags Users
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "User ID"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Forbidden"
+// @Failure 404 {object} commonerrors.ErrorMap "User not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /admin/users/id/{id}/unsuspend [post]
+func (c *UserController) UnsuspendUser(ctx *gin.Context) {
+	userId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, commonerrors.NewErrorMap(commonerrors.ErrUserNotFound))
+		return
+	}
+	user, err := c.UserService.Unsuspend(userId)
+	if err != nil {
+		ctx.JSON(suspensionErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelToUserResponseForAdmins(user),
+	})
+}
+
+// suspensionErrorStatus maps an error returned by UserService.Suspend or UserService.Unsuspend to an HTTP status code.
+func suspensionErrorStatus(err error) int {
+	switch err.Error() {
+	case commonerrors.ErrUserNotFound:
+		return http.StatusNotFound
+	case commonerrors.ErrInvalidSuspensionEnd:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// profileErrorStatus maps an error returned by UserService.UpdateProfile to an HTTP status code.
+func profileErrorStatus(err error) int {
+	switch err.Error() {
+	case commonerrors.ErrUsernameAlreadyExists:
+		return http.StatusConflict
+	case commonerrors.ErrUserNotFound, commonerrors.ErrInternalServer:
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// GetPasswordPolicy godoc
+// @Summary Get the password policy
+// @Description Returns the rules new passwords must follow, so that clients can show them before a password is submitted. Passwords found in the breached password list are refused as well.
+// @Tags Users
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse{data=userDTO.PasswordPolicy}
+// @Router /users/password-policy [get]
+func (c *UserController) GetPasswordPolicy(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromPasswordPolicy(auth.GetPasswordPolicy()),
+	})
+}
+
+// GetSignupChallenge godoc
+// @Summary Get a signup challenge
+// @Description Issues a proof-of-work challenge to solve before signing up at /users/signup: find a solution of at most 64 characters such that the SHA-256 hash of the challenge followed by the solution starts with difficulty zero bits. A difficulty of 0 means signup challenges are disabled. Every challenge can be used once, before it expires.
+// @Tags Users
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse{data=userDTO.SignupChallenge}
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/signup/challenge [get]
+func (c *UserController) GetSignupChallenge(ctx *gin.Context) {
+	challenge, difficulty, expiresAt, err := c.SignupGuardService.NewChallenge()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: userDTO.SignupChallenge{
+			Challenge:  challenge,
+			Algorithm:  auth.SignupChallengeAlgorithm,
+			Difficulty: difficulty,
+			ExpiresAt:  expiresAt,
+		},
+	})
+}
+
+// respondPasswordRejected responds with the rules a password broke if err is a *auth.PasswordPolicyError,
+// field being the name of the password in the request body. Returns whether it responded.
+func respondPasswordRejected(ctx *gin.Context, field string, err error) bool {
+	var policyErr *auth.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	fieldErrors := make([]commonerrors.FieldError, 0, len(policyErr.Violations))
+	for _, violation := range policyErr.Violations {
+		fieldErrors = append(fieldErrors, commonerrors.FieldError{
+			Field:   field,
+			Rule:    violation.Rule,
+			Message: violation.Message,
+		})
+	}
+	ctx.JSON(http.StatusBadRequest, commonerrors.NewFieldErrorMap(policyErr.Error(), fieldErrors))
+	return true
+}
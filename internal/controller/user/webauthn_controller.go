@@ -0,0 +1,266 @@
+package usercontroller
+
+import (
+	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	userservice "github.com/drunkleen/rasta/internal/service/user"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type WebAuthnController struct {
+	WebAuthnService *userservice.WebAuthnService
+	UserService     *userservice.UserService
+	SessionService  *userservice.SessionService
+	LockoutService  *userservice.LockoutService
+}
+
+// NewWebAuthnController creates a new instance of the WebAuthnController.
+//
+// webAuthnService is the WebAuthnService instance to be used by the WebAuthnController.
+// userService is the UserService instance to be used by the WebAuthnController.
+// sessionService is the SessionService instance to be used by the WebAuthnController.
+// lockoutService is the LockoutService instance to be used by the WebAuthnController.
+// Returns a pointer to the newly created WebAuthnController instance.
+func NewWebAuthnController(
+	webAuthnService *userservice.WebAuthnService,
+	userService *userservice.UserService,
+	sessionService *userservice.SessionService,
+	lockoutService *userservice.LockoutService,
+) *WebAuthnController {
+	return &WebAuthnController{
+		WebAuthnService: webAuthnService,
+		UserService:     userService,
+		SessionService:  sessionService,
+		LockoutService:  lockoutService,
+	}
+}
+
+// BeginRegistration godoc
+// @Summary Begin passkey registration
+// @Description Starts the registration of a passkey for the authenticated user. Pass the options to navigator.credentials.create and send the result to /users/webauthn/register/finish with the ceremony ID within five minutes.
+// @Tags WebAuthn
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse{data=userDTO.WebAuthnCeremony}
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/webauthn/register/begin [post]
+func (c *WebAuthnController) BeginRegistration(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	user, err := c.UserService.FindById(userId)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ceremonyId, options, err := c.WebAuthnService.BeginRegistration(user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.WebAuthnCeremony{CeremonyId: ceremonyId, Options: options},
+	})
+}
+
+// FinishRegistration godoc
+// @Summary Finish passkey registration
+// @Description Verifies the credential created by the authenticator and registers it as a passkey of the authenticated user. Once a passkey is registered, logging in with a password also requires a passkey.
+// @Tags WebAuthn
+// @Security BearerAuth
+// @Accept  json
+// @Produce  json
+// @Param registration body userDTO.WebAuthnRegister true "Ceremony ID and PublicKeyCredential"
+// @Success 201 {object} userDTO.GenericResponse{data=userDTO.WebAuthnCredential}
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/webauthn/register/finish [post]
+func (c *WebAuthnController) FinishRegistration(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	var reqBody userDTO.WebAuthnRegister
+	if err = ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	user, err := c.UserService.FindById(userId)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	credential, err := c.WebAuthnService.FinishRegistration(user, reqBody.CeremonyId, reqBody.Name, reqBody.Credential)
+	if err != nil {
+		ctx.JSON(webAuthnErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusCreated, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.FromModelToWebAuthnCredentialResponse(credential),
+	})
+}
+
+// GetCredentials godoc
+// @Summary List passkeys
+// @Description Lists the passkeys registered by the authenticated user.
+// @Tags WebAuthn
+// @Security BearerAuth
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse{data=[]userDTO.WebAuthnCredential}
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/webauthn/credentials [get]
+func (c *WebAuthnController) GetCredentials(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	credentials, err := c.WebAuthnService.FindByUserId(userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	respCredentials := make([]userDTO.WebAuthnCredential, len(credentials))
+	for i, credential := range credentials {
+		respCredentials[i] = *userDTO.FromModelToWebAuthnCredentialResponse(&credential)
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   respCredentials,
+	})
+}
+
+// DeleteCredential godoc
+// @Summary Delete a passkey
+// @Description Deletes one of the passkeys of the authenticated user.
+// @Tags WebAuthn
+// @Security BearerAuth
+// @Produce  json
+// @Param id path string true "Passkey ID"
+// @Success 200 {object} userDTO.GenericResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 404 {object} commonerrors.ErrorMap "Passkey not found"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/webauthn/credentials/{id} [delete]
+func (c *WebAuthnController) DeleteCredential(ctx *gin.Context) {
+	userId, err := contextUUID(ctx, "userId")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	if err = c.WebAuthnService.Delete(id, userId); err != nil {
+		ctx.JSON(webAuthnErrorStatus(err), commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data: struct {
+			Message string `json:"message"`
+		}{
+			Message: "passkey deleted successfully",
+		},
+	})
+}
+
+// BeginLogin godoc
+// @Summary Begin passkey login
+// @Description Starts a passkey-only login. Pass the options to navigator.credentials.get and send the result to /users/webauthn/login/finish with the ceremony ID within five minutes.
+// @Tags WebAuthn
+// @Produce  json
+// @Success 200 {object} userDTO.GenericResponse{data=userDTO.WebAuthnCeremony}
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/webauthn/login/begin [post]
+func (c *WebAuthnController) BeginLogin(ctx *gin.Context) {
+	ceremonyId, options, err := c.WebAuthnService.BeginLogin()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, userDTO.GenericResponse{
+		Status: "success",
+		Data:   userDTO.WebAuthnCeremony{CeremonyId: ceremonyId, Options: options},
+	})
+}
+
+// FinishLogin godoc
+// @Summary Finish passkey login
+// @Description Verifies the assertion of the authenticator and returns a JWT access token and a refresh token. Completes both passkey-only logins started at /users/webauthn/login/begin and the passkey second factor requested by /users/login.
+// @Tags WebAuthn
+// @Accept  json
+// @Produce  json
+// @Param login body userDTO.WebAuthnLogin true "Ceremony ID and PublicKeyCredential"
+// @Success 202 {object} userDTO.LoginResponse
+// @Failure 400 {object} commonerrors.ErrorMap "Bad Request"
+// @Failure 401 {object} commonerrors.ErrorMap "Unauthorized"
+// @Failure 403 {object} commonerrors.ErrorMap "Account suspended"
+// @Failure 429 {object} commonerrors.ErrorMap "Too many failed attempts"
+// @Failure 500 {object} commonerrors.ErrorMap "Internal Server Error"
+// @Router /users/webauthn/login/finish [post]
+func (c *WebAuthnController) FinishLogin(ctx *gin.Context) {
+	var reqBody userDTO.WebAuthnLogin
+	if err := ctx.ShouldBindJSON(&reqBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, commonerrors.NewErrorMap(commonerrors.ErrInvalidRequestBody))
+		return
+	}
+	ipAddress := ctx.ClientIP()
+	if retryAfter, err := c.LockoutService.Check(nil, ipAddress); err != nil {
+		respondTooManyAttempts(ctx, retryAfter, err)
+		return
+	}
+	user, err := c.WebAuthnService.FinishLogin(reqBody.CeremonyId, reqBody.Credential)
+	if err != nil {
+		if err.Error() == commonerrors.ErrInternalServer {
+			ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+			return
+		}
+		c.LockoutService.RegisterFailure(nil, ipAddress)
+		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	if user.IsSuspended() {
+		ctx.JSON(http.StatusForbidden, commonerrors.NewErrorMap(commonerrors.ErrAccountSuspended))
+		return
+	}
+	if !user.IsVerified {
+		ctx.JSON(http.StatusUnauthorized, commonerrors.NewErrorMap(commonerrors.ErrUserNotVerified))
+		return
+	}
+	if err = c.LockoutService.Reset(user.Id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	jwtToken, refreshToken, err := c.SessionService.Create(user, ctx.Request.UserAgent(), ipAddress)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, commonerrors.NewErrorMap(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusAccepted, userDTO.FromModelToUserLoginResponse(user, jwtToken, refreshToken))
+}
+
+// webAuthnErrorStatus maps an error returned by the WebAuthnService to an HTTP status code.
+func webAuthnErrorStatus(err error) int {
+	switch err.Error() {
+	case commonerrors.ErrInternalServer:
+		return http.StatusInternalServerError
+	case commonerrors.ErrPasskeyNotFound:
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -4,94 +4,361 @@ import (
 	"errors"
 	"github.com/drunkleen/rasta/internal/common/auth"
 	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	auditmodel "github.com/drunkleen/rasta/internal/models/audit"
 	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	auditrepository "github.com/drunkleen/rasta/internal/repository/audit"
 	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	auditservice "github.com/drunkleen/rasta/internal/service/audit"
 	userservice "github.com/drunkleen/rasta/internal/service/user"
 	"github.com/drunkleen/rasta/pkg/database"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
+// apiKeyScheme is the Authorization scheme API keys are sent with.
+const apiKeyScheme = "ApiKey "
+
+// impersonationHeader is the response header marking requests made by an admin impersonating a user.
+// It holds the ID of the admin.
+const impersonationHeader = "X-Impersonated-By"
+
 var (
-	userRepository = userrepository.NewUserRepository(database.DB)
-	userService    = userservice.NewUserService(userRepository)
+	userService    *userservice.UserService
+	sessionService *userservice.SessionService
+	roleService    *userservice.RoleService
+	apiKeyService  *userservice.ApiKeyService
+	auditService   *auditservice.AuditService
+	servicesOnce   sync.Once
 )
 
+// loadServices creates the services used by the middlewares.
+//
+// The services are created on first use rather than at package initialization,
+// because the database connection is only opened once the application starts.
+func loadServices() {
+	servicesOnce.Do(func() {
+		userService = userservice.NewUserService(userrepository.NewUserRepository(database.DB))
+		sessionService = userservice.NewSessionService(userrepository.NewSessionRepository(database.DB))
+		roleService = userservice.NewRoleService(userrepository.NewRoleRepository(database.DB))
+		apiKeyService = userservice.NewApiKeyService(userrepository.NewApiKeyRepository(database.DB))
+		auditService = auditservice.NewAuditService(auditrepository.NewAuditRepository(database.DB))
+	})
+}
+
 // extractAndValidateToken extracts and validates a JWT token from the Authorization header.
 //
 // If the JWT token is empty, the function returns an error.
 // If the token is invalid, the function returns an error.
-// If the token is valid, the function returns the user ID and email associated with the token.
+// If the token itself or the session it belongs to has been revoked, the function returns an error.
+// If the account the token was issued to no longer exists or is suspended, the function returns an error.
+// If the token is an impersonation token and its admin is suspended or may no longer impersonate users,
+// the function returns an error.
+// If the token is valid, the function returns the claims carried by the token and the user it was issued to.
 //
 // Parameters:
 // c *gin.Context is the gin context.
 //
 // Returns:
-// uuid.UUID is the user ID associated with the token.
-// string is the user email associated with the token.
+// *auth.Claims is the claims carried by the token.
+// *usermodel.User is the user the token was issued to.
 // error is an error object that is returned if the token is invalid or empty.
-func extractAndValidateToken(c *gin.Context) (uuid.UUID, string, error) {
+func extractAndValidateToken(c *gin.Context) (*auth.Claims, *usermodel.User, error) {
+	loadServices()
 	token := c.GetHeader("Authorization")
 	if token == "" {
-		return uuid.Nil, "", errors.New(commonerrors.ErrUnauthorizedToken)
+		return nil, nil, errors.New(commonerrors.ErrUnauthorizedToken)
+	}
+	claims, err := auth.ParseJWTToken(token)
+	if err != nil {
+		return nil, nil, errors.New(commonerrors.ErrUnauthorizedToken)
+	}
+	userId, err := uuid.Parse(claims.UserId)
+	if err != nil {
+		return nil, nil, errors.New(commonerrors.ErrUnauthorizedToken)
 	}
-	userIdStr, userEmail, err := auth.ValidateJWTToken(token)
+	sessionId, err := uuid.Parse(claims.SessionId)
 	if err != nil {
-		return uuid.Nil, "", errors.New(commonerrors.ErrUnauthorizedToken)
+		return nil, nil, errors.New(commonerrors.ErrUnauthorizedToken)
 	}
-	userId, err := uuid.Parse(userIdStr)
+	if sessionService.IsTokenRevoked(claims.Id) {
+		return nil, nil, errors.New(commonerrors.ErrTokenRevoked)
+	}
+	if !sessionService.Authenticate(sessionId) {
+		return nil, nil, errors.New(commonerrors.ErrSessionRevoked)
+	}
+	userModel, err := userService.FindById(userId)
 	if err != nil {
-		return uuid.Nil, "", errors.New(commonerrors.ErrUnauthorizedToken)
+		return nil, nil, errors.New(commonerrors.ErrUnauthorizedToken)
+	}
+	if userModel.IsSuspended() {
+		return nil, nil, errors.New(commonerrors.ErrAccountSuspended)
+	}
+	if claims.Actor != nil && !mayImpersonate(claims.Actor) {
+		return nil, nil, errors.New(commonerrors.ErrUnauthorizedToken)
+	}
+	return claims, userModel, nil
+}
+
+// mayImpersonate reports whether the admin an impersonation token was issued to still exists,
+// is not suspended and still holds the permission to impersonate users.
+func mayImpersonate(actor *auth.Actor) bool {
+	actorId, err := uuid.Parse(actor.Subject)
+	if err != nil {
+		return false
+	}
+	actorModel, err := userService.FindById(actorId)
+	if err != nil || actorModel.IsSuspended() {
+		return false
+	}
+	return roleService.HasPermissions(actorModel, usermodel.PermissionUsersImpersonate)
+}
+
+// setImpersonationContext marks a request made with an impersonation token: the admin is stored in
+// the gin context as "actorId" and returned in the X-Impersonated-By response header.
+func setImpersonationContext(c *gin.Context, claims *auth.Claims) {
+	c.Set("actorId", claims.Actor.Subject)
+	c.Header(impersonationHeader, claims.Actor.Subject)
+}
+
+// recordImpersonatedRequest records a request made with an impersonation token in the audit log,
+// once it has been handled. Failures are logged, since the response has already been written.
+func recordImpersonatedRequest(c *gin.Context, claims *auth.Claims) {
+	entry := &auditmodel.AuditLog{
+		Action:    auditmodel.ActionImpersonationRequest,
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		Status:    c.Writer.Status(),
+		IpAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	if actorId, err := uuid.Parse(claims.Actor.Subject); err == nil {
+		entry.ActorId = &actorId
+	}
+	if userId, err := uuid.Parse(claims.UserId); err == nil {
+		entry.UserId = &userId
+	}
+	if sessionId, err := uuid.Parse(claims.SessionId); err == nil {
+		entry.SessionId = &sessionId
+	}
+	if err := auditService.Record(entry); err != nil {
+		log.Printf("failed to record impersonated request %s %s: %v", entry.Method, entry.Path, err)
+	}
+}
+
+// rejectImpersonation aborts a request made with an impersonation token to a route admins may not
+// use while impersonating, and records it in the audit log.
+func rejectImpersonation(c *gin.Context, claims *auth.Claims) {
+	setImpersonationContext(c, claims)
+	c.AbortWithStatusJSON(http.StatusForbidden, commonerrors.NewErrorMap(commonerrors.ErrImpersonationForbidden))
+	recordImpersonatedRequest(c, claims)
+}
+
+// hasApiKey reports whether the request is authenticated with an API key rather than a JWT token.
+func hasApiKey(c *gin.Context) bool {
+	return strings.HasPrefix(c.GetHeader("Authorization"), apiKeyScheme)
+}
+
+// extractAndValidateApiKey extracts and validates an API key sent as "Authorization: ApiKey <key>".
+//
+// If the key is unknown or has expired, the function returns an error.
+// If the account the key was issued to no longer exists or is suspended, the function returns an error.
+// If the key is valid, the function records its use and returns the key and its owner.
+//
+// Parameters:
+// c *gin.Context is the gin context.
+//
+// Returns:
+// *usermodel.ApiKey is the key the request was made with.
+// *usermodel.User is the owner of the key.
+// error is an error object that is returned if the key is invalid.
+func extractAndValidateApiKey(c *gin.Context) (*usermodel.ApiKey, *usermodel.User, error) {
+	loadServices()
+	secret := strings.TrimSpace(strings.TrimPrefix(c.GetHeader("Authorization"), apiKeyScheme))
+	key, err := apiKeyService.Authenticate(secret, c.ClientIP())
+	if err != nil {
+		return nil, nil, err
+	}
+	userModel, err := userService.FindById(key.UserId)
+	if err != nil {
+		return nil, nil, errors.New(commonerrors.ErrInvalidApiKey)
+	}
+	if userModel.IsSuspended() {
+		return nil, nil, errors.New(commonerrors.ErrAccountSuspended)
 	}
-	return userId, userEmail, nil
+	return key, userModel, nil
+}
+
+// limitApiKey applies the rate limit of an API key and reports the remaining requests in the
+// X-RateLimit headers. Requests over the limit are aborted with 429 Too Many Requests.
+//
+// Returns whether the request may proceed.
+func limitApiKey(c *gin.Context, key *usermodel.ApiKey) bool {
+	allowed, remaining, retryAfter := apiKeyLimiter.allow(key.Id, key.RateLimit)
+	c.Header("X-RateLimit-Limit", strconv.Itoa(key.RateLimit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	if !allowed {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, commonerrors.NewErrorMap(commonerrors.ErrRateLimited))
+		return false
+	}
+	return true
+}
+
+// abortUnauthenticated aborts a request whose token was rejected by extractAndValidateToken.
+//
+// Suspended accounts are answered with 403 Forbidden so clients can tell them apart from
+// invalid tokens, every other error with 401 Unauthorized.
+func abortUnauthenticated(c *gin.Context, err error) {
+	status := http.StatusUnauthorized
+	if err.Error() == commonerrors.ErrAccountSuspended {
+		status = http.StatusForbidden
+	}
+	c.AbortWithStatusJSON(status, commonerrors.NewErrorMap(err.Error()))
+}
+
+// setTokenContext stores the session and token identifiers of the request in the gin context,
+// so handlers can revoke the token the request was made with.
+func setTokenContext(c *gin.Context, claims *auth.Claims) {
+	c.Set("sessionId", claims.SessionId)
+	c.Set("tokenId", claims.Id)
+	c.Set("tokenExpiresAt", time.Unix(claims.ExpiresAt, 0))
 }
 
 // JWTAuthMiddleware authenticates a user by validating the JWT token in the Authorization header.
 //
+// Requests made by an admin impersonating the user are marked with the X-Impersonated-By header
+// and recorded in the audit log.
+//
 // Parameter c *gin.Context is the gin context.
 //
 // Returns None
 func JWTAuthMiddleware(c *gin.Context) {
-	token := c.GetHeader("Authorization")
-	if token == "" {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, commonerrors.NewErrorMap(commonerrors.ErrUnauthorizedToken))
+	claims, _, err := extractAndValidateToken(c)
+	if err != nil {
+		abortUnauthenticated(c, err)
+		return
+	}
+	c.Set("userId", claims.UserId)
+	c.Set("userEmail", claims.Email)
+	setTokenContext(c, claims)
+	if claims.Actor != nil {
+		setImpersonationContext(c, claims)
+		c.Next()
+		recordImpersonatedRequest(c, claims)
+		return
+	}
+	c.Next()
+}
+
+// DenyImpersonation refuses requests made by an admin impersonating a user with 403 Forbidden.
+// It guards the routes changing the password, second factors or other credentials of the user,
+// and must run after JWTAuthMiddleware.
+//
+// Parameter c *gin.Context is the gin context.
+//
+// Returns None
+func DenyImpersonation(c *gin.Context) {
+	if _, ok := c.Get("actorId"); ok {
+		c.AbortWithStatusJSON(http.StatusForbidden, commonerrors.NewErrorMap(commonerrors.ErrImpersonationForbidden))
+		return
+	}
+	c.Next()
+}
+
+// ApiKeyAuthMiddleware authenticates a user either by an API key sent as "Authorization: ApiKey <key>",
+// or by a JWT token like JWTAuthMiddleware.
+//
+// Requests made with an API key are rate limited per key. They carry no session, so routes managing
+// sessions or credentials must keep using JWTAuthMiddleware.
+//
+// Parameter c *gin.Context is the gin context.
+//
+// Returns None
+func ApiKeyAuthMiddleware(c *gin.Context) {
+	if !hasApiKey(c) {
+		JWTAuthMiddleware(c)
 		return
 	}
-	userId, userEmail, err := auth.ValidateJWTToken(token)
+	key, userModel, err := extractAndValidateApiKey(c)
 	if err != nil {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, commonerrors.NewErrorMap(commonerrors.ErrUnauthorizedToken))
+		abortUnauthenticated(c, err)
+		return
+	}
+	if !limitApiKey(c, key) {
 		return
 	}
-	c.Set("userId", userId)
-	c.Set("userEmail", userEmail)
+	c.Set("userId", userModel.Id.String())
+	c.Set("userEmail", userModel.Email)
+	c.Set("apiKeyId", key.Id.String())
 	c.Next()
 }
 
-// AdminAuthMiddleware is a middleware function that authenticates and authorizes admin users.
+// RequirePermission returns a middleware that authenticates a user and authorizes the request
+// only if the user holds every one of the given permissions, either through the roles assigned
+// to the user or through the Admin account type.
+//
+// Requests may also be made with an API key, which must have been granted every one of the
+// permissions as well, and are rate limited per key. Requests made by an admin impersonating a
+// user are refused, so impersonation never grants the permissions of the user.
 //
 // Parameters:
-// c *gin.Context is the gin context.
+// permissions ...usermodel.Permission are the permissions required by the route.
 //
 // Returns:
-// None
-func AdminAuthMiddleware(c *gin.Context) {
-	userId, userEmail, err := extractAndValidateToken(c)
+// gin.HandlerFunc is the middleware.
+func RequirePermission(permissions ...usermodel.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if hasApiKey(c) {
+			requireApiKeyPermission(c, permissions)
+			return
+		}
+		claims, userModel, err := extractAndValidateToken(c)
+		if err != nil {
+			abortUnauthenticated(c, err)
+			return
+		}
+		if claims.Actor != nil {
+			rejectImpersonation(c, claims)
+			return
+		}
+		if !roleService.HasPermissions(userModel, permissions...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, commonerrors.NewErrorMap(commonerrors.ErrForbidden))
+			return
+		}
+		c.Set("userId", userModel.Id.String())
+		c.Set("userEmail", claims.Email)
+		c.Set("userModel", userModel)
+		setTokenContext(c, claims)
+		c.Next()
+	}
+}
+
+// requireApiKeyPermission authenticates a request made with an API key and authorizes it only if
+// both the key and its owner hold every one of the given permissions.
+func requireApiKeyPermission(c *gin.Context, permissions []usermodel.Permission) {
+	key, userModel, err := extractAndValidateApiKey(c)
 	if err != nil {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, commonerrors.NewErrorMap(err.Error()))
+		abortUnauthenticated(c, err)
 		return
 	}
-	userModel, err := userService.FindById(userId)
-	if err != nil {
-		c.AbortWithStatusJSON(http.StatusUnauthorized, commonerrors.NewErrorMap(err.Error()))
+	if !limitApiKey(c, key) {
 		return
 	}
-	if userModel.Account != usermodel.AccountTypeAdmin {
+	if !key.HasScopes(permissions...) || !roleService.HasPermissions(userModel, permissions...) {
 		c.AbortWithStatusJSON(http.StatusForbidden, commonerrors.NewErrorMap(commonerrors.ErrForbidden))
 		return
 	}
-	c.Set("userId", userModel.Id)
-	c.Set("userEmail", userEmail)
+	c.Set("userId", userModel.Id.String())
+	c.Set("userEmail", userModel.Email)
 	c.Set("userModel", userModel)
+	c.Set("apiKeyId", key.Id.String())
+	c.Set("apiKey", key)
 	c.Next()
 }
@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// rateWindow counts the requests made with an API key in the current minute.
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// rateLimiter limits the requests made with every API key to a number per minute, in fixed
+// one-minute windows. Counts are kept in memory, so every instance enforces the limit on its own.
+type rateLimiter struct {
+	mu        sync.Mutex
+	windows   map[uuid.UUID]*rateWindow
+	lastSweep time.Time
+}
+
+// apiKeyLimiter is the rate limiter shared by every route accepting API keys.
+var apiKeyLimiter = &rateLimiter{windows: make(map[uuid.UUID]*rateWindow)}
+
+// allow records a request made with a key and reports whether it is within limit.
+//
+// It also returns how many requests are left in the current window and, when the
+// request is refused, how long until the window resets.
+func (l *rateLimiter) allow(id uuid.UUID, limit int) (bool, int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if now.Sub(l.lastSweep) > time.Minute {
+		for key, window := range l.windows {
+			if now.Sub(window.start) >= time.Minute {
+				delete(l.windows, key)
+			}
+		}
+		l.lastSweep = now
+	}
+	window, ok := l.windows[id]
+	if !ok || now.Sub(window.start) >= time.Minute {
+		window = &rateWindow{start: now}
+		l.windows[id] = window
+	}
+	if window.count >= limit {
+		return false, 0, window.start.Add(time.Minute).Sub(now)
+	}
+	window.count++
+	return true, limit - window.count, 0
+}
@@ -0,0 +1,37 @@
+package auditmodel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Action identifies what an audit log entry records.
+type Action string
+
+// Constants representing the recorded actions.
+const (
+	// ActionImpersonationStart records an admin starting to impersonate a user, together with the reason given.
+	ActionImpersonationStart Action = "impersonation.start"
+	// ActionImpersonationRequest records a request made by an admin while impersonating a user.
+	ActionImpersonationRequest Action = "impersonation.request"
+)
+
+// AuditLog is an entry of the audit log. Entries are never updated or deleted.
+//
+// ActorId is the account that performed the action and UserId the account it was performed on
+// or on behalf of. Requests are described by their method, path, response status and origin.
+type AuditLog struct {
+	Id        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	Action    Action     `json:"action" gorm:"size:64;not null;index"`
+	ActorId   *uuid.UUID `json:"actor_id,omitempty" gorm:"type:uuid;index"`
+	UserId    *uuid.UUID `json:"user_id,omitempty" gorm:"type:uuid;index"`
+	SessionId *uuid.UUID `json:"session_id,omitempty" gorm:"type:uuid;index"`
+	Method    string     `json:"method,omitempty" gorm:"size:16"`
+	Path      string     `json:"path,omitempty" gorm:"size:512"`
+	Status    int        `json:"status,omitempty"`
+	IpAddress string     `json:"ip_address,omitempty" gorm:"size:64"`
+	UserAgent string     `json:"user_agent,omitempty" gorm:"size:512"`
+	Detail    string     `json:"detail,omitempty" gorm:"size:512"`
+	CreatedAt time.Time  `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp;index"`
+}
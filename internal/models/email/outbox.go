@@ -0,0 +1,58 @@
+package emailmodel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxStatus represents the delivery of an email of the outbox.
+type OutboxStatus string
+
+// Constants representing the outbox statuses.
+const (
+	// OutboxStatusQueued is an email waiting to be delivered, or to be retried.
+	OutboxStatusQueued OutboxStatus = "queued"
+	// OutboxStatusSent is an email accepted by the mail server.
+	OutboxStatusSent OutboxStatus = "sent"
+	// OutboxStatusFailed is an email given up on after EMAIL_OUTBOX_MAX_ATTEMPTS failed attempts.
+	OutboxStatusFailed OutboxStatus = "failed"
+	// OutboxStatusBounced is an email the mail server refused for good, such as for an unknown recipient.
+	OutboxStatusBounced OutboxStatus = "bounced"
+)
+
+// Kinds of emails, telling admins what an email of the outbox was sent for.
+const (
+	OutboxKindVerifyEmail       = "verify_email"
+	OutboxKindResetPassword     = "reset_password"
+	OutboxKindNewsletter        = "newsletter"
+	OutboxKindAccountStatus     = "account_status"
+	OutboxKindAccountLocked     = "account_locked"
+	OutboxKindLoginLink         = "login_link"
+	OutboxKindEmailChangeCode   = "email_change_code"
+	OutboxKindEmailChangeNotice = "email_change_notice"
+	OutboxKindAccountDeletion   = "account_deletion"
+	OutboxKindNewLogin          = "new_login"
+)
+
+// EmailOutbox is an email stored to be delivered in the background, written in the same transaction
+// as what it is sent for so that neither is lost without the other.
+//
+// Emails failing to be delivered are retried at NextAttemptAt, with an exponential backoff. The body
+// is encrypted, as it may hold codes and links signing users in.
+type EmailOutbox struct {
+	Id            uuid.UUID    `json:"id" gorm:"type:uuid;primaryKey"`
+	UserId        *uuid.UUID   `json:"user_id,omitempty" gorm:"type:uuid;index"`
+	Kind          string       `json:"kind" gorm:"size:32;not null;index"`
+	Sender        string       `json:"sender" gorm:"size:254;not null"`
+	Recipient     string       `json:"recipient" gorm:"size:254;not null;index"`
+	Subject       string       `json:"subject" gorm:"size:256;not null"`
+	Body          string       `json:"-" gorm:"type:text;not null;serializer:encrypted"`
+	Status        OutboxStatus `json:"status" gorm:"size:16;not null;index"`
+	Attempts      int          `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time    `json:"next_attempt_at" gorm:"type:timestamp with time zone;not null;index"`
+	LastError     string       `json:"last_error,omitempty" gorm:"size:512"`
+	SentAt        *time.Time   `json:"sent_at,omitempty" gorm:"type:timestamp with time zone"`
+	CreatedAt     time.Time    `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp;index"`
+	UpdatedAt     time.Time    `json:"updated_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+}
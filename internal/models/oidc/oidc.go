@@ -0,0 +1,81 @@
+package oidcmodel
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Constants representing the scopes partner apps may request.
+const (
+	ScopeOpenId  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// Scopes lists every scope known to the application, openid first.
+var Scopes = []string{ScopeOpenId, ScopeProfile, ScopeEmail}
+
+// OidcClient is a partner app allowed to sign users in with their Rasta account. Its
+// ID is the client_id of the app. Confidential clients authenticate at the token
+// endpoint with a secret, of which only the SHA-256 hash is stored; public clients
+// have no secret and rely on PKCE alone.
+type OidcClient struct {
+	Id           uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	Name         string    `json:"name" gorm:"size:64;not null"`
+	SecretHash   string    `json:"-" gorm:"size:64"`
+	RedirectUris string    `json:"-" gorm:"type:text;not null"`
+	CreatedAt    time.Time `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+}
+
+// IsPublic reports whether the client has no secret.
+func (c *OidcClient) IsPublic() bool {
+	return c.SecretHash == ""
+}
+
+// GetRedirectUris returns the URIs the client may be redirected to, stored one per line.
+func (c *OidcClient) GetRedirectUris() []string {
+	return strings.Fields(c.RedirectUris)
+}
+
+// AllowsRedirectUri reports whether uri is registered for the client. URIs must match exactly.
+func (c *OidcClient) AllowsRedirectUri(uri string) bool {
+	for _, redirectUri := range c.GetRedirectUris() {
+		if redirectUri == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// OidcConsent records the scopes a user allowed a partner app to access, so the
+// user is only asked again when the app requests more.
+type OidcConsent struct {
+	Id        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	UserId    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_oidc_consent"`
+	ClientId  uuid.UUID `json:"client_id" gorm:"type:uuid;not null;uniqueIndex:idx_oidc_consent"`
+	Scope     string    `json:"scope" gorm:"size:256;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+
+	Client OidcClient `json:"-" gorm:"foreignKey:ClientId"`
+}
+
+// OidcAuthorizationCode is a single-use code a partner app exchanges for tokens. It
+// is bound to the redirect URI and the S256 PKCE challenge of the authorization
+// request. Only the SHA-256 hash of the code is stored.
+type OidcAuthorizationCode struct {
+	Id            uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	CodeHash      string     `json:"-" gorm:"size:64;unique;not null"`
+	ClientId      uuid.UUID  `json:"client_id" gorm:"type:uuid;not null;index"`
+	UserId        uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	RedirectUri   string     `json:"redirect_uri" gorm:"type:text;not null"`
+	Scope         string     `json:"scope" gorm:"size:256;not null"`
+	Nonce         string     `json:"-" gorm:"size:256"`
+	CodeChallenge string     `json:"-" gorm:"size:128;not null"`
+	UsedAt        *time.Time `json:"used_at,omitempty" gorm:"type:timestamp with time zone"`
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"type:timestamp with time zone;not null;index"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+}
@@ -6,10 +6,11 @@ import (
 	"github.com/google/uuid"
 )
 
+// TicketComment is a message posted on a ticket, by the user who opened it or by staff.
 type TicketComment struct {
 	Id        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
-	TicketId  uuid.UUID `json:"ticket_id" gorm:"not null"`
-	UserId    uuid.UUID `json:"user_id" gorm:"not null"`
+	TicketId  uuid.UUID `json:"ticket_id" gorm:"type:uuid;not null;index"`
+	UserId    uuid.UUID `json:"user_id" gorm:"type:uuid;not null"`
 	Comment   string    `json:"comment" gorm:"type:text;not null"`
 	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
 }
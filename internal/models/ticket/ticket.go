@@ -33,15 +33,56 @@ const (
 	TicketCategoryGeneralQuery   TicketCategory = "general query"
 )
 
+// TicketStatuses lists every status a ticket can have.
+var TicketStatuses = []TicketStatus{TicketStatusOpen, TicketStatusInProgress, TicketStatusResolved, TicketStatusClosed}
+
+// IsValid reports whether the status is one of the known statuses.
+func (s TicketStatus) IsValid() bool {
+	for _, status := range TicketStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// TicketPriorities lists every priority a ticket can have.
+var TicketPriorities = []TicketPriority{TicketPriorityLow, TicketPriorityMedium, TicketPriorityHigh, TicketPriorityUrgent}
+
+// IsValid reports whether the priority is one of the known priorities.
+func (p TicketPriority) IsValid() bool {
+	for _, priority := range TicketPriorities {
+		if p == priority {
+			return true
+		}
+	}
+	return false
+}
+
+// TicketCategories lists every category a ticket can be filed under.
+var TicketCategories = []TicketCategory{TicketCategoryBugReport, TicketCategoryFeatureRequest, TicketCategoryAccountIssue, TicketCategoryGeneralQuery}
+
+// IsValid reports whether the category is one of the known categories.
+func (c TicketCategory) IsValid() bool {
+	for _, category := range TicketCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Ticket is a support request opened by a user. Staff holding the tickets permissions
+// work on it; AssignedTo is the member of staff handling it, if any.
 type Ticket struct {
 	Id          uuid.UUID       `json:"id" gorm:"type:uuid;primaryKey"`
 	Title       string          `json:"title" gorm:"size:256;not null"`
 	Description string          `json:"description" gorm:"type:text;not null"`
-	Status      TicketStatus    `json:"status" gorm:"type:varchar(32);not null;default:'Open'"`
-	Priority    TicketPriority  `json:"priority" gorm:"type:varchar(32);not null;default:'Medium'"`
+	Status      TicketStatus    `json:"status" gorm:"type:varchar(32);not null;default:'open';index"`
+	Priority    TicketPriority  `json:"priority" gorm:"type:varchar(32);not null;default:'medium'"`
 	Category    TicketCategory  `json:"category" gorm:"type:varchar(32);not null"`
-	UserId      uuid.UUID       `json:"user_id" gorm:"not null"`
-	AssignedTo  uuid.UUID       `json:"assigned_to" gorm:"type:uuid;default:null"`
+	UserId      uuid.UUID       `json:"user_id" gorm:"type:uuid;not null;index"`
+	AssignedTo  *uuid.UUID      `json:"assigned_to" gorm:"type:uuid;index"`
 	CreatedAt   time.Time       `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
 	UpdatedAt   time.Time       `json:"updated_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
 	Comments    []TicketComment `json:"comments" gorm:"foreignKey:TicketId"`
@@ -0,0 +1,27 @@
+package usermodel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountDeletion is a request of a user to delete their account.
+//
+// The account stays usable until ScheduledFor, and the user may cancel the deletion
+// until then. The account is then anonymized: its personal data is erased, the
+// content the user wrote is redacted and everything the user could sign in with
+// is deleted. The row of the user is kept so that records referring to it stay valid.
+type AccountDeletion struct {
+	Id           uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserId       uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	ScheduledFor time.Time  `json:"scheduled_for" gorm:"type:timestamp with time zone;not null;index"`
+	CanceledAt   *time.Time `json:"canceled_at,omitempty" gorm:"type:timestamp with time zone"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty" gorm:"type:timestamp with time zone"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+}
+
+// IsPending reports whether the deletion has neither been canceled nor carried out.
+func (d *AccountDeletion) IsPending() bool {
+	return d.CanceledAt == nil && d.CompletedAt == nil
+}
@@ -0,0 +1,59 @@
+package usermodel
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ApiKey is a long-lived credential an integration authenticates with on behalf
+// of a user or a service account. A key only grants the permissions in its
+// scopes that its owner still holds. Only the SHA-256 hash of the key is
+// stored; Prefix is kept so users can tell their keys apart.
+type ApiKey struct {
+	Id         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserId     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name       string     `json:"name" gorm:"size:64;not null"`
+	Prefix     string     `json:"prefix" gorm:"size:16;not null"`
+	KeyHash    string     `json:"-" gorm:"size:64;unique;not null"`
+	Scopes     string     `json:"scopes" gorm:"size:512"`
+	RateLimit  int        `json:"rate_limit" gorm:"not null"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" gorm:"type:timestamp with time zone"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" gorm:"type:timestamp with time zone"`
+	LastUsedIp string     `json:"last_used_ip" gorm:"size:64"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+}
+
+// GetScopes returns the permissions the key was granted, stored space-separated.
+func (k *ApiKey) GetScopes() []Permission {
+	fields := strings.Fields(k.Scopes)
+	scopes := make([]Permission, len(fields))
+	for i, field := range fields {
+		scopes[i] = Permission(field)
+	}
+	return scopes
+}
+
+// HasScopes reports whether the key was granted every one of the given permissions.
+func (k *ApiKey) HasScopes(permissions ...Permission) bool {
+	scopes := k.GetScopes()
+	for _, permission := range permissions {
+		found := false
+		for _, scope := range scopes {
+			if scope == permission {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// IsExpired reports whether the key has an expiry that has passed.
+func (k *ApiKey) IsExpired() bool {
+	return k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt)
+}
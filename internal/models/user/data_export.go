@@ -0,0 +1,37 @@
+package usermodel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DataExportStatus represents the progress of a data export.
+type DataExportStatus string
+
+// Constants representing the data export statuses.
+const (
+	DataExportStatusPending DataExportStatus = "pending"
+	DataExportStatusReady   DataExportStatus = "ready"
+	DataExportStatusFailed  DataExportStatus = "failed"
+)
+
+// DataExport is a copy of the data held about a user, requested by the user.
+//
+// The export is assembled in the background into a ZIP archive of JSON files, which
+// can be downloaded until ExpiresAt.
+type DataExport struct {
+	Id          uuid.UUID        `json:"id" gorm:"type:uuid;primaryKey"`
+	UserId      uuid.UUID        `json:"user_id" gorm:"type:uuid;not null;index"`
+	Status      DataExportStatus `json:"status" gorm:"size:16;not null"`
+	Archive     []byte           `json:"-" gorm:"type:bytea"`
+	Size        int              `json:"size" gorm:"not null;default:0"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty" gorm:"type:timestamp with time zone"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty" gorm:"type:timestamp with time zone"`
+	CreatedAt   time.Time        `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+}
+
+// IsDownloadable reports whether the archive is ready and has not expired yet.
+func (e *DataExport) IsDownloadable() bool {
+	return e.Status == DataExportStatusReady && e.ExpiresAt != nil && time.Now().Before(*e.ExpiresAt)
+}
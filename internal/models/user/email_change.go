@@ -0,0 +1,37 @@
+package usermodel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailChange is a request to change the email address of a user.
+//
+// The new address receives a code that completes the change, and the old address a link
+// that cancels it, or reverts it once completed, until CancelableUntil. Only the SHA-256
+// hashes of the code and of the token carried by the link are stored.
+type EmailChange struct {
+	Id              uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserId          uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	OldEmail        string     `json:"old_email" gorm:"not null"`
+	NewEmail        string     `json:"new_email" gorm:"not null"`
+	CodeHash        string     `json:"-" gorm:"size:64;not null"`
+	CancelTokenHash string     `json:"-" gorm:"size:64;unique;not null"`
+	Attempts        int        `json:"-" gorm:"not null;default:0"`
+	ExpiresAt       time.Time  `json:"expires_at" gorm:"type:timestamp with time zone;not null"`
+	CancelableUntil time.Time  `json:"cancelable_until" gorm:"type:timestamp with time zone;not null"`
+	VerifiedAt      *time.Time `json:"verified_at,omitempty" gorm:"type:timestamp with time zone"`
+	CanceledAt      *time.Time `json:"canceled_at,omitempty" gorm:"type:timestamp with time zone"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+}
+
+// IsPending reports whether the change still awaits the code sent to the new address.
+func (c *EmailChange) IsPending() bool {
+	return c.VerifiedAt == nil && c.CanceledAt == nil && time.Now().Before(c.ExpiresAt)
+}
+
+// IsCancelable reports whether the old address may still cancel or revert the change.
+func (c *EmailChange) IsCancelable() bool {
+	return c.CanceledAt == nil && time.Now().Before(c.CancelableUntil)
+}
@@ -0,0 +1,30 @@
+package usermodel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KnownDevice is a device a user has signed in from, identified by a fingerprint of its user
+// agent and coarse location. Signing in from a device that is not known yet is notified by
+// email, with a link reporting the login until ReportableUntil. Only the SHA-256 hash of the
+// token carried by the link is stored.
+type KnownDevice struct {
+	Id              uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserId          uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_known_device_fingerprint"`
+	Fingerprint     string     `json:"-" gorm:"size:64;not null;uniqueIndex:idx_known_device_fingerprint"`
+	Device          string     `json:"device" gorm:"size:64"`
+	UserAgent       string     `json:"user_agent" gorm:"size:512"`
+	IpAddress       string     `json:"ip_address" gorm:"size:64"`
+	Location        string     `json:"location" gorm:"size:256"`
+	ReportTokenHash *string    `json:"-" gorm:"size:64;unique"`
+	ReportableUntil *time.Time `json:"-" gorm:"type:timestamp with time zone"`
+	LastSeenAt      time.Time  `json:"last_seen_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+}
+
+// IsReportable reports whether the login that made the device known may still be reported.
+func (d *KnownDevice) IsReportable() bool {
+	return d.ReportTokenHash != nil && d.ReportableUntil != nil && time.Now().Before(*d.ReportableUntil)
+}
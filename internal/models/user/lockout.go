@@ -0,0 +1,25 @@
+package usermodel
+
+import (
+	"time"
+)
+
+// LockoutScope identifies what a failed-attempt counter is kept for.
+type LockoutScope string
+
+// Constants representing the lockout scopes.
+const (
+	LockoutScopeAccount LockoutScope = "account"
+	LockoutScopeIp      LockoutScope = "ip"
+)
+
+// Lockout counts the failed authentication attempts made against an account, or
+// from an IP address. Once too many attempts failed, further attempts are
+// refused until LockedUntil.
+type Lockout struct {
+	Scope        LockoutScope `json:"scope" gorm:"size:16;primaryKey"`
+	Subject      string       `json:"subject" gorm:"size:64;primaryKey"`
+	Failures     int          `json:"failures" gorm:"not null;default:0"`
+	LastFailedAt time.Time    `json:"last_failed_at" gorm:"type:timestamp with time zone;not null"`
+	LockedUntil  *time.Time   `json:"locked_until,omitempty" gorm:"type:timestamp with time zone;index"`
+}
@@ -0,0 +1,21 @@
+package usermodel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginLink is a passwordless sign-in request. The user receives a link
+// carrying an opaque token and a short code by email, either of which signs
+// them in once. Only the SHA-256 hashes of the token and the code are stored.
+type LoginLink struct {
+	Id        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserId    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	TokenHash string     `json:"-" gorm:"size:64;unique;not null"`
+	CodeHash  string     `json:"-" gorm:"size:64;not null"`
+	Attempts  int        `json:"-" gorm:"not null;default:0"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"type:timestamp with time zone;not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty" gorm:"type:timestamp with time zone"`
+	CreatedAt time.Time  `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+}
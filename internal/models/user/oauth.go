@@ -1,9 +1,25 @@
 package usermodel
 
-import "github.com/google/uuid"
+import (
+	"time"
 
+	"github.com/google/uuid"
+)
+
+// OAuth holds the TOTP secret of a user. The secret is encrypted at rest.
 type OAuth struct {
 	UserId  uuid.UUID `json:"user_id,omitempty" gorm:"not null;unique"`
 	Enabled bool      `json:"oauth_enabled,omitempty" gorm:"default:false"`
-	Secret  string    `json:"oauth_secret,omitempty" gorm:"size:512"`
+	Secret  string    `json:"oauth_secret,omitempty" gorm:"type:text;serializer:encrypted"`
+}
+
+// RecoveryCode is a one-time code that can be used instead of a TOTP code when
+// the user has lost access to their authenticator. Only the SHA-256 hash of the
+// code is stored.
+type RecoveryCode struct {
+	Id        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserId    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	CodeHash  string     `json:"-" gorm:"size:64;not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty" gorm:"type:timestamp with time zone"`
+	CreatedAt time.Time  `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
 }
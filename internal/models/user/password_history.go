@@ -0,0 +1,15 @@
+package usermodel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasswordHistory is a hash of a password a user had before, kept so that recent passwords are not reused.
+type PasswordHistory struct {
+	Id        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	UserId    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	Password  string    `json:"-" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+}
@@ -0,0 +1,88 @@
+package usermodel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Permission represents a single action a user may be allowed to perform.
+type Permission string
+
+// Constants representing the available permissions.
+const (
+	PermissionUsersRead        Permission = "users.read"
+	PermissionUsersWrite       Permission = "users.write"
+	PermissionUsersImpersonate Permission = "users.impersonate"
+	PermissionRolesRead        Permission = "roles.read"
+	PermissionRolesWrite       Permission = "roles.write"
+
+	PermissionNewsletterRead   Permission = "newsletter.read"
+	PermissionNewsletterSend   Permission = "newsletter.send"
+	PermissionNewsletterDelete Permission = "newsletter.delete"
+
+	PermissionTicketsRead   Permission = "tickets.read"
+	PermissionTicketsWrite  Permission = "tickets.write"
+	PermissionTicketsAssign Permission = "tickets.assign"
+
+	PermissionClientsRead  Permission = "clients.read"
+	PermissionClientsWrite Permission = "clients.write"
+
+	PermissionAuditRead Permission = "audit.read"
+
+	PermissionEmailsRead  Permission = "emails.read"
+	PermissionEmailsWrite Permission = "emails.write"
+)
+
+// Permissions lists every permission known to the application.
+var Permissions = []Permission{
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionUsersImpersonate,
+	PermissionRolesRead,
+	PermissionRolesWrite,
+	PermissionNewsletterRead,
+	PermissionNewsletterSend,
+	PermissionNewsletterDelete,
+	PermissionTicketsRead,
+	PermissionTicketsWrite,
+	PermissionTicketsAssign,
+	PermissionClientsRead,
+	PermissionClientsWrite,
+	PermissionAuditRead,
+	PermissionEmailsRead,
+	PermissionEmailsWrite,
+}
+
+// IsValid reports whether the permission is one of the known permissions.
+func (p Permission) IsValid() bool {
+	for _, permission := range Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Role is a named set of permissions that can be assigned to users.
+//
+// Users with the Admin account type are granted every permission regardless of their roles.
+type Role struct {
+	Id          uuid.UUID        `json:"id" gorm:"type:uuid;primaryKey"`
+	Name        string           `json:"name" gorm:"size:64;unique;not null"`
+	Description string           `json:"description" gorm:"size:256"`
+	CreatedAt   time.Time        `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+	UpdatedAt   time.Time        `json:"updated_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+	Permissions []RolePermission `json:"permissions" gorm:"foreignKey:RoleId"`
+}
+
+type RolePermission struct {
+	RoleId     uuid.UUID  `json:"-" gorm:"type:uuid;primaryKey"`
+	Permission Permission `json:"permission" gorm:"size:64;primaryKey"`
+}
+
+type UserRole struct {
+	UserId    uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey"`
+	RoleId    uuid.UUID `json:"role_id" gorm:"type:uuid;primaryKey"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+}
@@ -0,0 +1,44 @@
+package usermodel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session represents a signed-in device. Every refresh token issued for the
+// device belongs to the same session, so revoking the session revokes the
+// whole refresh-token family at once.
+//
+// Sessions started by an admin impersonating the user record the admin in
+// ImpersonatorId. They have no refresh tokens and cannot be extended.
+type Session struct {
+	Id             uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserId         uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	ImpersonatorId *uuid.UUID `json:"impersonator_id,omitempty" gorm:"type:uuid;index"`
+	UserAgent      string     `json:"user_agent" gorm:"size:512"`
+	IpAddress      string     `json:"ip_address" gorm:"size:64"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"type:timestamp with time zone;not null"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty" gorm:"type:timestamp with time zone"`
+	LastSeenAt     time.Time  `json:"last_seen_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+}
+
+// RefreshToken is a single-use refresh token. Only the SHA-256 hash of the
+// opaque token handed to the client is stored.
+type RefreshToken struct {
+	Id        uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	SessionId uuid.UUID  `json:"session_id" gorm:"type:uuid;not null;index"`
+	TokenHash string     `json:"-" gorm:"size:64;unique;not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty" gorm:"type:timestamp with time zone"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"type:timestamp with time zone;not null"`
+	CreatedAt time.Time  `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+}
+
+// RevokedToken is an access token that has been revoked before its expiry,
+// identified by its jti claim. Entries can be dropped once they expire.
+type RevokedToken struct {
+	TokenId   string    `json:"token_id" gorm:"size:64;primaryKey"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"type:timestamp with time zone;not null;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+}
@@ -0,0 +1,16 @@
+package usermodel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SignupAttempt records an account signed up from an IP address, so that the number of accounts
+// signed up from an address can be limited. It is not linked to the account and is deleted once
+// it falls out of the quota window.
+type SignupAttempt struct {
+	Id        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	IpAddress string    `json:"ip_address" gorm:"size:64;not null;index:idx_signup_attempt_ip"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:timestamp with time zone;not null;index:idx_signup_attempt_ip"`
+}
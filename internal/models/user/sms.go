@@ -0,0 +1,38 @@
+package usermodel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SmsPurpose is what a code sent by SMS can be used for.
+type SmsPurpose string
+
+const (
+	// SmsPurposeVerify verifies the account, and the phone number, of a new user.
+	SmsPurposeVerify SmsPurpose = "verify"
+	// SmsPurposeResetPassword resets the password of a user.
+	SmsPurposeResetPassword SmsPurpose = "reset_password"
+	// SmsPurposeLogin is the second factor of a user with SMS two-factor authentication enabled.
+	SmsPurposeLogin SmsPurpose = "login"
+	// SmsPurposePhone proves a signed-in user owns their phone number, to verify it or to
+	// disable SMS two-factor authentication.
+	SmsPurposePhone SmsPurpose = "phone"
+)
+
+// SmsCode is a one-time code sent by SMS. A user has at most one code per purpose, bound
+// to the phone number it was sent to. Only the hash of the code is stored.
+//
+// A sign-in code is also bound to the sign-in whose first factor has been checked, by the
+// SHA-256 hash of a token handed to the client, so the sign-in can be finished with it.
+type SmsCode struct {
+	UserId    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_sms_code_user_purpose"`
+	Purpose   SmsPurpose `json:"purpose" gorm:"size:16;not null;uniqueIndex:idx_sms_code_user_purpose"`
+	Phone     string     `json:"phone" gorm:"size:16;not null"`
+	Code      string     `json:"-" gorm:"size:256;not null"`
+	Expiry    time.Time  `json:"expiry" gorm:"type:timestamp with time zone;not null"`
+	Attempts  int        `json:"-" gorm:"not null;default:0"`
+	TokenHash *string    `json:"-" gorm:"size:64;unique"`
+	CreatedAt time.Time  `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+}
@@ -0,0 +1,37 @@
+package usermodel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Identity links an account at an external identity provider, known by the
+// subject the provider identifies it with, to a user.
+type Identity struct {
+	Id         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserId     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Provider   string     `json:"provider" gorm:"size:32;not null;uniqueIndex:idx_identity_subject"`
+	Subject    string     `json:"-" gorm:"size:255;not null;uniqueIndex:idx_identity_subject"`
+	Email      string     `json:"email" gorm:"size:128"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" gorm:"type:timestamp with time zone"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+}
+
+// SocialState keeps a sign-in at an identity provider between the redirect to
+// the provider and the callback. A state bound to a user links the identity to
+// that user instead of signing in. Once the provider has been called back,
+// IdentityId is set, so a sign-in waiting for a second factor can be retried
+// without a new authorization code. Only the SHA-256 hash of the state is stored,
+// and the provider session and the PKCE code verifier are encrypted at rest.
+type SocialState struct {
+	Id         uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	StateHash  string     `json:"-" gorm:"size:64;unique;not null"`
+	Provider   string     `json:"provider" gorm:"size:32;not null"`
+	UserId     *uuid.UUID `json:"user_id,omitempty" gorm:"type:uuid;index"`
+	Session    string     `json:"-" gorm:"type:text;not null;serializer:encrypted"`
+	Verifier   string     `json:"-" gorm:"type:text;not null;default:'';serializer:encrypted"`
+	Nonce      string     `json:"-" gorm:"size:64;not null;default:''"`
+	IdentityId *uuid.UUID `json:"identity_id,omitempty" gorm:"type:uuid"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"type:timestamp with time zone;not null;index"`
+}
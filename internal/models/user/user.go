@@ -45,12 +45,36 @@ const (
 	Polynesia              RegionType = "Polynesia"
 )
 
+// Regions lists every valid region.
+var Regions = []RegionType{
+	RegionTypeNorthernAmerica, RegionTypeCentralAmerica, RegionTypeCaribbean,
+	NorthernSouthAmerica, SouthernSouthAmerica, WesternSouthAmerica, EasternSouthAmerica,
+	RegionTypeScandinavia, RegionTypeSouthernEurope, RegionTypeWesternEurope, RegionTypeEasternEurope, RegionTypeCentralEurope,
+	RegionTypeMiddleEast, RegionTypeCentralAsia, RegionTypeEasternAsia, RegionTypeSouthernAsia, RegionTypeSoutheasternAsia, RegionTypeSiberia,
+	RegionTypeNorthernAfrica, RegionTypeWesternAfrica, RegionTypeCentralAfrica, RegionTypeHornOfAfrica, RegionTypeSouthernAfrica,
+	AustraliaAndNewZealand, Melanesia, Micronesia, Polynesia,
+}
+
+// IsValid reports whether the region is one of Regions.
+func (r RegionType) IsValid() bool {
+	for _, region := range Regions {
+		if r == region {
+			return true
+		}
+	}
+	return false
+}
+
 type AccountType string
 
 const (
 	AccountTypeNormal AccountType = "User"
 	AccountTypeSeller AccountType = "Seller"
 	AccountTypeAdmin  AccountType = "Admin"
+
+	// AccountTypeService is a non-human account used by integrations. It cannot
+	// sign in and authenticates with API keys only.
+	AccountTypeService AccountType = "Service"
 )
 
 type User struct {
@@ -58,7 +82,7 @@ type User struct {
 	FirstName  string      `json:"first_name" gorm:"size:64;not null"`
 	LastName   string      `json:"last_name" gorm:"size:64;not null"`
 	Username   string      `json:"username" gorm:"size:64;unique;not null"`
-	Email      string      `json:"email" gorm:"size:128;unique;not null"`
+	Email      string      `json:"email" gorm:"size:254;unique;not null"`
 	Password   string      `json:"password" gorm:"size:256;not null"`
 	IsVerified bool        `json:"is_verified" gorm:"default:false"`
 	IsDisabled bool        `json:"is_disabled" gorm:"default:false"`
@@ -67,12 +91,46 @@ type User struct {
 	CreatedAt  time.Time   `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
 	UpdatedAt  time.Time   `json:"updated_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
 
+	// CanonicalEmail is Email without its plus tag, so that accounts for the same mailbox can be
+	// told apart from accounts for different ones. See utils.CanonicalEmail.
+	CanonicalEmail string `json:"-" gorm:"size:254;not null;default:'';index"`
+
+	DisabledReason string     `json:"disabled_reason" gorm:"size:256"`
+	DisabledUntil  *time.Time `json:"disabled_until" gorm:"type:timestamp with time zone"`
+
+	// PasswordResetRequired is set when the user reports a login that was not theirs. Signing in
+	// with the password is refused until the password has been reset.
+	PasswordResetRequired bool `json:"password_reset_required" gorm:"not null;default:false"`
+
+	DisplayName string `json:"display_name" gorm:"size:64"`
+	Locale      string `json:"locale" gorm:"size:35"`
+	Timezone    string `json:"timezone" gorm:"size:64"`
+	Phone       string `json:"phone" gorm:"size:16"`
+	Bio         string `json:"bio" gorm:"size:512"`
+
+	// PhoneVerified is set once the user has proven they own Phone with a code sent by SMS.
+	// SMS two-factor authentication and password resets by SMS require a verified phone number.
+	PhoneVerified       bool `json:"phone_verified" gorm:"not null;default:false"`
+	SmsTwoFactorEnabled bool `json:"sms_two_factor_enabled" gorm:"not null;default:false"`
+
+	// AnonymizedAt is set once the account has been deleted. Its personal data is gone
+	// and it can no longer be signed in to.
+	AnonymizedAt *time.Time `json:"anonymized_at,omitempty" gorm:"type:timestamp with time zone"`
+
 	OAuth    OAuth    `gorm:"foreignKey:UserId"`
 	OtpEmail OtpEmail `gorm:"foreignKey:UserId"`
 	ResetPwd ResetPwd `gorm:"foreignKey:UserId"`
 }
 
-// TODO Ticketing System
+// IsSuspended reports whether the account is currently suspended. A suspension
+// with an expiry ends on its own once the expiry has passed.
+func (u *User) IsSuspended() bool {
+	if !u.IsDisabled {
+		return false
+	}
+	return u.DisabledUntil == nil || time.Now().Before(*u.DisabledUntil)
+}
+
 // TODO Gift Cards
 // TODO Product Listing
 // TODO Orders
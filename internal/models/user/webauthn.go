@@ -0,0 +1,41 @@
+package usermodel
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebAuthnCredential is a passkey registered by a user. The credential record
+// kept by the WebAuthn library, public key and signature counter included, is
+// stored as JSON in Data.
+type WebAuthnCredential struct {
+	Id           uuid.UUID  `json:"id" gorm:"type:uuid;primaryKey"`
+	UserId       uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	CredentialId []byte     `json:"-" gorm:"type:bytea;not null;uniqueIndex"`
+	Name         string     `json:"name" gorm:"size:64"`
+	Data         []byte     `json:"-" gorm:"type:bytea;not null"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty" gorm:"type:timestamp with time zone"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"type:timestamp with time zone;default:current_timestamp"`
+}
+
+// WebAuthnCeremony identifies what a WebAuthn challenge was issued for.
+type WebAuthnCeremony string
+
+// Constants representing the WebAuthn ceremonies.
+const (
+	WebAuthnCeremonyRegistration WebAuthnCeremony = "registration"
+	WebAuthnCeremonyLogin        WebAuthnCeremony = "login"
+)
+
+// WebAuthnChallenge keeps the server side of a WebAuthn ceremony between its
+// two steps. A login challenge bound to a user is a second factor, issued once
+// the password has been checked; an unbound one is a passkey-only login.
+// Challenges are single use.
+type WebAuthnChallenge struct {
+	Id        uuid.UUID        `json:"id" gorm:"type:uuid;primaryKey"`
+	UserId    *uuid.UUID       `json:"user_id,omitempty" gorm:"type:uuid;index"`
+	Ceremony  WebAuthnCeremony `json:"ceremony" gorm:"size:16;not null"`
+	Data      []byte           `json:"-" gorm:"type:bytea;not null"`
+	ExpiresAt time.Time        `json:"expires_at" gorm:"type:timestamp with time zone;not null;index"`
+}
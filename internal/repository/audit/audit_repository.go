@@ -0,0 +1,84 @@
+package auditrepository
+
+import (
+	"errors"
+	auditmodel "github.com/drunkleen/rasta/internal/models/audit"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+type AuditRepository struct {
+	DB *gorm.DB
+}
+
+// Filter narrows down the audit log entries returned by Find. Empty fields match every entry.
+type Filter struct {
+	Action    auditmodel.Action
+	ActorId   *uuid.UUID
+	UserId    *uuid.UUID
+	SessionId *uuid.UUID
+}
+
+// NewAuditRepository returns a new instance of AuditRepository.
+//
+// Parameters:
+// - db: the database connection to be used by the AuditRepository.
+//
+// Returns:
+// - *AuditRepository
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{DB: db}
+}
+
+// Create appends an entry to the audit log.
+//
+// Parameters:
+// - entry: the entry to append. Its ID is generated.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
+func (r *AuditRepository) Create(entry *auditmodel.AuditLog) error {
+	entry.Id = uuid.New()
+	entry.CreatedAt = time.Now()
+	if err := r.DB.Create(entry).Error; err != nil {
+		log.Printf("failed to create audit log entry: %v", err)
+		return errors.New("failed to create audit log entry")
+	}
+	return nil
+}
+
+// Find returns a page of the audit log entries matching a filter, most recent first.
+//
+// Parameters:
+// - filter: the filter the entries must match.
+// - offset: the number of entries to skip.
+// - limit: the maximum number of entries to return.
+//
+// Returns:
+// - []auditmodel.AuditLog
+// - int64: the number of entries matching the filter.
+// - error
+func (r *AuditRepository) Find(filter Filter, offset, limit int) ([]auditmodel.AuditLog, int64, error) {
+	query := r.DB.Model(&auditmodel.AuditLog{})
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ActorId != nil {
+		query = query.Where("actor_id = ?", *filter.ActorId)
+	}
+	if filter.UserId != nil {
+		query = query.Where("user_id = ?", *filter.UserId)
+	}
+	if filter.SessionId != nil {
+		query = query.Where("session_id = ?", *filter.SessionId)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	var entries []auditmodel.AuditLog
+	err := query.Order("created_at desc").Offset(offset).Limit(limit).Find(&entries).Error
+	return entries, count, err
+}
@@ -0,0 +1,267 @@
+package emailrepository
+
+import (
+	"errors"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"time"
+)
+
+type OutboxRepository struct {
+	DB *gorm.DB
+}
+
+// Filter narrows down the emails returned by Find. Empty fields match every email.
+type Filter struct {
+	Status    emailmodel.OutboxStatus
+	Kind      string
+	Recipient string
+	UserId    *uuid.UUID
+}
+
+// NewOutboxRepository returns a new instance of OutboxRepository.
+//
+// Parameters:
+// - db: the database connection to be used by the OutboxRepository.
+//
+// Returns:
+// - *OutboxRepository
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{DB: db}
+}
+
+// Enqueue adds emails to the outbox with a database connection or transaction, so that they are
+// only delivered once the transaction they are written in commits.
+//
+// Parameters:
+// - tx: the database connection or transaction.
+// - emails: the emails to add. Their ID is generated and they are due at once.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
+func Enqueue(tx *gorm.DB, emails ...*emailmodel.EmailOutbox) error {
+	now := time.Now()
+	for _, email := range emails {
+		email.Id = uuid.New()
+		email.Status = emailmodel.OutboxStatusQueued
+		email.Attempts = 0
+		email.NextAttemptAt = now
+		email.CreatedAt = now
+		email.UpdatedAt = now
+	}
+	if len(emails) == 0 {
+		return nil
+	}
+	return tx.Create(emails).Error
+}
+
+// Create adds emails to the outbox in a single transaction.
+//
+// Parameters:
+// - emails: the emails to add.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
+func (r *OutboxRepository) Create(emails ...*emailmodel.EmailOutbox) error {
+	if err := Enqueue(r.DB, emails...); err != nil {
+		log.Printf("failed to create outbox emails: %v", err)
+		return errors.New("failed to create outbox emails")
+	}
+	return nil
+}
+
+// ClaimDue claims the queued emails due for delivery, oldest first, so that other instances skip
+// them: each attempt is counted and the next one is pushed back by lease, after which an email is
+// retried if the instance delivering it stopped before recording the outcome.
+//
+// Parameters:
+// - limit: the maximum number of emails to claim.
+// - lease: how long the emails are reserved for.
+//
+// Returns:
+// - []emailmodel.EmailOutbox
+// - error
+func (r *OutboxRepository) ClaimDue(limit int, lease time.Duration) ([]emailmodel.EmailOutbox, error) {
+	var emails []emailmodel.EmailOutbox
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", emailmodel.OutboxStatusQueued, time.Now()).
+			Order("next_attempt_at").Limit(limit).Find(&emails).Error
+		if err != nil || len(emails) == 0 {
+			return err
+		}
+		ids := make([]uuid.UUID, len(emails))
+		for i := range emails {
+			ids[i] = emails[i].Id
+			emails[i].Attempts++
+		}
+		now := time.Now()
+		return tx.Model(&emailmodel.EmailOutbox{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"next_attempt_at": now.Add(lease),
+			"updated_at":      now,
+		}).Error
+	})
+	if err != nil {
+		log.Printf("failed to claim outbox emails: %v", err)
+		return nil, errors.New("failed to claim outbox emails")
+	}
+	return emails, nil
+}
+
+// MarkSent records that an email has been delivered.
+//
+// Parameters:
+// - id: the UUID of the email.
+//
+// Returns:
+// - error: if the update fails, an error is returned.
+func (r *OutboxRepository) MarkSent(id uuid.UUID) error {
+	now := time.Now()
+	return r.update(id, map[string]interface{}{
+		"status":     emailmodel.OutboxStatusSent,
+		"last_error": "",
+		"sent_at":    now,
+		"updated_at": now,
+	})
+}
+
+// MarkRetry records a failed delivery of an email to be retried.
+//
+// Parameters:
+// - id: the UUID of the email.
+// - nextAttemptAt: when the delivery is retried.
+// - lastError: why the delivery failed.
+//
+// Returns:
+// - error: if the update fails, an error is returned.
+func (r *OutboxRepository) MarkRetry(id uuid.UUID, nextAttemptAt time.Time, lastError string) error {
+	return r.update(id, map[string]interface{}{
+		"next_attempt_at": nextAttemptAt,
+		"last_error":      lastError,
+		"updated_at":      time.Now(),
+	})
+}
+
+// MarkUndelivered records that an email will not be delivered, because it failed too many times
+// or bounced.
+//
+// Parameters:
+// - id: the UUID of the email.
+// - status: OutboxStatusFailed or OutboxStatusBounced.
+// - lastError: why the delivery failed.
+//
+// Returns:
+// - error: if the update fails, an error is returned.
+func (r *OutboxRepository) MarkUndelivered(id uuid.UUID, status emailmodel.OutboxStatus, lastError string) error {
+	return r.update(id, map[string]interface{}{
+		"status":     status,
+		"last_error": lastError,
+		"updated_at": time.Now(),
+	})
+}
+
+// update updates the columns of an email.
+func (r *OutboxRepository) update(id uuid.UUID, updates map[string]interface{}) error {
+	if err := r.DB.Model(&emailmodel.EmailOutbox{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		log.Printf("failed to update outbox email: %v", err)
+		return errors.New("failed to update outbox email")
+	}
+	return nil
+}
+
+// Requeue queues an email that is no longer queued to be delivered again, with a fresh count of attempts.
+//
+// Parameters:
+// - id: the UUID of the email.
+//
+// Returns:
+// - bool: false if the email is already queued.
+// - error
+func (r *OutboxRepository) Requeue(id uuid.UUID) (bool, error) {
+	now := time.Now()
+	result := r.DB.Model(&emailmodel.EmailOutbox{}).
+		Where("id = ? AND status <> ?", id, emailmodel.OutboxStatusQueued).
+		Updates(map[string]interface{}{
+			"status":          emailmodel.OutboxStatusQueued,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"last_error":      "",
+			"sent_at":         nil,
+			"updated_at":      now,
+		})
+	if result.Error != nil {
+		log.Printf("failed to requeue outbox email: %v", result.Error)
+		return false, errors.New("failed to requeue outbox email")
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FindById finds an email by its ID.
+//
+// Parameters:
+// - id: the UUID of the email.
+//
+// Returns:
+// - *emailmodel.EmailOutbox
+// - error
+func (r *OutboxRepository) FindById(id uuid.UUID) (*emailmodel.EmailOutbox, error) {
+	var email emailmodel.EmailOutbox
+	err := r.DB.Omit("body").Where("id = ?", id).First(&email).Error
+	return &email, err
+}
+
+// Find returns a page of the emails matching a filter, most recent first. Their bodies are not read.
+//
+// Parameters:
+// - filter: the filter the emails must match.
+// - offset: the number of emails to skip.
+// - limit: the maximum number of emails to return.
+//
+// Returns:
+// - []emailmodel.EmailOutbox
+// - int64: the number of emails matching the filter.
+// - error
+func (r *OutboxRepository) Find(filter Filter, offset, limit int) ([]emailmodel.EmailOutbox, int64, error) {
+	query := r.DB.Model(&emailmodel.EmailOutbox{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Kind != "" {
+		query = query.Where("kind = ?", filter.Kind)
+	}
+	if filter.Recipient != "" {
+		query = query.Where("recipient = ?", filter.Recipient)
+	}
+	if filter.UserId != nil {
+		query = query.Where("user_id = ?", *filter.UserId)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	var emails []emailmodel.EmailOutbox
+	err := query.Omit("body").Order("created_at desc").Offset(offset).Limit(limit).Find(&emails).Error
+	return emails, count, err
+}
+
+// DeleteFinishedBefore deletes the emails no longer queued that were last updated before a time.
+//
+// Parameters:
+// - before: the time before which emails are deleted.
+//
+// Returns:
+// - int64: the number of emails deleted.
+// - error
+func (r *OutboxRepository) DeleteFinishedBefore(before time.Time) (int64, error) {
+	result := r.DB.Where("status <> ? AND updated_at < ?", emailmodel.OutboxStatusQueued, before).
+		Delete(&emailmodel.EmailOutbox{})
+	if result.Error != nil {
+		log.Printf("failed to delete outbox emails: %v", result.Error)
+		return 0, errors.New("failed to delete outbox emails")
+	}
+	return result.RowsAffected, nil
+}
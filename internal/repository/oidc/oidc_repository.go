@@ -0,0 +1,260 @@
+package oidcrepository
+
+import (
+	"errors"
+	oidcmodel "github.com/drunkleen/rasta/internal/models/oidc"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"time"
+)
+
+type OidcRepository struct {
+	DB *gorm.DB
+}
+
+// NewOidcRepository returns a new instance of OidcRepository.
+//
+// Parameters:
+// - db: the database connection to be used by the OidcRepository.
+//
+// Returns:
+// - *OidcRepository
+func NewOidcRepository(db *gorm.DB) *OidcRepository {
+	return &OidcRepository{DB: db}
+}
+
+// CreateClient registers a new partner app.
+//
+// Parameters:
+// - client: the client to store. Its ID is generated.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
+func (r *OidcRepository) CreateClient(client *oidcmodel.OidcClient) error {
+	client.Id = uuid.New()
+	now := time.Now()
+	client.CreatedAt = now
+	client.UpdatedAt = now
+	if err := r.DB.Create(client).Error; err != nil {
+		log.Printf("failed to create oidc client: %v", err)
+		return errors.New("failed to create client")
+	}
+	return nil
+}
+
+// FindClients returns every partner app, oldest first.
+//
+// Returns:
+// - []oidcmodel.OidcClient
+// - error
+func (r *OidcRepository) FindClients() ([]oidcmodel.OidcClient, error) {
+	var clients []oidcmodel.OidcClient
+	err := r.DB.Order("created_at").Find(&clients).Error
+	return clients, err
+}
+
+// FindClientById finds a partner app by its ID.
+//
+// Parameters:
+// - id: the UUID of the client.
+//
+// Returns:
+// - *oidcmodel.OidcClient
+// - error
+func (r *OidcRepository) FindClientById(id uuid.UUID) (*oidcmodel.OidcClient, error) {
+	var client oidcmodel.OidcClient
+	err := r.DB.Where("id = ?", id).First(&client).Error
+	return &client, err
+}
+
+// UpdateClient updates the name, redirect URIs and secret hash of a partner app.
+//
+// Parameters:
+// - client: the client with its new values.
+//
+// Returns:
+// - error: an error if the update fails.
+func (r *OidcRepository) UpdateClient(client *oidcmodel.OidcClient) error {
+	client.UpdatedAt = time.Now()
+	updates := map[string]interface{}{
+		"name":          client.Name,
+		"redirect_uris": client.RedirectUris,
+		"secret_hash":   client.SecretHash,
+		"updated_at":    client.UpdatedAt,
+	}
+	if err := r.DB.Model(&oidcmodel.OidcClient{}).Where("id = ?", client.Id).Updates(updates).Error; err != nil {
+		log.Printf("failed to update oidc client: %v", err)
+		return errors.New("failed to update client")
+	}
+	return nil
+}
+
+// DeleteClient deletes a partner app together with the consents given to it and its pending codes.
+//
+// Parameters:
+// - id: the UUID of the client.
+//
+// Returns:
+// - bool: whether a client matched.
+// - error: if the deletion fails, an error is returned.
+func (r *OidcRepository) DeleteClient(id uuid.UUID) (bool, error) {
+	var deleted int64
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("client_id = ?", id).Delete(&oidcmodel.OidcAuthorizationCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("client_id = ?", id).Delete(&oidcmodel.OidcConsent{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ?", id).Delete(&oidcmodel.OidcClient{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		log.Printf("failed to delete oidc client: %v", err)
+		return false, errors.New("failed to delete client")
+	}
+	return deleted > 0, nil
+}
+
+// FindConsent finds the consent a user gave to a partner app.
+//
+// Parameters:
+// - userId: the UUID of the user.
+// - clientId: the UUID of the client.
+//
+// Returns:
+// - *oidcmodel.OidcConsent
+// - error
+func (r *OidcRepository) FindConsent(userId, clientId uuid.UUID) (*oidcmodel.OidcConsent, error) {
+	var consent oidcmodel.OidcConsent
+	err := r.DB.Where("user_id = ? AND client_id = ?", userId, clientId).First(&consent).Error
+	return &consent, err
+}
+
+// FindConsentsByUserId returns the consents a user gave, including the partner apps they were given to.
+//
+// Parameters:
+// - userId: the UUID of the user.
+//
+// Returns:
+// - []oidcmodel.OidcConsent
+// - error
+func (r *OidcRepository) FindConsentsByUserId(userId uuid.UUID) ([]oidcmodel.OidcConsent, error) {
+	var consents []oidcmodel.OidcConsent
+	err := r.DB.Preload("Client").Where("user_id = ?", userId).Order("created_at").Find(&consents).Error
+	return consents, err
+}
+
+// SaveConsent stores the scopes a user allowed a partner app to access, replacing an earlier consent.
+//
+// Parameters:
+// - consent: the consent to store. Its ID is generated.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
+func (r *OidcRepository) SaveConsent(consent *oidcmodel.OidcConsent) error {
+	consent.Id = uuid.New()
+	now := time.Now()
+	consent.CreatedAt = now
+	consent.UpdatedAt = now
+	err := r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "client_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"scope", "updated_at"}),
+	}).Create(consent).Error
+	if err != nil {
+		log.Printf("failed to save oidc consent: %v", err)
+		return errors.New("failed to save consent")
+	}
+	return nil
+}
+
+// DeleteConsent revokes the consent a user gave to a partner app.
+//
+// Parameters:
+// - userId: the UUID of the user.
+// - clientId: the UUID of the client.
+//
+// Returns:
+// - bool: whether a consent matched.
+// - error: if the deletion fails, an error is returned.
+func (r *OidcRepository) DeleteConsent(userId, clientId uuid.UUID) (bool, error) {
+	result := r.DB.Where("user_id = ? AND client_id = ?", userId, clientId).Delete(&oidcmodel.OidcConsent{})
+	if result.Error != nil {
+		log.Printf("failed to delete oidc consent: %v", result.Error)
+		return false, errors.New("failed to delete consent")
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CreateCode stores a new authorization code and drops the expired ones.
+//
+// Parameters:
+// - code: the code to store. Its ID is generated.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
+func (r *OidcRepository) CreateCode(code *oidcmodel.OidcAuthorizationCode) error {
+	code.Id = uuid.New()
+	if err := r.DB.Where("expires_at < ?", time.Now()).Delete(&oidcmodel.OidcAuthorizationCode{}).Error; err != nil {
+		log.Printf("failed to delete expired authorization codes: %v", err)
+	}
+	if err := r.DB.Create(code).Error; err != nil {
+		log.Printf("failed to create authorization code: %v", err)
+		return errors.New("failed to create authorization code")
+	}
+	return nil
+}
+
+// FindCode finds an authorization code that has not expired by its hash.
+//
+// Parameters:
+// - codeHash: the SHA-256 hash of the code.
+//
+// Returns:
+// - *oidcmodel.OidcAuthorizationCode
+// - error
+func (r *OidcRepository) FindCode(codeHash string) (*oidcmodel.OidcAuthorizationCode, error) {
+	var code oidcmodel.OidcAuthorizationCode
+	err := r.DB.Where("code_hash = ? AND expires_at > ?", codeHash, time.Now()).First(&code).Error
+	return &code, err
+}
+
+// UseCode marks an authorization code as used.
+//
+// The update only matches a code that has not been used yet, so a code cannot be
+// exchanged twice, even by concurrent requests.
+//
+// Parameters:
+// - id: the UUID of the code.
+//
+// Returns:
+// - bool: whether an unused code matched.
+// - error: an error if the update fails, nil otherwise.
+func (r *OidcRepository) UseCode(id uuid.UUID) (bool, error) {
+	result := r.DB.Model(&oidcmodel.OidcAuthorizationCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		log.Printf("failed to use authorization code: %v", result.Error)
+		return false, errors.New("failed to use authorization code")
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FindUserById finds a user by their ID.
+//
+// Parameters:
+// - id: the UUID of the user.
+//
+// Returns:
+// - *usermodel.User
+// - error
+func (r *OidcRepository) FindUserById(id uuid.UUID) (*usermodel.User, error) {
+	var user usermodel.User
+	err := r.DB.Where("id = ?", id).First(&user).Error
+	return &user, err
+}
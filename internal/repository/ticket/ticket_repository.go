@@ -13,14 +13,32 @@ type TicketRepository struct {
 	DB *gorm.DB
 }
 
+// Filter narrows down the tickets returned by Find. Empty fields match every ticket.
+type Filter struct {
+	Status     ticketmodel.TicketStatus
+	Priority   ticketmodel.TicketPriority
+	Category   ticketmodel.TicketCategory
+	UserId     *uuid.UUID
+	AssignedTo *uuid.UUID
+	Unassigned bool
+}
+
 func NewTicketRepository(db *gorm.DB) *TicketRepository {
 	return &TicketRepository{DB: db}
 }
 
+// Create inserts a new ticket.
+//
+// Parameters:
+// - ticket: the ticket to insert. Its ID is generated.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
 func (r *TicketRepository) Create(ticket *ticketmodel.Ticket) error {
 	if ticket.Title == "" {
 		return errors.New("title is required")
 	}
+	ticket.Id = uuid.New()
 	if err := r.DB.Create(ticket).Error; err != nil {
 		log.Printf("failed to create ticket: %v", err)
 		return errors.New("could not create ticket")
@@ -47,9 +65,29 @@ func (r *TicketRepository) FindById(ticketID uuid.UUID) (*ticketmodel.Ticket, er
 	return &ticket, nil
 }
 
+// FindByIdWithComments retrieves a ticket along with its comments, oldest first.
+//
+// Parameters:
+// - ticketID: the ID of the ticket.
+//
+// Returns:
+// - *ticketmodel.Ticket
+// - error: if the ticket is not found, an error is returned.
+func (r *TicketRepository) FindByIdWithComments(ticketID uuid.UUID) (*ticketmodel.Ticket, error) {
+	var ticket ticketmodel.Ticket
+	err := r.DB.Preload("Comments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at asc")
+	}).Where("id = ?", ticketID).First(&ticket).Error
+	if err != nil {
+		log.Printf("ticket not found: %v", err)
+		return nil, errors.New("ticket not found")
+	}
+	return &ticket, nil
+}
+
 func (r *TicketRepository) FindByUserId(userID uuid.UUID) ([]ticketmodel.Ticket, error) {
 	var tickets []ticketmodel.Ticket
-	err := r.DB.Where("user_id = ?", userID).Find(&tickets).Error
+	err := r.DB.Where("user_id = ?", userID).Order("created_at desc").Find(&tickets).Error
 	if err != nil {
 		log.Printf("no tickets found for user: %v", err)
 		return nil, errors.New("no tickets found")
@@ -57,6 +95,46 @@ func (r *TicketRepository) FindByUserId(userID uuid.UUID) ([]ticketmodel.Ticket,
 	return tickets, nil
 }
 
+// Find returns a page of the tickets matching a filter, most recently updated first.
+//
+// Parameters:
+// - filter: the filter the tickets must match.
+// - offset: the number of tickets to skip.
+// - limit: the maximum number of tickets to return.
+//
+// Returns:
+// - []ticketmodel.Ticket
+// - int64: the number of tickets matching the filter.
+// - error
+func (r *TicketRepository) Find(filter Filter, offset, limit int) ([]ticketmodel.Ticket, int64, error) {
+	query := r.DB.Model(&ticketmodel.Ticket{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Priority != "" {
+		query = query.Where("priority = ?", filter.Priority)
+	}
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.UserId != nil {
+		query = query.Where("user_id = ?", *filter.UserId)
+	}
+	if filter.AssignedTo != nil {
+		query = query.Where("assigned_to = ?", *filter.AssignedTo)
+	}
+	if filter.Unassigned {
+		query = query.Where("assigned_to IS NULL")
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	var tickets []ticketmodel.Ticket
+	err := query.Order("updated_at desc").Offset(offset).Limit(limit).Find(&tickets).Error
+	return tickets, count, err
+}
+
 func (r *TicketRepository) UpdateStatus(ticketID uuid.UUID, status ticketmodel.TicketStatus) error {
 	updates := map[string]interface{}{
 		"status":     status,
@@ -83,11 +161,48 @@ func (r *TicketRepository) UpdatePriority(ticketID uuid.UUID, priority ticketmod
 	return nil
 }
 
+// UpdateAssignee assigns a ticket to a member of staff.
+//
+// Parameters:
+// - ticketID: the ID of the ticket.
+// - assignee: the ID of the member of staff, or nil to unassign the ticket.
+//
+// Returns:
+// - error: if the update fails, an error is returned.
+func (r *TicketRepository) UpdateAssignee(ticketID uuid.UUID, assignee *uuid.UUID) error {
+	updates := map[string]interface{}{
+		"assigned_to": assignee,
+		"updated_at":  time.Now(),
+	}
+	err := r.DB.Model(&ticketmodel.Ticket{}).Where("id = ?", ticketID).Updates(updates).Error
+	if err != nil {
+		log.Printf("failed to update ticket assignee: %v", err)
+		return errors.New("could not update ticket assignee")
+	}
+	return nil
+}
+
+// AddComment inserts a comment and bumps the update time of its ticket, in one transaction.
+//
+// Parameters:
+// - comment: the comment to insert. Its ID is generated.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
 func (r *TicketRepository) AddComment(comment *ticketmodel.TicketComment) error {
 	if comment.Comment == "" {
 		return errors.New("comment is required")
 	}
-	if err := r.DB.Create(comment).Error; err != nil {
+	comment.Id = uuid.New()
+	comment.CreatedAt = time.Now()
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		return tx.Model(&ticketmodel.Ticket{}).Where("id = ?", comment.TicketId).
+			Update("updated_at", comment.CreatedAt).Error
+	})
+	if err != nil {
 		log.Printf("failed to add comment: %v", err)
 		return errors.New("could not add comment")
 	}
@@ -0,0 +1,239 @@
+package userrepository
+
+import (
+	"errors"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
+	newslettermodel "github.com/drunkleen/rasta/internal/models/newsletter"
+	oidcmodel "github.com/drunkleen/rasta/internal/models/oidc"
+	ticketmodel "github.com/drunkleen/rasta/internal/models/ticket"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	emailrepository "github.com/drunkleen/rasta/internal/repository/email"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+// redacted replaces the content a deleted user wrote.
+const redacted = "[deleted]"
+
+type AccountDeletionRepository struct {
+	DB *gorm.DB
+}
+
+// NewAccountDeletionRepository returns a new instance of AccountDeletionRepository.
+//
+// Parameters:
+// - db: the database connection to be used by the AccountDeletionRepository.
+//
+// Returns:
+// - *AccountDeletionRepository
+func NewAccountDeletionRepository(db *gorm.DB) *AccountDeletionRepository {
+	return &AccountDeletionRepository{DB: db}
+}
+
+// Create stores a new account deletion, and adds the emails announcing it to the outbox in the
+// same transaction.
+//
+// Parameters:
+// - deletion: the deletion to store. Its ID is generated.
+// - emails: the emails notifying the user, if any.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
+func (r *AccountDeletionRepository) Create(deletion *usermodel.AccountDeletion, emails ...*emailmodel.EmailOutbox) error {
+	deletion.Id = uuid.New()
+	deletion.CreatedAt = time.Now()
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(deletion).Error; err != nil {
+			return err
+		}
+		return emailrepository.Enqueue(tx, emails...)
+	})
+	if err != nil {
+		log.Printf("failed to create account deletion: %v", err)
+		return errors.New("failed to create account deletion")
+	}
+	return nil
+}
+
+// FindPendingByUserId finds the pending account deletion of a user.
+//
+// Parameters:
+// - userId: the UUID of the user.
+//
+// Returns:
+// - *usermodel.AccountDeletion
+// - error
+func (r *AccountDeletionRepository) FindPendingByUserId(userId uuid.UUID) (*usermodel.AccountDeletion, error) {
+	var deletion usermodel.AccountDeletion
+	err := r.DB.Where("user_id = ? AND canceled_at IS NULL AND completed_at IS NULL", userId).First(&deletion).Error
+	return &deletion, err
+}
+
+// FindDue finds the pending account deletions whose grace period is over.
+//
+// Parameters:
+// - limit: the maximum number of deletions to return.
+//
+// Returns:
+// - []usermodel.AccountDeletion
+// - error
+func (r *AccountDeletionRepository) FindDue(limit int) ([]usermodel.AccountDeletion, error) {
+	var deletions []usermodel.AccountDeletion
+	err := r.DB.Where("canceled_at IS NULL AND completed_at IS NULL AND scheduled_for <= ?", time.Now()).
+		Order("scheduled_for asc").Limit(limit).Find(&deletions).Error
+	if err != nil {
+		log.Printf("failed to find due account deletions: %v", err)
+		return nil, errors.New("failed to find due account deletions")
+	}
+	return deletions, nil
+}
+
+// FindUserById finds the user an account deletion is for.
+//
+// Parameters:
+// - id: the UUID of the user.
+//
+// Returns:
+// - *usermodel.User
+// - error
+func (r *AccountDeletionRepository) FindUserById(id uuid.UUID) (*usermodel.User, error) {
+	var user usermodel.User
+	err := r.DB.Where("id = ?", id).First(&user).Error
+	return &user, err
+}
+
+// Cancel cancels a pending account deletion.
+//
+// Parameters:
+// - id: the UUID of the deletion.
+//
+// Returns:
+// - bool: whether a pending deletion was canceled.
+// - error: if the update fails, an error is returned.
+func (r *AccountDeletionRepository) Cancel(id uuid.UUID) (bool, error) {
+	result := r.DB.Model(&usermodel.AccountDeletion{}).
+		Where("id = ? AND canceled_at IS NULL AND completed_at IS NULL", id).
+		Update("canceled_at", time.Now())
+	if result.Error != nil {
+		log.Printf("failed to cancel account deletion: %v", result.Error)
+		return false, errors.New("failed to cancel account deletion")
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Anonymize carries out an account deletion in a single transaction.
+//
+// The personal data of the user is erased, the tickets and comments the user wrote are
+// redacted, the tickets assigned to the user are unassigned, the newsletter subscription of
+// the user is removed and every credential, session, grant, export and outbox email of the
+// user is deleted. The row of the user is kept, disabled, so that records referring to it
+// stay valid.
+//
+// Parameters:
+// - deletion: the deletion to carry out.
+// - emails: the emails notifying the user that the deletion has been carried out, added to the
+// outbox in the same transaction.
+//
+// Returns:
+// - error: if the transaction fails, an error is returned.
+func (r *AccountDeletionRepository) Anonymize(deletion *usermodel.AccountDeletion, emails ...*emailmodel.EmailOutbox) error {
+	now := time.Now()
+	userId := deletion.UserId
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var user usermodel.User
+		if err := tx.Where("id = ?", userId).First(&user).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("email = ?", user.Email).Delete(&newslettermodel.Newsletter{}).Error; err != nil {
+			return err
+		}
+		sessions := tx.Model(&usermodel.Session{}).Select("id").Where("user_id = ?", userId)
+		if err := tx.Where("session_id IN (?)", sessions).Delete(&usermodel.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		owned := []any{
+			&usermodel.Session{},
+			&usermodel.OAuth{},
+			&usermodel.RecoveryCode{},
+			&usermodel.OtpEmail{},
+			&usermodel.ResetPwd{},
+			&usermodel.SmsCode{},
+			&usermodel.PasswordHistory{},
+			&usermodel.KnownDevice{},
+			&usermodel.LoginLink{},
+			&usermodel.EmailChange{},
+			&usermodel.WebAuthnCredential{},
+			&usermodel.WebAuthnChallenge{},
+			&usermodel.Identity{},
+			&usermodel.SocialState{},
+			&usermodel.UserRole{},
+			&usermodel.ApiKey{},
+			&usermodel.DataExport{},
+			&oidcmodel.OidcConsent{},
+			&oidcmodel.OidcAuthorizationCode{},
+			&emailmodel.EmailOutbox{},
+		}
+		for _, model := range owned {
+			if err := tx.Where("user_id = ?", userId).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		err := tx.Where("scope = ? AND subject = ?", usermodel.LockoutScopeAccount, userId.String()).
+			Delete(&usermodel.Lockout{}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&ticketmodel.Ticket{}).Where("user_id = ?", userId).
+			Updates(map[string]interface{}{"title": redacted, "description": redacted, "updated_at": now}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&ticketmodel.TicketComment{}).Where("user_id = ?", userId).
+			Update("comment", redacted).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&ticketmodel.Ticket{}).Where("assigned_to = ?", userId).
+			Update("assigned_to", nil).Error
+		if err != nil {
+			return err
+		}
+		tombstone := "deleted-" + userId.String()
+		updates := map[string]interface{}{
+			"first_name":             "Deleted",
+			"last_name":              "User",
+			"username":               tombstone,
+			"email":                  tombstone + "@deleted.invalid",
+			"canonical_email":        tombstone + "@deleted.invalid",
+			"password":               "",
+			"is_disabled":            true,
+			"disabled_reason":        "account deleted",
+			"disabled_until":         nil,
+			"display_name":           "",
+			"locale":                 "",
+			"timezone":               "",
+			"phone":                  "",
+			"phone_verified":         false,
+			"sms_two_factor_enabled": false,
+			"bio":                    "",
+			"anonymized_at":          now,
+			"updated_at":             now,
+		}
+		if err = tx.Model(&usermodel.User{}).Where("id = ?", userId).Updates(updates).Error; err != nil {
+			return err
+		}
+		err = tx.Model(&usermodel.AccountDeletion{}).Where("id = ?", deletion.Id).Update("completed_at", now).Error
+		if err != nil {
+			return err
+		}
+		return emailrepository.Enqueue(tx, emails...)
+	})
+	if err != nil {
+		log.Printf("failed to anonymize user: %v", err)
+		return errors.New("failed to anonymize user")
+	}
+	deletion.CompletedAt = &now
+	return nil
+}
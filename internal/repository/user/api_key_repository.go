@@ -0,0 +1,211 @@
+package userrepository
+
+import (
+	"errors"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	"github.com/drunkleen/rasta/internal/common/utils"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+type ApiKeyRepository struct {
+	DB *gorm.DB
+}
+
+// NewApiKeyRepository returns a new instance of ApiKeyRepository.
+//
+// Parameters:
+// - db: the database connection to be used by the ApiKeyRepository.
+//
+// Returns:
+// - *ApiKeyRepository
+func NewApiKeyRepository(db *gorm.DB) *ApiKeyRepository {
+	return &ApiKeyRepository{DB: db}
+}
+
+// Create stores a new API key.
+//
+// Parameters:
+// - key: the key to store. Its ID is generated.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
+func (r *ApiKeyRepository) Create(key *usermodel.ApiKey) error {
+	key.Id = uuid.New()
+	key.CreatedAt = time.Now()
+	if err := r.DB.Create(key).Error; err != nil {
+		log.Printf("failed to create api key: %v", err)
+		return errors.New("failed to create api key")
+	}
+	return nil
+}
+
+// FindByHash finds an API key by the hash of the key.
+//
+// Parameters:
+// - keyHash: the SHA-256 hash of the key.
+//
+// Returns:
+// - *usermodel.ApiKey
+// - error
+func (r *ApiKeyRepository) FindByHash(keyHash string) (*usermodel.ApiKey, error) {
+	var key usermodel.ApiKey
+	err := r.DB.Where("key_hash = ?", keyHash).First(&key).Error
+	return &key, err
+}
+
+// FindByUserId returns the API keys of a user, oldest first.
+//
+// Parameters:
+// - userId: the UUID of the user.
+//
+// Returns:
+// - []usermodel.ApiKey
+// - error
+func (r *ApiKeyRepository) FindByUserId(userId uuid.UUID) ([]usermodel.ApiKey, error) {
+	var keys []usermodel.ApiKey
+	err := r.DB.Where("user_id = ?", userId).Order("created_at").Find(&keys).Error
+	return keys, err
+}
+
+// CountByUserId counts the API keys of a user.
+//
+// Parameters:
+// - userId: the UUID of the user.
+//
+// Returns:
+// - int64
+// - error
+func (r *ApiKeyRepository) CountByUserId(userId uuid.UUID) (int64, error) {
+	var count int64
+	err := r.DB.Model(&usermodel.ApiKey{}).Where("user_id = ?", userId).Count(&count).Error
+	return count, err
+}
+
+// UpdateUsage records a request made with an API key.
+//
+// Parameters:
+// - id: the UUID of the key.
+// - ipAddress: the IP address the request came from.
+//
+// Returns:
+// - error: an error if the update fails.
+func (r *ApiKeyRepository) UpdateUsage(id uuid.UUID, ipAddress string) error {
+	err := r.DB.Model(&usermodel.ApiKey{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_used_at": time.Now(), "last_used_ip": ipAddress}).Error
+	if err != nil {
+		log.Printf("failed to update api key usage: %v", err)
+		return errors.New("failed to update api key usage")
+	}
+	return nil
+}
+
+// Delete revokes an API key of a user.
+//
+// Parameters:
+// - id: the UUID of the key.
+// - userId: the UUID of the owner.
+//
+// Returns:
+// - bool: whether a key of the user matched.
+// - error: if the deletion fails, an error is returned.
+func (r *ApiKeyRepository) Delete(id, userId uuid.UUID) (bool, error) {
+	result := r.DB.Where("id = ? AND user_id = ?", id, userId).Delete(&usermodel.ApiKey{})
+	if result.Error != nil {
+		log.Printf("failed to delete api key: %v", result.Error)
+		return false, errors.New("failed to delete api key")
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CreateServiceAccount creates a service account.
+//
+// Parameters:
+// - user: the account to create. Its ID is generated and its password hashed.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
+func (r *ApiKeyRepository) CreateServiceAccount(user *usermodel.User) error {
+	user.Id = uuid.New()
+	now := time.Now()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	user.CanonicalEmail = utils.CanonicalEmail(user.Email)
+	var err error
+	user.Password, err = auth.HashPassword(user.Password)
+	if err != nil {
+		log.Printf("failed to hash password: %v", err)
+		return errors.New("failed to hash password")
+	}
+	if err = r.DB.Create(user).Error; err != nil {
+		log.Printf("failed to create service account: %v", err)
+		return errors.New("failed to create service account")
+	}
+	return nil
+}
+
+// FindServiceAccounts returns every service account, oldest first.
+//
+// Returns:
+// - []usermodel.User
+// - error
+func (r *ApiKeyRepository) FindServiceAccounts() ([]usermodel.User, error) {
+	var users []usermodel.User
+	err := r.DB.Where("account = ?", usermodel.AccountTypeService).Order("created_at").Find(&users).Error
+	return users, err
+}
+
+// FindServiceAccountById finds a service account by its ID.
+//
+// Parameters:
+// - id: the UUID of the account.
+//
+// Returns:
+// - *usermodel.User
+// - error
+func (r *ApiKeyRepository) FindServiceAccountById(id uuid.UUID) (*usermodel.User, error) {
+	var user usermodel.User
+	err := r.DB.Where("id = ? AND account = ?", id, usermodel.AccountTypeService).First(&user).Error
+	return &user, err
+}
+
+// DeleteServiceAccount deletes a service account together with its API keys and role assignments.
+//
+// Parameters:
+// - id: the UUID of the account.
+//
+// Returns:
+// - error: if the deletion fails, an error is returned and nothing is deleted.
+func (r *ApiKeyRepository) DeleteServiceAccount(id uuid.UUID) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", id).Delete(&usermodel.ApiKey{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&usermodel.UserRole{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ? AND account = ?", id, usermodel.AccountTypeService).Delete(&usermodel.User{}).Error
+	})
+	if err != nil {
+		log.Printf("failed to delete service account: %v", err)
+		return errors.New("failed to delete service account")
+	}
+	return nil
+}
+
+// UsernameExists reports whether an account uses a username.
+//
+// Parameters:
+// - username: the username.
+//
+// Returns:
+// - bool
+// - error
+func (r *ApiKeyRepository) UsernameExists(username string) (bool, error) {
+	var count int64
+	err := r.DB.Model(&usermodel.User{}).Where("username = ?", username).Count(&count).Error
+	return count > 0, err
+}
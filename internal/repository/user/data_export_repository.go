@@ -0,0 +1,216 @@
+package userrepository
+
+import (
+	"errors"
+	newslettermodel "github.com/drunkleen/rasta/internal/models/newsletter"
+	oidcmodel "github.com/drunkleen/rasta/internal/models/oidc"
+	ticketmodel "github.com/drunkleen/rasta/internal/models/ticket"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+// DataExportContents is the data held about a user, as collected for a data export.
+type DataExportContents struct {
+	Tickets       []ticketmodel.Ticket
+	Comments      []ticketmodel.TicketComment
+	Subscriptions []newslettermodel.Newsletter
+	Sessions      []usermodel.Session
+	Devices       []usermodel.KnownDevice
+	Identities    []usermodel.Identity
+	Passkeys      []usermodel.WebAuthnCredential
+	ApiKeys       []usermodel.ApiKey
+	Consents      []oidcmodel.OidcConsent
+	EmailChanges  []usermodel.EmailChange
+}
+
+type DataExportRepository struct {
+	DB *gorm.DB
+}
+
+// NewDataExportRepository returns a new instance of DataExportRepository.
+//
+// Parameters:
+// - db: the database connection to be used by the DataExportRepository.
+//
+// Returns:
+// - *DataExportRepository
+func NewDataExportRepository(db *gorm.DB) *DataExportRepository {
+	return &DataExportRepository{DB: db}
+}
+
+// Create stores a new data export.
+//
+// Parameters:
+// - export: the export to store. Its ID is generated.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
+func (r *DataExportRepository) Create(export *usermodel.DataExport) error {
+	export.Id = uuid.New()
+	export.CreatedAt = time.Now()
+	if err := r.DB.Create(export).Error; err != nil {
+		log.Printf("failed to create data export: %v", err)
+		return errors.New("failed to create data export")
+	}
+	return nil
+}
+
+// FindById finds a data export of a user, archive included.
+//
+// Parameters:
+// - id: the UUID of the export.
+// - userId: the UUID of the user the export must belong to.
+//
+// Returns:
+// - *usermodel.DataExport
+// - error
+func (r *DataExportRepository) FindById(id, userId uuid.UUID) (*usermodel.DataExport, error) {
+	var export usermodel.DataExport
+	err := r.DB.Where("id = ? AND user_id = ?", id, userId).First(&export).Error
+	return &export, err
+}
+
+// FindByUserId finds the data exports of a user, newest first and without their archives.
+//
+// Parameters:
+// - userId: the UUID of the user.
+//
+// Returns:
+// - []usermodel.DataExport
+// - error
+func (r *DataExportRepository) FindByUserId(userId uuid.UUID) ([]usermodel.DataExport, error) {
+	var exports []usermodel.DataExport
+	err := r.DB.Omit("archive").Where("user_id = ?", userId).Order("created_at desc").Find(&exports).Error
+	if err != nil {
+		log.Printf("failed to find data exports: %v", err)
+		return nil, errors.New("failed to find data exports")
+	}
+	return exports, nil
+}
+
+// HasPending reports whether a data export of a user is still being prepared.
+//
+// Parameters:
+// - userId: the UUID of the user.
+//
+// Returns:
+// - bool
+// - error
+func (r *DataExportRepository) HasPending(userId uuid.UUID) (bool, error) {
+	var count int64
+	err := r.DB.Model(&usermodel.DataExport{}).
+		Where("user_id = ? AND status = ?", userId, usermodel.DataExportStatusPending).Count(&count).Error
+	if err != nil {
+		log.Printf("failed to count pending data exports: %v", err)
+		return false, errors.New("failed to count pending data exports")
+	}
+	return count > 0, nil
+}
+
+// Collect loads the data held about a user for a data export.
+//
+// Comments holds every comment the user wrote, on their own tickets or not, while
+// Tickets holds the tickets of the user with every comment made on them.
+//
+// Parameters:
+// - user: the user whose data is collected.
+//
+// Returns:
+// - *DataExportContents
+// - error
+func (r *DataExportRepository) Collect(user *usermodel.User) (*DataExportContents, error) {
+	var contents DataExportContents
+	queries := []struct {
+		query *gorm.DB
+		dest  any
+	}{
+		{r.DB.Preload("Comments").Where("user_id = ?", user.Id), &contents.Tickets},
+		{r.DB.Where("user_id = ?", user.Id), &contents.Comments},
+		{r.DB.Where("email = ?", user.Email), &contents.Subscriptions},
+		{r.DB.Where("user_id = ?", user.Id), &contents.Sessions},
+		{r.DB.Where("user_id = ?", user.Id), &contents.Devices},
+		{r.DB.Where("user_id = ?", user.Id), &contents.Identities},
+		{r.DB.Where("user_id = ?", user.Id), &contents.Passkeys},
+		{r.DB.Where("user_id = ?", user.Id), &contents.ApiKeys},
+		{r.DB.Where("user_id = ?", user.Id), &contents.Consents},
+		{r.DB.Where("user_id = ?", user.Id), &contents.EmailChanges},
+	}
+	for _, q := range queries {
+		if err := q.query.Order("created_at asc").Find(q.dest).Error; err != nil {
+			log.Printf("failed to collect data export: %v", err)
+			return nil, errors.New("failed to collect data export")
+		}
+	}
+	return &contents, nil
+}
+
+// Complete stores the archive of a data export and marks it ready.
+//
+// Parameters:
+// - id: the UUID of the export.
+// - archive: the ZIP archive.
+// - expiresAt: until when the archive can be downloaded.
+//
+// Returns:
+// - error: if the update fails, an error is returned.
+func (r *DataExportRepository) Complete(id uuid.UUID, archive []byte, expiresAt time.Time) error {
+	updates := map[string]interface{}{
+		"status":       usermodel.DataExportStatusReady,
+		"archive":      archive,
+		"size":         len(archive),
+		"expires_at":   expiresAt,
+		"completed_at": time.Now(),
+	}
+	if err := r.DB.Model(&usermodel.DataExport{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		log.Printf("failed to complete data export: %v", err)
+		return errors.New("failed to complete data export")
+	}
+	return nil
+}
+
+// Fail marks a data export as failed.
+//
+// Parameters:
+// - id: the UUID of the export.
+//
+// Returns:
+// - error: if the update fails, an error is returned.
+func (r *DataExportRepository) Fail(id uuid.UUID) error {
+	updates := map[string]interface{}{
+		"status":       usermodel.DataExportStatusFailed,
+		"completed_at": time.Now(),
+	}
+	if err := r.DB.Model(&usermodel.DataExport{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		log.Printf("failed to fail data export: %v", err)
+		return errors.New("failed to fail data export")
+	}
+	return nil
+}
+
+// Purge drops the archives of expired data exports and fails the exports still pending
+// since before staleBefore, whose job did not survive a restart.
+//
+// Parameters:
+// - staleBefore: the time before which pending exports are considered abandoned.
+//
+// Returns:
+// - error: if an update fails, an error is returned.
+func (r *DataExportRepository) Purge(staleBefore time.Time) error {
+	now := time.Now()
+	err := r.DB.Model(&usermodel.DataExport{}).
+		Where("expires_at <= ? AND archive IS NOT NULL", now).
+		Update("archive", nil).Error
+	if err == nil {
+		err = r.DB.Model(&usermodel.DataExport{}).
+			Where("status = ? AND created_at < ?", usermodel.DataExportStatusPending, staleBefore).
+			Updates(map[string]interface{}{"status": usermodel.DataExportStatusFailed, "completed_at": now}).Error
+	}
+	if err != nil {
+		log.Printf("failed to purge data exports: %v", err)
+		return errors.New("failed to purge data exports")
+	}
+	return nil
+}
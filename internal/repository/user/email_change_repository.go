@@ -0,0 +1,230 @@
+package userrepository
+
+import (
+	"errors"
+	"github.com/drunkleen/rasta/internal/common/utils"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	emailrepository "github.com/drunkleen/rasta/internal/repository/email"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"time"
+)
+
+type EmailChangeRepository struct {
+	DB *gorm.DB
+}
+
+// NewEmailChangeRepository returns a new instance of EmailChangeRepository.
+//
+// Parameters:
+// - db: the database connection to be used by the EmailChangeRepository.
+//
+// Returns:
+// - *EmailChangeRepository
+func NewEmailChangeRepository(db *gorm.DB) *EmailChangeRepository {
+	return &EmailChangeRepository{DB: db}
+}
+
+// Create stores a new email change, replacing the unverified changes of the user, and adds the
+// emails announcing it to the outbox in the same transaction.
+//
+// Completed changes are kept, so their old address can still revert them.
+//
+// Parameters:
+// - change: the change to store. Its ID is generated.
+// - emails: the emails sent to the new and the old address.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
+func (r *EmailChangeRepository) Create(change *usermodel.EmailChange, emails ...*emailmodel.EmailOutbox) error {
+	change.Id = uuid.New()
+	change.CreatedAt = time.Now()
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND verified_at IS NULL", change.UserId).Delete(&usermodel.EmailChange{}).Error
+		if err != nil {
+			return err
+		}
+		if err = tx.Create(change).Error; err != nil {
+			return err
+		}
+		return emailrepository.Enqueue(tx, emails...)
+	})
+	if err != nil {
+		log.Printf("failed to create email change: %v", err)
+		return errors.New("failed to create email change")
+	}
+	return nil
+}
+
+// FindPendingByUserId finds the unverified email change of a user.
+//
+// Parameters:
+// - userId: the UUID of the user.
+//
+// Returns:
+// - *usermodel.EmailChange
+// - error
+func (r *EmailChangeRepository) FindPendingByUserId(userId uuid.UUID) (*usermodel.EmailChange, error) {
+	var change usermodel.EmailChange
+	err := r.DB.Where("user_id = ? AND verified_at IS NULL AND canceled_at IS NULL", userId).
+		Order("created_at desc").First(&change).Error
+	return &change, err
+}
+
+// FindByCancelTokenHash finds an email change by the hash of its cancellation token.
+//
+// Parameters:
+// - tokenHash: the SHA-256 hash of the token.
+//
+// Returns:
+// - *usermodel.EmailChange
+// - error
+func (r *EmailChangeRepository) FindByCancelTokenHash(tokenHash string) (*usermodel.EmailChange, error) {
+	var change usermodel.EmailChange
+	err := r.DB.Where("cancel_token_hash = ?", tokenHash).First(&change).Error
+	return &change, err
+}
+
+// ReserveAttempt counts a guess of the code of an email change before the guess is checked.
+//
+// The attempt is only counted while fewer than maxAttempts guesses have been made, in a single
+// statement, so that parallel guesses cannot exceed the limit.
+//
+// Parameters:
+// - id: the UUID of the change.
+// - maxAttempts: the number of guesses allowed.
+//
+// Returns:
+// - int: the number of guesses made so far, this one included.
+// - bool: false if no guess is left.
+// - error: an error if the update fails.
+func (r *EmailChangeRepository) ReserveAttempt(id uuid.UUID, maxAttempts int) (int, bool, error) {
+	var change usermodel.EmailChange
+	result := r.DB.Model(&change).Clauses(clause.Returning{Columns: []clause.Column{{Name: "attempts"}}}).
+		Where("id = ? AND attempts < ?", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		log.Printf("failed to reserve email change attempt: %v", result.Error)
+		return 0, false, result.Error
+	}
+	return change.Attempts, result.RowsAffected == 1, nil
+}
+
+// Verify completes an email change: the change is marked as verified and the user gets the new address.
+//
+// The update only matches a change that has been neither verified nor canceled, so a change can never
+// complete twice, even by concurrent requests.
+//
+// Parameters:
+// - change: the change to complete.
+//
+// Returns:
+// - bool: whether a pending change matched.
+// - error: if the update fails, an error is returned and nothing is changed.
+func (r *EmailChangeRepository) Verify(change *usermodel.EmailChange) (bool, error) {
+	verified := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&usermodel.EmailChange{}).
+			Where("id = ? AND verified_at IS NULL AND canceled_at IS NULL", change.Id).
+			Update("verified_at", now)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		err := tx.Model(&usermodel.User{}).Where("id = ?", change.UserId).
+			Updates(map[string]interface{}{
+				"email":           change.NewEmail,
+				"canonical_email": utils.CanonicalEmail(change.NewEmail),
+				"updated_at":      now,
+			}).Error
+		if err != nil {
+			return err
+		}
+		change.VerifiedAt = &now
+		verified = true
+		return nil
+	})
+	if err != nil {
+		log.Printf("failed to verify email change: %v", err)
+		return false, errors.New("failed to verify email change")
+	}
+	return verified, nil
+}
+
+// Cancel cancels an email change. A change that has already been verified is reverted, giving the
+// user back the old address, unless the user has changed their address again since.
+//
+// The update only matches a change that has not been canceled yet.
+//
+// Parameters:
+// - change: the change to cancel.
+//
+// Returns:
+// - bool: whether a change that was not canceled yet matched.
+// - error: if the update fails, an error is returned and nothing is changed.
+func (r *EmailChangeRepository) Cancel(change *usermodel.EmailChange) (bool, error) {
+	canceled := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&usermodel.EmailChange{}).
+			Where("id = ? AND canceled_at IS NULL", change.Id).
+			Update("canceled_at", now)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if change.VerifiedAt != nil {
+			err := tx.Model(&usermodel.User{}).Where("id = ? AND email = ?", change.UserId, change.NewEmail).
+				Updates(map[string]interface{}{
+					"email":           change.OldEmail,
+					"canonical_email": utils.CanonicalEmail(change.OldEmail),
+					"updated_at":      now,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		change.CanceledAt = &now
+		canceled = true
+		return nil
+	})
+	if err != nil {
+		log.Printf("failed to cancel email change: %v", err)
+		return false, errors.New("failed to cancel email change")
+	}
+	return canceled, nil
+}
+
+// Delete deletes an email change.
+//
+// Parameters:
+// - id: the UUID of the change.
+//
+// Returns:
+// - error: if the deletion fails, an error is returned.
+func (r *EmailChangeRepository) Delete(id uuid.UUID) error {
+	if err := r.DB.Where("id = ?", id).Delete(&usermodel.EmailChange{}).Error; err != nil {
+		log.Printf("failed to delete email change: %v", err)
+		return err
+	}
+	return nil
+}
+
+// EmailTaken reports whether an account other than the given user uses an email address, or an
+// address with the same canonical form.
+//
+// Parameters:
+// - email: the email address.
+// - canonicalEmail: the canonical form of the address, or an empty string to match only the address itself.
+// - userId: the UUID of the user to ignore.
+//
+// Returns:
+// - bool
+// - error
+func (r *EmailChangeRepository) EmailTaken(email, canonicalEmail string, userId uuid.UUID) (bool, error) {
+	var count int64
+	err := emailMatch(r.DB.Model(&usermodel.User{}), email, canonicalEmail).Where("id <> ?", userId).Count(&count).Error
+	return count > 0, err
+}
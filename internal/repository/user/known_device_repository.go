@@ -0,0 +1,214 @@
+package userrepository
+
+import (
+	"errors"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
+	oidcmodel "github.com/drunkleen/rasta/internal/models/oidc"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	emailrepository "github.com/drunkleen/rasta/internal/repository/email"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+type KnownDeviceRepository struct {
+	DB *gorm.DB
+}
+
+// NewKnownDeviceRepository returns a new instance of KnownDeviceRepository.
+//
+// Parameters:
+// - db: the database connection to be used by the KnownDeviceRepository.
+//
+// Returns:
+// - *KnownDeviceRepository
+func NewKnownDeviceRepository(db *gorm.DB) *KnownDeviceRepository {
+	return &KnownDeviceRepository{DB: db}
+}
+
+// Create stores a device a user has signed in from for the first time, and adds the email
+// notifying the user to the outbox in the same transaction.
+//
+// Parameters:
+// - device: the device to store. Its ID is generated.
+// - emails: the email notifying the user of the login, if any.
+//
+// Returns:
+// - error: if the insertion fails, for instance because the device has just been stored by a concurrent login.
+func (r *KnownDeviceRepository) Create(device *usermodel.KnownDevice, emails ...*emailmodel.EmailOutbox) error {
+	device.Id = uuid.New()
+	now := time.Now()
+	device.CreatedAt = now
+	device.LastSeenAt = now
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(device).Error; err != nil {
+			return err
+		}
+		return emailrepository.Enqueue(tx, emails...)
+	})
+	if err != nil {
+		log.Printf("failed to create known device: %v", err)
+		return errors.New("failed to create known device")
+	}
+	return nil
+}
+
+// HasAny reports whether any device of a user is known.
+//
+// Parameters:
+// - userId: the UUID of the user.
+//
+// Returns:
+// - bool: true if a device of the user is known.
+// - error
+func (r *KnownDeviceRepository) HasAny(userId uuid.UUID) (bool, error) {
+	var count int64
+	if err := r.DB.Model(&usermodel.KnownDevice{}).Where("user_id = ?", userId).Count(&count).Error; err != nil {
+		log.Printf("failed to count known devices: %v", err)
+		return false, errors.New("failed to count known devices")
+	}
+	return count > 0, nil
+}
+
+// FindByFingerprint finds a known device of a user by its fingerprint.
+//
+// Parameters:
+// - userId: the UUID of the user.
+// - fingerprint: the fingerprint of the device.
+//
+// Returns:
+// - *usermodel.KnownDevice
+// - error
+func (r *KnownDeviceRepository) FindByFingerprint(userId uuid.UUID, fingerprint string) (*usermodel.KnownDevice, error) {
+	var device usermodel.KnownDevice
+	err := r.DB.Where("user_id = ? AND fingerprint = ?", userId, fingerprint).First(&device).Error
+	return &device, err
+}
+
+// FindByReportTokenHash finds a known device by the hash of the token of its notification link.
+//
+// Parameters:
+// - tokenHash: the SHA-256 hash of the token.
+//
+// Returns:
+// - *usermodel.KnownDevice
+// - error
+func (r *KnownDeviceRepository) FindByReportTokenHash(tokenHash string) (*usermodel.KnownDevice, error) {
+	var device usermodel.KnownDevice
+	err := r.DB.Where("report_token_hash = ?", tokenHash).First(&device).Error
+	return &device, err
+}
+
+// FindByUserId finds the known devices of a user, most recently seen first.
+//
+// Parameters:
+// - userId: the UUID of the user.
+//
+// Returns:
+// - []usermodel.KnownDevice
+// - error
+func (r *KnownDeviceRepository) FindByUserId(userId uuid.UUID) ([]usermodel.KnownDevice, error) {
+	var devices []usermodel.KnownDevice
+	if err := r.DB.Where("user_id = ?", userId).Order("last_seen_at desc").Find(&devices).Error; err != nil {
+		log.Printf("failed to find known devices: %v", err)
+		return nil, errors.New("failed to find known devices")
+	}
+	return devices, nil
+}
+
+// Touch records that a user has signed in from a known device again.
+//
+// Parameters:
+// - id: the UUID of the device.
+// - ipAddress: the IP address the user signed in from.
+//
+// Returns:
+// - error: if the update fails, an error is returned.
+func (r *KnownDeviceRepository) Touch(id uuid.UUID, ipAddress string) error {
+	updates := map[string]interface{}{
+		"ip_address":   ipAddress,
+		"last_seen_at": time.Now(),
+	}
+	if err := r.DB.Model(&usermodel.KnownDevice{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		log.Printf("failed to update known device: %v", err)
+		return errors.New("failed to update known device")
+	}
+	return nil
+}
+
+// Delete forgets a known device of a user, so the next login from it is notified again.
+//
+// Parameters:
+// - id: the UUID of the device.
+// - userId: the UUID of the user the device must belong to.
+//
+// Returns:
+// - bool: true if a device has been deleted.
+// - error
+func (r *KnownDeviceRepository) Delete(id, userId uuid.UUID) (bool, error) {
+	result := r.DB.Where("id = ? AND user_id = ?", id, userId).Delete(&usermodel.KnownDevice{})
+	if result.Error != nil {
+		log.Printf("failed to delete known device: %v", result.Error)
+		return false, errors.New("failed to delete known device")
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Report records that the login which made a device known was not made by its user.
+//
+// The device is forgotten and the user must reset their password before signing in with it again.
+// The credentials created since the login, through which the intruder could sign back in, are
+// revoked: passkeys, linked social accounts, API keys and partner app consents, along with the
+// pending sign-in links and email changes of the user. Only the first report of a device succeeds.
+//
+// Parameters:
+// - device: the reported device.
+//
+// Returns:
+// - bool: true if the report has been recorded.
+// - error
+func (r *KnownDeviceRepository) Report(device *usermodel.KnownDevice) (bool, error) {
+	reported := false
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND report_token_hash = ?", device.Id, device.ReportTokenHash).
+			Delete(&usermodel.KnownDevice{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		reported = true
+		createdSince := []any{
+			&usermodel.WebAuthnCredential{},
+			&usermodel.Identity{},
+			&usermodel.ApiKey{},
+			&oidcmodel.OidcConsent{},
+		}
+		for _, model := range createdSince {
+			err := tx.Where("user_id = ? AND created_at >= ?", device.UserId, device.CreatedAt).Delete(model).Error
+			if err != nil {
+				return err
+			}
+		}
+		pending := []any{
+			&usermodel.LoginLink{},
+			&usermodel.SocialState{},
+			&oidcmodel.OidcAuthorizationCode{},
+		}
+		for _, model := range pending {
+			if err := tx.Where("user_id = ?", device.UserId).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		err := tx.Where("user_id = ? AND verified_at IS NULL", device.UserId).Delete(&usermodel.EmailChange{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&usermodel.User{}).Where("id = ?", device.UserId).
+			Update("password_reset_required", true).Error
+	})
+	if err != nil {
+		log.Printf("failed to report known device: %v", err)
+		return false, errors.New("failed to report known device")
+	}
+	return reported, nil
+}
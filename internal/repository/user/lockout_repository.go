@@ -0,0 +1,140 @@
+package userrepository
+
+import (
+	"errors"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	emailrepository "github.com/drunkleen/rasta/internal/repository/email"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"time"
+)
+
+type LockoutRepository struct {
+	DB *gorm.DB
+}
+
+// NewLockoutRepository returns a new instance of LockoutRepository.
+//
+// Parameters:
+// - db: the database connection to be used by the LockoutRepository.
+//
+// Returns:
+// - *LockoutRepository
+func NewLockoutRepository(db *gorm.DB) *LockoutRepository {
+	return &LockoutRepository{DB: db}
+}
+
+// Find finds the failed-attempt counter of a subject.
+//
+// Parameters:
+// - scope: the scope of the counter.
+// - subject: the account ID or IP address the counter is kept for.
+//
+// Returns:
+// - *usermodel.Lockout
+// - error
+func (r *LockoutRepository) Find(scope usermodel.LockoutScope, subject string) (*usermodel.Lockout, error) {
+	var lockout usermodel.Lockout
+	err := r.DB.Where("scope = ? AND subject = ?", scope, subject).First(&lockout).Error
+	return &lockout, err
+}
+
+// RegisterFailure increments the failed-attempt counter of a subject.
+//
+// The counter restarts from one, and its lockout is lifted, when the previous failure is older
+// than window. The counter is created or updated in a single upsert, so concurrent failures are
+// all counted, even the first ones.
+//
+// Parameters:
+// - scope: the scope of the counter.
+// - subject: the account ID or IP address the counter is kept for.
+// - window: how long a failure is remembered.
+//
+// Returns:
+// - *usermodel.Lockout: the updated counter.
+// - error: if the update operation fails, an error is returned.
+func (r *LockoutRepository) RegisterFailure(scope usermodel.LockoutScope, subject string, window time.Duration) (*usermodel.Lockout, error) {
+	now := time.Now()
+	expired := gorm.Expr("lockouts.last_failed_at < ?", now.Add(-window))
+	lockout := usermodel.Lockout{Scope: scope, Subject: subject, Failures: 1, LastFailedAt: now}
+	err := r.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "scope"}, {Name: "subject"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"failures":       gorm.Expr("CASE WHEN ? THEN 1 ELSE lockouts.failures + 1 END", expired),
+			"locked_until":   gorm.Expr("CASE WHEN ? THEN NULL ELSE lockouts.locked_until END", expired),
+			"last_failed_at": now,
+		}),
+	}, clause.Returning{}).Create(&lockout).Error
+	if err != nil {
+		log.Printf("failed to register failed attempt: %v", err)
+		return nil, errors.New("failed to register failed attempt")
+	}
+	return &lockout, nil
+}
+
+// Lock refuses further attempts of a subject until the given time, and adds the emails notifying
+// the owner of a locked account to the outbox in the same transaction.
+//
+// Parameters:
+// - scope: the scope of the counter.
+// - subject: the account ID or IP address the counter is kept for.
+// - until: the end of the lockout.
+// - emails: the emails notifying the owner of the account, if any.
+//
+// Returns:
+// - error: if the update operation fails, an error is returned.
+func (r *LockoutRepository) Lock(scope usermodel.LockoutScope, subject string, until time.Time, emails ...*emailmodel.EmailOutbox) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&usermodel.Lockout{}).
+			Where("scope = ? AND subject = ?", scope, subject).
+			Update("locked_until", until).Error
+		if err != nil {
+			return err
+		}
+		return emailrepository.Enqueue(tx, emails...)
+	})
+	if err != nil {
+		log.Printf("failed to lock %s %s: %v", scope, subject, err)
+		return errors.New("failed to lock")
+	}
+	return nil
+}
+
+// Delete removes the failed-attempt counter of a subject, lifting its lockout.
+//
+// Parameters:
+// - scope: the scope of the counter.
+// - subject: the account ID or IP address the counter is kept for.
+//
+// Returns:
+// - error: if the deletion operation fails, an error is returned.
+func (r *LockoutRepository) Delete(scope usermodel.LockoutScope, subject string) error {
+	err := r.DB.Where("scope = ? AND subject = ?", scope, subject).Delete(&usermodel.Lockout{}).Error
+	if err != nil {
+		log.Printf("failed to delete lockout: %v", err)
+		return errors.New("failed to delete lockout")
+	}
+	return nil
+}
+
+// FindLocked returns the subjects of a scope that are currently locked out.
+//
+// Parameters:
+// - scope: the scope of the counters.
+//
+// Returns:
+// - []usermodel.Lockout
+// - error
+func (r *LockoutRepository) FindLocked(scope usermodel.LockoutScope) ([]usermodel.Lockout, error) {
+	var lockouts []usermodel.Lockout
+	err := r.DB.Where("scope = ? AND locked_until > ?", scope, time.Now()).
+		Order("locked_until desc").
+		Find(&lockouts).Error
+	if err != nil {
+		log.Printf("failed to find lockouts: %v", err)
+		return nil, errors.New("failed to find lockouts")
+	}
+	return lockouts, nil
+}
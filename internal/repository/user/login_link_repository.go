@@ -0,0 +1,159 @@
+package userrepository
+
+import (
+	"errors"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	emailrepository "github.com/drunkleen/rasta/internal/repository/email"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"time"
+)
+
+type LoginLinkRepository struct {
+	DB *gorm.DB
+}
+
+// NewLoginLinkRepository returns a new instance of LoginLinkRepository.
+//
+// Parameters:
+// - db: the database connection to be used by the LoginLinkRepository.
+//
+// Returns:
+// - *LoginLinkRepository
+func NewLoginLinkRepository(db *gorm.DB) *LoginLinkRepository {
+	return &LoginLinkRepository{DB: db}
+}
+
+// Create stores a new sign-in link, replacing the previous links of the same user, and adds the
+// email delivering it to the outbox in the same transaction.
+//
+// Parameters:
+// - link: the link to store. Its ID is generated.
+// - email: the email giving the user the link.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
+func (r *LoginLinkRepository) Create(link *usermodel.LoginLink, email *emailmodel.EmailOutbox) error {
+	link.Id = uuid.New()
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", link.UserId).Delete(&usermodel.LoginLink{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(link).Error; err != nil {
+			return err
+		}
+		return emailrepository.Enqueue(tx, email)
+	})
+	if err != nil {
+		log.Printf("failed to create login link: %v", err)
+		return errors.New("failed to create login link")
+	}
+	return nil
+}
+
+// FindById finds a sign-in link by its ID.
+//
+// Parameters:
+// - id: the UUID of the link.
+//
+// Returns:
+// - *usermodel.LoginLink
+// - error
+func (r *LoginLinkRepository) FindById(id uuid.UUID) (*usermodel.LoginLink, error) {
+	var link usermodel.LoginLink
+	err := r.DB.Where("id = ?", id).First(&link).Error
+	return &link, err
+}
+
+// FindByTokenHash finds a sign-in link by the hash of its token.
+//
+// Parameters:
+// - tokenHash: the SHA-256 hash of the token.
+//
+// Returns:
+// - *usermodel.LoginLink
+// - error
+func (r *LoginLinkRepository) FindByTokenHash(tokenHash string) (*usermodel.LoginLink, error) {
+	var link usermodel.LoginLink
+	err := r.DB.Where("token_hash = ?", tokenHash).First(&link).Error
+	return &link, err
+}
+
+// ReserveAttempt counts a guess of the code of a sign-in link before the guess is checked.
+//
+// The attempt is only counted while fewer than maxAttempts guesses have been made, in a single
+// statement, so that parallel guesses cannot exceed the limit.
+//
+// Parameters:
+// - id: the UUID of the link.
+// - maxAttempts: the number of guesses allowed.
+//
+// Returns:
+// - int: the number of guesses made so far, this one included.
+// - bool: false if no guess is left.
+// - error: an error if the update fails.
+func (r *LoginLinkRepository) ReserveAttempt(id uuid.UUID, maxAttempts int) (int, bool, error) {
+	var link usermodel.LoginLink
+	result := r.DB.Model(&link).Clauses(clause.Returning{Columns: []clause.Column{{Name: "attempts"}}}).
+		Where("id = ? AND attempts < ?", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		log.Printf("failed to reserve login link attempt: %v", result.Error)
+		return 0, false, result.Error
+	}
+	return link.Attempts, result.RowsAffected == 1, nil
+}
+
+// Use marks an unused sign-in link as used.
+//
+// The update only matches a link that has not been used yet, so a link can never be used twice,
+// even by concurrent requests.
+//
+// Parameters:
+// - id: the UUID of the link.
+//
+// Returns:
+// - bool: whether an unused link matched.
+// - error: an error if the update fails, nil otherwise.
+func (r *LoginLinkRepository) Use(id uuid.UUID) (bool, error) {
+	result := r.DB.Model(&usermodel.LoginLink{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		log.Printf("failed to use login link: %v", result.Error)
+		return false, errors.New("failed to use login link")
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Delete deletes a sign-in link.
+//
+// Parameters:
+// - id: the UUID of the link.
+//
+// Returns:
+// - error: if the deletion fails, an error is returned.
+func (r *LoginLinkRepository) Delete(id uuid.UUID) error {
+	if err := r.DB.Where("id = ?", id).Delete(&usermodel.LoginLink{}).Error; err != nil {
+		log.Printf("failed to delete login link: %v", err)
+		return err
+	}
+	return nil
+}
+
+// FindUserById finds the owner of a sign-in link by the user ID.
+//
+// Parameters:
+// - id: the UUID of the user.
+//
+// Returns:
+// - *usermodel.User
+// - error
+func (r *LoginLinkRepository) FindUserById(id uuid.UUID) (*usermodel.User, error) {
+	var user usermodel.User
+	err := r.DB.Preload("OAuth").Where("id = ?", id).First(&user).Error
+	return &user, err
+}
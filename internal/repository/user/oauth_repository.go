@@ -6,6 +6,7 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"log"
+	"time"
 )
 
 type OAuthRepository struct {
@@ -78,7 +79,7 @@ func (r *OAuthRepository) UpdateOAuthEnabled(id uuid.UUID, oauthEnabled bool) er
 	return nil
 }
 
-// DeleteOAuth deletes the OAuth record associated with the given user ID.
+// DeleteOAuth deletes the OAuth record and the recovery codes associated with the given user ID.
 //
 // Parameters:
 // - id: the UUID of the user
@@ -86,7 +87,12 @@ func (r *OAuthRepository) UpdateOAuthEnabled(id uuid.UUID, oauthEnabled bool) er
 // Returns:
 // - error: an error if the deletion fails, nil otherwise.
 func (r *OAuthRepository) DeleteOAuth(id uuid.UUID) error {
-	err := r.DB.Where("user_id = ?", id).Delete(&usermodel.OAuth{}).Error
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", id).Delete(&usermodel.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", id).Delete(&usermodel.OAuth{}).Error
+	})
 	if err != nil {
 		log.Printf("failed to delete oauth: %v", err)
 		return err
@@ -106,14 +112,86 @@ func (r *OAuthRepository) DeleteOAuth(id uuid.UUID) error {
 //
 //	an error, if any
 func (r *OAuthRepository) UpdateOAuthSecret(id uuid.UUID, oauthEnabled bool, secret string) error {
-	updates := map[string]interface{}{
-		"enabled": oauthEnabled,
-		"secret":  secret,
-	}
-	err := r.DB.Model(&usermodel.OAuth{}).Where("user_id = ?", id).Updates(updates).Error
+	// The secret is updated from a struct, so that it is encrypted like on creation, bound to the
+	// user it belongs to.
+	updates := &usermodel.OAuth{UserId: id, Enabled: oauthEnabled, Secret: secret}
+	err := r.DB.Model(&usermodel.OAuth{}).Where("user_id = ?", id).Select("enabled", "secret").Updates(updates).Error
 	if err != nil {
 		log.Printf("failed to update otp_enabled: %v", err)
 		return err
 	}
 	return nil
 }
+
+// ReplaceRecoveryCodes replaces the recovery codes of the given user, invalidating the previous ones.
+//
+// Parameters:
+// - id: the UUID of the user
+// - codeHashes: the hashes of the new recovery codes
+//
+// Returns:
+// - error: an error if the operation fails, nil otherwise.
+func (r *OAuthRepository) ReplaceRecoveryCodes(id uuid.UUID, codeHashes []string) error {
+	now := time.Now()
+	codes := make([]usermodel.RecoveryCode, len(codeHashes))
+	for i, codeHash := range codeHashes {
+		codes[i] = usermodel.RecoveryCode{
+			Id:        uuid.New(),
+			UserId:    id,
+			CodeHash:  codeHash,
+			CreatedAt: now,
+		}
+	}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", id).Delete(&usermodel.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+	if err != nil {
+		log.Printf("failed to replace recovery codes: %v", err)
+		return errors.New("failed to replace recovery codes")
+	}
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code of the given user as used.
+//
+// The update only matches a code that has not been used yet, so a code can never be used twice,
+// even by concurrent requests.
+//
+// Parameters:
+// - id: the UUID of the user
+// - codeHash: the hash of the recovery code
+//
+// Returns:
+// - bool: whether an unused code matched.
+// - error: an error if the update fails, nil otherwise.
+func (r *OAuthRepository) UseRecoveryCode(id uuid.UUID, codeHash string) (bool, error) {
+	result := r.DB.Model(&usermodel.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", id, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		log.Printf("failed to use recovery code: %v", result.Error)
+		return false, errors.New("failed to use recovery code")
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountUnusedRecoveryCodes returns the number of recovery codes the given user has left.
+//
+// Parameters:
+// - id: the UUID of the user
+//
+// Returns:
+// - int64
+// - error
+func (r *OAuthRepository) CountUnusedRecoveryCodes(id uuid.UUID) (int64, error) {
+	var count int64
+	err := r.DB.Model(&usermodel.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", id).Count(&count).Error
+	if err != nil {
+		log.Printf("failed to count recovery codes: %v", err)
+		return 0, errors.New("failed to count recovery codes")
+	}
+	return count, nil
+}
@@ -2,10 +2,13 @@ package userrepository
 
 import (
 	"errors"
-	"github.com/drunkleen/rasta/internal/common/utils"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
 	"github.com/drunkleen/rasta/internal/models/user"
+	emailrepository "github.com/drunkleen/rasta/internal/repository/email"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"log"
 	"time"
 )
@@ -25,28 +28,24 @@ func NewOtpRepository(db *gorm.DB) *OtpRepository {
 	return &OtpRepository{DB: db}
 }
 
-// Create creates a new OTP entry for the given user ID.
+// Create creates a new OTP entry for the given user ID, replacing the previous one, and adds the
+// email delivering it to the outbox in the same transaction.
 //
-// It takes three parameters: userId, otpCode, and expTime.
+// It takes four parameters: userId, otpCode, expTime and email.
 // The userId is the unique identifier of the user,
 // the otpCode is the one-time password to be stored,
-// and the expTime is the time when the OTP expires.
+// the expTime is the time when the OTP expires,
+// and the email is the email giving the user the OTP.
 //
 // It returns an error if the operation fails.
-func (r *OtpRepository) Create(userId uuid.UUID, otpCode string, expTime time.Time) error {
+func (r *OtpRepository) Create(userId uuid.UUID, otpCode string, expTime time.Time, email *emailmodel.EmailOutbox) error {
 	if userId == uuid.Nil {
 		return errors.New("user ID is required")
 	}
 	if otpCode == "" {
 		return errors.New("otp code is required")
 	}
-	if err := r.DB.Where("user_id = ?", userId).First(&usermodel.OtpEmail{}).Error; err == nil {
-		err = r.Delete(userId)
-		if err != nil {
-			return err
-		}
-	}
-	hashedOtpCode, err := utils.HashString(otpCode)
+	hashedOtpCode, err := auth.HashPassword(otpCode)
 	if err != nil {
 		return err
 	}
@@ -56,8 +55,18 @@ func (r *OtpRepository) Create(userId uuid.UUID, otpCode string, expTime time.Ti
 		Code:   hashedOtpCode,
 		Expiry: expTime,
 	}
-	if err = r.DB.Create(topEmail).Error; err != nil {
-		return err
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userId).Delete(&usermodel.OtpEmail{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(topEmail).Error; err != nil {
+			return err
+		}
+		return emailrepository.Enqueue(tx, email)
+	})
+	if err != nil {
+		log.Printf("failed to create otp: %v", err)
+		return errors.New("failed to create otp")
 	}
 	return nil
 }
@@ -126,3 +135,28 @@ func (r *OtpRepository) DeleteByUserId(id uuid.UUID) error {
 	}
 	return nil
 }
+
+// ReserveAttempt counts a guess of the otp of a user before the guess is checked.
+//
+// The attempt is only counted while fewer than maxAttempts guesses have been made, in a single
+// statement, so that parallel guesses cannot exceed the limit.
+//
+// Parameters:
+// - id: the UUID of the user.
+// - maxAttempts: the number of guesses allowed.
+//
+// Returns:
+// - int: the number of guesses made so far, this one included.
+// - bool: false if no guess is left.
+// - error: an error if the update fails.
+func (r *OtpRepository) ReserveAttempt(id uuid.UUID, maxAttempts int) (int, bool, error) {
+	var otpEmail usermodel.OtpEmail
+	result := r.DB.Model(&otpEmail).Clauses(clause.Returning{Columns: []clause.Column{{Name: "attempts"}}}).
+		Where("user_id = ? AND attempts < ?", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		log.Printf("failed to reserve otp attempt: %v", result.Error)
+		return 0, false, result.Error
+	}
+	return otpEmail.Attempts, result.RowsAffected == 1, nil
+}
@@ -2,10 +2,13 @@ package userrepository
 
 import (
 	"errors"
-	"github.com/drunkleen/rasta/internal/common/utils"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
 	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	emailrepository "github.com/drunkleen/rasta/internal/repository/email"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"log"
 	"time"
 )
@@ -22,28 +25,24 @@ func NewResetPwdRepository(db *gorm.DB) *ResetPwdRepository {
 	return &ResetPwdRepository{DB: db}
 }
 
-// Create creates a new reset password entry in the database.
+// Create creates a new reset password entry in the database, replacing the previous one, and adds
+// the email delivering it to the outbox in the same transaction.
 //
 // userId is the ID of the user for which the reset password entry is to be created.
 // otpCode is the one-time password code to be stored.
 // expTime is the expiration time after which the reset password entry is no longer valid.
+// email is the email giving the user the code.
 //
 // It returns an error if the creation fails.
-func (r *ResetPwdRepository) Create(userId uuid.UUID, otpCode string, expTime time.Time) error {
+func (r *ResetPwdRepository) Create(userId uuid.UUID, otpCode string, expTime time.Time, email *emailmodel.EmailOutbox) error {
 	if userId == uuid.Nil {
 		return errors.New("user ID is required")
 	}
 	if otpCode == "" {
 		return errors.New("otp code is required")
 	}
-	if err := r.DB.Where("user_id = ?", userId).First(&usermodel.ResetPwd{}).Error; err == nil {
-		err = r.Delete(userId)
-		if err != nil {
-			return err
-		}
-	}
 
-	hashedOtpCode, err := utils.HashString(otpCode)
+	hashedOtpCode, err := auth.HashPassword(otpCode)
 	if err != nil {
 		return err
 	}
@@ -53,8 +52,18 @@ func (r *ResetPwdRepository) Create(userId uuid.UUID, otpCode string, expTime ti
 		Code:   hashedOtpCode,
 		Expiry: expTime,
 	}
-	if err = r.DB.Create(resetPwdModel).Error; err != nil {
-		return err
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userId).Delete(&usermodel.ResetPwd{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(resetPwdModel).Error; err != nil {
+			return err
+		}
+		return emailrepository.Enqueue(tx, email)
+	})
+	if err != nil {
+		log.Printf("failed to create reset password: %v", err)
+		return errors.New("failed to create reset password")
 	}
 	return nil
 }
@@ -114,3 +123,28 @@ func (r *ResetPwdRepository) DeleteByUserId(id uuid.UUID) error {
 	}
 	return nil
 }
+
+// ReserveAttempt counts a guess of the reset password code of a user before the guess is checked.
+//
+// The attempt is only counted while fewer than maxAttempts guesses have been made, in a single
+// statement, so that parallel guesses cannot exceed the limit.
+//
+// Parameters:
+// - id: the UUID of the user.
+// - maxAttempts: the number of guesses allowed.
+//
+// Returns:
+// - int: the number of guesses made so far, this one included.
+// - bool: false if no guess is left.
+// - error: an error if the update fails.
+func (r *ResetPwdRepository) ReserveAttempt(id uuid.UUID, maxAttempts int) (int, bool, error) {
+	var resetPwd usermodel.ResetPwd
+	result := r.DB.Model(&resetPwd).Clauses(clause.Returning{Columns: []clause.Column{{Name: "attempts"}}}).
+		Where("user_id = ? AND attempts < ?", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		log.Printf("failed to reserve reset password code attempt: %v", result.Error)
+		return 0, false, result.Error
+	}
+	return resetPwd.Attempts, result.RowsAffected == 1, nil
+}
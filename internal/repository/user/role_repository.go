@@ -0,0 +1,231 @@
+package userrepository
+
+import (
+	"errors"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+type RoleRepository struct {
+	DB *gorm.DB
+}
+
+// NewRoleRepository returns a new instance of RoleRepository.
+//
+// Parameters:
+// - db: the database connection to be used by the RoleRepository.
+//
+// Returns:
+// - *RoleRepository
+func NewRoleRepository(db *gorm.DB) *RoleRepository {
+	return &RoleRepository{DB: db}
+}
+
+// FindAll returns every role together with its permissions.
+//
+// Returns:
+// - []usermodel.Role
+// - error
+func (r *RoleRepository) FindAll() ([]usermodel.Role, error) {
+	var roles []usermodel.Role
+	err := r.DB.Preload("Permissions").Order("name asc").Find(&roles).Error
+	if err != nil {
+		log.Printf("failed to find roles: %v", err)
+		return nil, errors.New("failed to find roles")
+	}
+	return roles, nil
+}
+
+// FindById finds a role, together with its permissions, by its ID.
+//
+// Parameters:
+// - id: the UUID of the role.
+//
+// Returns:
+// - *usermodel.Role
+// - error
+func (r *RoleRepository) FindById(id uuid.UUID) (*usermodel.Role, error) {
+	var role usermodel.Role
+	err := r.DB.Preload("Permissions").Where("id = ?", id).First(&role).Error
+	return &role, err
+}
+
+// FindByName finds a role by its name.
+//
+// Parameters:
+// - name: the name of the role.
+//
+// Returns:
+// - *usermodel.Role
+// - error
+func (r *RoleRepository) FindByName(name string) (*usermodel.Role, error) {
+	var role usermodel.Role
+	err := r.DB.Preload("Permissions").Where("name = ?", name).First(&role).Error
+	return &role, err
+}
+
+// Create creates a new role together with its permissions.
+//
+// Parameters:
+// - role: the role to be created.
+//
+// Returns:
+// - error: if the creation operation fails, an error is returned.
+func (r *RoleRepository) Create(role *usermodel.Role) error {
+	role.Id = uuid.New()
+	now := time.Now()
+	role.CreatedAt = now
+	role.UpdatedAt = now
+	for i := range role.Permissions {
+		role.Permissions[i].RoleId = role.Id
+	}
+	if err := r.DB.Create(role).Error; err != nil {
+		log.Printf("failed to create role: %v", err)
+		return errors.New("failed to create role")
+	}
+	return nil
+}
+
+// Update updates the name and description of a role and replaces its permissions.
+//
+// Parameters:
+// - role: the role to be updated.
+//
+// Returns:
+// - error: if the update operation fails, an error is returned.
+func (r *RoleRepository) Update(role *usermodel.Role) error {
+	role.UpdatedAt = time.Now()
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"name":        role.Name,
+			"description": role.Description,
+			"updated_at":  role.UpdatedAt,
+		}
+		if err := tx.Model(&usermodel.Role{}).Where("id = ?", role.Id).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", role.Id).Delete(&usermodel.RolePermission{}).Error; err != nil {
+			return err
+		}
+		if len(role.Permissions) == 0 {
+			return nil
+		}
+		for i := range role.Permissions {
+			role.Permissions[i].RoleId = role.Id
+		}
+		return tx.Create(&role.Permissions).Error
+	})
+	if err != nil {
+		log.Printf("failed to update role: %v", err)
+		return errors.New("failed to update role")
+	}
+	return nil
+}
+
+// Delete removes a role, its permissions and every assignment of the role.
+//
+// Parameters:
+// - id: the UUID of the role.
+//
+// Returns:
+// - error: if the deletion operation fails, an error is returned.
+func (r *RoleRepository) Delete(id uuid.UUID) error {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", id).Delete(&usermodel.UserRole{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", id).Delete(&usermodel.RolePermission{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&usermodel.Role{}).Error
+	})
+	if err != nil {
+		log.Printf("failed to delete role: %v", err)
+		return errors.New("failed to delete role")
+	}
+	return nil
+}
+
+// FindByUserId returns the roles assigned to a user.
+//
+// Parameters:
+// - userId: the UUID of the user.
+//
+// Returns:
+// - []usermodel.Role
+// - error
+func (r *RoleRepository) FindByUserId(userId uuid.UUID) ([]usermodel.Role, error) {
+	var roles []usermodel.Role
+	err := r.DB.Preload("Permissions").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userId).
+		Order("roles.name asc").
+		Find(&roles).Error
+	if err != nil {
+		log.Printf("failed to find user roles: %v", err)
+		return nil, errors.New("failed to find user roles")
+	}
+	return roles, nil
+}
+
+// FindPermissionsByUserId returns the distinct permissions granted to a user through its roles.
+//
+// Parameters:
+// - userId: the UUID of the user.
+//
+// Returns:
+// - []usermodel.Permission
+// - error
+func (r *RoleRepository) FindPermissionsByUserId(userId uuid.UUID) ([]usermodel.Permission, error) {
+	var permissions []usermodel.Permission
+	err := r.DB.Model(&usermodel.RolePermission{}).
+		Distinct("role_permissions.permission").
+		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+		Where("user_roles.user_id = ?", userId).
+		Pluck("role_permissions.permission", &permissions).Error
+	if err != nil {
+		log.Printf("failed to find user permissions: %v", err)
+		return nil, errors.New("failed to find user permissions")
+	}
+	return permissions, nil
+}
+
+// AssignToUser assigns a role to a user. Assigning a role twice is a no-op.
+//
+// Parameters:
+// - userId: the UUID of the user.
+// - roleId: the UUID of the role.
+//
+// Returns:
+// - error: if the creation operation fails, an error is returned.
+func (r *RoleRepository) AssignToUser(userId, roleId uuid.UUID) error {
+	var userRole usermodel.UserRole
+	err := r.DB.Where(usermodel.UserRole{UserId: userId, RoleId: roleId}).
+		Attrs(usermodel.UserRole{CreatedAt: time.Now()}).
+		FirstOrCreate(&userRole).Error
+	if err != nil {
+		log.Printf("failed to assign role: %v", err)
+		return errors.New("failed to assign role")
+	}
+	return nil
+}
+
+// RemoveFromUser removes a role from a user.
+//
+// Parameters:
+// - userId: the UUID of the user.
+// - roleId: the UUID of the role.
+//
+// Returns:
+// - error: if the deletion operation fails, an error is returned.
+func (r *RoleRepository) RemoveFromUser(userId, roleId uuid.UUID) error {
+	err := r.DB.Where("user_id = ? AND role_id = ?", userId, roleId).Delete(&usermodel.UserRole{}).Error
+	if err != nil {
+		log.Printf("failed to remove role: %v", err)
+		return errors.New("failed to remove role")
+	}
+	return nil
+}
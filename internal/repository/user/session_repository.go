@@ -0,0 +1,298 @@
+package userrepository
+
+import (
+	"errors"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+type SessionRepository struct {
+	DB *gorm.DB
+}
+
+// NewSessionRepository returns a new instance of SessionRepository.
+//
+// Parameters:
+// - db: the database connection to be used by the SessionRepository.
+//
+// Returns:
+// - *SessionRepository
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{DB: db}
+}
+
+// Create creates a new session together with its first refresh token.
+//
+// Both records are written in a single transaction so a session never exists
+// without a usable refresh token.
+func (r *SessionRepository) Create(session *usermodel.Session, refreshToken *usermodel.RefreshToken) error {
+	if session.UserId == uuid.Nil {
+		return errors.New("user ID is required")
+	}
+	now := time.Now()
+	session.Id = uuid.New()
+	session.CreatedAt = now
+	session.LastSeenAt = now
+	refreshToken.Id = uuid.New()
+	refreshToken.SessionId = session.Id
+	refreshToken.CreatedAt = now
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		return tx.Create(refreshToken).Error
+	})
+	if err != nil {
+		log.Printf("failed to create session: %v", err)
+		return errors.New("failed to create session")
+	}
+	return nil
+}
+
+// CreateImpersonation creates a session for an admin impersonating a user.
+//
+// Impersonation sessions have no refresh token, so they end when their only access token expires.
+//
+// Parameters:
+// - session: the session to create. Its ID is generated.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
+func (r *SessionRepository) CreateImpersonation(session *usermodel.Session) error {
+	if session.UserId == uuid.Nil || session.ImpersonatorId == nil {
+		return errors.New("user ID and impersonator ID are required")
+	}
+	now := time.Now()
+	session.Id = uuid.New()
+	session.CreatedAt = now
+	session.LastSeenAt = now
+	if err := r.DB.Create(session).Error; err != nil {
+		log.Printf("failed to create impersonation session: %v", err)
+		return errors.New("failed to create impersonation session")
+	}
+	return nil
+}
+
+// FindById finds a session by its ID.
+//
+// Parameters:
+// - id: the UUID of the session.
+//
+// Returns:
+// - *usermodel.Session
+// - error
+func (r *SessionRepository) FindById(id uuid.UUID) (*usermodel.Session, error) {
+	var session usermodel.Session
+	err := r.DB.Where("id = ?", id).First(&session).Error
+	return &session, err
+}
+
+// FindRefreshTokenByHash finds a refresh token by the hash of the token.
+//
+// Parameters:
+// - tokenHash: the SHA-256 hash of the refresh token.
+//
+// Returns:
+// - *usermodel.RefreshToken
+// - error
+func (r *SessionRepository) FindRefreshTokenByHash(tokenHash string) (*usermodel.RefreshToken, error) {
+	var refreshToken usermodel.RefreshToken
+	err := r.DB.Where("token_hash = ?", tokenHash).First(&refreshToken).Error
+	return &refreshToken, err
+}
+
+// Rotate marks the given refresh token as used and stores its successor.
+//
+// The token is only marked as used if it has not been used before, which makes
+// concurrent refreshes with the same token fail for all but one caller.
+// Returns false if the token had already been used.
+func (r *SessionRepository) Rotate(used *usermodel.RefreshToken, next *usermodel.RefreshToken, session *usermodel.Session) (bool, error) {
+	now := time.Now()
+	rotated := false
+	next.Id = uuid.New()
+	next.SessionId = used.SessionId
+	next.CreatedAt = now
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&usermodel.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", used.Id).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		updates := map[string]interface{}{
+			"user_agent":   session.UserAgent,
+			"ip_address":   session.IpAddress,
+			"expires_at":   next.ExpiresAt,
+			"last_seen_at": now,
+		}
+		if err := tx.Model(&usermodel.Session{}).Where("id = ?", used.SessionId).Updates(updates).Error; err != nil {
+			return err
+		}
+		rotated = true
+		return nil
+	})
+	if err != nil {
+		log.Printf("failed to rotate refresh token: %v", err)
+		return false, errors.New("failed to rotate refresh token")
+	}
+	return rotated, nil
+}
+
+// Touch records a request made within a session.
+//
+// Parameters:
+// - id: the UUID of the session.
+//
+// Returns:
+// - error: if the update operation fails, an error is returned.
+func (r *SessionRepository) Touch(id uuid.UUID) error {
+	err := r.DB.Model(&usermodel.Session{}).Where("id = ?", id).Update("last_seen_at", time.Now()).Error
+	if err != nil {
+		log.Printf("failed to update session usage: %v", err)
+		return errors.New("failed to update session usage")
+	}
+	return nil
+}
+
+// Revoke revokes a session, and with it every refresh token of its family.
+//
+// Parameters:
+// - id: the UUID of the session.
+//
+// Returns:
+// - error: if the update operation fails, an error is returned.
+func (r *SessionRepository) Revoke(id uuid.UUID) error {
+	err := r.DB.Model(&usermodel.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		log.Printf("failed to revoke session: %v", err)
+		return errors.New("failed to revoke session")
+	}
+	return nil
+}
+
+// FindUserById finds the owner of a session by the user ID.
+//
+// Parameters:
+// - id: the UUID of the user.
+//
+// Returns:
+// - *usermodel.User
+// - error
+func (r *SessionRepository) FindUserById(id uuid.UUID) (*usermodel.User, error) {
+	var user usermodel.User
+	err := r.DB.Preload("OAuth").Where("id = ?", id).First(&user).Error
+	return &user, err
+}
+
+// FindActiveByUserId returns the sessions of a user that have neither expired nor been revoked.
+//
+// Parameters:
+// - userId: the UUID of the user.
+//
+// Returns:
+// - []usermodel.Session, most recently used first
+// - error
+func (r *SessionRepository) FindActiveByUserId(userId uuid.UUID) ([]usermodel.Session, error) {
+	var sessions []usermodel.Session
+	err := r.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userId, time.Now()).
+		Order("last_seen_at desc").
+		Find(&sessions).Error
+	if err != nil {
+		log.Printf("failed to find sessions: %v", err)
+		return nil, errors.New("failed to find sessions")
+	}
+	return sessions, nil
+}
+
+// RevokeForUser revokes a session only if it belongs to the given user.
+//
+// Parameters:
+// - id: the UUID of the session.
+// - userId: the UUID of the user owning the session.
+//
+// Returns:
+// - bool: whether a session was revoked.
+// - error: if the update operation fails, an error is returned.
+func (r *SessionRepository) RevokeForUser(id, userId uuid.UUID) (bool, error) {
+	result := r.DB.Model(&usermodel.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userId).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		log.Printf("failed to revoke session: %v", result.Error)
+		return false, errors.New("failed to revoke session")
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RevokeByUserId revokes every session of a user.
+//
+// Parameters:
+// - userId: the UUID of the user.
+//
+// Returns:
+// - error: if the update operation fails, an error is returned.
+func (r *SessionRepository) RevokeByUserId(userId uuid.UUID) error {
+	err := r.DB.Model(&usermodel.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userId).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		log.Printf("failed to revoke sessions: %v", err)
+		return errors.New("failed to revoke sessions")
+	}
+	return nil
+}
+
+// RevokeToken adds an access token to the revocation list.
+//
+// Expired entries are purged on the way, since an expired token is rejected anyway.
+//
+// Parameters:
+// - tokenId: the jti claim of the token.
+// - expiresAt: the expiry of the token.
+//
+// Returns:
+// - error: if the insert operation fails, an error is returned.
+func (r *SessionRepository) RevokeToken(tokenId string, expiresAt time.Time) error {
+	if tokenId == "" {
+		return errors.New("token ID is required")
+	}
+	now := time.Now()
+	if err := r.DB.Where("expires_at < ?", now).Delete(&usermodel.RevokedToken{}).Error; err != nil {
+		log.Printf("failed to purge revoked tokens: %v", err)
+	}
+	revokedToken := &usermodel.RevokedToken{
+		TokenId:   tokenId,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+	if err := r.DB.Save(revokedToken).Error; err != nil {
+		log.Printf("failed to revoke token: %v", err)
+		return errors.New("failed to revoke token")
+	}
+	return nil
+}
+
+// IsTokenRevoked reports whether an access token is on the revocation list.
+//
+// Parameters:
+// - tokenId: the jti claim of the token.
+//
+// Returns:
+// - bool
+// - error
+func (r *SessionRepository) IsTokenRevoked(tokenId string) (bool, error) {
+	var count int64
+	err := r.DB.Model(&usermodel.RevokedToken{}).Where("token_id = ?", tokenId).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,68 @@
+package userrepository
+
+import (
+	"errors"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+type SignupRepository struct {
+	DB *gorm.DB
+}
+
+// NewSignupRepository returns a new instance of SignupRepository.
+//
+// Parameters:
+// - db: the database connection to be used by the SignupRepository.
+//
+// Returns:
+// - *SignupRepository
+func NewSignupRepository(db *gorm.DB) *SignupRepository {
+	return &SignupRepository{DB: db}
+}
+
+// FindSince finds the accounts signed up from an IP address since a time, oldest first.
+//
+// Parameters:
+// - ipAddress: the IP address of the client.
+// - since: the start of the quota window.
+//
+// Returns:
+// - []usermodel.SignupAttempt
+// - error: if the query fails.
+func (r *SignupRepository) FindSince(ipAddress string, since time.Time) ([]usermodel.SignupAttempt, error) {
+	var attempts []usermodel.SignupAttempt
+	err := r.DB.Where("ip_address = ? AND created_at > ?", ipAddress, since).Order("created_at").Find(&attempts).Error
+	if err != nil {
+		log.Printf("failed to find signup attempts: %v", err)
+		return nil, errors.New("failed to find signup attempts")
+	}
+	return attempts, nil
+}
+
+// Create records an account signed up from an IP address, and deletes the records of every
+// address that are older than the quota window.
+//
+// Parameters:
+// - ipAddress: the IP address of the client.
+// - before: the start of the quota window.
+//
+// Returns:
+// - error: if the insertion fails.
+func (r *SignupRepository) Create(ipAddress string, before time.Time) error {
+	attempt := usermodel.SignupAttempt{Id: uuid.New(), IpAddress: ipAddress, CreatedAt: time.Now()}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("created_at <= ?", before).Delete(&usermodel.SignupAttempt{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&attempt).Error
+	})
+	if err != nil {
+		log.Printf("failed to create signup attempt: %v", err)
+		return errors.New("failed to create signup attempt")
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package userrepository
+
+import (
+	"errors"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"time"
+)
+
+type SmsRepository struct {
+	DB *gorm.DB
+}
+
+// NewSmsRepository returns a new instance of SmsRepository.
+//
+// Parameters:
+// - db: the database connection to be used by the SmsRepository.
+//
+// Returns:
+// - *SmsRepository
+func NewSmsRepository(db *gorm.DB) *SmsRepository {
+	return &SmsRepository{DB: db}
+}
+
+// Create stores the code sent by SMS to a user for a purpose, replacing the previous one.
+//
+// Parameters:
+// - record: the code to store, with its user, purpose, phone number, expiry and token hash, if any.
+// - code: the code, of which only the hash is stored.
+//
+// Returns:
+// - error: if the code cannot be stored.
+func (r *SmsRepository) Create(record *usermodel.SmsCode, code string) error {
+	hashedCode, err := auth.HashPassword(code)
+	if err != nil {
+		return err
+	}
+	record.Code = hashedCode
+	record.Attempts = 0
+	record.CreatedAt = time.Now()
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND purpose = ?", record.UserId, record.Purpose).Delete(&usermodel.SmsCode{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(record).Error
+	})
+	if err != nil {
+		log.Printf("failed to create sms code: %v", err)
+		return errors.New("failed to create sms code")
+	}
+	return nil
+}
+
+// Find finds the code sent by SMS to a user for a purpose.
+//
+// Parameters:
+// - userId: the UUID of the user.
+// - purpose: what the code can be used for.
+//
+// Returns:
+// - *usermodel.SmsCode
+// - error: gorm.ErrRecordNotFound if no code has been sent.
+func (r *SmsRepository) Find(userId uuid.UUID, purpose usermodel.SmsPurpose) (*usermodel.SmsCode, error) {
+	var record usermodel.SmsCode
+	err := r.DB.Where("user_id = ? AND purpose = ?", userId, purpose).First(&record).Error
+	return &record, err
+}
+
+// FindByTokenHash finds a sign-in code by the hash of the token of its sign-in.
+//
+// Parameters:
+// - tokenHash: the SHA-256 hash of the token.
+//
+// Returns:
+// - *usermodel.SmsCode
+// - error
+func (r *SmsRepository) FindByTokenHash(tokenHash string) (*usermodel.SmsCode, error) {
+	var record usermodel.SmsCode
+	err := r.DB.Where("token_hash = ?", tokenHash).First(&record).Error
+	return &record, err
+}
+
+// Delete deletes the code sent by SMS to a user for a purpose, if any.
+//
+// Parameters:
+// - userId: the UUID of the user.
+// - purpose: what the code can be used for.
+//
+// Returns:
+// - error: if the deletion fails.
+func (r *SmsRepository) Delete(userId uuid.UUID, purpose usermodel.SmsPurpose) error {
+	if err := r.DB.Where("user_id = ? AND purpose = ?", userId, purpose).Delete(&usermodel.SmsCode{}).Error; err != nil {
+		log.Printf("failed to delete sms code: %v", err)
+		return errors.New("failed to delete sms code")
+	}
+	return nil
+}
+
+// ReserveAttempt counts a guess of the code sent by SMS to a user for a purpose before the guess is checked.
+//
+// The attempt is only counted while fewer than maxAttempts guesses have been made, in a single
+// statement, so that parallel guesses cannot exceed the limit.
+//
+// Parameters:
+// - userId: the UUID of the user.
+// - purpose: what the code can be used for.
+// - maxAttempts: the number of guesses allowed.
+//
+// Returns:
+// - int: the number of guesses made so far, this one included.
+// - bool: false if no guess is left.
+// - error: an error if the update fails.
+func (r *SmsRepository) ReserveAttempt(userId uuid.UUID, purpose usermodel.SmsPurpose, maxAttempts int) (int, bool, error) {
+	var record usermodel.SmsCode
+	result := r.DB.Model(&record).Clauses(clause.Returning{Columns: []clause.Column{{Name: "attempts"}}}).
+		Where("user_id = ? AND purpose = ? AND attempts < ?", userId, purpose, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		log.Printf("failed to reserve sms code attempt: %v", result.Error)
+		return 0, false, result.Error
+	}
+	return record.Attempts, result.RowsAffected == 1, nil
+}
+
+// MarkPhoneAsVerified records that a user owns a phone number, provided it is still their phone number.
+//
+// Parameters:
+// - userId: the UUID of the user.
+// - phone: the phone number a code has been sent to.
+// - verifyAccount: whether the account of the user is verified as well.
+//
+// Returns:
+// - bool: false if the user has changed their phone number in the meantime.
+// - error
+func (r *SmsRepository) MarkPhoneAsVerified(userId uuid.UUID, phone string, verifyAccount bool) (bool, error) {
+	updates := map[string]interface{}{
+		"phone_verified": true,
+		"updated_at":     time.Now(),
+	}
+	if verifyAccount {
+		updates["is_verified"] = true
+	}
+	result := r.DB.Model(&usermodel.User{}).Where("id = ? AND phone = ?", userId, phone).Updates(updates)
+	if result.Error != nil {
+		log.Printf("failed to mark phone as verified: %v", result.Error)
+		return false, errors.New("failed to mark phone as verified")
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateSmsTwoFactor enables or disables SMS two-factor authentication for a user.
+//
+// Parameters:
+// - userId: the UUID of the user.
+// - enabled: whether SMS two-factor authentication is enabled.
+//
+// Returns:
+// - error: if the update fails.
+func (r *SmsRepository) UpdateSmsTwoFactor(userId uuid.UUID, enabled bool) error {
+	updates := map[string]interface{}{
+		"sms_two_factor_enabled": enabled,
+		"updated_at":             time.Now(),
+	}
+	if err := r.DB.Model(&usermodel.User{}).Where("id = ?", userId).Updates(updates).Error; err != nil {
+		log.Printf("failed to update sms_two_factor_enabled: %v", err)
+		return errors.New("failed to update sms_two_factor_enabled")
+	}
+	return nil
+}
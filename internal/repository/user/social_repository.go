@@ -0,0 +1,293 @@
+package userrepository
+
+import (
+	"errors"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	"github.com/drunkleen/rasta/internal/common/utils"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+type SocialRepository struct {
+	DB *gorm.DB
+}
+
+// NewSocialRepository returns a new instance of SocialRepository.
+//
+// Parameters:
+// - db: the database connection to be used by the SocialRepository.
+//
+// Returns:
+// - *SocialRepository
+func NewSocialRepository(db *gorm.DB) *SocialRepository {
+	return &SocialRepository{DB: db}
+}
+
+// CreateState stores a new sign-in at an identity provider and drops the expired ones.
+//
+// Parameters:
+// - state: the state to store. Its ID is generated.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
+func (r *SocialRepository) CreateState(state *usermodel.SocialState) error {
+	state.Id = uuid.New()
+	if err := r.DB.Where("expires_at < ?", time.Now()).Delete(&usermodel.SocialState{}).Error; err != nil {
+		log.Printf("failed to delete expired social states: %v", err)
+	}
+	if err := r.DB.Create(state).Error; err != nil {
+		log.Printf("failed to create social state: %v", err)
+		return errors.New("failed to create social state")
+	}
+	return nil
+}
+
+// FindState finds a sign-in that has not expired by the hash of its state.
+//
+// Parameters:
+// - stateHash: the SHA-256 hash of the state.
+// - provider: the provider the sign-in must have been started for.
+//
+// Returns:
+// - *usermodel.SocialState
+// - error
+func (r *SocialRepository) FindState(stateHash, provider string) (*usermodel.SocialState, error) {
+	var state usermodel.SocialState
+	err := r.DB.Where("state_hash = ? AND provider = ? AND expires_at > ?", stateHash, provider, time.Now()).
+		First(&state).Error
+	return &state, err
+}
+
+// SetStateIdentity records the identity a provider called back for.
+//
+// The update only matches a state that has not been called back yet, so an authorization
+// code cannot be exchanged twice for the same state, even by concurrent requests.
+//
+// Parameters:
+// - id: the UUID of the state.
+// - identityId: the UUID of the identity.
+//
+// Returns:
+// - bool: whether a state that had not been called back matched.
+// - error: an error if the update fails, nil otherwise.
+func (r *SocialRepository) SetStateIdentity(id, identityId uuid.UUID) (bool, error) {
+	result := r.DB.Model(&usermodel.SocialState{}).
+		Where("id = ? AND identity_id IS NULL", id).
+		Update("identity_id", identityId)
+	if result.Error != nil {
+		log.Printf("failed to update social state: %v", result.Error)
+		return false, errors.New("failed to update social state")
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteState deletes a sign-in at an identity provider.
+//
+// Parameters:
+// - id: the UUID of the state.
+//
+// Returns:
+// - error: if the deletion fails, an error is returned.
+func (r *SocialRepository) DeleteState(id uuid.UUID) error {
+	if err := r.DB.Where("id = ?", id).Delete(&usermodel.SocialState{}).Error; err != nil {
+		log.Printf("failed to delete social state: %v", err)
+		return err
+	}
+	return nil
+}
+
+// FindIdentity finds an identity by the subject a provider identifies it with.
+//
+// Parameters:
+// - provider: the name of the provider.
+// - subject: the subject of the account at the provider.
+//
+// Returns:
+// - *usermodel.Identity
+// - error
+func (r *SocialRepository) FindIdentity(provider, subject string) (*usermodel.Identity, error) {
+	var identity usermodel.Identity
+	err := r.DB.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	return &identity, err
+}
+
+// FindIdentityById finds an identity by its ID.
+//
+// Parameters:
+// - id: the UUID of the identity.
+//
+// Returns:
+// - *usermodel.Identity
+// - error
+func (r *SocialRepository) FindIdentityById(id uuid.UUID) (*usermodel.Identity, error) {
+	var identity usermodel.Identity
+	err := r.DB.Where("id = ?", id).First(&identity).Error
+	return &identity, err
+}
+
+// FindIdentitiesByUserId returns the identities linked to a user, oldest first.
+//
+// Parameters:
+// - userId: the UUID of the user.
+//
+// Returns:
+// - []usermodel.Identity
+// - error
+func (r *SocialRepository) FindIdentitiesByUserId(userId uuid.UUID) ([]usermodel.Identity, error) {
+	var identities []usermodel.Identity
+	err := r.DB.Where("user_id = ?", userId).Order("created_at").Find(&identities).Error
+	return identities, err
+}
+
+// CreateIdentity links a new identity to a user.
+//
+// Parameters:
+// - identity: the identity to store. Its ID is generated.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
+func (r *SocialRepository) CreateIdentity(identity *usermodel.Identity) error {
+	identity.Id = uuid.New()
+	if err := r.DB.Create(identity).Error; err != nil {
+		log.Printf("failed to create identity: %v", err)
+		return errors.New("failed to create identity")
+	}
+	return nil
+}
+
+// CreateUserWithIdentity creates a user signing up at an identity provider together with their identity.
+//
+// Parameters:
+// - user: the user to create. Its ID is generated and its password hashed.
+// - identity: the identity to link to the user. Its ID is generated.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned and neither is stored.
+func (r *SocialRepository) CreateUserWithIdentity(user *usermodel.User, identity *usermodel.Identity) error {
+	user.Id = uuid.New()
+	now := time.Now()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	user.CanonicalEmail = utils.CanonicalEmail(user.Email)
+	var err error
+	user.Password, err = auth.HashPassword(user.Password)
+	if err != nil {
+		log.Printf("failed to hash password: %v", err)
+		return errors.New("failed to hash password")
+	}
+	identity.Id = uuid.New()
+	identity.UserId = user.Id
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return tx.Create(identity).Error
+	})
+	if err != nil {
+		log.Printf("failed to create user with identity: %v", err)
+		return errors.New("failed to create user")
+	}
+	return nil
+}
+
+// UpdateIdentityUsage records a sign-in with an identity and the email address the provider sent.
+//
+// Parameters:
+// - id: the UUID of the identity.
+// - email: the email address of the account at the provider.
+//
+// Returns:
+// - error: an error if the update fails.
+func (r *SocialRepository) UpdateIdentityUsage(id uuid.UUID, email string) error {
+	err := r.DB.Model(&usermodel.Identity{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"email": email, "last_used_at": time.Now()}).Error
+	if err != nil {
+		log.Printf("failed to update identity usage: %v", err)
+		return errors.New("failed to update identity usage")
+	}
+	return nil
+}
+
+// DeleteIdentity unlinks an identity from its owner.
+//
+// Parameters:
+// - id: the UUID of the identity.
+// - userId: the UUID of the owner.
+//
+// Returns:
+// - bool: whether an identity of the user matched.
+// - error: if the deletion fails, an error is returned.
+func (r *SocialRepository) DeleteIdentity(id, userId uuid.UUID) (bool, error) {
+	result := r.DB.Where("id = ? AND user_id = ?", id, userId).Delete(&usermodel.Identity{})
+	if result.Error != nil {
+		log.Printf("failed to delete identity: %v", result.Error)
+		return false, errors.New("failed to delete identity")
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FindUserById finds a user by their ID, including their OAuth settings.
+//
+// Parameters:
+// - id: the UUID of the user.
+//
+// Returns:
+// - *usermodel.User
+// - error
+func (r *SocialRepository) FindUserById(id uuid.UUID) (*usermodel.User, error) {
+	var user usermodel.User
+	err := r.DB.Preload("OAuth").Where("id = ?", id).First(&user).Error
+	return &user, err
+}
+
+// MarkUserVerified marks the email address of a user as verified.
+//
+// Parameters:
+// - id: the UUID of the user.
+//
+// Returns:
+// - error: an error if the update fails.
+func (r *SocialRepository) MarkUserVerified(id uuid.UUID) error {
+	updates := map[string]interface{}{
+		"is_verified": true,
+		"updated_at":  time.Now(),
+	}
+	if err := r.DB.Model(&usermodel.User{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+		log.Printf("failed to update is_verified: %v", err)
+		return errors.New("failed to update is_verified")
+	}
+	return nil
+}
+
+// EmailExists reports whether an account uses an email address, or an address with the same
+// canonical form.
+//
+// Parameters:
+// - email: the email address.
+// - canonicalEmail: the canonical form of the address, or an empty string to match only the address itself.
+//
+// Returns:
+// - bool
+// - error
+func (r *SocialRepository) EmailExists(email, canonicalEmail string) (bool, error) {
+	var count int64
+	err := emailMatch(r.DB.Model(&usermodel.User{}), email, canonicalEmail).Count(&count).Error
+	return count > 0, err
+}
+
+// UsernameExists reports whether an account uses a username.
+//
+// Parameters:
+// - username: the username.
+//
+// Returns:
+// - bool
+// - error
+func (r *SocialRepository) UsernameExists(username string) (bool, error) {
+	var count int64
+	err := r.DB.Model(&usermodel.User{}).Where("username = ?", username).Count(&count).Error
+	return count > 0, err
+}
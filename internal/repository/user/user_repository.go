@@ -2,8 +2,11 @@ package userrepository
 
 import (
 	"errors"
+	"github.com/drunkleen/rasta/internal/common/auth"
 	"github.com/drunkleen/rasta/internal/common/utils"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
 	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	emailrepository "github.com/drunkleen/rasta/internal/repository/email"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"log"
@@ -116,14 +119,26 @@ func (r *UserRepository) FindByEmail(email string) (usermodel.User, error) {
 	return dbUser, nil
 }
 
-// FindByUsernameOrEmail finds a user by their username or email.
+// EmailExists reports whether an account uses an email address, or an address with the same
+// canonical form.
 //
 // Parameters:
-// - username: the username to search for.
-// - email: the email to search for.
+// - email: the email address.
+// - canonicalEmail: the canonical form of the address, or an empty string to match only the address itself.
 //
 // Returns:
-// - usermodel.User
+// - bool
+// - error
+func (r *UserRepository) EmailExists(email, canonicalEmail string) (bool, error) {
+	var count int64
+	err := emailMatch(r.DB.Model(&usermodel.User{}), email, canonicalEmail).Count(&count).Error
+	if err != nil {
+		log.Printf("failed to check email: %v", err)
+		return false, errors.New("failed to check email")
+	}
+	return count > 0, nil
+}
+
 // FindByUsernameOrEmail finds a user by their username or email.
 //
 // Parameters:
@@ -154,8 +169,9 @@ func (r *UserRepository) Create(user *usermodel.User) error {
 	now := time.Now()
 	user.CreatedAt = now
 	user.UpdatedAt = now
+	user.CanonicalEmail = utils.CanonicalEmail(user.Email)
 	var err error
-	user.Password, err = utils.HashString(user.Password)
+	user.Password, err = auth.HashPassword(user.Password)
 	if err != nil {
 		log.Printf("failed to hash password: %v", err)
 		return errors.New("failed to hash password")
@@ -184,22 +200,6 @@ func (r *UserRepository) Update(user *usermodel.User) error {
 	return nil
 }
 
-// Delete permanently removes a user from the UserRepository.
-//
-// Parameters:
-// - id: the unique identifier of the user to be deleted.
-//
-// Returns:
-// - error: if the deletion operation fails, an error is returned.
-func (r *UserRepository) Delete(id uuid.UUID) error {
-	err := r.DB.Delete(&usermodel.User{}, "id = ?", id).Error
-	if err != nil {
-		log.Printf("failed to delete user: %v", err)
-		return errors.New("failed to delete user")
-	}
-	return nil
-}
-
 // UpdateEmail updates the email of a user in the UserRepository.
 //
 // Parameters:
@@ -210,8 +210,9 @@ func (r *UserRepository) Delete(id uuid.UUID) error {
 // - error: if the update operation fails, an error is returned.
 func (r *UserRepository) UpdateEmail(id uuid.UUID, email string) error {
 	updates := map[string]interface{}{
-		"email":      email,
-		"updated_at": time.Now(),
+		"email":           email,
+		"canonical_email": utils.CanonicalEmail(email),
+		"updated_at":      time.Now(),
 	}
 	if err := r.DB.Model(&usermodel.User{}).Where("id = ?", id).Updates(updates).Error; err != nil {
 		log.Printf("failed to update email: %v", err)
@@ -222,24 +223,46 @@ func (r *UserRepository) UpdateEmail(id uuid.UUID, email string) error {
 
 // UpdatePassword updates the password of a user in the UserRepository.
 //
+// The replaced password hash is added to the password history of the user, of which only
+// the most recent entries are kept, and a required password reset is cleared.
+//
 // Parameters:
 // - id: the unique identifier of the user.
 // - password: the new password to be updated.
+// - history: the number of previous password hashes to keep, 0 clears the history.
 //
 // Returns:
 // - error: if the update operation fails, an error is returned.
-func (r *UserRepository) UpdatePassword(id uuid.UUID, password string) error {
+func (r *UserRepository) UpdatePassword(id uuid.UUID, password string, history int) error {
 	var err error
-	password, err = utils.HashString(password)
+	password, err = auth.HashPassword(password)
 	if err != nil {
 		log.Printf("failed to hash password: %v", err)
 		return errors.New("failed to hash password")
 	}
-	updates := map[string]interface{}{
-		"password":   password,
-		"updated_at": time.Now(),
-	}
-	err = r.DB.Model(&usermodel.User{}).Where("id = ?", id).Updates(updates).Error
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		var user usermodel.User
+		if err := tx.Select("id", "password").Where("id = ?", id).First(&user).Error; err != nil {
+			return err
+		}
+		if history > 0 && user.Password != "" {
+			entry := usermodel.PasswordHistory{Id: uuid.New(), UserId: id, Password: user.Password}
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+		}
+		kept := tx.Model(&usermodel.PasswordHistory{}).Select("id").Where("user_id = ?", id).
+			Order("created_at DESC").Limit(history)
+		if err := tx.Where("user_id = ? AND id NOT IN (?)", id, kept).Delete(&usermodel.PasswordHistory{}).Error; err != nil {
+			return err
+		}
+		updates := map[string]interface{}{
+			"password":                password,
+			"password_reset_required": false,
+			"updated_at":              time.Now(),
+		}
+		return tx.Model(&usermodel.User{}).Where("id = ?", id).Updates(updates).Error
+	})
 	if err != nil {
 		log.Printf("failed to update password: %v", err)
 		return errors.New("failed to update password")
@@ -247,6 +270,50 @@ func (r *UserRepository) UpdatePassword(id uuid.UUID, password string) error {
 	return nil
 }
 
+// FindPasswordHistory finds the most recent previous password hashes of a user, newest first.
+//
+// Parameters:
+// - id: the unique identifier of the user.
+// - limit: the maximum number of hashes to return.
+//
+// Returns:
+// - []usermodel.PasswordHistory: the previous password hashes of the user.
+// - error: if the find operation fails, an error is returned.
+func (r *UserRepository) FindPasswordHistory(id uuid.UUID, limit int) ([]usermodel.PasswordHistory, error) {
+	var history []usermodel.PasswordHistory
+	err := r.DB.Where("user_id = ?", id).Order("created_at DESC").Limit(limit).Find(&history).Error
+	if err != nil {
+		log.Printf("failed to find password history: %v", err)
+		return nil, errors.New("failed to find password history")
+	}
+	return history, nil
+}
+
+// RehashPassword replaces the password hash of a user by a new hash of the same password,
+// made with the configured algorithm. The hash is only replaced if it has not changed since
+// it was read, so a concurrent password change is never undone.
+//
+// Parameters:
+// - id: the unique identifier of the user.
+// - oldHash: the hash the password was verified against.
+// - password: the verified password.
+//
+// Returns:
+// - error: if the update operation fails, an error is returned.
+func (r *UserRepository) RehashPassword(id uuid.UUID, oldHash, password string) error {
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		log.Printf("failed to hash password: %v", err)
+		return errors.New("failed to hash password")
+	}
+	err = r.DB.Model(&usermodel.User{}).Where("id = ? AND password = ?", id, oldHash).Update("password", hash).Error
+	if err != nil {
+		log.Printf("failed to rehash password: %v", err)
+		return errors.New("failed to rehash password")
+	}
+	return nil
+}
+
 // UpdateUsername updates the username of a user in the UserRepository.
 //
 // Parameters:
@@ -284,6 +351,28 @@ func (r *UserRepository) UpdateRegion(id uuid.UUID, region string) error {
 	return nil
 }
 
+// UpdateProfile updates the profile fields of a user in the UserRepository, in one transaction.
+//
+// Parameters:
+// - user: the user whose username, region, first and last name, display name, locale, timezone,
+// phone and bio are saved.
+//
+// Returns:
+// - error: if the update operation fails, an error is returned and nothing is saved.
+func (r *UserRepository) UpdateProfile(user *usermodel.User) error {
+	user.UpdatedAt = time.Now()
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Model(user).
+			Select("username", "region", "first_name", "last_name", "display_name", "locale", "timezone", "phone", "phone_verified", "bio", "updated_at").
+			Updates(user).Error
+	})
+	if err != nil {
+		log.Printf("failed to update profile: %v", err)
+		return errors.New("failed to update profile")
+	}
+	return nil
+}
+
 // UpdateIsVerified updates the is_verified field of a user in the database.
 //
 // id is the unique identifier of the user to update.
@@ -314,3 +403,38 @@ func (r *UserRepository) UpdateIsDisabled(id uuid.UUID, isDisabled bool) error {
 	}
 	return nil
 }
+
+// UpdateSuspension updates the suspension status of the user with the given id.
+//
+// isDisabled is the new value of the is_disabled field, reason the reason of the suspension
+// and until its expiry, or nil for a suspension without expiry. emails are the emails notifying
+// the user, added to the outbox in the same transaction.
+// Returns an error if the update operation fails.
+func (r *UserRepository) UpdateSuspension(id uuid.UUID, isDisabled bool, reason string, until *time.Time, emails ...*emailmodel.EmailOutbox) error {
+	updates := map[string]interface{}{
+		"is_disabled":     isDisabled,
+		"disabled_reason": reason,
+		"disabled_until":  until,
+		"updated_at":      time.Now(),
+	}
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&usermodel.User{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		return emailrepository.Enqueue(tx, emails...)
+	})
+	if err != nil {
+		log.Printf("failed to update suspension: %v", err)
+		return errors.New("failed to update suspension")
+	}
+	return nil
+}
+
+// emailMatch restricts a query on users to those using an email address or, if canonicalEmail is
+// not empty, an address with the same canonical form.
+func emailMatch(query *gorm.DB, email, canonicalEmail string) *gorm.DB {
+	if canonicalEmail == "" {
+		return query.Where("email = ?", email)
+	}
+	return query.Where("email = ? OR canonical_email = ?", email, canonicalEmail)
+}
@@ -0,0 +1,176 @@
+package userrepository
+
+import (
+	"errors"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"time"
+)
+
+type WebAuthnRepository struct {
+	DB *gorm.DB
+}
+
+// NewWebAuthnRepository returns a new instance of WebAuthnRepository.
+//
+// Parameters:
+// - db: the database connection to be used by the WebAuthnRepository.
+//
+// Returns:
+// - *WebAuthnRepository
+func NewWebAuthnRepository(db *gorm.DB) *WebAuthnRepository {
+	return &WebAuthnRepository{DB: db}
+}
+
+// CreateChallenge stores the server side of a new ceremony and drops the expired ones.
+//
+// Parameters:
+// - challenge: the challenge to store. Its ID is generated.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
+func (r *WebAuthnRepository) CreateChallenge(challenge *usermodel.WebAuthnChallenge) error {
+	challenge.Id = uuid.New()
+	if err := r.DB.Where("expires_at < ?", time.Now()).Delete(&usermodel.WebAuthnChallenge{}).Error; err != nil {
+		log.Printf("failed to delete expired webauthn challenges: %v", err)
+	}
+	if err := r.DB.Create(challenge).Error; err != nil {
+		log.Printf("failed to create webauthn challenge: %v", err)
+		return errors.New("failed to create webauthn challenge")
+	}
+	return nil
+}
+
+// TakeChallenge finds a challenge that has not expired and deletes it, so it can only be used once.
+//
+// Parameters:
+// - id: the UUID of the challenge.
+// - ceremony: the ceremony the challenge must have been issued for.
+//
+// Returns:
+// - *usermodel.WebAuthnChallenge
+// - error
+func (r *WebAuthnRepository) TakeChallenge(id uuid.UUID, ceremony usermodel.WebAuthnCeremony) (*usermodel.WebAuthnChallenge, error) {
+	var challenge usermodel.WebAuthnChallenge
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND ceremony = ? AND expires_at > ?", id, ceremony, time.Now()).
+			First(&challenge).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&challenge).Error
+	})
+	return &challenge, err
+}
+
+// CreateCredential stores a newly registered credential.
+//
+// Parameters:
+// - credential: the credential to store. Its ID is generated.
+//
+// Returns:
+// - error: if the insertion fails, an error is returned.
+func (r *WebAuthnRepository) CreateCredential(credential *usermodel.WebAuthnCredential) error {
+	credential.Id = uuid.New()
+	if err := r.DB.Create(credential).Error; err != nil {
+		log.Printf("failed to create webauthn credential: %v", err)
+		return errors.New("failed to create webauthn credential")
+	}
+	return nil
+}
+
+// FindCredentialsByUserId returns the credentials registered by a user, oldest first.
+//
+// Parameters:
+// - userId: the UUID of the user.
+//
+// Returns:
+// - []usermodel.WebAuthnCredential
+// - error
+func (r *WebAuthnRepository) FindCredentialsByUserId(userId uuid.UUID) ([]usermodel.WebAuthnCredential, error) {
+	var credentials []usermodel.WebAuthnCredential
+	err := r.DB.Where("user_id = ?", userId).Order("created_at").Find(&credentials).Error
+	return credentials, err
+}
+
+// CountCredentialsByUserId returns the number of credentials registered by a user.
+//
+// Parameters:
+// - userId: the UUID of the user.
+//
+// Returns:
+// - int64
+// - error
+func (r *WebAuthnRepository) CountCredentialsByUserId(userId uuid.UUID) (int64, error) {
+	var count int64
+	err := r.DB.Model(&usermodel.WebAuthnCredential{}).Where("user_id = ?", userId).Count(&count).Error
+	return count, err
+}
+
+// FindCredentialByCredentialId finds a credential by the ID the authenticator assigned to it.
+//
+// Parameters:
+// - credentialId: the raw credential ID.
+//
+// Returns:
+// - *usermodel.WebAuthnCredential
+// - error
+func (r *WebAuthnRepository) FindCredentialByCredentialId(credentialId []byte) (*usermodel.WebAuthnCredential, error) {
+	var credential usermodel.WebAuthnCredential
+	err := r.DB.Where("credential_id = ?", credentialId).First(&credential).Error
+	return &credential, err
+}
+
+// UpdateCredentialUsage stores the credential record updated by a login and its time of use.
+//
+// Parameters:
+// - id: the UUID of the credential.
+// - data: the JSON encoded credential record.
+//
+// Returns:
+// - error: if the update fails, an error is returned.
+func (r *WebAuthnRepository) UpdateCredentialUsage(id uuid.UUID, data []byte) error {
+	err := r.DB.Model(&usermodel.WebAuthnCredential{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"data": data, "last_used_at": time.Now()}).Error
+	if err != nil {
+		log.Printf("failed to update webauthn credential: %v", err)
+		return errors.New("failed to update webauthn credential")
+	}
+	return nil
+}
+
+// DeleteCredential deletes a credential of a user.
+//
+// Parameters:
+// - id: the UUID of the credential.
+// - userId: the UUID of the user the credential must belong to.
+//
+// Returns:
+// - bool: whether a credential was deleted.
+// - error: if the deletion fails, an error is returned.
+func (r *WebAuthnRepository) DeleteCredential(id, userId uuid.UUID) (bool, error) {
+	result := r.DB.Where("id = ? AND user_id = ?", id, userId).Delete(&usermodel.WebAuthnCredential{})
+	if result.Error != nil {
+		log.Printf("failed to delete webauthn credential: %v", result.Error)
+		return false, errors.New("failed to delete webauthn credential")
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FindUserById finds the owner of a credential by the user ID.
+//
+// Parameters:
+// - id: the UUID of the user.
+//
+// Returns:
+// - *usermodel.User
+// - error
+func (r *WebAuthnRepository) FindUserById(id uuid.UUID) (*usermodel.User, error) {
+	var user usermodel.User
+	err := r.DB.Preload("OAuth").Where("id = ?", id).First(&user).Error
+	return &user, err
+}
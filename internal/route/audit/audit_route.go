@@ -0,0 +1,26 @@
+package auditroute
+
+import (
+	auditcontroller "github.com/drunkleen/rasta/internal/controller/audit"
+	"github.com/drunkleen/rasta/internal/middlewares"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	auditrepository "github.com/drunkleen/rasta/internal/repository/audit"
+	auditservice "github.com/drunkleen/rasta/internal/service/audit"
+	"github.com/drunkleen/rasta/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterAuditRoutes registers the endpoints through which admins read the audit log.
+func RegisterAuditRoutes(r *gin.RouterGroup) {
+	auditRepository := auditrepository.NewAuditRepository(database.DB)
+	auditService := auditservice.NewAuditService(auditRepository)
+	auditController := auditcontroller.NewAuditController(auditService)
+
+	adminAuditRoute := r.Group("/admin/audit-logs")
+
+	registerAdminAuditRoutes(adminAuditRoute, auditController)
+}
+
+func registerAdminAuditRoutes(r *gin.RouterGroup, auditController *auditcontroller.AuditController) {
+	r.GET("/", middlewares.RequirePermission(usermodel.PermissionAuditRead), auditController.GetAuditLogs)
+}
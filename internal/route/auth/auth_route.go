@@ -0,0 +1,17 @@
+package authroute
+
+import (
+	authcontroller "github.com/drunkleen/rasta/internal/controller/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterWellKnownRoutes registers the /.well-known endpoints. They live at the
+// root of the server rather than under the API base path.
+func RegisterWellKnownRoutes(r *gin.RouterGroup) {
+	jwksController := authcontroller.NewJWKSController()
+	oidcDiscoveryController := authcontroller.NewOidcDiscoveryController()
+
+	wellKnownRoute := r.Group("/.well-known")
+	wellKnownRoute.GET("/jwks.json", jwksController.GetJWKS)
+	wellKnownRoute.GET("/openid-configuration", oidcDiscoveryController.GetConfiguration)
+}
@@ -0,0 +1,31 @@
+package emailroute
+
+import (
+	emailcontroller "github.com/drunkleen/rasta/internal/controller/email"
+	"github.com/drunkleen/rasta/internal/middlewares"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	emailrepository "github.com/drunkleen/rasta/internal/repository/email"
+	emailservice "github.com/drunkleen/rasta/internal/service/email"
+	"github.com/drunkleen/rasta/pkg/database"
+	emailPkg "github.com/drunkleen/rasta/pkg/email"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterEmailRoutes registers the endpoints through which admins follow and resend the emails of
+// the outbox.
+func RegisterEmailRoutes(r *gin.RouterGroup) {
+	outboxRepository := emailrepository.NewOutboxRepository(database.DB)
+	outboxService := emailservice.NewOutboxService(outboxRepository, emailPkg.DefaultMailer())
+	outboxController := emailcontroller.NewOutboxController(outboxService)
+
+	adminEmailRoute := r.Group("/admin/emails")
+
+	registerAdminEmailRoutes(adminEmailRoute, outboxController)
+}
+
+func registerAdminEmailRoutes(r *gin.RouterGroup, outboxController *emailcontroller.OutboxController) {
+	emailsRead := middlewares.RequirePermission(usermodel.PermissionEmailsRead)
+	r.GET("/", emailsRead, outboxController.GetEmails)
+	r.GET("/:id", emailsRead, outboxController.GetEmail)
+	r.POST("/:id/resend", middlewares.RequirePermission(usermodel.PermissionEmailsWrite), outboxController.ResendEmail)
+}
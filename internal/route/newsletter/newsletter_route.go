@@ -3,23 +3,27 @@ package newsletterroute
 import (
 	newslettercontroller "github.com/drunkleen/rasta/internal/controller/newsletter"
 	"github.com/drunkleen/rasta/internal/middlewares"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	emailrepository "github.com/drunkleen/rasta/internal/repository/email"
 	newsletterrepository "github.com/drunkleen/rasta/internal/repository/newsletter"
+	emailservice "github.com/drunkleen/rasta/internal/service/email"
 	newsletterservice "github.com/drunkleen/rasta/internal/service/newsletter"
 	"github.com/drunkleen/rasta/pkg/database"
+	emailPkg "github.com/drunkleen/rasta/pkg/email"
 	"github.com/gin-gonic/gin"
 )
 
 func RegisterUserRoutes(r *gin.RouterGroup) {
 	db := database.DB
 	nlRepository := newsletterrepository.NewNewsletterRepository(db)
-	nlService := newsletterservice.NewNewsletterService(nlRepository)
+	outboxService := emailservice.NewOutboxService(emailrepository.NewOutboxRepository(db), emailPkg.DefaultMailer())
+	nlService := newsletterservice.NewNewsletterService(nlRepository, outboxService)
 	nlController := newslettercontroller.NewNewsletterController(nlService)
 
 	userRoute := r.Group("/users/newsletter")
 	//userRoute.Use(middlewares.JWTAuthMiddleware)
 
 	adminOnlyRoute := r.Group("/admin/newsletter")
-	adminOnlyRoute.Use(middlewares.AdminAuthMiddleware)
 
 	registerOpenRoutes(userRoute, nlController)
 	registerAdminOnlyRoutes(adminOnlyRoute, nlController)
@@ -30,9 +34,10 @@ func registerOpenRoutes(r *gin.RouterGroup, newsletterController *newslettercont
 	r.POST("/unsubscribe", newsletterController.Unsubscribe)
 }
 func registerAdminOnlyRoutes(r *gin.RouterGroup, newsletterController *newslettercontroller.NewsletterController) {
-	r.GET("/subscribers", newsletterController.GetSubscribers)
-	r.GET("/subscribers/count", newsletterController.GetSubscribersCount)
-	r.GET("/unsubscribed/count", newsletterController.GetUnsubscribedCount)
-	r.DELETE("/delete", newsletterController.DeleteSubscriber)
-	r.POST("/send", newsletterController.SendNewsletterToEveryActiveParticipants)
+	newsletterRead := middlewares.RequirePermission(usermodel.PermissionNewsletterRead)
+	r.GET("/subscribers", newsletterRead, newsletterController.GetSubscribers)
+	r.GET("/subscribers/count", newsletterRead, newsletterController.GetSubscribersCount)
+	r.GET("/unsubscribed/count", newsletterRead, newsletterController.GetUnsubscribedCount)
+	r.DELETE("/delete", middlewares.RequirePermission(usermodel.PermissionNewsletterDelete), newsletterController.DeleteSubscriber)
+	r.POST("/send", middlewares.RequirePermission(usermodel.PermissionNewsletterSend), newsletterController.SendNewsletterToEveryActiveParticipants)
 }
@@ -0,0 +1,54 @@
+package oidcroute
+
+import (
+	oidccontroller "github.com/drunkleen/rasta/internal/controller/oidc"
+	"github.com/drunkleen/rasta/internal/middlewares"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	oidcrepository "github.com/drunkleen/rasta/internal/repository/oidc"
+	oidcservice "github.com/drunkleen/rasta/internal/service/oidc"
+	"github.com/drunkleen/rasta/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterOidcRoutes registers the endpoints through which Rasta acts as an OpenID Connect
+// provider for partner apps. The discovery document is served with the /.well-known endpoints.
+func RegisterOidcRoutes(r *gin.RouterGroup) {
+	db := database.DB
+	oidcRepository := oidcrepository.NewOidcRepository(db)
+	oidcService := oidcservice.NewOidcService(oidcRepository)
+	oidcController := oidccontroller.NewOidcController(oidcService)
+	clientController := oidccontroller.NewClientController(oidcService)
+
+	oidcRoute := r.Group("/oidc")
+	oidcRouteClosed := oidcRoute.Group("/")
+	oidcRouteClosed.Use(middlewares.JWTAuthMiddleware)
+	adminClientRoute := r.Group("/admin/oidc/clients")
+
+	registerOpenOidcRoutes(oidcRoute, oidcController)
+	registerClosedOidcRoutes(oidcRouteClosed, oidcController)
+	registerAdminClientRoutes(adminClientRoute, clientController)
+}
+
+func registerOpenOidcRoutes(r *gin.RouterGroup, oidcController *oidccontroller.OidcController) {
+	r.GET("/authorize", oidcController.Authorize)
+	r.POST("/token", oidcController.Token)
+	r.GET("/userinfo", oidcController.UserInfo)
+	r.POST("/userinfo", oidcController.UserInfo)
+}
+
+func registerClosedOidcRoutes(r *gin.RouterGroup, oidcController *oidccontroller.OidcController) {
+	r.GET("/consent", oidcController.GetConsent)
+	r.POST("/consent", middlewares.DenyImpersonation, oidcController.Consent)
+	r.GET("/consents", oidcController.GetConsents)
+	r.DELETE("/consents/:clientId", middlewares.DenyImpersonation, oidcController.RevokeConsent)
+}
+
+func registerAdminClientRoutes(r *gin.RouterGroup, clientController *oidccontroller.ClientController) {
+	clientsRead := middlewares.RequirePermission(usermodel.PermissionClientsRead)
+	clientsWrite := middlewares.RequirePermission(usermodel.PermissionClientsWrite)
+	r.GET("/", clientsRead, clientController.GetClients)
+	r.POST("/", clientsWrite, clientController.CreateClient)
+	r.PUT("/:id", clientsWrite, clientController.UpdateClient)
+	r.POST("/:id/secret", clientsWrite, clientController.RotateSecret)
+	r.DELETE("/:id", clientsWrite, clientController.DeleteClient)
+}
@@ -0,0 +1,48 @@
+package ticketroute
+
+import (
+	ticketcontroller "github.com/drunkleen/rasta/internal/controller/ticket"
+	"github.com/drunkleen/rasta/internal/middlewares"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	ticketrepository "github.com/drunkleen/rasta/internal/repository/ticket"
+	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	ticketservice "github.com/drunkleen/rasta/internal/service/ticket"
+	userservice "github.com/drunkleen/rasta/internal/service/user"
+	"github.com/drunkleen/rasta/pkg/database"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterTicketRoutes registers the endpoints through which users open support tickets and
+// staff holding the tickets permissions work on them.
+func RegisterTicketRoutes(r *gin.RouterGroup) {
+	ticketRepository := ticketrepository.NewTicketRepository(database.DB)
+	userRepository := userrepository.NewUserRepository(database.DB)
+	roleRepository := userrepository.NewRoleRepository(database.DB)
+	roleService := userservice.NewRoleService(roleRepository)
+	ticketService := ticketservice.NewTicketService(ticketRepository, userRepository, roleService)
+	ticketController := ticketcontroller.NewTicketController(ticketService)
+
+	ticketRoute := r.Group("/tickets")
+	ticketRoute.Use(middlewares.JWTAuthMiddleware)
+	adminTicketRoute := r.Group("/admin/tickets")
+
+	registerClosedTicketRoutes(ticketRoute, ticketController)
+	registerAdminTicketRoutes(adminTicketRoute, ticketController)
+}
+
+func registerClosedTicketRoutes(r *gin.RouterGroup, ticketController *ticketcontroller.TicketController) {
+	r.POST("/", middlewares.DenyImpersonation, ticketController.CreateTicket)
+	r.GET("/", ticketController.GetMyTickets)
+	r.GET("/:id", ticketController.GetMyTicket)
+	r.POST("/:id/comments", middlewares.DenyImpersonation, ticketController.CommentMyTicket)
+}
+
+func registerAdminTicketRoutes(r *gin.RouterGroup, ticketController *ticketcontroller.TicketController) {
+	ticketsRead := middlewares.RequirePermission(usermodel.PermissionTicketsRead)
+	ticketsWrite := middlewares.RequirePermission(usermodel.PermissionTicketsWrite)
+	r.GET("/", ticketsRead, ticketController.GetTickets)
+	r.GET("/:id", ticketsRead, ticketController.GetTicket)
+	r.POST("/:id/comments", ticketsWrite, ticketController.CommentTicket)
+	r.PATCH("/:id", ticketsWrite, ticketController.UpdateTicket)
+	r.PUT("/:id/assignee", middlewares.RequirePermission(usermodel.PermissionTicketsAssign), ticketController.AssignTicket)
+}
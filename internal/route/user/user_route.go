@@ -3,7 +3,10 @@ package userroute
 import (
 	"github.com/drunkleen/rasta/internal/controller/user"
 	"github.com/drunkleen/rasta/internal/middlewares"
+	"github.com/drunkleen/rasta/internal/models/user"
+	"github.com/drunkleen/rasta/internal/repository/audit"
 	"github.com/drunkleen/rasta/internal/repository/user"
+	"github.com/drunkleen/rasta/internal/service/audit"
 	"github.com/drunkleen/rasta/internal/service/user"
 	"github.com/drunkleen/rasta/pkg/database"
 	"github.com/gin-gonic/gin"
@@ -16,33 +19,98 @@ func RegisterUserRoutes(r *gin.RouterGroup) {
 	userRepository := userrepository.NewUserRepository(db)
 	oauthRepository := userrepository.NewOAuthRepository(db)
 	resetPwdRepository := userrepository.NewResetPwdRepository(db)
+	sessionRepository := userrepository.NewSessionRepository(db)
+	roleRepository := userrepository.NewRoleRepository(db)
+	lockoutRepository := userrepository.NewLockoutRepository(db)
+	webAuthnRepository := userrepository.NewWebAuthnRepository(db)
+	loginLinkRepository := userrepository.NewLoginLinkRepository(db)
+	socialRepository := userrepository.NewSocialRepository(db)
+	apiKeyRepository := userrepository.NewApiKeyRepository(db)
+	emailChangeRepository := userrepository.NewEmailChangeRepository(db)
+	accountDeletionRepository := userrepository.NewAccountDeletionRepository(db)
+	dataExportRepository := userrepository.NewDataExportRepository(db)
+	knownDeviceRepository := userrepository.NewKnownDeviceRepository(db)
+	smsRepository := userrepository.NewSmsRepository(db)
+	signupRepository := userrepository.NewSignupRepository(db)
+	auditRepository := auditrepository.NewAuditRepository(db)
 
 	otpService := userservice.NewOtpService(otpRepository)
 	userService := userservice.NewUserService(userRepository)
 	oauthService := userservice.NewOAuthService(oauthRepository)
 	resetPwdService := userservice.NewResetPwd(resetPwdRepository)
+	sessionService := userservice.NewSessionService(sessionRepository)
+	roleService := userservice.NewRoleService(roleRepository)
+	lockoutService := userservice.NewLockoutService(lockoutRepository)
+	webAuthnService := userservice.NewWebAuthnService(webAuthnRepository)
+	loginLinkService := userservice.NewLoginLinkService(loginLinkRepository)
+	socialService := userservice.NewSocialService(socialRepository)
+	apiKeyService := userservice.NewApiKeyService(apiKeyRepository)
+	emailChangeService := userservice.NewEmailChangeService(emailChangeRepository)
+	accountDeletionService := userservice.NewAccountDeletionService(accountDeletionRepository)
+	dataExportService := userservice.NewDataExportService(dataExportRepository)
+	knownDeviceService := userservice.NewKnownDeviceService(knownDeviceRepository)
+	smsService := userservice.NewSmsService(smsRepository)
+	signupGuardService := userservice.NewSignupGuardService(signupRepository)
+	auditService := auditservice.NewAuditService(auditRepository)
 
-	otpController := usercontroller.NewOtpController(otpService, userService)
-	userController := usercontroller.NewUserController(userService, otpService)
+	otpController := usercontroller.NewOtpController(otpService, userService, lockoutService, smsService)
+	userController := usercontroller.NewUserController(userService, otpService, oauthService, sessionService, lockoutService, webAuthnService, knownDeviceService, smsService, signupGuardService)
 	oauthController := usercontroller.NewOAuthController(oauthService, userService)
-	resetPwdController := usercontroller.NewResetPwdController(resetPwdService, userService)
+	resetPwdController := usercontroller.NewResetPwdController(resetPwdService, userService, sessionService, lockoutService, smsService)
+	sessionController := usercontroller.NewSessionController(sessionService)
+	roleController := usercontroller.NewRoleController(roleService, userService)
+	lockoutController := usercontroller.NewLockoutController(lockoutService, userService)
+	webAuthnController := usercontroller.NewWebAuthnController(webAuthnService, userService, sessionService, lockoutService)
+	loginLinkController := usercontroller.NewLoginLinkController(loginLinkService, userService, oauthService, sessionService, lockoutService, webAuthnService, smsService)
+	socialController := usercontroller.NewSocialController(socialService, otpService, oauthService, sessionService, lockoutService, webAuthnService, smsService)
+	apiKeyController := usercontroller.NewApiKeyController(apiKeyService, userService, roleService)
+	serviceAccountController := usercontroller.NewServiceAccountController(apiKeyService)
+	impersonationController := usercontroller.NewImpersonationController(sessionService, userService, auditService)
+	emailChangeController := usercontroller.NewEmailChangeController(emailChangeService, userService, oauthService, sessionService, lockoutService)
+	accountDeletionController := usercontroller.NewAccountDeletionController(accountDeletionService, userService, oauthService, lockoutService)
+	dataExportController := usercontroller.NewDataExportController(dataExportService, userService)
+	knownDeviceController := usercontroller.NewKnownDeviceController(knownDeviceService, userService, sessionService, resetPwdService)
+	smsController := usercontroller.NewSmsController(smsService, userService, sessionService, lockoutService, knownDeviceService)
 
 	userRoute := r.Group("/users")
 	userRouteClosed := userRoute.Group("/")
 	userRouteClosed.Use(middlewares.JWTAuthMiddleware)
-	adminOnlyRoute := r.Group("/admin")
-	adminOnlyRoute.Use(middlewares.AdminAuthMiddleware)
+	userRouteApiKey := userRoute.Group("/")
+	userRouteApiKey.Use(middlewares.ApiKeyAuthMiddleware)
+	adminUserRoute := r.Group("/admin/users")
+	adminRoleRoute := r.Group("/admin/roles")
+	adminServiceAccountRoute := r.Group("/admin/service-accounts")
 
 	registerOpenUserRoutes(userRoute, userController, resetPwdController)
 	registerOpenOtpRoutes(userRoute, otpController)
+	registerOpenSessionRoutes(userRoute, sessionController)
+	registerOpenWebAuthnRoutes(userRoute, webAuthnController)
+	registerOpenLoginLinkRoutes(userRoute, loginLinkController)
+	registerOpenSocialRoutes(userRoute, socialController)
+	registerOpenEmailChangeRoutes(userRoute, emailChangeController)
+	registerOpenKnownDeviceRoutes(userRoute, knownDeviceController)
+	registerOpenSmsRoutes(userRoute, smsController)
 	registerClosedUserRoutes(userRouteClosed, userController)
+	registerApiKeyUserRoutes(userRouteApiKey, userController)
+	registerClosedApiKeyRoutes(userRouteClosed, apiKeyController)
 	registerClosedOAuthRoutes(userRouteClosed, oauthController)
-	registerAdminRoutes(adminOnlyRoute, userController)
+	registerClosedSessionRoutes(userRouteClosed, sessionController)
+	registerClosedWebAuthnRoutes(userRouteClosed, webAuthnController)
+	registerClosedSocialRoutes(userRouteClosed, socialController)
+	registerClosedEmailChangeRoutes(userRouteClosed, emailChangeController)
+	registerClosedAccountRoutes(userRouteClosed, accountDeletionController, dataExportController)
+	registerClosedKnownDeviceRoutes(userRouteClosed, knownDeviceController)
+	registerClosedSmsRoutes(userRouteClosed, smsController)
+	registerAdminUserRoutes(adminUserRoute, userController, roleController, lockoutController, impersonationController, accountDeletionController)
+	registerAdminRoleRoutes(adminRoleRoute, roleController)
+	registerAdminServiceAccountRoutes(adminServiceAccountRoute, serviceAccountController)
 }
 
 func registerOpenUserRoutes(r *gin.RouterGroup, userController *usercontroller.UserController, resetPwd *usercontroller.ResetPwdController) {
 	r.POST("/login", userController.Login)
 	r.POST("/signup", userController.Create)
+	r.GET("/signup/challenge", userController.GetSignupChallenge)
+	r.GET("/password-policy", userController.GetPasswordPolicy)
 	r.GET("/reset-password", resetPwd.Send)
 	r.POST("/reset-password/:id/verify", resetPwd.VerifyAndResetPassword)
 }
@@ -52,19 +120,160 @@ func registerOpenOtpRoutes(r *gin.RouterGroup, otpController *usercontroller.Otp
 	r.POST("/otp/:id/verify", otpController.VerifyEmail)
 }
 
+func registerOpenSessionRoutes(r *gin.RouterGroup, sessionController *usercontroller.SessionController) {
+	r.POST("/token/refresh", sessionController.RefreshToken)
+}
+
+func registerClosedSessionRoutes(r *gin.RouterGroup, sessionController *usercontroller.SessionController) {
+	r.POST("/logout", sessionController.Logout)
+	r.GET("/sessions", sessionController.GetSessions)
+	r.DELETE("/sessions", middlewares.DenyImpersonation, sessionController.RevokeAllSessions)
+	r.DELETE("/sessions/:id", middlewares.DenyImpersonation, sessionController.RevokeSession)
+}
+
+func registerOpenWebAuthnRoutes(r *gin.RouterGroup, webAuthnController *usercontroller.WebAuthnController) {
+	r.POST("/webauthn/login/begin", webAuthnController.BeginLogin)
+	r.POST("/webauthn/login/finish", webAuthnController.FinishLogin)
+}
+
+func registerOpenLoginLinkRoutes(r *gin.RouterGroup, loginLinkController *usercontroller.LoginLinkController) {
+	r.POST("/login/email", loginLinkController.Send)
+	r.POST("/login/email/verify", loginLinkController.Verify)
+}
+
+func registerOpenSocialRoutes(r *gin.RouterGroup, socialController *usercontroller.SocialController) {
+	r.GET("/social/providers", socialController.GetProviders)
+	r.POST("/social/:provider/begin", socialController.Begin)
+	r.POST("/social/:provider/callback", socialController.Callback)
+}
+
+func registerClosedSocialRoutes(r *gin.RouterGroup, socialController *usercontroller.SocialController) {
+	r.POST("/social/:provider/link", middlewares.DenyImpersonation, socialController.BeginLink)
+	r.POST("/social/:provider/link/callback", middlewares.DenyImpersonation, socialController.FinishLink)
+	r.GET("/social/identities", socialController.GetIdentities)
+	r.DELETE("/social/identities/:id", middlewares.DenyImpersonation, socialController.Unlink)
+}
+
+func registerOpenEmailChangeRoutes(r *gin.RouterGroup, emailChangeController *usercontroller.EmailChangeController) {
+	r.POST("/email/cancel", emailChangeController.CancelEmailChange)
+}
+
+func registerClosedEmailChangeRoutes(r *gin.RouterGroup, emailChangeController *usercontroller.EmailChangeController) {
+	r.POST("/me/email", middlewares.DenyImpersonation, emailChangeController.RequestEmailChange)
+	r.POST("/me/email/verify", middlewares.DenyImpersonation, emailChangeController.VerifyEmailChange)
+}
+
+func registerClosedAccountRoutes(
+	r *gin.RouterGroup,
+	accountDeletionController *usercontroller.AccountDeletionController,
+	dataExportController *usercontroller.DataExportController,
+) {
+	r.DELETE("/me", middlewares.DenyImpersonation, accountDeletionController.ScheduleDeletion)
+	r.GET("/me/deletion", accountDeletionController.GetDeletion)
+	r.DELETE("/me/deletion", middlewares.DenyImpersonation, accountDeletionController.CancelDeletion)
+	r.GET("/me/exports", dataExportController.GetExports)
+	r.POST("/me/exports", middlewares.DenyImpersonation, dataExportController.RequestExport)
+	r.GET("/me/exports/:id/download", middlewares.DenyImpersonation, dataExportController.DownloadExport)
+}
+
+func registerOpenKnownDeviceRoutes(r *gin.RouterGroup, knownDeviceController *usercontroller.KnownDeviceController) {
+	r.POST("/login/report", knownDeviceController.ReportLogin)
+}
+
+func registerClosedKnownDeviceRoutes(r *gin.RouterGroup, knownDeviceController *usercontroller.KnownDeviceController) {
+	r.GET("/me/devices", knownDeviceController.GetDevices)
+	r.DELETE("/me/devices/:id", middlewares.DenyImpersonation, knownDeviceController.DeleteDevice)
+}
+
+func registerOpenSmsRoutes(r *gin.RouterGroup, smsController *usercontroller.SmsController) {
+	r.POST("/login/sms", smsController.FinishLogin)
+}
+
+func registerClosedSmsRoutes(r *gin.RouterGroup, smsController *usercontroller.SmsController) {
+	r.POST("/me/phone/code", middlewares.DenyImpersonation, smsController.SendPhoneCode)
+	r.POST("/me/phone/verify", middlewares.DenyImpersonation, smsController.VerifyPhone)
+	r.POST("/me/phone/2fa", middlewares.DenyImpersonation, smsController.EnableTwoFactor)
+	r.DELETE("/me/phone/2fa", middlewares.DenyImpersonation, smsController.DisableTwoFactor)
+}
+
+func registerClosedWebAuthnRoutes(r *gin.RouterGroup, webAuthnController *usercontroller.WebAuthnController) {
+	r.POST("/webauthn/register/begin", middlewares.DenyImpersonation, webAuthnController.BeginRegistration)
+	r.POST("/webauthn/register/finish", middlewares.DenyImpersonation, webAuthnController.FinishRegistration)
+	r.GET("/webauthn/credentials", webAuthnController.GetCredentials)
+	r.DELETE("/webauthn/credentials/:id", middlewares.DenyImpersonation, webAuthnController.DeleteCredential)
+}
+
 func registerClosedUserRoutes(r *gin.RouterGroup, userController *usercontroller.UserController) {
+	r.GET("/me", userController.GetProfile)
+	r.PATCH("/me", middlewares.DenyImpersonation, userController.UpdateProfile)
+	r.PUT("/me/password", middlewares.DenyImpersonation, userController.UpdatePassword)
+}
+
+func registerApiKeyUserRoutes(r *gin.RouterGroup, userController *usercontroller.UserController) {
 	r.GET("/:username", userController.FindUserByUsername)
-	r.GET("/:username/update-password", userController.UpdatePassword)
+}
+
+func registerClosedApiKeyRoutes(r *gin.RouterGroup, apiKeyController *usercontroller.ApiKeyController) {
+	r.GET("/api-keys", apiKeyController.GetApiKeys)
+	r.POST("/api-keys", middlewares.DenyImpersonation, apiKeyController.CreateApiKey)
+	r.DELETE("/api-keys/:id", middlewares.DenyImpersonation, apiKeyController.RevokeApiKey)
 }
 
 func registerClosedOAuthRoutes(r *gin.RouterGroup, oauthController *usercontroller.OAuthController) {
-	r.GET("/oauth/generate", oauthController.GenerateOAuth)
-	r.POST("/oauth/enable", oauthController.VerifyAndEnableOAuth)
-	r.DELETE("/oauth/disable", oauthController.DisableOAuth)
+	r.GET("/oauth/generate", middlewares.DenyImpersonation, oauthController.GenerateOAuth)
+	r.POST("/oauth/enable", middlewares.DenyImpersonation, oauthController.VerifyAndEnableOAuth)
+	r.DELETE("/oauth/disable", middlewares.DenyImpersonation, oauthController.DisableOAuth)
+	r.GET("/oauth/recovery-codes", oauthController.GetRecoveryCodesCount)
+	r.POST("/oauth/recovery-codes", middlewares.DenyImpersonation, oauthController.RegenerateRecoveryCodes)
+}
+
+func registerAdminUserRoutes(
+	r *gin.RouterGroup,
+	userController *usercontroller.UserController,
+	roleController *usercontroller.RoleController,
+	lockoutController *usercontroller.LockoutController,
+	impersonationController *usercontroller.ImpersonationController,
+	accountDeletionController *usercontroller.AccountDeletionController,
+) {
+	usersRead := middlewares.RequirePermission(usermodel.PermissionUsersRead)
+	r.GET("/", usersRead, userController.GetWithPagination)
+	r.GET("/count", usersRead, userController.GetAllUsersCount)
+	r.GET("/locked", usersRead, lockoutController.GetLockedAccounts)
+	r.GET("/id/:id", usersRead, userController.FindUserByID)
+
+	usersWrite := middlewares.RequirePermission(usermodel.PermissionUsersWrite)
+	r.POST("/id/:id/suspend", usersWrite, userController.SuspendUser)
+	r.POST("/id/:id/unsuspend", usersWrite, userController.UnsuspendUser)
+	r.DELETE("/id/:id/lockout", usersWrite, lockoutController.UnlockAccount)
+	r.DELETE("/id/:id", usersWrite, accountDeletionController.DeleteUser)
+
+	usersImpersonate := middlewares.RequirePermission(usermodel.PermissionUsersRead, usermodel.PermissionUsersImpersonate)
+	r.POST("/id/:id/impersonate", usersImpersonate, impersonationController.Impersonate)
+
+	rolesRead := middlewares.RequirePermission(usermodel.PermissionUsersRead, usermodel.PermissionRolesRead)
+	rolesWrite := middlewares.RequirePermission(usermodel.PermissionUsersRead, usermodel.PermissionRolesWrite)
+	r.GET("/id/:id/roles", rolesRead, roleController.GetUserRoles)
+	r.POST("/id/:id/roles", rolesWrite, roleController.AssignUserRole)
+	r.DELETE("/id/:id/roles/:roleId", rolesWrite, roleController.RemoveUserRole)
+}
+
+func registerAdminRoleRoutes(r *gin.RouterGroup, roleController *usercontroller.RoleController) {
+	rolesRead := middlewares.RequirePermission(usermodel.PermissionRolesRead)
+	rolesWrite := middlewares.RequirePermission(usermodel.PermissionRolesWrite)
+	r.GET("/", rolesRead, roleController.GetRoles)
+	r.GET("/permissions", rolesRead, roleController.GetPermissions)
+	r.POST("/", rolesWrite, roleController.CreateRole)
+	r.PUT("/:id", rolesWrite, roleController.UpdateRole)
+	r.DELETE("/:id", rolesWrite, roleController.DeleteRole)
 }
 
-func registerAdminRoutes(r *gin.RouterGroup, userController *usercontroller.UserController) {
-	r.GET("/", userController.GetWithPagination)
-	r.GET("/count", userController.GetAllUsersCount)
-	r.GET("/id/:id", userController.FindUserByID)
+func registerAdminServiceAccountRoutes(r *gin.RouterGroup, serviceAccountController *usercontroller.ServiceAccountController) {
+	usersRead := middlewares.RequirePermission(usermodel.PermissionUsersRead)
+	usersWrite := middlewares.RequirePermission(usermodel.PermissionUsersWrite)
+	r.GET("/", usersRead, serviceAccountController.GetServiceAccounts)
+	r.POST("/", usersWrite, serviceAccountController.CreateServiceAccount)
+	r.DELETE("/:id", usersWrite, serviceAccountController.DeleteServiceAccount)
+	r.GET("/:id/api-keys", usersRead, serviceAccountController.GetApiKeys)
+	r.POST("/:id/api-keys", usersWrite, serviceAccountController.CreateApiKey)
+	r.DELETE("/:id/api-keys/:keyId", usersWrite, serviceAccountController.RevokeApiKey)
 }
@@ -0,0 +1,115 @@
+// Package server bootstraps the API. Every entrypoint starts it through Run, so that they all
+// configure the same services and serve the same routes.
+package server
+
+import (
+	"fmt"
+	"github.com/drunkleen/rasta/config"
+	_ "github.com/drunkleen/rasta/docs/swagger"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	emailrepository "github.com/drunkleen/rasta/internal/repository/email"
+	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	auditroute "github.com/drunkleen/rasta/internal/route/audit"
+	authroute "github.com/drunkleen/rasta/internal/route/auth"
+	emailroute "github.com/drunkleen/rasta/internal/route/email"
+	newsletterroute "github.com/drunkleen/rasta/internal/route/newsletter"
+	oidcroute "github.com/drunkleen/rasta/internal/route/oidc"
+	ticketroute "github.com/drunkleen/rasta/internal/route/ticket"
+	userroute "github.com/drunkleen/rasta/internal/route/user"
+	emailservice "github.com/drunkleen/rasta/internal/service/email"
+	userservice "github.com/drunkleen/rasta/internal/service/user"
+	"github.com/drunkleen/rasta/pkg/database"
+	emailPkg "github.com/drunkleen/rasta/pkg/email"
+	"github.com/drunkleen/rasta/pkg/geoip"
+	smsPkg "github.com/drunkleen/rasta/pkg/sms"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"log"
+	"time"
+)
+
+// Init loads the configuration, connects to the database and configures every service the routes
+// depend on. It panics if any of them cannot be configured.
+func Init() {
+	config.Init()
+	if err := auth.InitEncryption(); err != nil {
+		log.Panicf("failed to load encryption keys: %v", err)
+	}
+	database.InitDB()
+	if err := auth.InitPasswordHasher(); err != nil {
+		log.Panicf("failed to configure password hashing: %v", err)
+	}
+	if err := auth.InitPasswordPolicy(); err != nil {
+		log.Panicf("failed to configure password policy: %v", err)
+	}
+	if err := auth.InitBlockedDomains(); err != nil {
+		log.Panicf("failed to load blocked email domains: %v", err)
+	}
+	if err := auth.InitSignupChallenge(); err != nil {
+		log.Panicf("failed to configure signup challenges: %v", err)
+	}
+	if err := geoip.Init(); err != nil {
+		log.Panicf("failed to load geoip database: %v", err)
+	}
+	if err := auth.InitKeySet(); err != nil {
+		log.Panicf("failed to load signing keys: %v", err)
+	}
+	if err := auth.InitWebAuthn(); err != nil {
+		log.Panicf("failed to configure webauthn: %v", err)
+	}
+	if err := auth.InitSocialProviders(); err != nil {
+		log.Panicf("failed to configure identity providers: %v", err)
+	}
+	if err := smsPkg.Init(); err != nil {
+		log.Panicf("failed to configure sms delivery: %v", err)
+	}
+	if err := emailPkg.Init(); err != nil {
+		log.Panicf("failed to configure email delivery: %v", err)
+	}
+}
+
+// NewRouter returns the engine serving the API documentation, the well-known endpoints and the API.
+// Init must have been called first.
+//
+// Client addresses are only read from forwarding headers set by the proxies of TRUSTED_PROXIES.
+// It panics if TRUSTED_PROXIES holds an invalid address.
+func NewRouter() *gin.Engine {
+	r := gin.Default()
+	if err := r.SetTrustedProxies(config.GetTrustedProxies()); err != nil {
+		log.Panicf("failed to configure trusted proxies: %v", err)
+	}
+	r.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	authroute.RegisterWellKnownRoutes(&r.RouterGroup)
+	api := r.Group("/api/v1")
+
+	userroute.RegisterUserRoutes(api)
+	newsletterroute.RegisterUserRoutes(api)
+	oidcroute.RegisterOidcRoutes(api)
+	auditroute.RegisterAuditRoutes(api)
+	emailroute.RegisterEmailRoutes(api)
+	ticketroute.RegisterTicketRoutes(api)
+	return r
+}
+
+// StartWorkers starts the background workers delivering queued emails, deleting the accounts whose
+// grace period ended and purging expired data exports. Init must have been called first.
+func StartWorkers() {
+	db := database.DB
+	emailservice.NewOutboxService(emailrepository.NewOutboxRepository(db), emailPkg.DefaultMailer()).StartWorker()
+	userservice.NewAccountDeletionService(userrepository.NewAccountDeletionRepository(db)).StartWorker(5 * time.Minute)
+	userservice.NewDataExportService(userrepository.NewDataExportRepository(db)).StartWorker(time.Hour)
+}
+
+// Run initialises the server, starts the background workers and serves the API on SERVER_PORT
+// until it fails.
+func Run() {
+	Init()
+	fmt.Printf("\nEnvironment Variables:%+v\n\n", config.GetEnvVars())
+
+	StartWorkers()
+	r := NewRouter()
+	if r.Run(":"+config.GetServerPort()) != nil {
+		return
+	}
+}
@@ -0,0 +1,66 @@
+package auditservice
+
+import (
+	"errors"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	auditmodel "github.com/drunkleen/rasta/internal/models/audit"
+	auditrepository "github.com/drunkleen/rasta/internal/repository/audit"
+)
+
+// MaxPageSize is the largest number of audit log entries returned at once.
+const MaxPageSize = 100
+
+type AuditService struct {
+	Repository *auditrepository.AuditRepository
+}
+
+// NewAuditService creates a new instance of the AuditService struct.
+//
+// It takes a pointer to an AuditRepository as a parameter and returns a pointer to an AuditService.
+func NewAuditService(repository *auditrepository.AuditRepository) *AuditService {
+	return &AuditService{Repository: repository}
+}
+
+// Record appends an entry to the audit log.
+//
+// Fields too long for the log are truncated rather than rejected, so an entry is never lost to its size.
+// Returns an error if the entry could not be stored.
+func (s *AuditService) Record(entry *auditmodel.AuditLog) error {
+	entry.Path = truncate(entry.Path, 512)
+	entry.UserAgent = truncate(entry.UserAgent, 512)
+	entry.Detail = truncate(entry.Detail, 512)
+	if err := s.Repository.Create(entry); err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	return nil
+}
+
+// Find returns a page of the audit log entries matching a filter, most recent first.
+//
+// limit is the page size, at most 100, and page the 1-based page number.
+// Returns the entries, the number of entries matching the filter and an error if any.
+func (s *AuditService) Find(filter auditrepository.Filter, limit, page int) ([]auditmodel.AuditLog, int64, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	if page <= 0 {
+		page = 1
+	}
+	entries, count, err := s.Repository.Find(filter, (page-1)*limit, limit)
+	if err != nil {
+		return nil, 0, errors.New(commonerrors.ErrInternalServer)
+	}
+	return entries, count, nil
+}
+
+// truncate shortens s to at most n characters.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
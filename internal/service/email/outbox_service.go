@@ -0,0 +1,208 @@
+package emailservice
+
+import (
+	"errors"
+	"github.com/drunkleen/rasta/config"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
+	emailrepository "github.com/drunkleen/rasta/internal/repository/email"
+	emailPkg "github.com/drunkleen/rasta/pkg/email"
+	"github.com/google/uuid"
+	"log"
+	"time"
+)
+
+// MaxPageSize is the largest number of emails returned at once.
+const MaxPageSize = 100
+
+const (
+	// outboxBatchSize is the number of emails claimed at once for delivery.
+	outboxBatchSize = 50
+	// outboxLease is how long claimed emails are reserved for the instance delivering them.
+	outboxLease = 5 * time.Minute
+	// maxOutboxRetryDelay caps the delay before an email that failed to be delivered is retried.
+	maxOutboxRetryDelay = 6 * time.Hour
+)
+
+type OutboxService struct {
+	Repository *emailrepository.OutboxRepository
+	Mailer     emailPkg.Mailer
+}
+
+// NewOutboxService creates a new instance of the OutboxService struct.
+//
+// It takes a pointer to an OutboxRepository and the Mailer emails are delivered with as parameters,
+// and returns a pointer to an OutboxService.
+func NewOutboxService(repository *emailrepository.OutboxRepository, mailer emailPkg.Mailer) *OutboxService {
+	return &OutboxService{Repository: repository, Mailer: mailer}
+}
+
+// NewOutboxEmail returns a rendered email as an email of the outbox, to be written along with what it
+// is sent for.
+//
+// kind tells what the email is sent for, and userId is the user it is sent to, if any.
+func NewOutboxEmail(kind string, userId *uuid.UUID, message *emailPkg.Message) *emailmodel.EmailOutbox {
+	return &emailmodel.EmailOutbox{
+		UserId:    userId,
+		Kind:      kind,
+		Sender:    message.From,
+		Recipient: message.To,
+		Subject:   message.Subject,
+		Body:      message.HTML,
+	}
+}
+
+// Enqueue adds rendered emails to the outbox, to be delivered in the background.
+//
+// Returns an error if the emails could not be stored; none of them is then delivered.
+func (s *OutboxService) Enqueue(kind string, messages ...*emailPkg.Message) error {
+	emails := make([]*emailmodel.EmailOutbox, len(messages))
+	for i, message := range messages {
+		emails[i] = NewOutboxEmail(kind, nil, message)
+	}
+	if err := s.Repository.Create(emails...); err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	return nil
+}
+
+// ProcessDue delivers the emails of the outbox that are due, batch by batch, until none is left.
+//
+// An email that fails to be delivered is retried with an exponential backoff, from
+// EMAIL_OUTBOX_RETRY_DELAY up to six hours, and given up on after EMAIL_OUTBOX_MAX_ATTEMPTS attempts.
+// An email the mail server refuses for good is marked as bounced and not retried.
+func (s *OutboxService) ProcessDue() {
+	for {
+		emails, err := s.Repository.ClaimDue(outboxBatchSize, outboxLease)
+		if err != nil || len(emails) == 0 {
+			return
+		}
+		for i := range emails {
+			s.deliver(&emails[i])
+		}
+		if len(emails) < outboxBatchSize {
+			return
+		}
+	}
+}
+
+// deliver sends an email claimed from the outbox and records the outcome.
+func (s *OutboxService) deliver(email *emailmodel.EmailOutbox) {
+	err := s.Mailer.Send(&emailPkg.Message{
+		From:    email.Sender,
+		To:      email.Recipient,
+		Subject: email.Subject,
+		HTML:    email.Body,
+	})
+	if err == nil {
+		_ = s.Repository.MarkSent(email.Id)
+		return
+	}
+	lastError := truncate(err.Error(), 512)
+	switch {
+	case emailPkg.IsBounce(err):
+		log.Printf("email %v to %s bounced: %v", email.Id, email.Recipient, err)
+		_ = s.Repository.MarkUndelivered(email.Id, emailmodel.OutboxStatusBounced, lastError)
+	case email.Attempts >= config.GetEmailOutboxMaxAttempts():
+		log.Printf("giving up on email %v to %s after %d attempts: %v", email.Id, email.Recipient, email.Attempts, err)
+		_ = s.Repository.MarkUndelivered(email.Id, emailmodel.OutboxStatusFailed, lastError)
+	default:
+		_ = s.Repository.MarkRetry(email.Id, time.Now().Add(retryDelay(email.Attempts)), lastError)
+	}
+}
+
+// retryDelay returns how long to wait before attempting to deliver an email again, after attempts
+// failed attempts.
+func retryDelay(attempts int) time.Duration {
+	delay := time.Duration(config.GetEmailOutboxRetryDelay()) * time.Second
+	for i := 1; i < attempts && delay < maxOutboxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxOutboxRetryDelay)
+}
+
+// DeleteExpired deletes the emails sent or given up on longer ago than EMAIL_OUTBOX_RETENTION.
+func (s *OutboxService) DeleteExpired() {
+	retention := config.GetEmailOutboxRetention()
+	if retention == 0 {
+		return
+	}
+	count, err := s.Repository.DeleteFinishedBefore(time.Now().Add(-time.Duration(retention) * time.Second))
+	if err == nil && count > 0 {
+		log.Printf("deleted %d emails from the outbox", count)
+	}
+}
+
+// StartWorker delivers the emails of the outbox every EMAIL_OUTBOX_POLL_INTERVAL seconds, and deletes
+// the expired ones every hour. It returns immediately.
+func (s *OutboxService) StartWorker() {
+	go func() {
+		lastCleanup := time.Time{}
+		for range time.Tick(time.Duration(config.GetEmailOutboxPollInterval()) * time.Second) {
+			s.ProcessDue()
+			if time.Since(lastCleanup) >= time.Hour {
+				s.DeleteExpired()
+				lastCleanup = time.Now()
+			}
+		}
+	}()
+}
+
+// Find returns a page of the emails matching a filter, most recent first.
+//
+// limit is the page size, at most 100, and page the 1-based page number.
+// Returns the emails, the number of emails matching the filter and an error if any.
+func (s *OutboxService) Find(filter emailrepository.Filter, limit, page int) ([]emailmodel.EmailOutbox, int64, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	if page <= 0 {
+		page = 1
+	}
+	emails, count, err := s.Repository.Find(filter, (page-1)*limit, limit)
+	if err != nil {
+		return nil, 0, errors.New(commonerrors.ErrInternalServer)
+	}
+	return emails, count, nil
+}
+
+// FindById returns an email of the outbox.
+//
+// Returns the email, or ErrOutboxEmailNotFound if there is none with this ID.
+func (s *OutboxService) FindById(id uuid.UUID) (*emailmodel.EmailOutbox, error) {
+	email, err := s.Repository.FindById(id)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrOutboxEmailNotFound)
+	}
+	return email, nil
+}
+
+// Resend queues an email that was sent, failed or bounced to be delivered again.
+//
+// Returns the queued email, ErrOutboxEmailNotFound if there is none with this ID, or
+// ErrOutboxEmailQueued if it is already waiting to be delivered.
+func (s *OutboxService) Resend(id uuid.UUID) (*emailmodel.EmailOutbox, error) {
+	if _, err := s.FindById(id); err != nil {
+		return nil, err
+	}
+	requeued, err := s.Repository.Requeue(id)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	if !requeued {
+		return nil, errors.New(commonerrors.ErrOutboxEmailQueued)
+	}
+	return s.FindById(id)
+}
+
+// truncate shortens s to at most n characters.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
@@ -1,17 +1,23 @@
 package newsletterservice
 
 import (
+	"errors"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
 	newslettermodel "github.com/drunkleen/rasta/internal/models/newsletter"
 	"github.com/drunkleen/rasta/internal/repository/newsletter"
+	emailservice "github.com/drunkleen/rasta/internal/service/email"
 	emailPkg "github.com/drunkleen/rasta/pkg/email"
+	"log"
 )
 
 type NewsletterService struct {
-	Repository *newsletterrepository.NewsletterRepository
+	Repository    *newsletterrepository.NewsletterRepository
+	OutboxService *emailservice.OutboxService
 }
 
-func NewNewsletterService(repository *newsletterrepository.NewsletterRepository) *NewsletterService {
-	return &NewsletterService{Repository: repository}
+func NewNewsletterService(repository *newsletterrepository.NewsletterRepository, outboxService *emailservice.OutboxService) *NewsletterService {
+	return &NewsletterService{Repository: repository, OutboxService: outboxService}
 }
 
 func (s *NewsletterService) Create(email *string) error {
@@ -65,8 +71,14 @@ func (s *NewsletterService) SendNewslettersEmail(emailMessage *string, limit int
 		if err != nil {
 			return err
 		}
-		err = emailPkg.SendNewsletter(newsletters, emailMessage)
-		if err != nil {
+		messages := make([]*emailPkg.Message, len(*newsletters))
+		for i, newsletter := range *newsletters {
+			if messages[i], err = emailPkg.NewNewsletter(newsletter.Email, *emailMessage); err != nil {
+				log.Printf("failed to render newsletter: %v", err)
+				return errors.New(commonerrors.ErrInternalServer)
+			}
+		}
+		if err = s.OutboxService.Enqueue(emailmodel.OutboxKindNewsletter, messages...); err != nil {
 			return err
 		}
 	}
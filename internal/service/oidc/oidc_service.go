@@ -0,0 +1,399 @@
+package oidcservice
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	oidcmodel "github.com/drunkleen/rasta/internal/models/oidc"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	oidcrepository "github.com/drunkleen/rasta/internal/repository/oidc"
+	"github.com/google/uuid"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+type OidcService struct {
+	Repository *oidcrepository.OidcRepository
+}
+
+// NewOidcService creates a new instance of the OidcService struct.
+//
+// It takes a pointer to an OidcRepository as a parameter and returns a pointer to an OidcService
+// letting partner apps sign users in with their Rasta account.
+func NewOidcService(repository *oidcrepository.OidcRepository) *OidcService {
+	return &OidcService{Repository: repository}
+}
+
+// Available reports whether Rasta can act as an OpenID Connect provider, which requires asymmetric signing keys.
+func (s *OidcService) Available() bool {
+	return auth.OidcAvailable()
+}
+
+// CreateClient registers a partner app.
+//
+// name is shown to users on the consent screen and redirectUris are the URIs the app may be redirected to.
+// Public clients, such as single-page and mobile apps, get no secret.
+// Returns the client, its secret, which is only ever returned here, and an error if any.
+func (s *OidcService) CreateClient(name string, redirectUris []string, public bool) (*oidcmodel.OidcClient, string, error) {
+	client, err := buildClient(name, redirectUris)
+	if err != nil {
+		return nil, "", err
+	}
+	var secret string
+	if !public {
+		if secret, err = auth.GenerateRefreshToken(); err != nil {
+			return nil, "", errors.New(commonerrors.ErrInternalServer)
+		}
+		client.SecretHash = auth.HashToken(secret)
+	}
+	if err = s.Repository.CreateClient(client); err != nil {
+		return nil, "", errors.New(commonerrors.ErrInternalServer)
+	}
+	return client, secret, nil
+}
+
+// FindClients returns every partner app.
+//
+// Returns the clients and an error if any.
+func (s *OidcService) FindClients() ([]oidcmodel.OidcClient, error) {
+	clients, err := s.Repository.FindClients()
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return clients, nil
+}
+
+// FindClientById finds a partner app by its ID.
+//
+// id is the client ID of the app.
+// Returns the client and an error if the client does not exist.
+func (s *OidcService) FindClientById(id uuid.UUID) (*oidcmodel.OidcClient, error) {
+	client, err := s.Repository.FindClientById(id)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrOidcClientNotFound)
+	}
+	return client, nil
+}
+
+// UpdateClient renames a partner app and replaces its redirect URIs.
+//
+// id is the client ID of the app.
+// Returns the updated client and an error if any.
+func (s *OidcService) UpdateClient(id uuid.UUID, name string, redirectUris []string) (*oidcmodel.OidcClient, error) {
+	client, err := s.FindClientById(id)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := buildClient(name, redirectUris)
+	if err != nil {
+		return nil, err
+	}
+	client.Name = updated.Name
+	client.RedirectUris = updated.RedirectUris
+	if err = s.Repository.UpdateClient(client); err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return client, nil
+}
+
+// RotateSecret issues a new secret to a partner app. The previous secret stops working at once,
+// and a public client becomes confidential.
+//
+// id is the client ID of the app.
+// Returns the client, its new secret and an error if any.
+func (s *OidcService) RotateSecret(id uuid.UUID) (*oidcmodel.OidcClient, string, error) {
+	client, err := s.FindClientById(id)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, "", errors.New(commonerrors.ErrInternalServer)
+	}
+	client.SecretHash = auth.HashToken(secret)
+	if err = s.Repository.UpdateClient(client); err != nil {
+		return nil, "", errors.New(commonerrors.ErrInternalServer)
+	}
+	return client, secret, nil
+}
+
+// DeleteClient deletes a partner app and the consents users gave it.
+//
+// id is the client ID of the app.
+// Returns an error if the client does not exist or the deletion fails.
+func (s *OidcService) DeleteClient(id uuid.UUID) error {
+	deleted, err := s.Repository.DeleteClient(id)
+	if err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	if !deleted {
+		return errors.New(commonerrors.ErrOidcClientNotFound)
+	}
+	return nil
+}
+
+// ValidateAuthorization checks the parameters a partner app sent a user to the authorization endpoint with.
+//
+// Only the authorization code flow with a S256 PKCE challenge is supported, and openid must be requested.
+// Scopes other than the known ones are ignored.
+// Returns the client, the granted scopes and an error if any. The redirect URI must not be used unless
+// the error is neither ErrOidcUnavailable, ErrOidcClientNotFound nor ErrInvalidRedirectUri.
+func (s *OidcService) ValidateAuthorization(clientId, redirectUri, responseType, scope, codeChallenge, codeChallengeMethod string) (*oidcmodel.OidcClient, []string, error) {
+	if !auth.OidcAvailable() {
+		return nil, nil, errors.New(commonerrors.ErrOidcUnavailable)
+	}
+	id, err := uuid.Parse(clientId)
+	if err != nil {
+		return nil, nil, errors.New(commonerrors.ErrOidcClientNotFound)
+	}
+	client, err := s.FindClientById(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !client.AllowsRedirectUri(redirectUri) {
+		return nil, nil, errors.New(commonerrors.ErrInvalidRedirectUri)
+	}
+	if responseType != "code" {
+		return client, nil, errors.New(commonerrors.ErrUnsupportedResponse)
+	}
+	scopes := parseScopes(scope)
+	if !slices.Contains(scopes, oidcmodel.ScopeOpenId) {
+		return client, nil, errors.New(commonerrors.ErrInvalidScope)
+	}
+	if codeChallengeMethod != "S256" || len(codeChallenge) != 43 {
+		return client, nil, errors.New(commonerrors.ErrPkceRequired)
+	}
+	return client, scopes, nil
+}
+
+// HasConsent reports whether a user already allowed a partner app to access every one of the given scopes.
+func (s *OidcService) HasConsent(userId, clientId uuid.UUID, scopes []string) bool {
+	consent, err := s.Repository.FindConsent(userId, clientId)
+	if err != nil {
+		return false
+	}
+	granted := strings.Fields(consent.Scope)
+	for _, scope := range scopes {
+		if !slices.Contains(granted, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// Approve records that a user allowed a partner app to access the given scopes and issues an
+// authorization code the app exchanges for tokens within auth.OidcCodeTimeout.
+//
+// The code is bound to the redirect URI, the nonce and the PKCE challenge of the authorization request.
+// Returns the code and an error if any.
+func (s *OidcService) Approve(userId uuid.UUID, client *oidcmodel.OidcClient, scopes []string, redirectUri, nonce, codeChallenge string) (string, error) {
+	granted := scopes
+	if consent, err := s.Repository.FindConsent(userId, client.Id); err == nil {
+		granted = parseScopes(consent.Scope + " " + strings.Join(scopes, " "))
+	}
+	consent := &oidcmodel.OidcConsent{UserId: userId, ClientId: client.Id, Scope: strings.Join(granted, " ")}
+	if err := s.Repository.SaveConsent(consent); err != nil {
+		return "", errors.New(commonerrors.ErrInternalServer)
+	}
+	code, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return "", errors.New(commonerrors.ErrInternalServer)
+	}
+	err = s.Repository.CreateCode(&oidcmodel.OidcAuthorizationCode{
+		CodeHash:      auth.HashToken(code),
+		ClientId:      client.Id,
+		UserId:        userId,
+		RedirectUri:   redirectUri,
+		Scope:         strings.Join(scopes, " "),
+		Nonce:         nonce,
+		CodeChallenge: codeChallenge,
+		ExpiresAt:     time.Now().Add(auth.OidcCodeTimeout),
+	})
+	if err != nil {
+		return "", errors.New(commonerrors.ErrInternalServer)
+	}
+	return code, nil
+}
+
+// Exchange exchanges an authorization code for an access token and an ID token.
+//
+// clientSecret is required for confidential clients, redirectUri must be the one the code was issued
+// for and codeVerifier must match its PKCE challenge. A code can only be exchanged once.
+// Returns the access token, the ID token, the granted scopes and an error if any.
+func (s *OidcService) Exchange(clientId, clientSecret, code, redirectUri, codeVerifier string) (string, string, string, error) {
+	if !auth.OidcAvailable() {
+		return "", "", "", errors.New(commonerrors.ErrOidcUnavailable)
+	}
+	client, err := s.authenticateClient(clientId, clientSecret)
+	if err != nil {
+		return "", "", "", err
+	}
+	stored, err := s.Repository.FindCode(auth.HashToken(code))
+	if err != nil || stored.UsedAt != nil || stored.ClientId != client.Id || stored.RedirectUri != redirectUri {
+		return "", "", "", errors.New(commonerrors.ErrInvalidGrant)
+	}
+	if !auth.VerifyPkce(codeVerifier, stored.CodeChallenge) {
+		return "", "", "", errors.New(commonerrors.ErrInvalidGrant)
+	}
+	used, err := s.Repository.UseCode(stored.Id)
+	if err != nil {
+		return "", "", "", errors.New(commonerrors.ErrInternalServer)
+	}
+	if !used {
+		return "", "", "", errors.New(commonerrors.ErrInvalidGrant)
+	}
+	user, err := s.activeUser(stored.UserId)
+	if err != nil {
+		return "", "", "", err
+	}
+	subject := user.Id.String()
+	accessToken, err := auth.GenerateOidcAccessToken(subject, client.Id.String(), stored.Scope)
+	if err != nil {
+		log.Printf("failed to generate oidc access token: %v", err)
+		return "", "", "", errors.New(commonerrors.ErrInternalServer)
+	}
+	idToken, err := auth.GenerateIdToken(subject, client.Id.String(), stored.Nonce, userClaims(user, strings.Fields(stored.Scope)))
+	if err != nil {
+		log.Printf("failed to generate id token: %v", err)
+		return "", "", "", errors.New(commonerrors.ErrInternalServer)
+	}
+	return accessToken, idToken, stored.Scope, nil
+}
+
+// UserInfo returns the claims about a user a partner app was granted, for an access token issued by Exchange.
+//
+// Returns the claims and an error if the token is invalid or the account is no longer usable.
+func (s *OidcService) UserInfo(accessToken string) (map[string]any, error) {
+	claims, err := auth.ParseOidcAccessToken(accessToken)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrUnauthorizedToken)
+	}
+	userId, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrUnauthorizedToken)
+	}
+	user, err := s.activeUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	info := userClaims(user, strings.Fields(claims.Scope))
+	info["sub"] = claims.Subject
+	return info, nil
+}
+
+// FindConsents returns the consents a user gave to partner apps.
+//
+// userId is the unique identifier of the user.
+// Returns the consents and an error if any.
+func (s *OidcService) FindConsents(userId uuid.UUID) ([]oidcmodel.OidcConsent, error) {
+	consents, err := s.Repository.FindConsentsByUserId(userId)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return consents, nil
+}
+
+// RevokeConsent revokes the consent a user gave to a partner app, so the user is asked again on the next sign-in.
+//
+// userId is the unique identifier of the user and clientId the client ID of the app.
+// Returns an error if no consent was given or the deletion fails.
+func (s *OidcService) RevokeConsent(userId, clientId uuid.UUID) error {
+	deleted, err := s.Repository.DeleteConsent(userId, clientId)
+	if err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	if !deleted {
+		return errors.New(commonerrors.ErrConsentNotFound)
+	}
+	return nil
+}
+
+// authenticateClient finds the partner app a token request was made by and checks its secret, if it has one.
+func (s *OidcService) authenticateClient(clientId, clientSecret string) (*oidcmodel.OidcClient, error) {
+	id, err := uuid.Parse(clientId)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInvalidClient)
+	}
+	client, err := s.Repository.FindClientById(id)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInvalidClient)
+	}
+	if client.IsPublic() {
+		return client, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(auth.HashToken(clientSecret)), []byte(client.SecretHash)) != 1 {
+		return nil, errors.New(commonerrors.ErrInvalidClient)
+	}
+	return client, nil
+}
+
+// activeUser finds a user that may still sign in to partner apps.
+func (s *OidcService) activeUser(id uuid.UUID) (*usermodel.User, error) {
+	user, err := s.Repository.FindUserById(id)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrUserNotFound)
+	}
+	if user.IsSuspended() {
+		return nil, errors.New(commonerrors.ErrAccountSuspended)
+	}
+	return user, nil
+}
+
+// buildClient validates the name and redirect URIs of a partner app.
+//
+// Redirect URIs must be absolute and carry no fragment. Custom schemes are allowed for native apps.
+func buildClient(name string, redirectUris []string) (*oidcmodel.OidcClient, error) {
+	name = strings.TrimSpace(name)
+	if len(name) < 2 || len(name) > 64 {
+		return nil, errors.New(commonerrors.ErrInvalidClientName)
+	}
+	if len(redirectUris) == 0 {
+		return nil, errors.New(commonerrors.ErrInvalidRedirectUri)
+	}
+	for _, redirectUri := range redirectUris {
+		parsed, err := url.Parse(redirectUri)
+		if err != nil || !parsed.IsAbs() || parsed.Fragment != "" || strings.ContainsAny(redirectUri, " \t\r\n") {
+			return nil, errors.New(commonerrors.ErrInvalidRedirectUri + ": " + redirectUri)
+		}
+	}
+	return &oidcmodel.OidcClient{Name: name, RedirectUris: strings.Join(redirectUris, "\n")}, nil
+}
+
+// parseScopes returns the known scopes among a space-separated list of scopes, in the order of oidcmodel.Scopes.
+func parseScopes(scope string) []string {
+	requested := strings.Fields(scope)
+	scopes := make([]string, 0, len(oidcmodel.Scopes))
+	for _, known := range oidcmodel.Scopes {
+		if slices.Contains(requested, known) {
+			scopes = append(scopes, known)
+		}
+	}
+	return scopes
+}
+
+// userClaims returns the claims about a user granted by the given scopes.
+//
+// The username, account type and region are granted with openid, since partner apps authorize users by them.
+func userClaims(user *usermodel.User, scopes []string) map[string]any {
+	claims := map[string]any{
+		"username": user.Username,
+		"account":  user.Account,
+		"region":   user.Region,
+	}
+	if slices.Contains(scopes, oidcmodel.ScopeProfile) {
+		claims["name"] = strings.TrimSpace(user.FirstName + " " + user.LastName)
+		claims["given_name"] = user.FirstName
+		claims["family_name"] = user.LastName
+		claims["preferred_username"] = user.Username
+		claims["updated_at"] = user.UpdatedAt.Unix()
+	}
+	if slices.Contains(scopes, oidcmodel.ScopeEmail) {
+		claims["email"] = user.Email
+		claims["email_verified"] = user.IsVerified
+	}
+	return claims
+}
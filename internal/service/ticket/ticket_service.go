@@ -1 +1,218 @@
-package ticket
+package ticketservice
+
+import (
+	"errors"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	ticketmodel "github.com/drunkleen/rasta/internal/models/ticket"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	ticketrepository "github.com/drunkleen/rasta/internal/repository/ticket"
+	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	userservice "github.com/drunkleen/rasta/internal/service/user"
+	"github.com/google/uuid"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxPageSize is the largest number of tickets returned at once.
+const MaxPageSize = 100
+
+const (
+	// maxTitleLength is the largest number of characters of a ticket title.
+	maxTitleLength = 256
+	// maxBodyLength is the largest number of characters of a ticket description or comment.
+	maxBodyLength = 10000
+)
+
+type TicketService struct {
+	Repository     *ticketrepository.TicketRepository
+	UserRepository *userrepository.UserRepository
+	RoleService    *userservice.RoleService
+}
+
+// NewTicketService creates a new instance of the TicketService struct.
+//
+// It takes a pointer to a TicketRepository, a pointer to the UserRepository and a pointer to the
+// RoleService, used to check who tickets can be assigned to, and returns a pointer to a TicketService.
+func NewTicketService(repository *ticketrepository.TicketRepository, userRepository *userrepository.UserRepository, roleService *userservice.RoleService) *TicketService {
+	return &TicketService{Repository: repository, UserRepository: userRepository, RoleService: roleService}
+}
+
+// Create opens a ticket on behalf of a user.
+//
+// The priority defaults to medium when empty. Returns the ticket, or an error if a field is invalid
+// or the ticket could not be stored.
+func (s *TicketService) Create(userId uuid.UUID, title, description string, category ticketmodel.TicketCategory, priority ticketmodel.TicketPriority) (*ticketmodel.Ticket, error) {
+	title = strings.TrimSpace(title)
+	if n := utf8.RuneCountInString(title); n < 3 || n > maxTitleLength {
+		return nil, errors.New(commonerrors.ErrInvalidTicketTitle)
+	}
+	description = strings.TrimSpace(description)
+	if n := utf8.RuneCountInString(description); n < 1 || n > maxBodyLength {
+		return nil, errors.New(commonerrors.ErrInvalidTicketBody)
+	}
+	if !category.IsValid() {
+		return nil, errors.New(commonerrors.ErrInvalidTicketCategory)
+	}
+	if priority == "" {
+		priority = ticketmodel.TicketPriorityMedium
+	}
+	if !priority.IsValid() {
+		return nil, errors.New(commonerrors.ErrInvalidTicketPriority)
+	}
+	ticket := &ticketmodel.Ticket{
+		Title:       title,
+		Description: description,
+		Status:      ticketmodel.TicketStatusOpen,
+		Priority:    priority,
+		Category:    category,
+		UserId:      userId,
+	}
+	if err := s.Repository.Create(ticket); err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return ticket, nil
+}
+
+// FindByUserId returns the tickets a user opened, most recent first.
+func (s *TicketService) FindByUserId(userId uuid.UUID) ([]ticketmodel.Ticket, error) {
+	tickets, err := s.Repository.FindByUserId(userId)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return tickets, nil
+}
+
+// FindOwnedById returns a ticket a user opened, along with its comments.
+//
+// Tickets opened by someone else are reported as not found, so that their existence is not disclosed.
+func (s *TicketService) FindOwnedById(userId, id uuid.UUID) (*ticketmodel.Ticket, error) {
+	ticket, err := s.Repository.FindByIdWithComments(id)
+	if err != nil || ticket.UserId != userId {
+		return nil, errors.New(commonerrors.ErrTicketNotFound)
+	}
+	return ticket, nil
+}
+
+// FindById returns any ticket along with its comments.
+func (s *TicketService) FindById(id uuid.UUID) (*ticketmodel.Ticket, error) {
+	ticket, err := s.Repository.FindByIdWithComments(id)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrTicketNotFound)
+	}
+	return ticket, nil
+}
+
+// Find returns a page of the tickets matching a filter, most recently updated first.
+//
+// limit is the page size, at most 100, and page the 1-based page number.
+// Returns the tickets, the number of tickets matching the filter and an error if any.
+func (s *TicketService) Find(filter ticketrepository.Filter, limit, page int) ([]ticketmodel.Ticket, int64, error) {
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, 0, errors.New(commonerrors.ErrInvalidTicketStatus)
+	}
+	if filter.Priority != "" && !filter.Priority.IsValid() {
+		return nil, 0, errors.New(commonerrors.ErrInvalidTicketPriority)
+	}
+	if filter.Category != "" && !filter.Category.IsValid() {
+		return nil, 0, errors.New(commonerrors.ErrInvalidTicketCategory)
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	if page <= 0 {
+		page = 1
+	}
+	tickets, count, err := s.Repository.Find(filter, (page-1)*limit, limit)
+	if err != nil {
+		return nil, 0, errors.New(commonerrors.ErrInternalServer)
+	}
+	return tickets, count, nil
+}
+
+// Comment adds a comment of a user to a ticket they opened.
+//
+// Closed tickets no longer take comments from their author. Returns the comment, or an error if the
+// ticket is not found, is closed or the comment is invalid.
+func (s *TicketService) Comment(userId, id uuid.UUID, comment string) (*ticketmodel.TicketComment, error) {
+	ticket, err := s.Repository.FindById(id)
+	if err != nil || ticket.UserId != userId {
+		return nil, errors.New(commonerrors.ErrTicketNotFound)
+	}
+	if ticket.Status == ticketmodel.TicketStatusClosed {
+		return nil, errors.New(commonerrors.ErrTicketClosed)
+	}
+	return s.addComment(ticket.Id, userId, comment)
+}
+
+// StaffComment adds a comment of a member of staff to any ticket, closed or not.
+//
+// Returns the comment, or an error if the ticket is not found or the comment is invalid.
+func (s *TicketService) StaffComment(staffId, id uuid.UUID, comment string) (*ticketmodel.TicketComment, error) {
+	ticket, err := s.Repository.FindById(id)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrTicketNotFound)
+	}
+	return s.addComment(ticket.Id, staffId, comment)
+}
+
+// Update changes the status and priority of a ticket. Nil fields are left unchanged.
+//
+// Returns the updated ticket, or an error if the ticket is not found or a field is invalid.
+func (s *TicketService) Update(id uuid.UUID, status *ticketmodel.TicketStatus, priority *ticketmodel.TicketPriority) (*ticketmodel.Ticket, error) {
+	if status != nil && !status.IsValid() {
+		return nil, errors.New(commonerrors.ErrInvalidTicketStatus)
+	}
+	if priority != nil && !priority.IsValid() {
+		return nil, errors.New(commonerrors.ErrInvalidTicketPriority)
+	}
+	if _, err := s.Repository.FindById(id); err != nil {
+		return nil, errors.New(commonerrors.ErrTicketNotFound)
+	}
+	if status != nil {
+		if err := s.Repository.UpdateStatus(id, *status); err != nil {
+			return nil, errors.New(commonerrors.ErrInternalServer)
+		}
+	}
+	if priority != nil {
+		if err := s.Repository.UpdatePriority(id, *priority); err != nil {
+			return nil, errors.New(commonerrors.ErrInternalServer)
+		}
+	}
+	return s.FindById(id)
+}
+
+// Assign hands a ticket over to a member of staff, or unassigns it when assignee is nil.
+//
+// The assignee must hold the tickets.write permission, so that they can work on the ticket.
+// Returns the updated ticket, or an error if the ticket is not found or the assignee cannot take it.
+func (s *TicketService) Assign(id uuid.UUID, assignee *uuid.UUID) (*ticketmodel.Ticket, error) {
+	if _, err := s.Repository.FindById(id); err != nil {
+		return nil, errors.New(commonerrors.ErrTicketNotFound)
+	}
+	if assignee != nil {
+		user, err := s.UserRepository.FindById(*assignee)
+		if err != nil || user.IsSuspended() || !s.RoleService.HasPermissions(&user, usermodel.PermissionTicketsWrite) {
+			return nil, errors.New(commonerrors.ErrInvalidTicketAssignee)
+		}
+	}
+	if err := s.Repository.UpdateAssignee(id, assignee); err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return s.FindById(id)
+}
+
+// addComment validates and stores a comment on a ticket.
+func (s *TicketService) addComment(ticketId, userId uuid.UUID, comment string) (*ticketmodel.TicketComment, error) {
+	comment = strings.TrimSpace(comment)
+	if n := utf8.RuneCountInString(comment); n < 1 || n > maxBodyLength {
+		return nil, errors.New(commonerrors.ErrInvalidTicketComment)
+	}
+	ticketComment := &ticketmodel.TicketComment{TicketId: ticketId, UserId: userId, Comment: comment}
+	if err := s.Repository.AddComment(ticketComment); err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return ticketComment, nil
+}
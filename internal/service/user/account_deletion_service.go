@@ -0,0 +1,166 @@
+package userservice
+
+import (
+	"errors"
+	"github.com/drunkleen/rasta/config"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	emailservice "github.com/drunkleen/rasta/internal/service/email"
+	emailPkg "github.com/drunkleen/rasta/pkg/email"
+	"github.com/google/uuid"
+	"log"
+	"time"
+)
+
+// accountDeletionBatch is the number of due deletions carried out per run of the worker.
+const accountDeletionBatch = 50
+
+type AccountDeletionService struct {
+	Repository *userrepository.AccountDeletionRepository
+}
+
+// NewAccountDeletionService creates a new instance of the AccountDeletionService struct.
+//
+// It takes a pointer to an AccountDeletionRepository as a parameter and returns a pointer to an AccountDeletionService.
+func NewAccountDeletionService(repository *userrepository.AccountDeletionRepository) *AccountDeletionService {
+	return &AccountDeletionService{Repository: repository}
+}
+
+// Schedule schedules the deletion of the account of a user.
+//
+// The current password of the user is required. The account is deleted once ACCOUNT_DELETION_GRACE
+// seconds have passed, and the user is notified by email. Admins and service accounts cannot delete
+// themselves. Returns the deletion and an error if any.
+func (s *AccountDeletionService) Schedule(user *usermodel.User, password string) (*usermodel.AccountDeletion, error) {
+	if user.Account == usermodel.AccountTypeAdmin || user.Account == usermodel.AccountTypeService {
+		return nil, errors.New(commonerrors.ErrCannotDeleteAccount)
+	}
+	if ok, _ := auth.VerifyPassword(password, user.Password); !ok {
+		return nil, errors.New(commonerrors.ErrInvalidCredentials)
+	}
+	if _, err := s.Repository.FindPendingByUserId(user.Id); err == nil {
+		return nil, errors.New(commonerrors.ErrDeletionScheduled)
+	}
+	deletion := &usermodel.AccountDeletion{
+		UserId:       user.Id,
+		ScheduledFor: time.Now().Add(time.Duration(config.GetAccountDeletionGrace()) * time.Second),
+	}
+	email, err := deletionEmail(user, deletion.ScheduledFor, false)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	if err = s.Repository.Create(deletion, email); err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return deletion, nil
+}
+
+// DeleteNow deletes the account of a user at once, as done by an admin.
+//
+// A pending deletion is carried out ahead of its schedule. The user is notified by email.
+// Admins, service accounts and accounts already deleted cannot be deleted this way.
+// Returns an error if the deletion fails.
+func (s *AccountDeletionService) DeleteNow(user *usermodel.User) error {
+	if user.Account == usermodel.AccountTypeAdmin || user.Account == usermodel.AccountTypeService || user.AnonymizedAt != nil {
+		return errors.New(commonerrors.ErrCannotDeleteAccount)
+	}
+	deletion, err := s.Repository.FindPendingByUserId(user.Id)
+	if err != nil {
+		deletion = &usermodel.AccountDeletion{UserId: user.Id, ScheduledFor: time.Now()}
+		if err = s.Repository.Create(deletion); err != nil {
+			return errors.New(commonerrors.ErrInternalServer)
+		}
+	}
+	email, err := deletionEmail(user, time.Now(), true)
+	if err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	if err = s.Repository.Anonymize(deletion, email); err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	return nil
+}
+
+// FindPending finds the pending account deletion of a user.
+//
+// Returns the deletion and ErrDeletionNotFound if no deletion is scheduled.
+func (s *AccountDeletionService) FindPending(userId uuid.UUID) (*usermodel.AccountDeletion, error) {
+	deletion, err := s.Repository.FindPendingByUserId(userId)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrDeletionNotFound)
+	}
+	return deletion, nil
+}
+
+// Cancel cancels the pending account deletion of a user.
+//
+// Returns ErrDeletionNotFound if no deletion is scheduled.
+func (s *AccountDeletionService) Cancel(userId uuid.UUID) error {
+	deletion, err := s.FindPending(userId)
+	if err != nil {
+		return err
+	}
+	canceled, err := s.Repository.Cancel(deletion.Id)
+	if err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	if !canceled {
+		return errors.New(commonerrors.ErrDeletionNotFound)
+	}
+	return nil
+}
+
+// ProcessDue anonymizes the accounts whose grace period is over and notifies their
+// owners, at the address they had, that the deletion has been carried out.
+func (s *AccountDeletionService) ProcessDue() {
+	deletions, err := s.Repository.FindDue(accountDeletionBatch)
+	if err != nil {
+		return
+	}
+	for i := range deletions {
+		deletion := &deletions[i]
+		user, err := s.Repository.FindUserById(deletion.UserId)
+		if err != nil {
+			log.Printf("failed to find user %v to delete: %v", deletion.UserId, err)
+			continue
+		}
+		email, err := deletionEmail(user, deletion.ScheduledFor, true)
+		if err != nil {
+			continue
+		}
+		if err = s.Repository.Anonymize(deletion, email); err != nil {
+			continue
+		}
+		log.Printf("account %v deleted", deletion.UserId)
+	}
+}
+
+// deletionEmail renders the email notifying a user that their account is scheduled for deletion,
+// or has been deleted, as an email of the outbox.
+//
+// The email of a completed deletion is not linked to the user, as the account no longer holds the
+// address it is sent to.
+func deletionEmail(user *usermodel.User, scheduledFor time.Time, completed bool) (*emailmodel.EmailOutbox, error) {
+	message, err := emailPkg.NewEmailAccountDeletion(user, scheduledFor, completed)
+	if err != nil {
+		log.Printf("failed to render account deletion email: %v", err)
+		return nil, err
+	}
+	userId := &user.Id
+	if completed {
+		userId = nil
+	}
+	return emailservice.NewOutboxEmail(emailmodel.OutboxKindAccountDeletion, userId, message), nil
+}
+
+// StartWorker carries out due account deletions every interval. It returns immediately.
+func (s *AccountDeletionService) StartWorker(interval time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			s.ProcessDue()
+		}
+	}()
+}
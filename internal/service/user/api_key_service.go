@@ -0,0 +1,212 @@
+package userservice
+
+import (
+	"errors"
+	"github.com/drunkleen/rasta/config"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	"github.com/drunkleen/rasta/internal/common/utils"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	"github.com/google/uuid"
+	"log"
+	"strings"
+	"time"
+)
+
+// maxApiKeysPerUser is how many API keys a user or service account may hold at once.
+const maxApiKeysPerUser = 25
+
+// apiKeyUsageInterval is how often the last use of an API key is written, so busy keys do not
+// cause a database write on every request.
+const apiKeyUsageInterval = time.Minute
+
+// serviceAccountEmailDomain is the reserved domain service accounts get their unique, undeliverable email address in.
+const serviceAccountEmailDomain = "service.invalid"
+
+type ApiKeyService struct {
+	Repository *userrepository.ApiKeyRepository
+}
+
+// NewApiKeyService creates a new instance of the ApiKeyService struct.
+//
+// It takes a pointer to an ApiKeyRepository as a parameter and returns a pointer to an ApiKeyService.
+func NewApiKeyService(repository *userrepository.ApiKeyRepository) *ApiKeyService {
+	return &ApiKeyService{Repository: repository}
+}
+
+// Create issues an API key to a user or a service account.
+//
+// scopes are the permissions the key grants, as far as its owner holds them. rateLimit is the number
+// of requests per minute the key may make, API_KEY_RATE_LIMIT when 0. expiresInDays is the lifetime
+// of the key, which never expires when 0.
+// Returns the key, the secret key itself, which is only ever returned here, and an error if any.
+func (s *ApiKeyService) Create(userId uuid.UUID, name string, scopes []usermodel.Permission, rateLimit, expiresInDays int) (*usermodel.ApiKey, string, error) {
+	name = strings.TrimSpace(name)
+	if len(name) < 2 || len(name) > 64 {
+		return nil, "", errors.New(commonerrors.ErrInvalidApiKeyName)
+	}
+	if rateLimit == 0 {
+		rateLimit = config.GetApiKeyRateLimit()
+	}
+	if rateLimit < 0 || rateLimit > config.GetApiKeyMaxRateLimit() {
+		return nil, "", errors.New(commonerrors.ErrInvalidRateLimit)
+	}
+	if expiresInDays < 0 {
+		return nil, "", errors.New(commonerrors.ErrInvalidExpiry)
+	}
+	names := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !scope.IsValid() {
+			return nil, "", errors.New(commonerrors.ErrInvalidPermission + ": " + string(scope))
+		}
+		names = append(names, string(scope))
+	}
+	count, err := s.Repository.CountByUserId(userId)
+	if err != nil {
+		return nil, "", errors.New(commonerrors.ErrInternalServer)
+	}
+	if count >= maxApiKeysPerUser {
+		return nil, "", errors.New(commonerrors.ErrTooManyApiKeys)
+	}
+	secret, prefix, err := auth.GenerateApiKey()
+	if err != nil {
+		return nil, "", errors.New(commonerrors.ErrInternalServer)
+	}
+	key := &usermodel.ApiKey{
+		UserId:    userId,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   auth.HashToken(secret),
+		Scopes:    strings.Join(names, " "),
+		RateLimit: rateLimit,
+	}
+	if expiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, expiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+	if err = s.Repository.Create(key); err != nil {
+		return nil, "", errors.New(commonerrors.ErrInternalServer)
+	}
+	return key, secret, nil
+}
+
+// Authenticate finds the API key a request was made with and records its use.
+//
+// secret is the key sent in the Authorization header and ipAddress the address the request came from.
+// Returns the key and an error if the key is unknown or expired.
+func (s *ApiKeyService) Authenticate(secret, ipAddress string) (*usermodel.ApiKey, error) {
+	if !strings.HasPrefix(secret, auth.ApiKeyPrefix) {
+		return nil, errors.New(commonerrors.ErrInvalidApiKey)
+	}
+	key, err := s.Repository.FindByHash(auth.HashToken(secret))
+	if err != nil || key.IsExpired() {
+		return nil, errors.New(commonerrors.ErrInvalidApiKey)
+	}
+	if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > apiKeyUsageInterval || key.LastUsedIp != ipAddress {
+		if err = s.Repository.UpdateUsage(key.Id, ipAddress); err != nil {
+			log.Printf("failed to record use of api key %v: %v", key.Id, err)
+		}
+	}
+	return key, nil
+}
+
+// FindByUserId returns the API keys of a user or a service account.
+//
+// userId is the unique identifier of the owner.
+// Returns the keys and an error if any.
+func (s *ApiKeyService) FindByUserId(userId uuid.UUID) ([]usermodel.ApiKey, error) {
+	keys, err := s.Repository.FindByUserId(userId)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return keys, nil
+}
+
+// Revoke revokes an API key. Requests made with it are rejected at once.
+//
+// id is the unique identifier of the key and userId the unique identifier of its owner.
+// Returns an error if the key does not exist or the deletion fails.
+func (s *ApiKeyService) Revoke(id, userId uuid.UUID) error {
+	deleted, err := s.Repository.Delete(id, userId)
+	if err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	if !deleted {
+		return errors.New(commonerrors.ErrApiKeyNotFound)
+	}
+	return nil
+}
+
+// CreateServiceAccount creates a non-human account for an integration.
+//
+// The account cannot sign in: it gets a random password and an undeliverable email address, and
+// authenticates with the API keys issued to it. Its permissions are granted through roles.
+// Returns the account and an error if any.
+func (s *ApiKeyService) CreateServiceAccount(username, name string, region usermodel.RegionType) (*usermodel.User, error) {
+	username = strings.ToLower(username)
+	if !utils.UsernameValid(username) {
+		return nil, errors.New(commonerrors.ErrInvalidUsername)
+	}
+	exists, err := s.Repository.UsernameExists(username)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	if exists {
+		return nil, errors.New(commonerrors.ErrUsernameAlreadyExists)
+	}
+	password, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	user := &usermodel.User{
+		FirstName:  truncate(strings.TrimSpace(name), maxNameLength),
+		Username:   username,
+		Email:      username + "@" + serviceAccountEmailDomain,
+		Password:   password,
+		IsVerified: true,
+		Account:    usermodel.AccountTypeService,
+		Region:     region,
+	}
+	if err = s.Repository.CreateServiceAccount(user); err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return user, nil
+}
+
+// FindServiceAccounts returns every service account.
+//
+// Returns the accounts and an error if any.
+func (s *ApiKeyService) FindServiceAccounts() ([]usermodel.User, error) {
+	users, err := s.Repository.FindServiceAccounts()
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return users, nil
+}
+
+// FindServiceAccountById finds a service account by its ID.
+//
+// id is the unique identifier of the account.
+// Returns the account and an error if no service account has this ID.
+func (s *ApiKeyService) FindServiceAccountById(id uuid.UUID) (*usermodel.User, error) {
+	user, err := s.Repository.FindServiceAccountById(id)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrServiceAccountNotFound)
+	}
+	return user, nil
+}
+
+// DeleteServiceAccount deletes a service account and revokes its API keys.
+//
+// id is the unique identifier of the account.
+// Returns an error if the account does not exist or the deletion fails.
+func (s *ApiKeyService) DeleteServiceAccount(id uuid.UUID) error {
+	if _, err := s.FindServiceAccountById(id); err != nil {
+		return err
+	}
+	if err := s.Repository.DeleteServiceAccount(id); err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package userservice
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/drunkleen/rasta/config"
+	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	"github.com/google/uuid"
+	"log"
+	"time"
+)
+
+// dataExportTimeout is how long an export may stay pending before it is considered abandoned.
+const dataExportTimeout = time.Hour
+
+type DataExportService struct {
+	Repository *userrepository.DataExportRepository
+}
+
+// NewDataExportService creates a new instance of the DataExportService struct.
+//
+// It takes a pointer to a DataExportRepository as a parameter and returns a pointer to a DataExportService.
+func NewDataExportService(repository *userrepository.DataExportRepository) *DataExportService {
+	return &DataExportService{Repository: repository}
+}
+
+// Request starts a data export for a user.
+//
+// The export is assembled in the background; its status tells when the archive is ready.
+// A user may only have one export being prepared at a time. Returns the pending export and an error if any.
+func (s *DataExportService) Request(user *usermodel.User) (*usermodel.DataExport, error) {
+	pending, err := s.Repository.HasPending(user.Id)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	if pending {
+		return nil, errors.New(commonerrors.ErrDataExportPending)
+	}
+	export := &usermodel.DataExport{UserId: user.Id, Status: usermodel.DataExportStatusPending}
+	if err = s.Repository.Create(export); err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	go s.build(export.Id, *user)
+	return export, nil
+}
+
+// FindByUserId returns the data exports of a user, newest first.
+func (s *DataExportService) FindByUserId(userId uuid.UUID) ([]usermodel.DataExport, error) {
+	exports, err := s.Repository.FindByUserId(userId)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return exports, nil
+}
+
+// Download returns a data export of a user with its archive.
+//
+// Returns ErrDataExportNotFound if the export does not belong to the user, and
+// ErrDataExportNotReady if it is still pending, failed or has expired.
+func (s *DataExportService) Download(id, userId uuid.UUID) (*usermodel.DataExport, error) {
+	export, err := s.Repository.FindById(id, userId)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrDataExportNotFound)
+	}
+	if !export.IsDownloadable() || len(export.Archive) == 0 {
+		return nil, errors.New(commonerrors.ErrDataExportNotReady)
+	}
+	return export, nil
+}
+
+// StartWorker drops expired archives and fails abandoned exports every interval. It returns immediately.
+func (s *DataExportService) StartWorker(interval time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			_ = s.Repository.Purge(time.Now().Add(-dataExportTimeout))
+		}
+	}()
+}
+
+// build assembles the archive of an export and stores it, valid for DATA_EXPORT_EXPIRY seconds.
+func (s *DataExportService) build(id uuid.UUID, user usermodel.User) {
+	archive, err := s.assemble(&user)
+	if err != nil {
+		log.Printf("failed to build data export %v: %v", id, err)
+		_ = s.Repository.Fail(id)
+		return
+	}
+	expiresAt := time.Now().Add(time.Duration(config.GetDataExportExpiry()) * time.Second)
+	if err = s.Repository.Complete(id, archive, expiresAt); err != nil {
+		_ = s.Repository.Fail(id)
+	}
+}
+
+// assemble collects the data held about a user into a ZIP archive with one JSON file per kind of data.
+func (s *DataExportService) assemble(user *usermodel.User) ([]byte, error) {
+	contents, err := s.Repository.Collect(user)
+	if err != nil {
+		return nil, err
+	}
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", userDTO.FromModelToProfileResponse(user)},
+		{"tickets.json", contents.Tickets},
+		{"comments.json", contents.Comments},
+		{"subscriptions.json", contents.Subscriptions},
+		{"sessions.json", contents.Sessions},
+		{"devices.json", contents.Devices},
+		{"identities.json", contents.Identities},
+		{"passkeys.json", contents.Passkeys},
+		{"api_keys.json", contents.ApiKeys},
+		{"consents.json", contents.Consents},
+		{"email_changes.json", contents.EmailChanges},
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err = encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+	if err = zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,161 @@
+package userservice
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/drunkleen/rasta/config"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	"github.com/drunkleen/rasta/internal/common/utils"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	emailservice "github.com/drunkleen/rasta/internal/service/email"
+	emailPkg "github.com/drunkleen/rasta/pkg/email"
+	"log"
+	"strings"
+	"time"
+)
+
+type EmailChangeService struct {
+	Repository *userrepository.EmailChangeRepository
+}
+
+// NewEmailChangeService creates a new instance of the EmailChangeService struct.
+//
+// It takes a pointer to an EmailChangeRepository as a parameter and returns a pointer to an EmailChangeService.
+func NewEmailChangeService(repository *userrepository.EmailChangeRepository) *EmailChangeService {
+	return &EmailChangeService{Repository: repository}
+}
+
+// Request starts changing the email address of a user.
+//
+// The current password of the user is required. A code completing the change is sent to the new
+// address, valid for EMAIL_OTP_EXPIRY seconds, and the current address is sent a link cancelling it,
+// valid for EMAIL_CHANGE_CANCEL_WINDOW seconds. Both emails are written to the outbox in the same
+// transaction as the change. The request replaces any change not completed yet.
+// Addresses of domains refused at signup are refused as well.
+// Returns the change and an error if any.
+func (s *EmailChangeService) Request(user *usermodel.User, password, newEmail string) (*usermodel.EmailChange, error) {
+	if ok, _ := auth.VerifyPassword(password, user.Password); !ok {
+		return nil, errors.New(commonerrors.ErrInvalidCredentials)
+	}
+	newEmail = strings.TrimSpace(newEmail)
+	if !utils.EmailValidate(&newEmail) {
+		return nil, errors.New(commonerrors.ErrInvalidEmail)
+	}
+	if auth.IsDomainBlocked(newEmail[strings.LastIndexByte(newEmail, '@')+1:]) {
+		return nil, errors.New(commonerrors.ErrEmailDomainBlocked)
+	}
+	if strings.EqualFold(newEmail, user.Email) {
+		return nil, errors.New(commonerrors.ErrSameEmail)
+	}
+	taken, err := s.Repository.EmailTaken(newEmail, utils.EmailDuplicateKey(newEmail), user.Id)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	if taken {
+		return nil, errors.New(commonerrors.ErrEmailAlreadyExists)
+	}
+	// A sign-in link is made of the same secrets: a strong token and a short numeric code.
+	cancelToken, code, err := auth.GenerateLoginLink()
+	if err != nil {
+		log.Printf("failed to generate email change secrets: %v", err)
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	now := time.Now()
+	change := &usermodel.EmailChange{
+		UserId:          user.Id,
+		OldEmail:        user.Email,
+		NewEmail:        newEmail,
+		CodeHash:        auth.HashToken(code),
+		CancelTokenHash: auth.HashToken(cancelToken),
+		ExpiresAt:       now.Add(time.Duration(config.GetEnvEmailOTPExpiry()) * time.Second),
+		CancelableUntil: now.Add(time.Duration(config.GetEmailChangeCancelWindow()) * time.Second),
+	}
+	codeMessage, err := emailPkg.NewEmailChangeCode(user, newEmail, code, change.ExpiresAt)
+	if err != nil {
+		log.Printf("Error rendering email change code: %v", err)
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	noticeMessage, err := emailPkg.NewEmailChangeNotice(user, newEmail, utils.WithTokenQuery(config.GetEmailChangeCancelUrl(), cancelToken), change.CancelableUntil)
+	if err != nil {
+		log.Printf("Error rendering email change notice: %v", err)
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	err = s.Repository.Create(change,
+		emailservice.NewOutboxEmail(emailmodel.OutboxKindEmailChangeCode, &user.Id, codeMessage),
+		emailservice.NewOutboxEmail(emailmodel.OutboxKindEmailChangeNotice, &user.Id, noticeMessage),
+	)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return change, nil
+}
+
+// Verify completes the pending email change of a user with the code sent to the new address.
+//
+// Every guess is counted before it is checked, and the change is dropped once OTP_MAX_ATTEMPTS guesses
+// have been made, so the code cannot be brute-forced, even by parallel guesses.
+// Returns the completed change, or ErrInvalidEmailChange if the code is wrong or no change is pending.
+func (s *EmailChangeService) Verify(user *usermodel.User, code string) (*usermodel.EmailChange, error) {
+	change, err := s.Repository.FindPendingByUserId(user.Id)
+	if err != nil || !change.IsPending() {
+		return nil, errors.New(commonerrors.ErrInvalidEmailChange)
+	}
+	attempts, reserved, err := s.Repository.ReserveAttempt(change.Id, config.GetOtpMaxAttempts())
+	if err != nil || !reserved {
+		return nil, errors.New(commonerrors.ErrInvalidEmailChange)
+	}
+	if subtle.ConstantTimeCompare([]byte(auth.HashToken(strings.TrimSpace(code))), []byte(change.CodeHash)) != 1 {
+		if attempts >= config.GetOtpMaxAttempts() {
+			log.Printf("dropping email change of user %v after %d wrong guesses", change.UserId, attempts)
+			_ = s.Repository.Delete(change.Id)
+		}
+		return nil, errors.New(commonerrors.ErrInvalidEmailChange)
+	}
+	taken, err := s.Repository.EmailTaken(change.NewEmail, utils.EmailDuplicateKey(change.NewEmail), user.Id)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	if taken {
+		return nil, errors.New(commonerrors.ErrEmailAlreadyExists)
+	}
+	verified, err := s.Repository.Verify(change)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	if !verified {
+		return nil, errors.New(commonerrors.ErrInvalidEmailChange)
+	}
+	return change, nil
+}
+
+// Cancel cancels an email change with the token of the link sent to the old address. A change that
+// has already been completed is reverted, giving the user back the old address.
+//
+// Returns the canceled change, or ErrInvalidEmailCancel if the token is unknown, the change has
+// already been canceled or the cancellation window is over.
+func (s *EmailChangeService) Cancel(token string) (*usermodel.EmailChange, error) {
+	change, err := s.Repository.FindByCancelTokenHash(auth.HashToken(token))
+	if err != nil || !change.IsCancelable() {
+		return nil, errors.New(commonerrors.ErrInvalidEmailCancel)
+	}
+	if change.VerifiedAt != nil {
+		taken, err := s.Repository.EmailTaken(change.OldEmail, utils.EmailDuplicateKey(change.OldEmail), change.UserId)
+		if err != nil {
+			return nil, errors.New(commonerrors.ErrInternalServer)
+		}
+		if taken {
+			return nil, errors.New(commonerrors.ErrEmailAlreadyExists)
+		}
+	}
+	canceled, err := s.Repository.Cancel(change)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	if !canceled {
+		return nil, errors.New(commonerrors.ErrInvalidEmailCancel)
+	}
+	return change, nil
+}
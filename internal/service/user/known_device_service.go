@@ -0,0 +1,144 @@
+package userservice
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/drunkleen/rasta/config"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	"github.com/drunkleen/rasta/internal/common/utils"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	emailservice "github.com/drunkleen/rasta/internal/service/email"
+	emailPkg "github.com/drunkleen/rasta/pkg/email"
+	"github.com/drunkleen/rasta/pkg/geoip"
+	"github.com/google/uuid"
+	"log"
+	"net/netip"
+	"time"
+)
+
+type KnownDeviceService struct {
+	Repository *userrepository.KnownDeviceRepository
+}
+
+// NewKnownDeviceService creates a new instance of the KnownDeviceService struct.
+//
+// It takes a pointer to a KnownDeviceRepository as a parameter and returns a pointer to a KnownDeviceService.
+func NewKnownDeviceService(repository *userrepository.KnownDeviceRepository) *KnownDeviceService {
+	return &KnownDeviceService{Repository: repository}
+}
+
+// Recognize records a login of a user from a device, fingerprinted by its user agent and coarse location.
+//
+// A device that is not known yet is stored, and the user is notified by email with a link reporting
+// the login, valid for LOGIN_REPORT_WINDOW seconds, written to the outbox along with the device. The first device of a user, such as the one they
+// signed up from, is stored without notification. Failures are logged rather than returned, so they
+// never prevent a login.
+func (s *KnownDeviceService) Recognize(user *usermodel.User, userAgent, ipAddress string) {
+	location, _ := geoip.Lookup(ipAddress)
+	fingerprint := deviceFingerprint(userAgent, ipAddress, location)
+	if device, err := s.Repository.FindByFingerprint(user.Id, fingerprint); err == nil {
+		_ = s.Repository.Touch(device.Id, ipAddress)
+		return
+	}
+	known, err := s.Repository.HasAny(user.Id)
+	if err != nil {
+		return
+	}
+	device := &usermodel.KnownDevice{
+		UserId:      user.Id,
+		Fingerprint: fingerprint,
+		Device:      utils.DeviceFromUserAgent(userAgent),
+		UserAgent:   truncate(userAgent, 512),
+		IpAddress:   ipAddress,
+		Location:    location.String(),
+	}
+	if !known {
+		_ = s.Repository.Create(device)
+		return
+	}
+	token, err := auth.GenerateRefreshToken()
+	if err != nil {
+		log.Printf("failed to generate login report token: %v", err)
+		return
+	}
+	tokenHash := auth.HashToken(token)
+	reportableUntil := time.Now().Add(time.Duration(config.GetLoginReportWindow()) * time.Second)
+	device.ReportTokenHash = &tokenHash
+	device.ReportableUntil = &reportableUntil
+	message, err := emailPkg.NewEmailNewLogin(user, device, utils.WithTokenQuery(config.GetLoginReportUrl(), token))
+	if err != nil {
+		log.Printf("failed to render new login email: %v", err)
+		return
+	}
+	_ = s.Repository.Create(device, emailservice.NewOutboxEmail(emailmodel.OutboxKindNewLogin, &user.Id, message))
+}
+
+// FindByUserId returns the known devices of a user, most recently seen first.
+func (s *KnownDeviceService) FindByUserId(userId uuid.UUID) ([]usermodel.KnownDevice, error) {
+	devices, err := s.Repository.FindByUserId(userId)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return devices, nil
+}
+
+// Delete forgets a known device of a user, so the next login from it is notified again.
+//
+// Returns ErrDeviceNotFound if the user has no such device.
+func (s *KnownDeviceService) Delete(userId, id uuid.UUID) error {
+	deleted, err := s.Repository.Delete(id, userId)
+	if err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	if !deleted {
+		return errors.New(commonerrors.ErrDeviceNotFound)
+	}
+	return nil
+}
+
+// Report reports the login which made a device known as not made by its user, with the token of
+// the link sent in the notification. The device is forgotten, the credentials created since the login
+// are revoked and the user must reset their password before signing in with it again; revoking the
+// sessions of the user is up to the caller.
+//
+// Returns the reported device, or ErrInvalidLoginReport if the token is unknown, has been used or
+// the link has expired.
+func (s *KnownDeviceService) Report(token string) (*usermodel.KnownDevice, error) {
+	device, err := s.Repository.FindByReportTokenHash(auth.HashToken(token))
+	if err != nil || !device.IsReportable() {
+		return nil, errors.New(commonerrors.ErrInvalidLoginReport)
+	}
+	reported, err := s.Repository.Report(device)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	if !reported {
+		return nil, errors.New(commonerrors.ErrInvalidLoginReport)
+	}
+	return device, nil
+}
+
+// deviceFingerprint identifies a device by its user agent and coarse location. Without a GeoIP
+// location, the network of the IP address stands in for it, so that a device keeps its fingerprint
+// while its address changes within the network.
+func deviceFingerprint(userAgent, ipAddress string, location geoip.Location) string {
+	place := location.String()
+	if place == "" {
+		place = ipAddress
+		if addr, err := netip.ParseAddr(ipAddress); err == nil {
+			bits := 48
+			if addr.Unmap().Is4() {
+				bits = 24
+			}
+			if prefix, err := addr.Unmap().Prefix(bits); err == nil {
+				place = prefix.String()
+			}
+		}
+	}
+	sum := sha256.Sum256([]byte(userAgent + "\x00" + place))
+	return hex.EncodeToString(sum[:])
+}
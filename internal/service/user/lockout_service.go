@@ -0,0 +1,156 @@
+package userservice
+
+import (
+	"errors"
+	"github.com/drunkleen/rasta/config"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	emailservice "github.com/drunkleen/rasta/internal/service/email"
+	emailPkg "github.com/drunkleen/rasta/pkg/email"
+	"github.com/google/uuid"
+	"log"
+	"time"
+)
+
+// maxLockout caps the duration of a single lockout.
+const maxLockout = 24 * time.Hour
+
+// maxDelay caps the delay enforced between failed attempts before a lockout.
+const maxDelay = 30 * time.Second
+
+type LockoutService struct {
+	Repository *userrepository.LockoutRepository
+}
+
+// NewLockoutService creates a new instance of the LockoutService struct.
+//
+// It takes a pointer to a LockoutRepository as a parameter and returns a pointer to a LockoutService.
+func NewLockoutService(repository *userrepository.LockoutRepository) *LockoutService {
+	return &LockoutService{Repository: repository}
+}
+
+// Check reports whether an attempt made against an account from an IP address may proceed.
+//
+// user is the targeted account, or nil if no account matches. ipAddress is the address of the client.
+// Returns how long the client has to wait and ErrAccountLocked or ErrTooManyAttempts if the attempt is refused.
+func (s *LockoutService) Check(user *usermodel.User, ipAddress string) (time.Duration, error) {
+	if retryAfter := s.retryAfter(usermodel.LockoutScopeIp, ipAddress); retryAfter > 0 {
+		return retryAfter, errors.New(commonerrors.ErrTooManyAttempts)
+	}
+	if user == nil {
+		return 0, nil
+	}
+	if retryAfter := s.retryAfter(usermodel.LockoutScopeAccount, user.Id.String()); retryAfter > 0 {
+		return retryAfter, errors.New(commonerrors.ErrAccountLocked)
+	}
+	return 0, nil
+}
+
+// RegisterFailure records a failed attempt against an account from an IP address.
+//
+// Every failure delays the next attempt a little longer. Once an account or an IP address
+// reaches its threshold it is locked out, and the owner of a locked account is notified by email.
+// user is the targeted account, or nil if no account matches. ipAddress is the address of the client.
+func (s *LockoutService) RegisterFailure(user *usermodel.User, ipAddress string) {
+	s.registerFailure(usermodel.LockoutScopeIp, ipAddress, config.GetLockoutIpThreshold(), nil)
+	if user == nil {
+		return
+	}
+	lockout, locked := s.registerFailure(usermodel.LockoutScopeAccount, user.Id.String(), config.GetLockoutThreshold(),
+		func(lockout *usermodel.Lockout, lockedUntil time.Time) *emailmodel.EmailOutbox {
+			message, err := emailPkg.NewEmailAccountLocked(user, lockout.Failures, ipAddress, lockedUntil)
+			if err != nil {
+				log.Printf("failed to render account locked email: %v", err)
+				return nil
+			}
+			return emailservice.NewOutboxEmail(emailmodel.OutboxKindAccountLocked, &user.Id, message)
+		})
+	if locked {
+		log.Printf("account %v locked after %d failed attempts", user.Id, lockout.Failures)
+	}
+}
+
+// Reset clears the failed attempts of an account, lifting its lockout.
+//
+// userId is the unique identifier of the account.
+// Returns an error if the deletion operation fails.
+func (s *LockoutService) Reset(userId uuid.UUID) error {
+	if err := s.Repository.Delete(usermodel.LockoutScopeAccount, userId.String()); err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	return nil
+}
+
+// FindLockedAccounts returns the lockouts of the accounts that are currently locked.
+//
+// Returns the lockouts and an error if any.
+func (s *LockoutService) FindLockedAccounts() ([]usermodel.Lockout, error) {
+	lockouts, err := s.Repository.FindLocked(usermodel.LockoutScopeAccount)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return lockouts, nil
+}
+
+// retryAfter returns how long a subject is still locked out, or 0 if it is not.
+func (s *LockoutService) retryAfter(scope usermodel.LockoutScope, subject string) time.Duration {
+	lockout, err := s.Repository.Find(scope, subject)
+	if err != nil || lockout.LockedUntil == nil {
+		return 0
+	}
+	if retryAfter := time.Until(*lockout.LockedUntil); retryAfter > 0 {
+		return retryAfter
+	}
+	return 0
+}
+
+// registerFailure increments the counter of a subject and locks the subject out for as long
+// as lockDuration requires. When the failure starts a lockout and notify is not nil, the email
+// notify returns is written to the outbox along with the lockout.
+// Returns the counter and whether the failure started a lockout.
+func (s *LockoutService) registerFailure(scope usermodel.LockoutScope, subject string, threshold int, notify func(lockout *usermodel.Lockout, lockedUntil time.Time) *emailmodel.EmailOutbox) (*usermodel.Lockout, bool) {
+	if subject == "" {
+		return nil, false
+	}
+	lockout, err := s.Repository.RegisterFailure(scope, subject, time.Duration(config.GetLockoutWindow())*time.Second)
+	if err != nil {
+		return nil, false
+	}
+	duration := lockDuration(lockout.Failures, threshold)
+	if duration == 0 {
+		return lockout, false
+	}
+	lockedUntil := time.Now().Add(duration)
+	starts := lockout.Failures%threshold == 0
+	var emails []*emailmodel.EmailOutbox
+	if starts && notify != nil {
+		if email := notify(lockout, lockedUntil); email != nil {
+			emails = append(emails, email)
+		}
+	}
+	if err = s.Repository.Lock(scope, subject, lockedUntil, emails...); err != nil {
+		return lockout, false
+	}
+	lockout.LockedUntil = &lockedUntil
+	return lockout, starts
+}
+
+// lockDuration returns how long a subject is locked out after the given number of failures.
+//
+// Below the threshold the delay doubles with every failure, starting at one second from the
+// second failure on. Every threshold failures a lockout starts, twice as long as the previous one.
+func lockDuration(failures, threshold int) time.Duration {
+	if failures < threshold {
+		if failures < 2 {
+			return 0
+		}
+		return min(time.Second<<(failures-2), maxDelay)
+	}
+	lockouts := failures/threshold - 1
+	if lockouts > 16 {
+		return maxLockout
+	}
+	return min(time.Duration(config.GetLockoutDuration())*time.Second<<lockouts, maxLockout)
+}
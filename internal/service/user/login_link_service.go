@@ -0,0 +1,122 @@
+package userservice
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/drunkleen/rasta/config"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	"github.com/drunkleen/rasta/internal/common/utils"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	emailservice "github.com/drunkleen/rasta/internal/service/email"
+	emailPkg "github.com/drunkleen/rasta/pkg/email"
+	"github.com/google/uuid"
+	"log"
+	"time"
+)
+
+type LoginLinkService struct {
+	Repository *userrepository.LoginLinkRepository
+}
+
+// NewLoginLinkService creates a new instance of the LoginLinkService struct.
+//
+// It takes a pointer to a LoginLinkRepository as a parameter and returns a pointer to a LoginLinkService.
+func NewLoginLinkService(repository *userrepository.LoginLinkRepository) *LoginLinkService {
+	return &LoginLinkService{Repository: repository}
+}
+
+// GenerateAndSendEmail creates a sign-in link for a user and sends it by email along with a code.
+//
+// The link and the code are valid once, for EMAIL_OTP_EXPIRY seconds, and replace any link sent before.
+// The email is written to the outbox in the same transaction as the link.
+// Returns the ID of the link, which is needed to sign in with the code, and an error if any.
+func (s *LoginLinkService) GenerateAndSendEmail(user *usermodel.User) (uuid.UUID, error) {
+	token, code, err := auth.GenerateLoginLink()
+	if err != nil {
+		log.Printf("failed to generate login link: %v", err)
+		return uuid.Nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	link := &usermodel.LoginLink{
+		UserId:    user.Id,
+		TokenHash: auth.HashToken(token),
+		CodeHash:  auth.HashToken(code),
+		ExpiresAt: time.Now().Add(time.Duration(config.GetEnvEmailOTPExpiry()) * time.Second),
+	}
+	message, err := emailPkg.NewEmailLoginLink(user, utils.WithTokenQuery(config.GetEmailLoginUrl(), token), code, link.ExpiresAt)
+	if err != nil {
+		log.Printf("Error rendering login link email: %v", err)
+		return uuid.Nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	email := emailservice.NewOutboxEmail(emailmodel.OutboxKindLoginLink, &user.Id, message)
+	if err = s.Repository.Create(link, email); err != nil {
+		return uuid.Nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return link.Id, nil
+}
+
+// VerifyToken checks the token of a sign-in link.
+//
+// Returns the link and its owner, or ErrInvalidLoginLink if the token is unknown, expired or has been used.
+func (s *LoginLinkService) VerifyToken(token string) (*usermodel.LoginLink, *usermodel.User, error) {
+	link, err := s.Repository.FindByTokenHash(auth.HashToken(token))
+	if err != nil || !s.isUsable(link) {
+		return nil, nil, errors.New(commonerrors.ErrInvalidLoginLink)
+	}
+	return s.withOwner(link)
+}
+
+// VerifyCode checks the code guessed for a sign-in link.
+//
+// Every guess is counted before it is checked, and the link is invalidated once OTP_MAX_ATTEMPTS guesses
+// have been made, so the code cannot be brute-forced, even by parallel guesses.
+// id is the ID returned when the link was sent, and code is the guessed code.
+// Returns the link and its owner, or ErrInvalidLoginLink if the code is wrong or the link is not usable.
+func (s *LoginLinkService) VerifyCode(id uuid.UUID, code string) (*usermodel.LoginLink, *usermodel.User, error) {
+	link, err := s.Repository.FindById(id)
+	if err != nil || !s.isUsable(link) {
+		return nil, nil, errors.New(commonerrors.ErrInvalidLoginLink)
+	}
+	attempts, reserved, err := s.Repository.ReserveAttempt(link.Id, config.GetOtpMaxAttempts())
+	if err != nil || !reserved {
+		return nil, nil, errors.New(commonerrors.ErrInvalidLoginLink)
+	}
+	if subtle.ConstantTimeCompare([]byte(auth.HashToken(code)), []byte(link.CodeHash)) == 1 {
+		return s.withOwner(link)
+	}
+	if attempts >= config.GetOtpMaxAttempts() {
+		log.Printf("invalidating login link of user %v after %d wrong guesses", link.UserId, attempts)
+		_ = s.Repository.Delete(link.Id)
+	}
+	return nil, nil, errors.New(commonerrors.ErrInvalidLoginLink)
+}
+
+// Use consumes a sign-in link, so neither its token nor its code can be used again.
+//
+// Returns ErrInvalidLoginLink if the link has already been used, by a concurrent request for instance.
+func (s *LoginLinkService) Use(link *usermodel.LoginLink) error {
+	used, err := s.Repository.Use(link.Id)
+	if err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	if !used {
+		return errors.New(commonerrors.ErrInvalidLoginLink)
+	}
+	return nil
+}
+
+// isUsable reports whether a sign-in link has not expired, been used or been guessed too often.
+func (s *LoginLinkService) isUsable(link *usermodel.LoginLink) bool {
+	return link.UsedAt == nil && time.Now().Before(link.ExpiresAt) && link.Attempts < config.GetOtpMaxAttempts()
+}
+
+// withOwner loads the owner of a sign-in link, including their OAuth settings.
+func (s *LoginLinkService) withOwner(link *usermodel.LoginLink) (*usermodel.LoginLink, *usermodel.User, error) {
+	user, err := s.Repository.FindUserById(link.UserId)
+	if err != nil {
+		return nil, nil, errors.New(commonerrors.ErrInvalidLoginLink)
+	}
+	return link, user, nil
+}
@@ -84,3 +84,51 @@ func (s *OAuthService) UpdateOAuthSecret(email string, id uuid.UUID) (string, er
 	}
 	return oauthSecret, nil
 }
+
+// GenerateRecoveryCodes issues a new set of recovery codes for a given user, invalidating the previous set.
+//
+// It takes a user ID as a parameter to identify the user.
+// It returns the plain recovery codes, which are never stored and can only be shown once, and an error.
+func (s *OAuthService) GenerateRecoveryCodes(id uuid.UUID) ([]string, error) {
+	codes, err := auth.GenerateRecoveryCodes()
+	if err != nil {
+		log.Printf("failed to generate recovery codes: %v", err)
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	codeHashes := make([]string, len(codes))
+	for i, code := range codes {
+		codeHashes[i] = auth.HashToken(code)
+	}
+	if err = s.Repository.ReplaceRecoveryCodes(id, codeHashes); err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return codes, nil
+}
+
+// UseRecoveryCode validates a recovery code of a given user and consumes it.
+//
+// It takes a user and a recovery code as parameters.
+// It returns an error if the code is unknown or has already been used.
+func (s *OAuthService) UseRecoveryCode(user *usermodel.User, code string) error {
+	used, err := s.Repository.UseRecoveryCode(user.Id, auth.HashToken(auth.NormalizeRecoveryCode(code)))
+	if err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	if !used {
+		return errors.New(commonerrors.ErrInvalidRecoveryCode)
+	}
+	log.Printf("recovery code used by user %v", user.Id)
+	return nil
+}
+
+// CountRecoveryCodes returns the number of unused recovery codes of a given user.
+//
+// It takes a user ID as a parameter to identify the user.
+// It returns the number of codes left and an error.
+func (s *OAuthService) CountRecoveryCodes(id uuid.UUID) (int64, error) {
+	count, err := s.Repository.CountUnusedRecoveryCodes(id)
+	if err != nil {
+		return 0, errors.New(commonerrors.ErrInternalServer)
+	}
+	return count, nil
+}
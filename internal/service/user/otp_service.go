@@ -5,8 +5,10 @@ import (
 	"github.com/drunkleen/rasta/config"
 	"github.com/drunkleen/rasta/internal/common/auth"
 	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
 	"github.com/drunkleen/rasta/internal/models/user"
 	"github.com/drunkleen/rasta/internal/repository/user"
+	emailservice "github.com/drunkleen/rasta/internal/service/email"
 	emailPkg "github.com/drunkleen/rasta/pkg/email"
 	"github.com/google/uuid"
 	"log"
@@ -25,25 +27,24 @@ func NewOtpService(repository *userrepository.OtpRepository) *OtpService {
 	return &OtpService{Repository: repository}
 }
 
-// GenerateOtpAndSendEmail generates a new OTP code, saves it to the repository, and sends an email to the user with the OTP code.
+// GenerateOtpAndSendEmail generates a new OTP code, saves it to the repository, and queues an email to the user with the OTP code.
 //
+// The email is written to the outbox in the same transaction as the OTP, and delivered in the background,
+// so a mail server failing for a while delays it rather than losing it.
 // Parameter userModel is the usermodel.User object of the user to send the OTP to, and userId is the unique identifier of the user.
 // Return type is an error object that is returned if any of the operations fail.
 func (s *OtpService) GenerateOtpAndSendEmail(userModel *usermodel.User, userId uuid.UUID) error {
 	otpCode := auth.GenerateOtpCode(8)
 	expTime := time.Now().Add(time.Duration(config.GetEnvEmailOTPExpiry()) * time.Second)
 
-	err := s.Repository.Create(userId, otpCode, expTime)
-	if err != nil {
-		return err
-	}
-
 	userModel.OtpEmail.Code = otpCode
-
-	err = emailPkg.SendEmailVerify(userModel)
+	message, err := emailPkg.NewEmailVerify(userModel)
 	if err != nil {
-		log.Printf("Error sending email Otp: %v", err)
-		_ = s.Repository.Delete(userId)
+		log.Printf("Error rendering email Otp: %v", err)
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	email := emailservice.NewOutboxEmail(emailmodel.OutboxKindVerifyEmail, &userId, message)
+	if err = s.Repository.Create(userId, otpCode, expTime, email); err != nil {
 		return errors.New(commonerrors.ErrInternalServer)
 	}
 	return nil
@@ -101,3 +102,28 @@ func (s *OtpService) Delete(id uuid.UUID) error {
 	}
 	return nil
 }
+
+// Verify checks the email verification OTP guessed by a user.
+//
+// Every guess is counted before it is checked, and the code is invalidated once OTP_MAX_ATTEMPTS
+// guesses have been made, so it cannot be brute-forced, even by parallel guesses.
+// user is the user including their OtpEmail record, and code is the guessed code.
+// Returns ErrInvalidOtp if the code is wrong, expired or has been invalidated.
+func (s *OtpService) Verify(user *usermodel.User, code string) error {
+	record := user.OtpEmail
+	if record.Code == "" || time.Now().After(record.Expiry) {
+		return errors.New(commonerrors.ErrInvalidOtp)
+	}
+	attempts, reserved, err := s.Repository.ReserveAttempt(user.Id, config.GetOtpMaxAttempts())
+	if err != nil || !reserved {
+		return errors.New(commonerrors.ErrInvalidOtp)
+	}
+	if ok, _ := auth.VerifyPassword(code, record.Code); ok {
+		return nil
+	}
+	if attempts >= config.GetOtpMaxAttempts() {
+		log.Printf("invalidating email verification OTP of user %v after %d wrong guesses", user.Id, attempts)
+		_ = s.Repository.Delete(user.Id)
+	}
+	return errors.New(commonerrors.ErrInvalidOtp)
+}
@@ -5,8 +5,10 @@ import (
 	"github.com/drunkleen/rasta/config"
 	"github.com/drunkleen/rasta/internal/common/auth"
 	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
 	usermodel "github.com/drunkleen/rasta/internal/models/user"
 	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	emailservice "github.com/drunkleen/rasta/internal/service/email"
 	emailPkg "github.com/drunkleen/rasta/pkg/email"
 	"github.com/google/uuid"
 	"log"
@@ -28,39 +30,29 @@ func NewResetPwd(repository *userrepository.ResetPwdRepository) *ResetPwdService
 	return &ResetPwdService{Repository: repository}
 }
 
-// GenerateResetPwdAndSendEmail generates a reset password OTP and sends it to the user via email.
+// GenerateResetPwdAndSendEmail generates a reset password OTP and queues it to the user via email.
 //
 // It takes a user model and a user ID as parameters and returns an error.
 //
 // The OTP is generated using the GenerateOtpCode function in the auth package.
 // The OTP is valid for the amount of time specified in the env variable EMAIL_OTP_EXPIRY.
-// The generated OTP is stored in the repository.
-// The user model is then updated with the generated OTP.
-// The email is sent using the SendEmailResetPassword function in the email package.
-// If the email cannot be sent, the generated OTP is deleted from the repository and an error is returned.
+// The user model is updated with the generated OTP, and the email is rendered using the NewEmailResetPassword
+// function in the email package.
+// The generated OTP is stored in the repository together with the email, which is written to the outbox in
+// the same transaction and delivered in the background.
+// If either cannot be stored, neither is and an error is returned.
 func (s *ResetPwdService) GenerateResetPwdAndSendEmail(userModel *usermodel.User, userId uuid.UUID) error {
 	otpCode := auth.GenerateOtpCode(8)
 	expTime := time.Now().Add(time.Duration(config.GetEnvEmailOTPExpiry()) * time.Second)
-	// FindByUserId retrieves a ResetPwd model by its User ID.
-	//
-	// Parameters:
-	// - id: the UUID of the User.
-	//
-	// Returns:
-	// - *usermodel.ResetPwd: the ResetPwd model if found, or nil if not found.
-	// - error: an error if the ResetPwd model cannot be retrieved.
-
-	err := s.Repository.Create(userId, otpCode, expTime)
-	if err != nil {
-		return err
-	}
 
 	userModel.ResetPwd.Code = otpCode
-
-	err = emailPkg.SendEmailResetPassword(userModel)
+	message, err := emailPkg.NewEmailResetPassword(userModel)
 	if err != nil {
-		log.Printf("Error sending email reset password model: %v", err)
-		_ = s.Repository.Delete(userId)
+		log.Printf("Error rendering email reset password model: %v", err)
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	email := emailservice.NewOutboxEmail(emailmodel.OutboxKindResetPassword, &userId, message)
+	if err = s.Repository.Create(userId, otpCode, expTime, email); err != nil {
 		return errors.New(commonerrors.ErrInternalServer)
 	}
 	return nil
@@ -128,3 +120,28 @@ func (s *ResetPwdService) Delete(id uuid.UUID) error {
 	}
 	return nil
 }
+
+// Verify checks the reset password OTP guessed by a user.
+//
+// Every guess is counted before it is checked, and the code is invalidated once OTP_MAX_ATTEMPTS
+// guesses have been made, so it cannot be brute-forced, even by parallel guesses.
+// user is the user including their ResetPwd record, and code is the guessed code.
+// Returns ErrInvalidOtp if the code is wrong, expired or has been invalidated.
+func (s *ResetPwdService) Verify(user *usermodel.User, code string) error {
+	record := user.ResetPwd
+	if record.Code == "" || time.Now().After(record.Expiry) {
+		return errors.New(commonerrors.ErrInvalidOtp)
+	}
+	attempts, reserved, err := s.Repository.ReserveAttempt(user.Id, config.GetOtpMaxAttempts())
+	if err != nil || !reserved {
+		return errors.New(commonerrors.ErrInvalidOtp)
+	}
+	if ok, _ := auth.VerifyPassword(code, record.Code); ok {
+		return nil
+	}
+	if attempts >= config.GetOtpMaxAttempts() {
+		log.Printf("invalidating reset password OTP of user %v after %d wrong guesses", user.Id, attempts)
+		_ = s.Repository.Delete(user.Id)
+	}
+	return errors.New(commonerrors.ErrInvalidOtp)
+}
@@ -0,0 +1,230 @@
+package userservice
+
+import (
+	"errors"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	"github.com/google/uuid"
+	"log"
+	"strings"
+)
+
+type RoleService struct {
+	Repository *userrepository.RoleRepository
+}
+
+// NewRoleService creates a new instance of the RoleService struct.
+//
+// It takes a pointer to a RoleRepository as a parameter and returns a pointer to a RoleService.
+func NewRoleService(repository *userrepository.RoleRepository) *RoleService {
+	return &RoleService{Repository: repository}
+}
+
+// FindAll returns every role together with its permissions.
+//
+// Returns the roles and an error if any.
+func (s *RoleService) FindAll() ([]usermodel.Role, error) {
+	roles, err := s.Repository.FindAll()
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return roles, nil
+}
+
+// FindById finds a role by its ID.
+//
+// id is the unique identifier of the role.
+// Returns the role and an error if the role does not exist.
+func (s *RoleService) FindById(id uuid.UUID) (*usermodel.Role, error) {
+	role, err := s.Repository.FindById(id)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrRoleNotFound)
+	}
+	return role, nil
+}
+
+// Create creates a new role granting the given permissions.
+//
+// name must be unique, and every permission must be a known permission held by actor, the user
+// creating the role, and granted to apiKey if the request was made with one.
+// Returns the created role and an error if any.
+func (s *RoleService) Create(actor *usermodel.User, apiKey *usermodel.ApiKey, name, description string, permissions []usermodel.Permission) (*usermodel.Role, error) {
+	role, err := s.buildRole(name, description, permissions)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkGrantable(actor, apiKey, role); err != nil {
+		return nil, err
+	}
+	if _, err = s.Repository.FindByName(role.Name); err == nil {
+		return nil, errors.New(commonerrors.ErrRoleAlreadyExists)
+	}
+	if err = s.Repository.Create(role); err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return role, nil
+}
+
+// Update updates the name and description of a role and replaces its permissions.
+//
+// id is the unique identifier of the role. Like with Create, every permission the role grants, both
+// as stored and as updated, must be held by actor and granted to apiKey if the request was made with one.
+// Returns the updated role and an error if any.
+func (s *RoleService) Update(actor *usermodel.User, apiKey *usermodel.ApiKey, id uuid.UUID, name, description string, permissions []usermodel.Permission) (*usermodel.Role, error) {
+	existingRole, err := s.Repository.FindById(id)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrRoleNotFound)
+	}
+	if err = s.checkGrantable(actor, apiKey, existingRole); err != nil {
+		return nil, err
+	}
+	role, err := s.buildRole(name, description, permissions)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkGrantable(actor, apiKey, role); err != nil {
+		return nil, err
+	}
+	if existing, err := s.Repository.FindByName(role.Name); err == nil && existing.Id != id {
+		return nil, errors.New(commonerrors.ErrRoleAlreadyExists)
+	}
+	role.Id = id
+	if err = s.Repository.Update(role); err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return s.Repository.FindById(id)
+}
+
+// Delete deletes a role and unassigns it from every user.
+//
+// id is the unique identifier of the role. Every permission of the role must be held by actor, the
+// user deleting it, and granted to apiKey if the request was made with one.
+// Returns an error if the role does not exist, cannot be managed by actor or the deletion fails.
+func (s *RoleService) Delete(actor *usermodel.User, apiKey *usermodel.ApiKey, id uuid.UUID) error {
+	role, err := s.Repository.FindById(id)
+	if err != nil {
+		return errors.New(commonerrors.ErrRoleNotFound)
+	}
+	if err = s.checkGrantable(actor, apiKey, role); err != nil {
+		return err
+	}
+	if err = s.Repository.Delete(id); err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	return nil
+}
+
+// FindByUserId returns the roles assigned to a user.
+//
+// userId is the unique identifier of the user.
+// Returns the roles and an error if any.
+func (s *RoleService) FindByUserId(userId uuid.UUID) ([]usermodel.Role, error) {
+	roles, err := s.Repository.FindByUserId(userId)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return roles, nil
+}
+
+// AssignToUser assigns a role to a user.
+//
+// userId is the unique identifier of the user and roleId the unique identifier of the role. Every
+// permission of the role must be held by actor, the user assigning it, and granted to apiKey if the
+// request was made with one.
+// Returns an error if the role does not exist, cannot be granted by actor or the assignment fails.
+func (s *RoleService) AssignToUser(actor *usermodel.User, apiKey *usermodel.ApiKey, userId, roleId uuid.UUID) error {
+	role, err := s.Repository.FindById(roleId)
+	if err != nil {
+		return errors.New(commonerrors.ErrRoleNotFound)
+	}
+	if err = s.checkGrantable(actor, apiKey, role); err != nil {
+		return err
+	}
+	if err := s.Repository.AssignToUser(userId, roleId); err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	return nil
+}
+
+// RemoveFromUser removes a role from a user.
+//
+// userId is the unique identifier of the user and roleId the unique identifier of the role. Like with
+// AssignToUser, every permission of the role must be held by actor, the user removing it, and granted
+// to apiKey if the request was made with one.
+// Returns an error if the role does not exist, cannot be managed by actor or the removal fails.
+func (s *RoleService) RemoveFromUser(actor *usermodel.User, apiKey *usermodel.ApiKey, userId, roleId uuid.UUID) error {
+	role, err := s.Repository.FindById(roleId)
+	if err != nil {
+		return errors.New(commonerrors.ErrRoleNotFound)
+	}
+	if err = s.checkGrantable(actor, apiKey, role); err != nil {
+		return err
+	}
+	if err = s.Repository.RemoveFromUser(userId, roleId); err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	return nil
+}
+
+// HasPermissions reports whether a user holds every one of the given permissions.
+//
+// Users with the Admin account type hold every permission. Lookup failures are
+// reported as missing permissions.
+func (s *RoleService) HasPermissions(user *usermodel.User, permissions ...usermodel.Permission) bool {
+	if user.Account == usermodel.AccountTypeAdmin {
+		return true
+	}
+	granted, err := s.Repository.FindPermissionsByUserId(user.Id)
+	if err != nil {
+		log.Printf("failed to load permissions of user %v: %v", user.Id, err)
+		return false
+	}
+	for _, permission := range permissions {
+		found := false
+		for _, g := range granted {
+			if g == permission {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// checkGrantable returns ErrPermissionNotHeld if actor does not hold every permission of a role, or
+// apiKey, when not nil, was not granted all of them, so that no one can grant more than they hold.
+func (s *RoleService) checkGrantable(actor *usermodel.User, apiKey *usermodel.ApiKey, role *usermodel.Role) error {
+	permissions := make([]usermodel.Permission, len(role.Permissions))
+	for i, permission := range role.Permissions {
+		permissions[i] = permission.Permission
+	}
+	if !s.HasPermissions(actor, permissions...) || (apiKey != nil && !apiKey.HasScopes(permissions...)) {
+		return errors.New(commonerrors.ErrPermissionNotHeld)
+	}
+	return nil
+}
+
+// buildRole validates the role fields and builds the role model.
+func (s *RoleService) buildRole(name, description string, permissions []usermodel.Permission) (*usermodel.Role, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) < 2 || len(name) > 64 {
+		return nil, errors.New(commonerrors.ErrInvalidRoleName)
+	}
+	role := &usermodel.Role{Name: name, Description: description}
+	seen := make(map[usermodel.Permission]bool, len(permissions))
+	for _, permission := range permissions {
+		if !permission.IsValid() {
+			return nil, errors.New(commonerrors.ErrInvalidPermission + ": " + string(permission))
+		}
+		if seen[permission] {
+			continue
+		}
+		seen[permission] = true
+		role.Permissions = append(role.Permissions, usermodel.RolePermission{Permission: permission})
+	}
+	return role, nil
+}
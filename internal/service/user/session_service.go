@@ -0,0 +1,248 @@
+package userservice
+
+import (
+	"errors"
+	"github.com/drunkleen/rasta/config"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	"github.com/google/uuid"
+	"log"
+	"time"
+)
+
+// sessionUsageInterval is how often the last use of a session is written, so active sessions do not
+// cause a database write on every request.
+const sessionUsageInterval = time.Minute
+
+type SessionService struct {
+	Repository *userrepository.SessionRepository
+}
+
+// NewSessionService creates a new instance of the SessionService struct.
+//
+// It takes a pointer to a SessionRepository as a parameter and returns a pointer to a SessionService.
+func NewSessionService(repository *userrepository.SessionRepository) *SessionService {
+	return &SessionService{Repository: repository}
+}
+
+// Create starts a new session for the given user and issues its first token pair.
+//
+// userAgent and ipAddress describe the device the session is created for.
+// Returns the access token, the refresh token and an error if any.
+func (s *SessionService) Create(user *usermodel.User, userAgent, ipAddress string) (string, string, error) {
+	refreshToken, err := auth.GenerateRefreshToken()
+	if err != nil {
+		log.Printf("failed to generate refresh token: %v", err)
+		return "", "", errors.New(commonerrors.ErrInternalServer)
+	}
+	expiresAt := time.Now().Add(time.Duration(config.GetJwtRefreshExpiry()) * time.Second)
+	session := &usermodel.Session{
+		UserId:    user.Id,
+		UserAgent: userAgent,
+		IpAddress: ipAddress,
+		ExpiresAt: expiresAt,
+	}
+	refreshTokenModel := &usermodel.RefreshToken{
+		TokenHash: auth.HashToken(refreshToken),
+		ExpiresAt: expiresAt,
+	}
+	if err = s.Repository.Create(session, refreshTokenModel); err != nil {
+		return "", "", errors.New(commonerrors.ErrInternalServer)
+	}
+	accessToken, err := auth.GenerateJWTToken(user.Email, user.Id.String(), session.Id.String())
+	if err != nil {
+		log.Printf("failed to generate JWT token: %v", err)
+		return "", "", errors.New(commonerrors.ErrInternalServer)
+	}
+	return accessToken, refreshToken, nil
+}
+
+// Impersonate starts a session in which an admin acts as a user, and issues its only access token.
+//
+// The token carries the admin in its act claim and expires after IMPERSONATION_EXPIRY seconds. Admins
+// cannot impersonate themselves, other admins, service accounts or suspended users.
+// Returns the session, the access token and an error if any.
+func (s *SessionService) Impersonate(actor, user *usermodel.User, userAgent, ipAddress string) (*usermodel.Session, string, error) {
+	if actor.Id == user.Id || user.Account == usermodel.AccountTypeAdmin || user.Account == usermodel.AccountTypeService {
+		return nil, "", errors.New(commonerrors.ErrCannotImpersonate)
+	}
+	if user.IsSuspended() {
+		return nil, "", errors.New(commonerrors.ErrAccountSuspended)
+	}
+	session := &usermodel.Session{
+		UserId:         user.Id,
+		ImpersonatorId: &actor.Id,
+		UserAgent:      userAgent,
+		IpAddress:      ipAddress,
+		ExpiresAt:      time.Now().Add(time.Duration(config.GetImpersonationExpiry()) * time.Second),
+	}
+	if err := s.Repository.CreateImpersonation(session); err != nil {
+		return nil, "", errors.New(commonerrors.ErrInternalServer)
+	}
+	accessToken, err := auth.GenerateImpersonationToken(user.Email, user.Id.String(), session.Id.String(), actor.Id.String(), session.ExpiresAt)
+	if err != nil {
+		log.Printf("failed to generate impersonation token: %v", err)
+		_ = s.Repository.Revoke(session.Id)
+		return nil, "", errors.New(commonerrors.ErrInternalServer)
+	}
+	return session, accessToken, nil
+}
+
+// Refresh exchanges a refresh token for a new token pair.
+//
+// Refresh tokens are single use. Presenting a token that has already been
+// exchanged means it leaked, so the whole session is revoked.
+// Returns the session owner, the new access token, the new refresh token and an error if any.
+func (s *SessionService) Refresh(refreshToken, userAgent, ipAddress string) (*usermodel.User, string, string, error) {
+	used, err := s.Repository.FindRefreshTokenByHash(auth.HashToken(refreshToken))
+	if err != nil {
+		return nil, "", "", errors.New(commonerrors.ErrInvalidRefreshToken)
+	}
+	session, err := s.Repository.FindById(used.SessionId)
+	if err != nil || !s.isSessionActive(session) {
+		return nil, "", "", errors.New(commonerrors.ErrInvalidRefreshToken)
+	}
+	if used.UsedAt != nil {
+		s.revokeReusedSession(session.Id)
+		return nil, "", "", errors.New(commonerrors.ErrInvalidRefreshToken)
+	}
+	if time.Now().After(used.ExpiresAt) {
+		return nil, "", "", errors.New(commonerrors.ErrInvalidRefreshToken)
+	}
+	user, err := s.Repository.FindUserById(session.UserId)
+	if err != nil {
+		return nil, "", "", errors.New(commonerrors.ErrInvalidRefreshToken)
+	}
+	if user.IsSuspended() {
+		return nil, "", "", errors.New(commonerrors.ErrAccountSuspended)
+	}
+
+	nextToken, err := auth.GenerateRefreshToken()
+	if err != nil {
+		log.Printf("failed to generate refresh token: %v", err)
+		return nil, "", "", errors.New(commonerrors.ErrInternalServer)
+	}
+	next := &usermodel.RefreshToken{
+		TokenHash: auth.HashToken(nextToken),
+		ExpiresAt: time.Now().Add(time.Duration(config.GetJwtRefreshExpiry()) * time.Second),
+	}
+	session.UserAgent = userAgent
+	session.IpAddress = ipAddress
+	rotated, err := s.Repository.Rotate(used, next, session)
+	if err != nil {
+		return nil, "", "", errors.New(commonerrors.ErrInternalServer)
+	}
+	if !rotated {
+		s.revokeReusedSession(session.Id)
+		return nil, "", "", errors.New(commonerrors.ErrInvalidRefreshToken)
+	}
+
+	accessToken, err := auth.GenerateJWTToken(user.Email, user.Id.String(), session.Id.String())
+	if err != nil {
+		log.Printf("failed to generate JWT token: %v", err)
+		return nil, "", "", errors.New(commonerrors.ErrInternalServer)
+	}
+	return user, accessToken, nextToken, nil
+}
+
+// Authenticate reports whether the session with the given ID exists and has neither expired nor been revoked,
+// and records its use.
+//
+// id is the unique identifier of the session.
+func (s *SessionService) Authenticate(id uuid.UUID) bool {
+	session, err := s.Repository.FindById(id)
+	if err != nil || !s.isSessionActive(session) {
+		return false
+	}
+	if time.Since(session.LastSeenAt) > sessionUsageInterval {
+		if err = s.Repository.Touch(id); err != nil {
+			log.Printf("failed to record use of session %v: %v", id, err)
+		}
+	}
+	return true
+}
+
+// Revoke revokes the session with the given ID.
+//
+// id is the unique identifier of the session.
+// Returns an error if the update operation fails.
+func (s *SessionService) Revoke(id uuid.UUID) error {
+	if err := s.Repository.Revoke(id); err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	return nil
+}
+
+// FindActiveByUserId returns the active sessions of a user.
+//
+// userId is the unique identifier of the user.
+// Returns the sessions and an error if any.
+func (s *SessionService) FindActiveByUserId(userId uuid.UUID) ([]usermodel.Session, error) {
+	sessions, err := s.Repository.FindActiveByUserId(userId)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return sessions, nil
+}
+
+// RevokeForUser revokes a single session of a user.
+//
+// id is the unique identifier of the session and userId the unique identifier of its owner.
+// Returns an error if the session does not exist, does not belong to the user or the update operation fails.
+func (s *SessionService) RevokeForUser(id, userId uuid.UUID) error {
+	revoked, err := s.Repository.RevokeForUser(id, userId)
+	if err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	if !revoked {
+		return errors.New(commonerrors.ErrSessionNotFound)
+	}
+	return nil
+}
+
+// RevokeAll revokes every session of a user, logging the user out everywhere.
+//
+// userId is the unique identifier of the user.
+// Returns an error if the update operation fails.
+func (s *SessionService) RevokeAll(userId uuid.UUID) error {
+	if err := s.Repository.RevokeByUserId(userId); err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	return nil
+}
+
+// Logout revokes the session an access token belongs to, together with the token itself.
+//
+// sessionId is the session of the token, tokenId its jti claim and expiresAt its expiry.
+// Returns an error if any of the update operations fail.
+func (s *SessionService) Logout(sessionId uuid.UUID, tokenId string, expiresAt time.Time) error {
+	if err := s.Repository.RevokeToken(tokenId, expiresAt); err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	return s.Revoke(sessionId)
+}
+
+// IsTokenRevoked reports whether an access token has been revoked.
+//
+// tokenId is the jti claim of the token. Lookup failures are reported as revoked.
+func (s *SessionService) IsTokenRevoked(tokenId string) bool {
+	revoked, err := s.Repository.IsTokenRevoked(tokenId)
+	if err != nil {
+		log.Printf("failed to check token revocation: %v", err)
+		return true
+	}
+	return revoked
+}
+
+func (s *SessionService) isSessionActive(session *usermodel.Session) bool {
+	return session.RevokedAt == nil && time.Now().Before(session.ExpiresAt)
+}
+
+func (s *SessionService) revokeReusedSession(id uuid.UUID) {
+	log.Printf("refresh token reuse detected, revoking session %v", id)
+	if err := s.Repository.Revoke(id); err != nil {
+		log.Printf("failed to revoke session %v: %v", id, err)
+	}
+}
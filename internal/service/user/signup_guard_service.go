@@ -0,0 +1,98 @@
+package userservice
+
+import (
+	"errors"
+	"github.com/drunkleen/rasta/config"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	"github.com/drunkleen/rasta/internal/common/utils"
+	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	"log"
+	"strings"
+	"time"
+)
+
+type SignupGuardService struct {
+	Repository *userrepository.SignupRepository
+}
+
+// NewSignupGuardService creates a new instance of the SignupGuardService struct.
+//
+// It takes a pointer to a SignupRepository as a parameter and returns a pointer to a SignupGuardService.
+func NewSignupGuardService(repository *userrepository.SignupRepository) *SignupGuardService {
+	return &SignupGuardService{Repository: repository}
+}
+
+// NewChallenge issues a proof-of-work challenge to solve before signing up.
+//
+// Returns the challenge, the number of leading zero bits its solution must have and its expiry.
+func (s *SignupGuardService) NewChallenge() (string, int, time.Time, error) {
+	challenge, difficulty, expiresAt, err := auth.GenerateSignupChallenge()
+	if err != nil {
+		log.Printf("failed to generate signup challenge: %v", err)
+		return "", 0, time.Time{}, errors.New(commonerrors.ErrInternalServer)
+	}
+	return challenge, difficulty, expiresAt, nil
+}
+
+// Check decides whether an account may be signed up with an email address from an IP address.
+//
+// The signup is refused if the address has used up its quota of SIGNUP_IP_QUOTA signups, if the
+// email domain is blocked or, with SIGNUP_STRICT_EMAIL_DOMAIN, is not a well-formed host name, and
+// unless a challenge is solved when SIGNUP_POW_DIFFICULTY is set. The challenge is used up.
+// Returns how long the client has to wait and ErrSignupQuotaExceeded, or another error if the signup is refused.
+func (s *SignupGuardService) Check(email, ipAddress, challenge, solution string) (time.Duration, error) {
+	if retryAfter, err := s.checkQuota(ipAddress); err != nil {
+		return retryAfter, err
+	}
+	if at := strings.LastIndexByte(email, '@'); at >= 0 {
+		domain := strings.ToLower(email[at+1:])
+		if config.GetSignupStrictEmailDomain() && !utils.DomainValid(domain) {
+			return 0, errors.New(commonerrors.ErrInvalidEmailDomain)
+		}
+		if auth.IsDomainBlocked(domain) {
+			return 0, errors.New(commonerrors.ErrEmailDomainBlocked)
+		}
+	}
+	if config.GetSignupPowDifficulty() == 0 {
+		return 0, nil
+	}
+	if challenge == "" || solution == "" {
+		return 0, errors.New(commonerrors.ErrSignupChallengeNeeded)
+	}
+	return 0, auth.VerifySignupChallenge(challenge, solution)
+}
+
+// Record counts an account signed up from an IP address towards its quota.
+func (s *SignupGuardService) Record(ipAddress string) {
+	if config.GetSignupIpQuota() == 0 {
+		return
+	}
+	if err := s.Repository.Create(ipAddress, time.Now().Add(-quotaWindow())); err != nil {
+		log.Printf("failed to record signup from %s: %v", ipAddress, err)
+	}
+}
+
+// checkQuota returns how long until an IP address may sign up another account, and
+// ErrSignupQuotaExceeded if it has used up its quota.
+func (s *SignupGuardService) checkQuota(ipAddress string) (time.Duration, error) {
+	quota := config.GetSignupIpQuota()
+	if quota == 0 {
+		return 0, nil
+	}
+	window := quotaWindow()
+	attempts, err := s.Repository.FindSince(ipAddress, time.Now().Add(-window))
+	if err != nil {
+		return 0, errors.New(commonerrors.ErrInternalServer)
+	}
+	if len(attempts) < quota {
+		return 0, nil
+	}
+	// The address may sign up again once enough of its signups fall out of the window.
+	return time.Until(attempts[len(attempts)-quota].CreatedAt.Add(window)), errors.New(commonerrors.ErrSignupQuotaExceeded)
+}
+
+// quotaWindow returns the duration signups are counted over.
+func quotaWindow() time.Duration {
+	return time.Duration(config.GetSignupIpQuotaWindow()) * time.Second
+}
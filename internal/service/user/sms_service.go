@@ -0,0 +1,220 @@
+package userservice
+
+import (
+	"errors"
+	"github.com/drunkleen/rasta/config"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	smsPkg "github.com/drunkleen/rasta/pkg/sms"
+	"github.com/google/uuid"
+	"log"
+	"time"
+)
+
+// smsPurposeTexts completes "<code> is your code to ..." in the text message of every purpose.
+var smsPurposeTexts = map[usermodel.SmsPurpose]string{
+	usermodel.SmsPurposeVerify:        "verify your account",
+	usermodel.SmsPurposeResetPassword: "reset your password",
+	usermodel.SmsPurposeLogin:         "sign in",
+	usermodel.SmsPurposePhone:         "confirm your phone number",
+}
+
+type SmsService struct {
+	Repository *userrepository.SmsRepository
+}
+
+// NewSmsService creates a new instance of the SmsService struct.
+//
+// It takes a pointer to a SmsRepository as a parameter and returns a pointer to a SmsService.
+func NewSmsService(repository *userrepository.SmsRepository) *SmsService {
+	return &SmsService{Repository: repository}
+}
+
+// SendCode generates a code for a purpose and sends it by SMS to the phone number of a user,
+// replacing the code previously sent for that purpose. The code is valid for SMS_CODE_EXPIRY seconds.
+//
+// Codes for a password reset or a sign-in are only sent to verified phone numbers. Another code for
+// the same purpose cannot be sent before SMS_RESEND_INTERVAL seconds have passed.
+// Returns ErrPhoneRequired, ErrPhoneNotVerified or ErrSmsTooSoon if the code cannot be sent.
+func (s *SmsService) SendCode(user *usermodel.User, purpose usermodel.SmsPurpose) error {
+	return s.sendCode(user, purpose, nil)
+}
+
+// sendCode sends a code for a purpose by SMS, binding it to the hash of a sign-in token if any.
+func (s *SmsService) sendCode(user *usermodel.User, purpose usermodel.SmsPurpose, tokenHash *string) error {
+	if user.Phone == "" {
+		return errors.New(commonerrors.ErrPhoneRequired)
+	}
+	if !user.PhoneVerified && (purpose == usermodel.SmsPurposeResetPassword || purpose == usermodel.SmsPurposeLogin) {
+		return errors.New(commonerrors.ErrPhoneNotVerified)
+	}
+	if previous, err := s.Repository.Find(user.Id, purpose); err == nil {
+		interval := time.Duration(config.GetSmsResendInterval()) * time.Second
+		if time.Since(previous.CreatedAt) < interval {
+			return errors.New(commonerrors.ErrSmsTooSoon)
+		}
+	}
+	code, err := auth.GenerateSmsCode()
+	if err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	expiry := time.Now().Add(time.Duration(config.GetSmsCodeExpiry()) * time.Second)
+	record := &usermodel.SmsCode{
+		UserId:    user.Id,
+		Purpose:   purpose,
+		Phone:     user.Phone,
+		Expiry:    expiry,
+		TokenHash: tokenHash,
+	}
+	if err = s.Repository.Create(record, code); err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	if err = smsPkg.SendSmsCode(user.Phone, smsPurposeTexts[purpose], code, expiry); err != nil {
+		log.Printf("failed to send %s sms code: %v", purpose, err)
+		_ = s.Repository.Delete(user.Id, purpose)
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	return nil
+}
+
+// Verify checks the code sent by SMS for a purpose guessed by a user. The code stays valid until
+// Delete is called, so that it can be retried if the action it authorizes fails.
+//
+// The code is only valid while the user keeps the phone number it was sent to, and is invalidated
+// once OTP_MAX_ATTEMPTS guesses have been made, so it cannot be brute-forced. Every guess is counted
+// before it is checked, so that parallel guesses cannot exceed the limit.
+// Returns ErrInvalidOtp if the code is wrong, expired or has been invalidated.
+func (s *SmsService) Verify(user *usermodel.User, purpose usermodel.SmsPurpose, code string) error {
+	record, err := s.Repository.Find(user.Id, purpose)
+	if err != nil || record.Phone != user.Phone || time.Now().After(record.Expiry) {
+		return errors.New(commonerrors.ErrInvalidOtp)
+	}
+	attempts, reserved, err := s.Repository.ReserveAttempt(user.Id, purpose, config.GetOtpMaxAttempts())
+	if err != nil || !reserved {
+		return errors.New(commonerrors.ErrInvalidOtp)
+	}
+	if ok, _ := auth.VerifyPassword(code, record.Code); ok {
+		return nil
+	}
+	if attempts >= config.GetOtpMaxAttempts() {
+		log.Printf("invalidating %s sms code of user %v after %d wrong guesses", purpose, user.Id, attempts)
+		_ = s.Repository.Delete(user.Id, purpose)
+	}
+	return errors.New(commonerrors.ErrInvalidOtp)
+}
+
+// Delete invalidates the code sent by SMS to a user for a purpose, once it has been used.
+func (s *SmsService) Delete(userId uuid.UUID, purpose usermodel.SmsPurpose) error {
+	if err := s.Repository.Delete(userId, purpose); err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	return nil
+}
+
+// VerifyAccount verifies the account of a user with the code sent by SMS to their phone number,
+// which is verified as well.
+//
+// Returns ErrInvalidOtp if the code is wrong or the user has changed their phone number since.
+func (s *SmsService) VerifyAccount(user *usermodel.User, code string) error {
+	return s.verifyPhone(user, usermodel.SmsPurposeVerify, code, true)
+}
+
+// VerifyPhone verifies the phone number of a signed-in user with the code sent to it by SMS.
+//
+// Returns ErrPhoneAlreadyVerified, or ErrInvalidOtp if the code is wrong or the user has changed
+// their phone number since.
+func (s *SmsService) VerifyPhone(user *usermodel.User, code string) error {
+	if user.PhoneVerified {
+		return errors.New(commonerrors.ErrPhoneAlreadyVerified)
+	}
+	return s.verifyPhone(user, usermodel.SmsPurposePhone, code, false)
+}
+
+// verifyPhone checks a code sent by SMS for a purpose and marks the phone number it was sent to
+// as verified, along with the account if verifyAccount is set.
+func (s *SmsService) verifyPhone(user *usermodel.User, purpose usermodel.SmsPurpose, code string, verifyAccount bool) error {
+	if err := s.Verify(user, purpose, code); err != nil {
+		return err
+	}
+	verified, err := s.Repository.MarkPhoneAsVerified(user.Id, user.Phone, verifyAccount)
+	if err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	if !verified {
+		return errors.New(commonerrors.ErrInvalidOtp)
+	}
+	return s.Delete(user.Id, purpose)
+}
+
+// BeginLogin sends a sign-in code by SMS to a user with SMS two-factor authentication enabled,
+// whose first factor has been checked.
+//
+// Returns the token with which the sign-in is finished by FinishLogin, or ErrSmsTooSoon if a code
+// has just been sent.
+func (s *SmsService) BeginLogin(user *usermodel.User) (string, error) {
+	token, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return "", errors.New(commonerrors.ErrInternalServer)
+	}
+	tokenHash := auth.HashToken(token)
+	if err = s.sendCode(user, usermodel.SmsPurposeLogin, &tokenHash); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// FindLogin returns the ID of the user signing in with the token returned by BeginLogin.
+//
+// Returns ErrInvalidSmsLogin if the token is unknown or its code has expired or been used.
+func (s *SmsService) FindLogin(token string) (uuid.UUID, error) {
+	record, err := s.Repository.FindByTokenHash(auth.HashToken(token))
+	if err != nil || time.Now().After(record.Expiry) {
+		return uuid.Nil, errors.New(commonerrors.ErrInvalidSmsLogin)
+	}
+	return record.UserId, nil
+}
+
+// FinishLogin checks the sign-in code guessed by a user and invalidates it once used.
+//
+// Returns ErrInvalidOtp if the code is wrong, expired or has been invalidated.
+func (s *SmsService) FinishLogin(user *usermodel.User, code string) error {
+	if err := s.Verify(user, usermodel.SmsPurposeLogin, code); err != nil {
+		return err
+	}
+	return s.Delete(user.Id, usermodel.SmsPurposeLogin)
+}
+
+// EnableTwoFactor enables SMS two-factor authentication for a user with a verified phone number.
+//
+// Returns ErrSmsTwoFactorEnabled or ErrPhoneNotVerified if it cannot be enabled.
+func (s *SmsService) EnableTwoFactor(user *usermodel.User) error {
+	if user.SmsTwoFactorEnabled {
+		return errors.New(commonerrors.ErrSmsTwoFactorEnabled)
+	}
+	if user.Phone == "" || !user.PhoneVerified {
+		return errors.New(commonerrors.ErrPhoneNotVerified)
+	}
+	if err := s.Repository.UpdateSmsTwoFactor(user.Id, true); err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	return nil
+}
+
+// DisableTwoFactor disables SMS two-factor authentication for a user, with a code sent to their
+// phone number to confirm they still own it.
+//
+// Returns ErrSmsTwoFactorDisabled, or ErrInvalidOtp if the code is wrong.
+func (s *SmsService) DisableTwoFactor(user *usermodel.User, code string) error {
+	if !user.SmsTwoFactorEnabled {
+		return errors.New(commonerrors.ErrSmsTwoFactorDisabled)
+	}
+	if err := s.Verify(user, usermodel.SmsPurposePhone, code); err != nil {
+		return err
+	}
+	if err := s.Repository.UpdateSmsTwoFactor(user.Id, false); err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	return s.Delete(user.Id, usermodel.SmsPurposePhone)
+}
@@ -0,0 +1,380 @@
+package userservice
+
+import (
+	"errors"
+	"fmt"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	"github.com/drunkleen/rasta/internal/common/utils"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	"github.com/google/uuid"
+	"github.com/markbates/goth"
+	"gorm.io/gorm"
+	"log"
+	"math/rand"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxNameLength is the length of the first and last names stored for a user.
+const maxNameLength = 64
+
+type SocialService struct {
+	Repository *userrepository.SocialRepository
+}
+
+// NewSocialService creates a new instance of the SocialService struct.
+//
+// It takes a pointer to a SocialRepository as a parameter and returns a pointer to a SocialService
+// signing users in with the identity providers registered by auth.InitSocialProviders.
+func NewSocialService(repository *userrepository.SocialRepository) *SocialService {
+	return &SocialService{Repository: repository}
+}
+
+// Providers returns the names of the identity providers users may sign in with.
+func (s *SocialService) Providers() []string {
+	return auth.GetSocialProviderNames()
+}
+
+// Begin starts a sign-in at an identity provider.
+//
+// provider is the name of the provider and userId the user the identity is linked to, or nil to sign in.
+// Returns the URL of the provider to send the user to and an error if any.
+func (s *SocialService) Begin(provider string, userId *uuid.UUID) (string, error) {
+	p, err := auth.GetSocialProvider(provider)
+	if err != nil {
+		return "", errors.New(commonerrors.ErrSocialProviderNotFound)
+	}
+	var secrets [3]string
+	for i := range secrets {
+		if secrets[i], err = auth.GenerateRefreshToken(); err != nil {
+			log.Printf("failed to generate social state: %v", err)
+			return "", errors.New(commonerrors.ErrInternalServer)
+		}
+	}
+	state, verifier, nonce := secrets[0], secrets[1], secrets[2]
+	session, err := p.BeginAuth(state)
+	if err != nil {
+		log.Printf("failed to begin %s sign-in: %v", provider, err)
+		return "", errors.New(commonerrors.ErrInternalServer)
+	}
+	authUrl, err := session.GetAuthURL()
+	if err == nil {
+		authUrl, err = auth.SocialAuthURL(p, authUrl, verifier, nonce)
+	}
+	if err != nil {
+		log.Printf("failed to begin %s sign-in: %v", provider, err)
+		return "", errors.New(commonerrors.ErrInternalServer)
+	}
+	stored := &usermodel.SocialState{
+		StateHash: auth.HashToken(state),
+		Provider:  provider,
+		UserId:    userId,
+		Session:   session.Marshal(),
+		Verifier:  verifier,
+		Nonce:     nonce,
+		ExpiresAt: time.Now().Add(auth.SocialStateTimeout),
+	}
+	if err = s.Repository.CreateState(stored); err != nil {
+		return "", errors.New(commonerrors.ErrInternalServer)
+	}
+	return authUrl, nil
+}
+
+// SignIn completes a sign-in started by Begin without a user.
+//
+// The user signs in with the identity linked to their account at the provider. An unknown identity
+// signs up a new user, whose email address is verified if the provider asserts it, unless another
+// account already uses the email address. The state can be presented again until Finish is called,
+// without a new authorization code, so a sign-in waiting for a second factor can be retried.
+// Returns the state, the user, whether the user has just been created and an error if any.
+func (s *SocialService) SignIn(provider, state, code string) (*usermodel.SocialState, *usermodel.User, bool, error) {
+	stored, err := s.Repository.FindState(auth.HashToken(state), provider)
+	if err != nil || stored.UserId != nil {
+		return nil, nil, false, errors.New(commonerrors.ErrInvalidSocialState)
+	}
+	if stored.IdentityId != nil {
+		identity, err := s.Repository.FindIdentityById(*stored.IdentityId)
+		if err != nil {
+			return nil, nil, false, errors.New(commonerrors.ErrInvalidSocialState)
+		}
+		user, err := s.findUser(identity.UserId)
+		return stored, user, false, err
+	}
+	external, err := s.authorize(stored, state, code)
+	if err != nil {
+		s.Finish(stored)
+		return nil, nil, false, err
+	}
+	user, identity, created, err := s.resolve(external)
+	if err != nil {
+		s.Finish(stored)
+		return nil, nil, false, err
+	}
+	authorized, err := s.Repository.SetStateIdentity(stored.Id, identity.Id)
+	if err != nil {
+		return nil, nil, false, errors.New(commonerrors.ErrInternalServer)
+	}
+	if !authorized {
+		return nil, nil, false, errors.New(commonerrors.ErrInvalidSocialState)
+	}
+	return stored, user, created, nil
+}
+
+// Link completes a sign-in started by Begin for a user, linking the identity at the provider to them.
+//
+// The email address of the user is verified if it matches the one the provider asserts.
+// Returns the linked identity and an error if the identity is linked to another account.
+func (s *SocialService) Link(provider, state, code string, userId uuid.UUID) (*usermodel.Identity, error) {
+	stored, err := s.Repository.FindState(auth.HashToken(state), provider)
+	if err != nil || stored.UserId == nil || *stored.UserId != userId || stored.IdentityId != nil {
+		return nil, errors.New(commonerrors.ErrInvalidSocialState)
+	}
+	external, err := s.authorize(stored, state, code)
+	_ = s.Repository.DeleteState(stored.Id)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.findUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := s.Repository.FindIdentity(provider, external.UserID)
+	switch {
+	case err == nil:
+		if identity.UserId != userId {
+			return nil, errors.New(commonerrors.ErrIdentityAlreadyLinked)
+		}
+		if err = s.Repository.UpdateIdentityUsage(identity.Id, external.Email); err != nil {
+			return nil, errors.New(commonerrors.ErrInternalServer)
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		identity = &usermodel.Identity{
+			UserId:   userId,
+			Provider: provider,
+			Subject:  external.UserID,
+			Email:    external.Email,
+		}
+		if err = s.Repository.CreateIdentity(identity); err != nil {
+			return nil, errors.New(commonerrors.ErrInternalServer)
+		}
+	default:
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	s.verifyEmail(user, external)
+	return identity, nil
+}
+
+// Finish ends a sign-in, so its state cannot be presented again.
+func (s *SocialService) Finish(state *usermodel.SocialState) {
+	if err := s.Repository.DeleteState(state.Id); err != nil {
+		log.Printf("failed to finish social sign-in %v: %v", state.Id, err)
+	}
+}
+
+// FindByUserId returns the identities linked to a user.
+//
+// userId is the unique identifier of the user.
+// Returns the identities and an error if any.
+func (s *SocialService) FindByUserId(userId uuid.UUID) ([]usermodel.Identity, error) {
+	identities, err := s.Repository.FindIdentitiesByUserId(userId)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return identities, nil
+}
+
+// Unlink unlinks an identity from a user. The user can no longer sign in with it.
+//
+// id is the unique identifier of the identity and userId the unique identifier of its owner.
+// Returns an error if the identity does not exist or the deletion fails.
+func (s *SocialService) Unlink(id, userId uuid.UUID) error {
+	deleted, err := s.Repository.DeleteIdentity(id, userId)
+	if err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	if !deleted {
+		return errors.New(commonerrors.ErrIdentityNotFound)
+	}
+	return nil
+}
+
+// authorize exchanges the authorization code sent back by a provider, with the PKCE code verifier of the
+// sign-in, and fetches the account it identifies once the nonce of its ID token has been checked.
+func (s *SocialService) authorize(stored *usermodel.SocialState, state, code string) (*goth.User, error) {
+	p, err := auth.GetSocialProvider(stored.Provider)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrSocialProviderNotFound)
+	}
+	session, err := p.UnmarshalSession(stored.Session)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	params := url.Values{"code": {code}, "state": {state}}
+	if auth.SocialUsesPKCE(p) {
+		params.Set("code_verifier", stored.Verifier)
+	}
+	if _, err = session.Authorize(p, params); err != nil {
+		log.Printf("failed to authorize %s sign-in: %v", stored.Provider, err)
+		return nil, errors.New(commonerrors.ErrSocialSignInFailed)
+	}
+	external, err := p.FetchUser(session)
+	if err != nil || external.UserID == "" {
+		log.Printf("failed to fetch %s user: %v", stored.Provider, err)
+		return nil, errors.New(commonerrors.ErrSocialSignInFailed)
+	}
+	if !auth.SocialNonceValid(&external, stored.Nonce) {
+		log.Printf("%s sign-in %v returned an ID token with another nonce", stored.Provider, stored.Id)
+		return nil, errors.New(commonerrors.ErrSocialSignInFailed)
+	}
+	return &external, nil
+}
+
+// resolve finds the user an identity is linked to, signing up a new user for an unknown identity.
+func (s *SocialService) resolve(external *goth.User) (*usermodel.User, *usermodel.Identity, bool, error) {
+	identity, err := s.Repository.FindIdentity(external.Provider, external.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user, identity, err := s.signUp(external)
+		return user, identity, err == nil, err
+	}
+	if err != nil {
+		return nil, nil, false, errors.New(commonerrors.ErrInternalServer)
+	}
+	if err = s.Repository.UpdateIdentityUsage(identity.Id, external.Email); err != nil {
+		return nil, nil, false, errors.New(commonerrors.ErrInternalServer)
+	}
+	user, err := s.findUser(identity.UserId)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	s.verifyEmail(user, external)
+	return user, identity, false, nil
+}
+
+// signUp creates a user for an identity that is not linked to any account yet.
+func (s *SocialService) signUp(external *goth.User) (*usermodel.User, *usermodel.Identity, error) {
+	email := strings.TrimSpace(external.Email)
+	if email == "" {
+		return nil, nil, errors.New(commonerrors.ErrSocialEmailRequired)
+	}
+	if !utils.EmailValidate(&email) {
+		return nil, nil, errors.New(commonerrors.ErrInvalidEmail)
+	}
+	exists, err := s.Repository.EmailExists(email, utils.EmailDuplicateKey(email))
+	if err != nil {
+		return nil, nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	if exists {
+		return nil, nil, errors.New(commonerrors.ErrSocialEmailExists)
+	}
+	username, err := s.username(external, email)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Users signing up at a provider have no password; they can set one by resetting it.
+	password, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	firstName, lastName := socialNames(external)
+	user := &usermodel.User{
+		FirstName:  firstName,
+		LastName:   lastName,
+		Username:   username,
+		Email:      email,
+		Password:   password,
+		IsVerified: auth.SocialEmailVerified(external),
+	}
+	identity := &usermodel.Identity{
+		Provider: external.Provider,
+		Subject:  external.UserID,
+		Email:    external.Email,
+	}
+	if err = s.Repository.CreateUserWithIdentity(user, identity); err != nil {
+		return nil, nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return user, identity, nil
+}
+
+// username picks a free username for a user signing up at a provider, based on their
+// nickname or the local part of their email address.
+func (s *SocialService) username(external *goth.User, email string) (string, error) {
+	base := usernameFrom(external.NickName)
+	if base == "" {
+		base = usernameFrom(strings.SplitN(email, "@", 2)[0])
+	}
+	if len(base) < 4 || !utils.UsernameValid(base) {
+		base = "user" + base
+	}
+	candidate := base
+	for i := 0; i < 10; i++ {
+		exists, err := s.Repository.UsernameExists(candidate)
+		if err != nil {
+			return "", errors.New(commonerrors.ErrInternalServer)
+		}
+		if !exists {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s%04d", base, rand.Intn(10000))
+	}
+	return "", errors.New(commonerrors.ErrInternalServer)
+}
+
+// verifyEmail marks the email address of a user as verified if a provider asserts it.
+func (s *SocialService) verifyEmail(user *usermodel.User, external *goth.User) {
+	if user.IsVerified || !auth.SocialEmailVerified(external) || !strings.EqualFold(user.Email, external.Email) {
+		return
+	}
+	if err := s.Repository.MarkUserVerified(user.Id); err != nil {
+		return
+	}
+	user.IsVerified = true
+}
+
+// findUser loads a user including their OAuth settings.
+func (s *SocialService) findUser(id uuid.UUID) (*usermodel.User, error) {
+	user, err := s.Repository.FindUserById(id)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrUserNotFound)
+	}
+	return user, nil
+}
+
+// usernameFrom keeps the lowercase letters and digits of a name.
+func usernameFrom(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	username := b.String()
+	if len(username) > 32 {
+		username = username[:32]
+	}
+	return username
+}
+
+// socialNames returns the first and last name of a user signing up at a provider.
+func socialNames(external *goth.User) (string, string) {
+	firstName, lastName := external.FirstName, external.LastName
+	if firstName == "" && lastName == "" {
+		name := strings.TrimSpace(external.Name)
+		if name == "" {
+			name = external.NickName
+		}
+		firstName, lastName, _ = strings.Cut(name, " ")
+	}
+	return truncate(strings.TrimSpace(firstName), maxNameLength), truncate(strings.TrimSpace(lastName), maxNameLength)
+}
+
+// truncate shortens a string to at most n runes.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
@@ -0,0 +1,421 @@
+package userservice
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/drunkleen/rasta/config"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	_ "github.com/drunkleen/rasta/pkg/database"
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	testOidcProvider = "oidc"
+	testOidcClientId = "rasta"
+)
+
+// mockOidcAccount is an account at the mock OpenID Connect provider.
+type mockOidcAccount struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// mockOidcGrant is an authorization code issued by the mock provider, bound to the PKCE challenge
+// and the nonce of the authorization request.
+type mockOidcGrant struct {
+	account   mockOidcAccount
+	challenge string
+	nonce     string
+}
+
+// mockOidcProvider is a local OpenID Connect provider. Its authorization endpoint is not served:
+// tests sign in with authorize, which plays the part of the browser and the user.
+type mockOidcProvider struct {
+	server *httptest.Server
+	mu     sync.Mutex
+	grants map[string]mockOidcGrant
+}
+
+func newMockOidcProvider() *mockOidcProvider {
+	p := &mockOidcProvider{grants: make(map[string]mockOidcGrant)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/token", p.token)
+	p.server = httptest.NewServer(mux)
+	return p
+}
+
+func (p *mockOidcProvider) discovery(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 p.server.URL,
+		"authorization_endpoint": p.server.URL + "/authorize",
+		"token_endpoint":         p.server.URL + "/token",
+		"jwks_uri":               p.server.URL + "/jwks",
+	})
+}
+
+// token redeems an authorization code once, if the code verifier matches the challenge it was issued for.
+func (p *mockOidcProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	p.mu.Lock()
+	grant, ok := p.grants[r.PostForm.Get("code")]
+	delete(p.grants, r.PostForm.Get("code"))
+	p.mu.Unlock()
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || grant.challenge != base64.RawURLEncoding.EncodeToString(verifier[:]) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":            p.server.URL,
+		"aud":            testOidcClientId,
+		"sub":            grant.account.Subject,
+		"email":          grant.account.Email,
+		"email_verified": grant.account.EmailVerified,
+		"nonce":          grant.nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	})
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access-" + grant.account.Subject,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     header + "." + base64.RawURLEncoding.EncodeToString(claims) + ".",
+	})
+}
+
+// authorize signs account in at the URL of the authorization endpoint returned by Begin.
+// Returns the state and the authorization code the provider redirects back with.
+func (p *mockOidcProvider) authorize(t *testing.T, authUrl string, account mockOidcAccount) (string, string) {
+	t.Helper()
+	u, err := url.Parse(authUrl)
+	if err != nil {
+		t.Fatalf("invalid authorization URL: %v", err)
+	}
+	query := u.Query()
+	if query.Get("client_id") != testOidcClientId || query.Get("code_challenge_method") != "S256" ||
+		query.Get("code_challenge") == "" || query.Get("nonce") == "" {
+		t.Fatalf("authorization request without client ID, PKCE challenge or nonce: %s", authUrl)
+	}
+	return query.Get("state"), p.issue(account, query.Get("code_challenge"), query.Get("nonce"))
+}
+
+// issue issues an authorization code for an account.
+func (p *mockOidcProvider) issue(account mockOidcAccount, challenge, nonce string) string {
+	code := randomString()
+	p.mu.Lock()
+	p.grants[code] = mockOidcGrant{account: account, challenge: challenge, nonce: nonce}
+	p.mu.Unlock()
+	return code
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+var mockProvider *mockOidcProvider
+
+func TestMain(m *testing.M) {
+	mockProvider = newMockOidcProvider()
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	for name, value := range map[string]string{
+		"ENCRYPTION_KEYS":           "test:" + base64.StdEncoding.EncodeToString(key),
+		"PASSWORD_HASH_ALGORITHM":   "bcrypt",
+		"BCRYPT_COST":               "4",
+		"SOCIAL_REDIRECT_URL":       "https://rasta.test/social",
+		"SOCIAL_OIDC_NAME":          testOidcProvider,
+		"SOCIAL_OIDC_CLIENT_ID":     testOidcClientId,
+		"SOCIAL_OIDC_CLIENT_SECRET": "secret",
+		"SOCIAL_OIDC_DISCOVERY_URL": mockProvider.server.URL + "/.well-known/openid-configuration",
+	} {
+		_ = os.Setenv(name, value)
+	}
+	config.Init()
+	if err := auth.InitEncryption(); err != nil {
+		log.Fatalf("failed to load encryption keys: %v", err)
+	}
+	if err := auth.InitPasswordHasher(); err != nil {
+		log.Fatalf("failed to configure password hashing: %v", err)
+	}
+	if err := auth.InitSocialProviders(); err != nil {
+		log.Fatalf("failed to configure identity providers: %v", err)
+	}
+	code := m.Run()
+	mockProvider.server.Close()
+	os.Exit(code)
+}
+
+// newTestSocialService returns a SocialService backed by an empty in-memory database.
+func newTestSocialService(t *testing.T) (*SocialService, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	models := []interface{}{&usermodel.User{}, &usermodel.OAuth{}, &usermodel.Identity{}, &usermodel.SocialState{}}
+	for _, model := range models {
+		// SQLite only reads back the times of columns declared as timestamp.
+		stmt := &gorm.Statement{DB: db}
+		if err = stmt.Parse(model); err != nil {
+			t.Fatalf("failed to parse model: %v", err)
+		}
+		for _, field := range stmt.Schema.Fields {
+			if strings.HasPrefix(string(field.DataType), "timestamp") {
+				field.DataType = "timestamp"
+			}
+		}
+	}
+	if err = db.AutoMigrate(models...); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	return NewSocialService(userrepository.NewSocialRepository(db)), db
+}
+
+func createTestUser(t *testing.T, db *gorm.DB, username, email string) *usermodel.User {
+	t.Helper()
+	user := &usermodel.User{Id: uuid.New(), Username: username, Email: email, Password: "hash", Region: "US"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return user
+}
+
+// signIn performs a whole sign-in of account at the mock provider.
+func signIn(t *testing.T, s *SocialService, account mockOidcAccount) (*usermodel.SocialState, *usermodel.User, bool, error) {
+	t.Helper()
+	authUrl, err := s.Begin(testOidcProvider, nil)
+	if err != nil {
+		t.Fatalf("failed to begin sign-in: %v", err)
+	}
+	state, code := mockProvider.authorize(t, authUrl, account)
+	return s.SignIn(testOidcProvider, state, code)
+}
+
+func assertError(t *testing.T, err error, want string) {
+	t.Helper()
+	if err == nil || err.Error() != want {
+		t.Fatalf("got error %v, want %q", err, want)
+	}
+}
+
+func TestSocialSignInSignsUpUser(t *testing.T) {
+	s, _ := newTestSocialService(t)
+
+	state, user, created, err := signIn(t, s, mockOidcAccount{Subject: "alice", Email: "alice@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatalf("sign-in failed: %v", err)
+	}
+	if !created || user.Email != "alice@example.com" || !user.IsVerified {
+		t.Errorf("got user %s (verified: %v, created: %v), want a new verified alice@example.com", user.Email, user.IsVerified, created)
+	}
+	s.Finish(state)
+
+	_, again, created, err := signIn(t, s, mockOidcAccount{Subject: "alice", Email: "alice@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatalf("second sign-in failed: %v", err)
+	}
+	if created || again.Id != user.Id {
+		t.Errorf("second sign-in signed in user %v (created: %v), want %v", again.Id, created, user.Id)
+	}
+}
+
+func TestSocialSignInRejectsUnknownState(t *testing.T) {
+	s, _ := newTestSocialService(t)
+	authUrl, err := s.Begin(testOidcProvider, nil)
+	if err != nil {
+		t.Fatalf("failed to begin sign-in: %v", err)
+	}
+	state, code := mockProvider.authorize(t, authUrl, mockOidcAccount{Subject: "alice", Email: "alice@example.com"})
+
+	_, _, _, err = s.SignIn(testOidcProvider, randomString(), code)
+	assertError(t, err, commonerrors.ErrInvalidSocialState)
+	_, _, _, err = s.SignIn("google", state, code)
+	assertError(t, err, commonerrors.ErrInvalidSocialState)
+}
+
+func TestSocialSignInRejectsFinishedState(t *testing.T) {
+	s, _ := newTestSocialService(t)
+	authUrl, err := s.Begin(testOidcProvider, nil)
+	if err != nil {
+		t.Fatalf("failed to begin sign-in: %v", err)
+	}
+	state, code := mockProvider.authorize(t, authUrl, mockOidcAccount{Subject: "alice", Email: "alice@example.com"})
+	stored, _, _, err := s.SignIn(testOidcProvider, state, code)
+	if err != nil {
+		t.Fatalf("sign-in failed: %v", err)
+	}
+	s.Finish(stored)
+
+	_, _, _, err = s.SignIn(testOidcProvider, state, code)
+	assertError(t, err, commonerrors.ErrInvalidSocialState)
+}
+
+func TestSocialSignInRejectsCodeOfAnotherSignIn(t *testing.T) {
+	s, _ := newTestSocialService(t)
+	attackerUrl, err := s.Begin(testOidcProvider, nil)
+	if err != nil {
+		t.Fatalf("failed to begin sign-in: %v", err)
+	}
+	victimUrl, err := s.Begin(testOidcProvider, nil)
+	if err != nil {
+		t.Fatalf("failed to begin sign-in: %v", err)
+	}
+	_, attackerCode := mockProvider.authorize(t, attackerUrl, mockOidcAccount{Subject: "mallory", Email: "mallory@example.com"})
+	victimState := mustQuery(t, victimUrl, "state")
+
+	_, _, _, err = s.SignIn(testOidcProvider, victimState, attackerCode)
+	assertError(t, err, commonerrors.ErrSocialSignInFailed)
+	_, _, _, err = s.SignIn(testOidcProvider, victimState, attackerCode)
+	assertError(t, err, commonerrors.ErrInvalidSocialState)
+}
+
+func TestSocialSignInRejectsIdTokenOfAnotherSignIn(t *testing.T) {
+	s, _ := newTestSocialService(t)
+	authUrl, err := s.Begin(testOidcProvider, nil)
+	if err != nil {
+		t.Fatalf("failed to begin sign-in: %v", err)
+	}
+	code := mockProvider.issue(mockOidcAccount{Subject: "alice", Email: "alice@example.com"},
+		mustQuery(t, authUrl, "code_challenge"), randomString())
+
+	_, _, _, err = s.SignIn(testOidcProvider, mustQuery(t, authUrl, "state"), code)
+	assertError(t, err, commonerrors.ErrSocialSignInFailed)
+}
+
+func TestSocialSignInWithUnverifiedEmail(t *testing.T) {
+	s, db := newTestSocialService(t)
+
+	_, user, created, err := signIn(t, s, mockOidcAccount{Subject: "bob", Email: "bob@example.com"})
+	if err != nil {
+		t.Fatalf("sign-in failed: %v", err)
+	}
+	if !created || user.IsVerified {
+		t.Errorf("got user %s (verified: %v, created: %v), want a new unverified user", user.Email, user.IsVerified, created)
+	}
+
+	victim := createTestUser(t, db, "victim", "victim@example.com")
+	for _, verified := range []bool{false, true} {
+		_, _, _, err = signIn(t, s, mockOidcAccount{Subject: "mallory", Email: victim.Email, EmailVerified: verified})
+		assertError(t, err, commonerrors.ErrSocialEmailExists)
+	}
+	var count int64
+	db.Model(&usermodel.Identity{}).Where("user_id = ?", victim.Id).Count(&count)
+	if count != 0 {
+		t.Errorf("an identity was linked to the account using the same email address")
+	}
+}
+
+func TestSocialLink(t *testing.T) {
+	s, db := newTestSocialService(t)
+	user := createTestUser(t, db, "carol", "carol@example.com")
+	account := mockOidcAccount{Subject: "carol", Email: "carol@example.com", EmailVerified: true}
+
+	authUrl, err := s.Begin(testOidcProvider, &user.Id)
+	if err != nil {
+		t.Fatalf("failed to begin linking: %v", err)
+	}
+	state, code := mockProvider.authorize(t, authUrl, account)
+	identity, err := s.Link(testOidcProvider, state, code, user.Id)
+	if err != nil {
+		t.Fatalf("linking failed: %v", err)
+	}
+	if identity.UserId != user.Id || identity.Subject != account.Subject {
+		t.Errorf("linked identity %s to user %v, want %s to %v", identity.Subject, identity.UserId, account.Subject, user.Id)
+	}
+	var stored usermodel.User
+	db.First(&stored, "id = ?", user.Id)
+	if !stored.IsVerified {
+		t.Error("email address asserted by the provider was not verified")
+	}
+
+	_, signedIn, created, err := signIn(t, s, account)
+	if err != nil {
+		t.Fatalf("sign-in with linked identity failed: %v", err)
+	}
+	if created || signedIn.Id != user.Id {
+		t.Errorf("signed in user %v (created: %v), want %v", signedIn.Id, created, user.Id)
+	}
+}
+
+func TestSocialLinkRejectsIdentityOfAnotherUser(t *testing.T) {
+	s, db := newTestSocialService(t)
+	_, owner, _, err := signIn(t, s, mockOidcAccount{Subject: "dave", Email: "dave@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatalf("sign-in failed: %v", err)
+	}
+	user := createTestUser(t, db, "erin", "erin@example.com")
+
+	authUrl, err := s.Begin(testOidcProvider, &user.Id)
+	if err != nil {
+		t.Fatalf("failed to begin linking: %v", err)
+	}
+	state, code := mockProvider.authorize(t, authUrl, mockOidcAccount{Subject: "dave", Email: "dave@example.com", EmailVerified: true})
+	_, err = s.Link(testOidcProvider, state, code, user.Id)
+	assertError(t, err, commonerrors.ErrIdentityAlreadyLinked)
+
+	identities, _ := s.FindByUserId(owner.Id)
+	if len(identities) != 1 {
+		t.Errorf("owner has %d identities, want 1", len(identities))
+	}
+}
+
+func TestSocialLinkRejectsStateOfAnotherUser(t *testing.T) {
+	s, db := newTestSocialService(t)
+	user := createTestUser(t, db, "frank", "frank@example.com")
+	other := createTestUser(t, db, "grace", "grace@example.com")
+
+	authUrl, err := s.Begin(testOidcProvider, &user.Id)
+	if err != nil {
+		t.Fatalf("failed to begin linking: %v", err)
+	}
+	state, code := mockProvider.authorize(t, authUrl, mockOidcAccount{Subject: "grace", Email: "grace@example.com"})
+	_, err = s.Link(testOidcProvider, state, code, other.Id)
+	assertError(t, err, commonerrors.ErrInvalidSocialState)
+	_, _, _, err = s.SignIn(testOidcProvider, state, code)
+	assertError(t, err, commonerrors.ErrInvalidSocialState)
+}
+
+func mustQuery(t *testing.T, rawUrl, name string) string {
+	t.Helper()
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		t.Fatalf("invalid URL: %v", err)
+	}
+	return u.Query().Get(name)
+}
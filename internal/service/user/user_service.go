@@ -2,14 +2,21 @@ package userservice
 
 import (
 	"errors"
+	"fmt"
 	userDTO "github.com/drunkleen/rasta/internal/DTO/user"
+	"github.com/drunkleen/rasta/internal/common/auth"
 	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
 	"github.com/drunkleen/rasta/internal/common/utils"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
 	usermodel "github.com/drunkleen/rasta/internal/models/user"
 	"github.com/drunkleen/rasta/internal/repository/user"
+	emailservice "github.com/drunkleen/rasta/internal/service/email"
+	emailPkg "github.com/drunkleen/rasta/pkg/email"
 	"github.com/google/uuid"
 	"log"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 type UserService struct {
@@ -147,32 +154,41 @@ func (s *UserService) FindByUsernameOrEmail(username string) (usermodel.User, er
 // Create creates a new user.
 //
 // The user is created with the provided userDto, and the password is checked
-// for complexity. If the password is not complex enough, an error is returned.
-// The user is also checked for uniqueness by their username and email. If
-// either the username or email is already in use, an error is returned.
+// against the password policy. If it breaks a rule, a *auth.PasswordPolicyError is returned.
+// The user is also checked for uniqueness by their username and email, which is
+// normalized first. If either the username or email is already in use, an error is
+// returned; unless EMAIL_PLUS_ADDRESSING is allow, an email differing from one in
+// use only by its plus tag is in use as well.
 // Finally, the user is created in the database, and the created user is returned.
 // If the creation fails, an error is returned.
 func (s *UserService) Create(userDto *userDTO.UserCreate) (*usermodel.User, error) {
 	userModel := userDto.UserCreateResponseToModel()
 
-	if !utils.PasswordValid(userModel.Password) {
-		return &usermodel.User{}, errors.New(commonerrors.ErrPasswordTooWeak)
+	if err := auth.CheckPassword(userModel.Password, passwordUserInputs(userModel)...); err != nil {
+		return &usermodel.User{}, err
 	}
 	userModel.Username = strings.ToLower(userModel.Username)
 	if _, err := s.Repository.FindByUsername(userModel.Username); err == nil {
 		return &usermodel.User{}, errors.New(commonerrors.ErrUsernameAlreadyExists)
 	}
-	if _, err := s.Repository.FindByEmail(userModel.Email); err == nil {
-		return &usermodel.User{}, errors.New(commonerrors.ErrEmailAlreadyExists)
-	}
 	if !utils.EmailValidate(&userModel.Email) {
 		return &usermodel.User{}, errors.New(commonerrors.ErrInvalidEmail)
 	}
+	exists, err := s.Repository.EmailExists(userModel.Email, utils.EmailDuplicateKey(userModel.Email))
+	if err != nil {
+		return &usermodel.User{}, errors.New(commonerrors.ErrInternalServer)
+	}
+	if exists {
+		return &usermodel.User{}, errors.New(commonerrors.ErrEmailAlreadyExists)
+	}
 	if !utils.UsernameValid(userModel.Username) {
 		return &usermodel.User{}, errors.New(commonerrors.ErrInvalidUsername)
 	}
+	if userModel.Phone != "" && !utils.PhoneValid(userModel.Phone) {
+		return &usermodel.User{}, errors.New(commonerrors.ErrInvalidPhone)
+	}
 
-	err := s.Repository.Create(userModel)
+	err = s.Repository.Create(userModel)
 	if err != nil {
 		log.Printf("Error creating user: %v", err)
 		return &usermodel.User{}, errors.New(commonerrors.ErrInternalServer)
@@ -181,22 +197,52 @@ func (s *UserService) Create(userDto *userDTO.UserCreate) (*usermodel.User, erro
 	return userModel, nil
 }
 
+// FindByLogin finds the user a login attempt is made for.
+//
+// usernameOrEmail is the username or email entered by the user; usernames are matched case-insensitively
+// and emails are normalized.
+// Returns the user and an error if no user matches.
+func (s *UserService) FindByLogin(usernameOrEmail string) (*usermodel.User, error) {
+	email := usernameOrEmail
+	if normalized, ok := utils.NormalizeEmail(usernameOrEmail); ok {
+		email = normalized
+	}
+	dbUser, err := s.Repository.FindByUsernameOrEmail(strings.ToLower(usernameOrEmail), email)
+	if err != nil {
+		log.Println("Error finding user: ", err)
+		return nil, errors.New(commonerrors.ErrUserNotFound)
+	}
+	return &dbUser, nil
+}
+
 // Login authenticates a user by their username or email and password.
 //
 // usernameOrEmail is the username or email of the user to authenticate.
 // password is the password of the user to authenticate.
-// Returns the authenticated user and an error if authentication fails.
+// Service accounts never sign in with a password.
+// A password hash made with another algorithm or other parameters than configured is
+// replaced by a new hash of the password once it has been verified.
+// Returns the authenticated user and an error if authentication fails or the account is suspended.
 func (s *UserService) Login(usernameOrEmail, password string) (usermodel.User, error) {
-	data := strings.ToLower(usernameOrEmail)
-	dbUser, err := s.Repository.FindByUsernameOrEmail(data, usernameOrEmail)
-	if err != nil {
-		log.Println("Error finding user: ", err)
+	dbUser, err := s.FindByLogin(usernameOrEmail)
+	if err != nil || dbUser.Account == usermodel.AccountTypeService {
 		return usermodel.User{}, errors.New(commonerrors.ErrInvalidCredentials)
 	}
-	if !utils.CompareHashWithString(password, dbUser.Password) {
+	ok, rehash := auth.VerifyPassword(password, dbUser.Password)
+	if !ok {
 		return usermodel.User{}, errors.New(commonerrors.ErrInvalidCredentials)
 	}
-	return dbUser, nil
+	if rehash {
+		// A failed upgrade is retried on the next login.
+		_ = s.Repository.RehashPassword(dbUser.Id, dbUser.Password, password)
+	}
+	if dbUser.IsSuspended() {
+		return usermodel.User{}, errors.New(commonerrors.ErrAccountSuspended)
+	}
+	if dbUser.PasswordResetRequired {
+		return usermodel.User{}, errors.New(commonerrors.ErrPasswordResetRequired)
+	}
+	return *dbUser, nil
 }
 
 // Update updates a user.
@@ -207,19 +253,6 @@ func (s *UserService) Update(user *usermodel.User) error {
 	return s.Repository.Update(user)
 }
 
-// Delete deletes a user by ID.
-//
-// id is the unique identifier of the user to delete.
-// Returns an error if the deletion operation fails.
-func (s *UserService) Delete(id uuid.UUID) error {
-	err := s.Repository.Delete(id)
-	if err != nil {
-		log.Printf("Error deleting user: %v", err)
-		return errors.New(commonerrors.ErrInvalidUserId)
-	}
-	return nil
-}
-
 // UpdateEmail updates the email address associated with a user.
 //
 // id is the unique identifier of the user, and email is the new email address to associate with the user.
@@ -230,51 +263,218 @@ func (s *UserService) UpdateEmail(id uuid.UUID, email string) error {
 
 // UpdatePassword updates the password associated with a user.
 //
-// id is the unique identifier of the user, and newPassword is the new password to associate with the user.
-// Returns an error if the update operation fails.
-func (s *UserService) UpdatePassword(id uuid.UUID, newPassword string) error {
-	if !utils.PasswordValid(newPassword) {
-		return errors.New(commonerrors.ErrPasswordTooWeak)
-	}
+// id is the unique identifier of the user, oldPassword is the current password of the user,
+// and newPassword is the new password to associate with the user. The current password is checked
+// first, so that nothing is revealed about the new one to a caller who does not know it.
+// Returns ErrInvalidCredentials if oldPassword is wrong, a *auth.PasswordPolicyError if the new
+// password breaks the password policy or was used recently, or an error if the update operation fails.
+func (s *UserService) UpdatePassword(id uuid.UUID, oldPassword, newPassword string) error {
 	userModel, err := s.Repository.FindById(id)
 	if err != nil {
 		log.Printf("Error finding user by ID: %v", err)
 		return errors.New(commonerrors.ErrInvalidUserId)
 	}
-	if !utils.CompareHashWithString(newPassword, userModel.Password) {
+	if ok, _ := auth.VerifyPassword(oldPassword, userModel.Password); !ok {
 		return errors.New(commonerrors.ErrInvalidCredentials)
 	}
-	return s.Repository.UpdatePassword(id, newPassword)
+	if err = auth.CheckPassword(newPassword, passwordUserInputs(&userModel)...); err != nil {
+		return err
+	}
+	if err = s.checkPasswordHistory(&userModel, newPassword); err != nil {
+		return err
+	}
+	return s.Repository.UpdatePassword(id, newPassword, max(auth.GetPasswordPolicy().History-1, 0))
 }
 
 // ResetPassword resets the password associated with a user.
 //
 // id is the unique identifier of the user, and newPassword is the new password to associate with the user.
-// Returns an error if the update operation fails.
+// Returns a *auth.PasswordPolicyError if the new password breaks the password policy or was
+// used recently, or an error if the update operation fails.
 func (s *UserService) ResetPassword(id uuid.UUID, newPassword string) error {
-	if !utils.PasswordValid(newPassword) {
-		return errors.New(commonerrors.ErrPasswordTooWeak)
+	userModel, err := s.Repository.FindById(id)
+	if err != nil {
+		log.Printf("Error finding user by ID: %v", err)
+		return errors.New(commonerrors.ErrInvalidUserId)
 	}
-	return s.Repository.UpdatePassword(id, newPassword)
+	if err = auth.CheckPassword(newPassword, passwordUserInputs(&userModel)...); err != nil {
+		return err
+	}
+	if err = s.checkPasswordHistory(&userModel, newPassword); err != nil {
+		return err
+	}
+	return s.Repository.UpdatePassword(id, newPassword, max(auth.GetPasswordPolicy().History-1, 0))
+}
+
+// checkPasswordHistory checks that a new password is none of the most recent passwords of a user,
+// the current one included, as many as the password policy remembers.
+func (s *UserService) checkPasswordHistory(user *usermodel.User, password string) error {
+	remembered := auth.GetPasswordPolicy().History
+	if remembered <= 0 {
+		return nil
+	}
+	hashes := []string{user.Password}
+	if remembered > 1 {
+		history, err := s.Repository.FindPasswordHistory(user.Id, remembered-1)
+		if err != nil {
+			return errors.New(commonerrors.ErrInternalServer)
+		}
+		for _, entry := range history {
+			hashes = append(hashes, entry.Password)
+		}
+	}
+	message := "must not be your current password"
+	if remembered > 1 {
+		message = fmt.Sprintf("must not be one of your last %d passwords", remembered)
+	}
+	for _, hash := range hashes {
+		if ok, _ := auth.VerifyPassword(password, hash); ok {
+			return &auth.PasswordPolicyError{Violations: []auth.PasswordViolation{{
+				Rule:    auth.PasswordRuleHistory,
+				Message: message,
+			}}}
+		}
+	}
+	return nil
+}
+
+// passwordUserInputs returns the details of a user that make a password easy to guess when it contains them.
+func passwordUserInputs(user *usermodel.User) []string {
+	return []string{user.Username, user.Email, user.FirstName, user.LastName, user.DisplayName}
 }
 
 // UpdateUsername updates the username of a user.
 //
 // id is the unique identifier of the user, and username is the new username to associate with the user.
-// Returns an error if the update operation fails.
+// The username is stored in lower case, like at sign-up, and must not be taken by another user.
+// Returns an error if the username is invalid or taken, or the update operation fails.
 func (s *UserService) UpdateUsername(id uuid.UUID, username string) error {
+	username = strings.ToLower(username)
 	if !utils.UsernameValid(username) {
 		return errors.New(commonerrors.ErrInvalidUsername)
 	}
-	return s.Repository.UpdateUsername(id, username)
+	if dbUser, err := s.Repository.FindByUsername(username); err == nil && dbUser.Id != id {
+		return errors.New(commonerrors.ErrUsernameAlreadyExists)
+	}
+	if err := s.Repository.UpdateUsername(id, username); err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	return nil
 }
 
 // UpdateRegion updates the region of a user.
 //
 // id is the unique identifier of the user, and region is the name of the region to associate with the user.
-// Returns an error if the update operation fails.
+// Returns an error if the region is not valid or the update operation fails.
 func (s *UserService) UpdateRegion(id uuid.UUID, country string) error {
-	return s.Repository.UpdateRegion(id, country)
+	if !usermodel.RegionType(country).IsValid() {
+		return errors.New(commonerrors.ErrInvalidRegion)
+	}
+	if err := s.Repository.UpdateRegion(id, country); err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	return nil
+}
+
+// UpdateProfile applies a partial update to the profile of a user.
+//
+// Every field of update is validated before anything is saved; fields that are
+// nil are left unchanged. The username, validated like in UpdateUsername, and the
+// region are saved along with the other fields, in one transaction, so that a failure
+// leaves the profile unchanged. Returns the updated user and an error if a field is
+// invalid, the username is taken or the update fails.
+func (s *UserService) UpdateProfile(id uuid.UUID, update *userDTO.ProfileUpdate) (*usermodel.User, error) {
+	dbUser, err := s.Repository.FindById(id)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrUserNotFound)
+	}
+	if err = applyProfileUpdate(&dbUser, update); err != nil {
+		return nil, err
+	}
+	if update.Region != nil {
+		if !update.Region.IsValid() {
+			return nil, errors.New(commonerrors.ErrInvalidRegion)
+		}
+		dbUser.Region = *update.Region
+	}
+	if update.Username != nil && strings.ToLower(*update.Username) != dbUser.Username {
+		username := strings.ToLower(*update.Username)
+		if !utils.UsernameValid(username) {
+			return nil, errors.New(commonerrors.ErrInvalidUsername)
+		}
+		if other, err := s.Repository.FindByUsername(username); err == nil && other.Id != id {
+			return nil, errors.New(commonerrors.ErrUsernameAlreadyExists)
+		}
+		dbUser.Username = username
+	}
+	if err = s.Repository.UpdateProfile(&dbUser); err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	dbUser, err = s.Repository.FindById(id)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return &dbUser, nil
+}
+
+// applyProfileUpdate validates the profile fields of update and copies them onto user.
+func applyProfileUpdate(user *usermodel.User, update *userDTO.ProfileUpdate) error {
+	if update.FirstName != nil {
+		firstName := strings.TrimSpace(*update.FirstName)
+		if firstName == "" || utf8.RuneCountInString(firstName) > 64 {
+			return errors.New(commonerrors.ErrInvalidName)
+		}
+		user.FirstName = firstName
+	}
+	if update.LastName != nil {
+		lastName := strings.TrimSpace(*update.LastName)
+		if lastName == "" || utf8.RuneCountInString(lastName) > 64 {
+			return errors.New(commonerrors.ErrInvalidName)
+		}
+		user.LastName = lastName
+	}
+	if update.DisplayName != nil {
+		displayName := strings.TrimSpace(*update.DisplayName)
+		if utf8.RuneCountInString(displayName) > 64 {
+			return errors.New(commonerrors.ErrInvalidDisplayName)
+		}
+		user.DisplayName = displayName
+	}
+	if update.Locale != nil {
+		user.Locale = ""
+		if *update.Locale != "" {
+			locale, ok := utils.LocaleValid(*update.Locale)
+			if !ok {
+				return errors.New(commonerrors.ErrInvalidLocale)
+			}
+			user.Locale = locale
+		}
+	}
+	if update.Timezone != nil {
+		if *update.Timezone != "" && !utils.TimezoneValid(*update.Timezone) {
+			return errors.New(commonerrors.ErrInvalidTimezone)
+		}
+		user.Timezone = *update.Timezone
+	}
+	if update.Phone != nil && *update.Phone != user.Phone {
+		if *update.Phone != "" && !utils.PhoneValid(*update.Phone) {
+			return errors.New(commonerrors.ErrInvalidPhone)
+		}
+		// The phone number receives second factors, so it cannot be swapped from a session alone.
+		if user.SmsTwoFactorEnabled {
+			return errors.New(commonerrors.ErrPhoneLocked)
+		}
+		user.Phone = *update.Phone
+		user.PhoneVerified = false
+	}
+	if update.Bio != nil {
+		bio := strings.TrimSpace(*update.Bio)
+		if utf8.RuneCountInString(bio) > 512 {
+			return errors.New(commonerrors.ErrInvalidBio)
+		}
+		user.Bio = bio
+	}
+	return nil
 }
 
 // MarkEmailAsVerified marks the email address associated with the user as verified.
@@ -296,3 +496,48 @@ func (s *UserService) MarkEmailAsVerified(id uuid.UUID) error {
 func (s *UserService) UpdateIsDisabled(id uuid.UUID, isDisabled bool) error {
 	return s.Repository.UpdateIsDisabled(id, isDisabled)
 }
+
+// Suspend suspends a user account and notifies the user by email.
+//
+// id is the unique identifier of the user, reason the reason shown to the user and until
+// the end of the suspension, or nil to suspend the account until it is unsuspended.
+// Returns the updated user and an error if any.
+func (s *UserService) Suspend(id uuid.UUID, reason string, until *time.Time) (*usermodel.User, error) {
+	if until != nil && !until.After(time.Now()) {
+		return nil, errors.New(commonerrors.ErrInvalidSuspensionEnd)
+	}
+	return s.updateSuspension(id, true, strings.TrimSpace(reason), until)
+}
+
+// Unsuspend lifts the suspension of a user account and notifies the user by email.
+//
+// id is the unique identifier of the user.
+// Returns the updated user and an error if any.
+func (s *UserService) Unsuspend(id uuid.UUID) (*usermodel.User, error) {
+	return s.updateSuspension(id, false, "", nil)
+}
+
+// updateSuspension updates the suspension status of a user and writes the email notifying the user
+// to the outbox in the same transaction.
+func (s *UserService) updateSuspension(id uuid.UUID, isDisabled bool, reason string, until *time.Time) (*usermodel.User, error) {
+	dbUser, err := s.Repository.FindById(id)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrUserNotFound)
+	}
+	dbUser.IsDisabled = isDisabled
+	dbUser.DisabledReason = reason
+	dbUser.DisabledUntil = until
+	message, err := emailPkg.NewEmailAccountStatus(&dbUser)
+	if err != nil {
+		log.Printf("failed to render account status email: %v", err)
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	email := emailservice.NewOutboxEmail(emailmodel.OutboxKindAccountStatus, &id, message)
+	if err = s.Repository.UpdateSuspension(id, isDisabled, reason, until, email); err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	if dbUser, err = s.Repository.FindById(id); err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return &dbUser, nil
+}
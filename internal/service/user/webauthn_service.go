@@ -0,0 +1,272 @@
+package userservice
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/drunkleen/rasta/internal/common/auth"
+	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
+	usermodel "github.com/drunkleen/rasta/internal/models/user"
+	userrepository "github.com/drunkleen/rasta/internal/repository/user"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"log"
+	"strings"
+	"time"
+)
+
+// defaultPasskeyName names the passkeys registered without a name.
+const defaultPasskeyName = "Passkey"
+
+type WebAuthnService struct {
+	Repository *userrepository.WebAuthnRepository
+	WebAuthn   *webauthn.WebAuthn
+}
+
+// NewWebAuthnService creates a new instance of the WebAuthnService struct.
+//
+// It takes a pointer to a WebAuthnRepository as a parameter and returns a pointer to a WebAuthnService
+// performing the ceremonies for the relying party configured by auth.InitWebAuthn.
+func NewWebAuthnService(repository *userrepository.WebAuthnRepository) *WebAuthnService {
+	return &WebAuthnService{Repository: repository, WebAuthn: auth.GetWebAuthn()}
+}
+
+// BeginRegistration starts the registration of a new passkey for a user.
+//
+// Returns the ID of the ceremony, the options to pass to navigator.credentials.create and an error if any.
+func (s *WebAuthnService) BeginRegistration(user *usermodel.User) (uuid.UUID, *protocol.CredentialCreation, error) {
+	webAuthnUser, err := s.webAuthnUser(user)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	creation, session, err := s.WebAuthn.BeginRegistration(webAuthnUser, webauthn.WithExclusions(webAuthnUser.ExcludedCredentials()))
+	if err != nil {
+		log.Printf("failed to begin webauthn registration: %v", err)
+		return uuid.Nil, nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	ceremonyId, err := s.saveChallenge(&user.Id, usermodel.WebAuthnCeremonyRegistration, session)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	return ceremonyId, creation, nil
+}
+
+// FinishRegistration verifies the response of the authenticator to a registration ceremony and stores the new passkey.
+//
+// ceremonyId is the ID returned by BeginRegistration, name an optional name for the passkey
+// and response the JSON encoded PublicKeyCredential returned by the authenticator.
+// Returns the stored passkey and an error if any.
+func (s *WebAuthnService) FinishRegistration(user *usermodel.User, ceremonyId uuid.UUID, name string, response []byte) (*usermodel.WebAuthnCredential, error) {
+	challenge, session, err := s.takeChallenge(ceremonyId, usermodel.WebAuthnCeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.UserId == nil || *challenge.UserId != user.Id {
+		return nil, errors.New(commonerrors.ErrInvalidCeremony)
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInvalidPasskey)
+	}
+	webAuthnUser, err := s.webAuthnUser(user)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := s.WebAuthn.CreateCredential(webAuthnUser, *session, parsed)
+	if err != nil {
+		log.Printf("failed to verify webauthn registration: %v", err)
+		return nil, errors.New(commonerrors.ErrInvalidPasskey)
+	}
+	data, err := json.Marshal(credential)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	if name = strings.TrimSpace(name); name == "" {
+		name = defaultPasskeyName
+	}
+	stored := &usermodel.WebAuthnCredential{
+		UserId:       user.Id,
+		CredentialId: credential.ID,
+		Name:         name,
+		Data:         data,
+	}
+	if err = s.Repository.CreateCredential(stored); err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return stored, nil
+}
+
+// BeginLogin starts a passkey-only login, where the authenticator picks the account.
+//
+// Returns the ID of the ceremony, the options to pass to navigator.credentials.get and an error if any.
+func (s *WebAuthnService) BeginLogin() (uuid.UUID, *protocol.CredentialAssertion, error) {
+	assertion, session, err := s.WebAuthn.BeginDiscoverableLogin()
+	if err != nil {
+		log.Printf("failed to begin webauthn login: %v", err)
+		return uuid.Nil, nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	ceremonyId, err := s.saveChallenge(nil, usermodel.WebAuthnCeremonyLogin, session)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	return ceremonyId, assertion, nil
+}
+
+// BeginSecondFactor starts the verification of one of the passkeys of a user whose password has been checked.
+//
+// Returns the ID of the ceremony, the options to pass to navigator.credentials.get and an error if any.
+func (s *WebAuthnService) BeginSecondFactor(user *usermodel.User) (uuid.UUID, *protocol.CredentialAssertion, error) {
+	webAuthnUser, err := s.webAuthnUser(user)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	if len(webAuthnUser.Credentials) == 0 {
+		return uuid.Nil, nil, errors.New(commonerrors.ErrPasskeyNotFound)
+	}
+	assertion, session, err := s.WebAuthn.BeginLogin(webAuthnUser)
+	if err != nil {
+		log.Printf("failed to begin webauthn login: %v", err)
+		return uuid.Nil, nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	ceremonyId, err := s.saveChallenge(&user.Id, usermodel.WebAuthnCeremonyLogin, session)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	return ceremonyId, assertion, nil
+}
+
+// FinishLogin verifies the response of the authenticator to a ceremony started by BeginLogin or BeginSecondFactor.
+//
+// ceremonyId is the ID of the ceremony and response the JSON encoded PublicKeyCredential returned by the authenticator.
+// The signature counter of the passkey is checked and updated, so a cloned authenticator is refused.
+// Returns the authenticated user and an error if any.
+func (s *WebAuthnService) FinishLogin(ceremonyId uuid.UUID, response []byte) (*usermodel.User, error) {
+	challenge, session, err := s.takeChallenge(ceremonyId, usermodel.WebAuthnCeremonyLogin)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInvalidPasskey)
+	}
+	stored, err := s.Repository.FindCredentialByCredentialId(parsed.RawID)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInvalidPasskey)
+	}
+	if challenge.UserId != nil && *challenge.UserId != stored.UserId {
+		return nil, errors.New(commonerrors.ErrInvalidPasskey)
+	}
+	user, err := s.Repository.FindUserById(stored.UserId)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInvalidPasskey)
+	}
+	webAuthnUser, err := s.webAuthnUser(user)
+	if err != nil {
+		return nil, err
+	}
+	credential, err := auth.ValidateWebAuthnLogin(s.WebAuthn, webAuthnUser, *session, parsed)
+	if err != nil {
+		log.Printf("failed to verify webauthn credential %v of user %v: %v", stored.Id, user.Id, err)
+		return nil, errors.New(commonerrors.ErrInvalidPasskey)
+	}
+	data, err := json.Marshal(credential)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	if err = s.Repository.UpdateCredentialUsage(stored.Id, data); err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return user, nil
+}
+
+// FindByUserId returns the passkeys registered by a user.
+//
+// userId is the unique identifier of the user.
+// Returns the passkeys and an error if any.
+func (s *WebAuthnService) FindByUserId(userId uuid.UUID) ([]usermodel.WebAuthnCredential, error) {
+	credentials, err := s.Repository.FindCredentialsByUserId(userId)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return credentials, nil
+}
+
+// HasCredentials reports whether a user has registered a passkey, in which case
+// logging in with a password requires a passkey as a second factor.
+func (s *WebAuthnService) HasCredentials(userId uuid.UUID) bool {
+	count, err := s.Repository.CountCredentialsByUserId(userId)
+	if err != nil {
+		log.Printf("failed to count webauthn credentials of user %v: %v", userId, err)
+		return false
+	}
+	return count > 0
+}
+
+// Delete deletes a passkey of a user.
+//
+// id is the unique identifier of the passkey and userId the unique identifier of its owner.
+// Returns an error if the passkey does not exist or the deletion fails.
+func (s *WebAuthnService) Delete(id, userId uuid.UUID) error {
+	deleted, err := s.Repository.DeleteCredential(id, userId)
+	if err != nil {
+		return errors.New(commonerrors.ErrInternalServer)
+	}
+	if !deleted {
+		return errors.New(commonerrors.ErrPasskeyNotFound)
+	}
+	return nil
+}
+
+// webAuthnUser loads the passkeys of a user as seen by the WebAuthn ceremonies.
+func (s *WebAuthnService) webAuthnUser(user *usermodel.User) (*auth.WebAuthnUser, error) {
+	stored, err := s.Repository.FindCredentialsByUserId(user.Id)
+	if err != nil {
+		return nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	credentials := make([]webauthn.Credential, 0, len(stored))
+	for _, c := range stored {
+		var credential webauthn.Credential
+		if err = json.Unmarshal(c.Data, &credential); err != nil {
+			log.Printf("failed to decode webauthn credential %v: %v", c.Id, err)
+			continue
+		}
+		credentials = append(credentials, credential)
+	}
+	return &auth.WebAuthnUser{
+		Id:          user.Id[:],
+		Name:        user.Username,
+		DisplayName: strings.TrimSpace(user.FirstName + " " + user.LastName),
+		Credentials: credentials,
+	}, nil
+}
+
+// saveChallenge stores the server side of a ceremony until the client completes it.
+func (s *WebAuthnService) saveChallenge(userId *uuid.UUID, ceremony usermodel.WebAuthnCeremony, session *webauthn.SessionData) (uuid.UUID, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return uuid.Nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	challenge := &usermodel.WebAuthnChallenge{
+		UserId:    userId,
+		Ceremony:  ceremony,
+		Data:      data,
+		ExpiresAt: time.Now().Add(auth.WebAuthnCeremonyTimeout),
+	}
+	if err = s.Repository.CreateChallenge(challenge); err != nil {
+		return uuid.Nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return challenge.Id, nil
+}
+
+// takeChallenge consumes the server side of a ceremony.
+func (s *WebAuthnService) takeChallenge(ceremonyId uuid.UUID, ceremony usermodel.WebAuthnCeremony) (*usermodel.WebAuthnChallenge, *webauthn.SessionData, error) {
+	challenge, err := s.Repository.TakeChallenge(ceremonyId, ceremony)
+	if err != nil {
+		return nil, nil, errors.New(commonerrors.ErrInvalidCeremony)
+	}
+	var session webauthn.SessionData
+	if err = json.Unmarshal(challenge.Data, &session); err != nil {
+		return nil, nil, errors.New(commonerrors.ErrInternalServer)
+	}
+	return challenge, &session, nil
+}
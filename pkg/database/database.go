@@ -2,9 +2,13 @@ package database
 
 import (
 	"github.com/drunkleen/rasta/config"
+	auditmodel "github.com/drunkleen/rasta/internal/models/audit"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
 	newslettermodel "github.com/drunkleen/rasta/internal/models/newsletter"
+	oidcmodel "github.com/drunkleen/rasta/internal/models/oidc"
 	ticketmodel "github.com/drunkleen/rasta/internal/models/ticket"
 	"github.com/drunkleen/rasta/internal/models/user"
+	"github.com/google/uuid"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"log"
@@ -16,7 +20,9 @@ var (
 
 // InitDB initializes the database connection using the database string
 // obtained from the configuration.  It also creates the tables for the
-// models defined in the `models` package.
+// models defined in the `models` package and encrypts the sensitive values
+// stored in plain text, so encryption must be initialized first. The canonical
+// email addresses of users stored before they were recorded are filled in.
 func InitDB() {
 	dbString := config.GetDBString()
 
@@ -28,6 +34,15 @@ func InitDB() {
 	if err = createTables(); err != nil {
 		log.Panic("could not create tables")
 	}
+	if err = encryptColumns(); err != nil {
+		log.Panicf("could not encrypt sensitive columns: %v", err)
+	}
+	if err = canonicalizeEmails(); err != nil {
+		log.Panicf("could not canonicalize email addresses: %v", err)
+	}
+	if err = seedRoles(); err != nil {
+		log.Panic("could not seed roles")
+	}
 }
 
 // createTables creates the tables for the models defined in the `models`
@@ -39,12 +54,90 @@ func createTables() error {
 	if err := DB.AutoMigrate(&usermodel.OAuth{}); err != nil {
 		return err
 	}
+	if err := DB.AutoMigrate(&usermodel.RecoveryCode{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.WebAuthnCredential{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.WebAuthnChallenge{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.Identity{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.SocialState{}); err != nil {
+		return err
+	}
 	if err := DB.AutoMigrate(&usermodel.OtpEmail{}); err != nil {
 		return err
 	}
 	if err := DB.AutoMigrate(&usermodel.ResetPwd{}); err != nil {
 		return err
 	}
+	if err := DB.AutoMigrate(&usermodel.SmsCode{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.LoginLink{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.PasswordHistory{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.EmailChange{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.Session{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.RefreshToken{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.RevokedToken{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.KnownDevice{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.Lockout{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.Role{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.RolePermission{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.UserRole{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.ApiKey{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.AccountDeletion{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.DataExport{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&usermodel.SignupAttempt{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&emailmodel.EmailOutbox{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&auditmodel.AuditLog{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&oidcmodel.OidcClient{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&oidcmodel.OidcConsent{}); err != nil {
+		return err
+	}
+	if err := DB.AutoMigrate(&oidcmodel.OidcAuthorizationCode{}); err != nil {
+		return err
+	}
 	if err := DB.AutoMigrate(&newslettermodel.Newsletter{}); err != nil {
 		return err
 	}
@@ -57,3 +150,52 @@ func createTables() error {
 
 	return nil
 }
+
+// defaultRoles are the roles created on first start, so staff can be granted
+// limited rights without being given the Admin account type.
+var defaultRoles = []usermodel.Role{
+	{
+		Name:        "support",
+		Description: "Support staff handling tickets",
+		Permissions: []usermodel.RolePermission{
+			{Permission: usermodel.PermissionUsersRead},
+			{Permission: usermodel.PermissionUsersImpersonate},
+			{Permission: usermodel.PermissionTicketsRead},
+			{Permission: usermodel.PermissionTicketsWrite},
+			{Permission: usermodel.PermissionTicketsAssign},
+			{Permission: usermodel.PermissionEmailsRead},
+		},
+	},
+	{
+		Name:        "newsletter-editor",
+		Description: "Editors managing the newsletter",
+		Permissions: []usermodel.RolePermission{
+			{Permission: usermodel.PermissionNewsletterRead},
+			{Permission: usermodel.PermissionNewsletterSend},
+		},
+	},
+}
+
+// seedRoles creates the default roles that do not exist yet. Existing roles,
+// including their permissions, are left untouched.
+func seedRoles() error {
+	for _, role := range defaultRoles {
+		var count int64
+		if err := DB.Model(&usermodel.Role{}).Where("name = ?", role.Name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		role.Id = uuid.New()
+		permissions := make([]usermodel.RolePermission, len(role.Permissions))
+		for i, permission := range role.Permissions {
+			permissions[i] = usermodel.RolePermission{RoleId: role.Id, Permission: permission.Permission}
+		}
+		role.Permissions = permissions
+		if err := DB.Create(&role).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/drunkleen/rasta/internal/common/utils"
+	"github.com/drunkleen/rasta/internal/models/user"
+	"gorm.io/gorm"
+)
+
+// canonicalizeBatchSize is the number of users read at once when canonicalizing email addresses.
+const canonicalizeBatchSize = 500
+
+// CanonicalizeEmails fills in the canonical email address of the users stored before it was
+// recorded, and normalizes their email address on the way. An address whose normalized form is
+// already used by another account is kept as it is. It runs when the database is initialized.
+//
+// Returns the number of users updated.
+func CanonicalizeEmails(db *gorm.DB) (int, error) {
+	type row struct {
+		Id    string
+		Email string
+	}
+	count := 0
+	last := ""
+	for {
+		var rows []row
+		query := db.Model(&usermodel.User{}).Select("id::text AS id, email").Where("canonical_email = ''")
+		if last != "" {
+			query = query.Where("id::text > ?", last)
+		}
+		if err := query.Order("id::text").Limit(canonicalizeBatchSize).Scan(&rows).Error; err != nil {
+			return count, fmt.Errorf("failed to read email addresses: %w", err)
+		}
+		for _, r := range rows {
+			email := r.Email
+			if normalized, ok := utils.NormalizeEmail(r.Email); ok && normalized != r.Email {
+				var taken int64
+				err := db.Model(&usermodel.User{}).Where("email = ? AND id::text <> ?", normalized, r.Id).Count(&taken).Error
+				if err != nil {
+					return count, fmt.Errorf("failed to check email address of %s: %w", r.Id, err)
+				}
+				if taken == 0 {
+					email = normalized
+				}
+			}
+			// The update is skipped if the address has changed since it was read.
+			result := db.Model(&usermodel.User{}).
+				Where("id::text = ? AND email = ? AND canonical_email = ''", r.Id, r.Email).
+				Updates(map[string]interface{}{"email": email, "canonical_email": utils.CanonicalEmail(email)})
+			if result.Error != nil {
+				return count, fmt.Errorf("failed to canonicalize email address of %s: %w", r.Id, result.Error)
+			}
+			count += int(result.RowsAffected)
+		}
+		if len(rows) < canonicalizeBatchSize {
+			return count, nil
+		}
+		last = rows[len(rows)-1].Id
+	}
+}
+
+// canonicalizeEmails fills in the canonical email addresses missing since they were introduced.
+func canonicalizeEmails() error {
+	count, err := CanonicalizeEmails(DB)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Printf("canonicalized %d email addresses", count)
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+
+	"github.com/drunkleen/rasta/internal/common/auth"
+	emailmodel "github.com/drunkleen/rasta/internal/models/email"
+	"github.com/drunkleen/rasta/internal/models/user"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	schema.RegisterSerializer("encrypted", EncryptedSerializer{})
+}
+
+// EncryptedSerializer encrypts string fields tagged with `gorm:"serializer:encrypted"` before they
+// are written and decrypts them when they are read, binding every value to its table, column and
+// row, so that no value can be moved to another column or row.
+//
+// Every encrypted field must be listed in encryptedColumns, and its row key must be set before it
+// is written and selected along with it when it is read. Empty strings are stored as is. Values
+// stored in plain text, or encrypted before they were bound to their row, are refused; EncryptColumns
+// brings them up to date when the database is initialized, before anything is read. Only inserts and
+// updates from a struct go through the serializer, updates from a map must encrypt the value themselves.
+type EncryptedSerializer struct{}
+
+// Scan implements the schema.SerializerInterface interface.
+func (EncryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("unsupported type %T for encrypted field %s", dbValue, field.Name)
+	}
+	if value != "" {
+		binding, err := rowEncryptionContext(ctx, field, dst)
+		if err != nil {
+			return err
+		}
+		if !auth.IsEncrypted(value) {
+			log.Printf("refusing %s: the stored value is not encrypted", binding)
+			return fmt.Errorf("%s is not encrypted", binding)
+		}
+		if value, err = auth.Decrypt(value, binding); err != nil {
+			return err
+		}
+	}
+	field.ReflectValueOf(ctx, dst).SetString(value)
+	return nil
+}
+
+// Value implements the schema.SerializerInterface interface.
+func (EncryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("unsupported type %T for encrypted field %s", fieldValue, field.Name)
+	}
+	if value == "" {
+		return value, nil
+	}
+	binding, err := rowEncryptionContext(ctx, field, dst)
+	if err != nil {
+		return nil, err
+	}
+	return auth.Encrypt(value, binding)
+}
+
+// rowEncryptionContext returns the context the value of an encrypted field of a row is bound to.
+//
+// Returns an error if the field is not listed in encryptedColumns or the row key is not set.
+func rowEncryptionContext(ctx context.Context, field *schema.Field, dst reflect.Value) (string, error) {
+	for _, encrypted := range encryptedColumns {
+		if reflect.TypeOf(encrypted.model).Elem() != field.Schema.ModelType || encrypted.column != field.DBName {
+			continue
+		}
+		keyField := field.Schema.LookUpField(encrypted.key)
+		if keyField == nil {
+			break
+		}
+		key, isZero := keyField.ValueOf(ctx, dst)
+		if isZero {
+			return "", fmt.Errorf("the %s of encrypted field %s is not set", encrypted.key, field.Name)
+		}
+		return encryptionContext(field.Schema.Table, field.DBName, fmt.Sprint(key)), nil
+	}
+	return "", fmt.Errorf("encrypted field %s is not listed in the encrypted columns", field.Name)
+}
+
+// encryptionContext returns the context an encrypted column binds the value of a row to.
+func encryptionContext(table, column, rowKey string) string {
+	return table + "." + column + "/" + rowKey
+}
+
+// legacyEncryptionContext returns the context values were encrypted with before they were bound
+// to their row.
+func legacyEncryptionContext(table, column string) string {
+	return table + "." + column
+}
+
+// encryptedColumn is a column encrypted with EncryptedSerializer, with the column its rows are
+// identified by.
+type encryptedColumn struct {
+	model  interface{}
+	key    string
+	column string
+}
+
+// encryptedColumns lists every column encrypted with EncryptedSerializer.
+var encryptedColumns = []encryptedColumn{
+	{model: &usermodel.OAuth{}, key: "user_id", column: "secret"},
+	{model: &usermodel.SocialState{}, key: "id", column: "session"},
+	{model: &usermodel.SocialState{}, key: "id", column: "verifier"},
+	{model: &emailmodel.EmailOutbox{}, key: "id", column: "body"},
+}
+
+// reencryptBatchSize is the number of rows read at once when encrypting a column.
+const reencryptBatchSize = 500
+
+// EncryptColumns encrypts the values of the encrypted columns stored in plain text, before the
+// columns were encrypted, and encrypts again the values encrypted before they were bound to their
+// row. It runs when the database is initialized, as such values are refused when read.
+//
+// Returns the number of values encrypted.
+func EncryptColumns(db *gorm.DB) (int, error) {
+	return reencryptColumns(db, auth.EncryptedPrefix)
+}
+
+// ReencryptColumns encrypts the values of the encrypted columns that are not encrypted with the
+// active master key: values stored in plain text or not bound to their row, like EncryptColumns, and
+// values encrypted with a retired key, whose data key is re-encrypted with the active one. Once it
+// has run, retired keys can be removed from ENCRYPTION_KEYS.
+//
+// Every instance must know the active key before it is used, so this only runs on demand, with
+// cmd/reencrypt, after a key rotation has been rolled out.
+//
+// Returns the number of values updated.
+func ReencryptColumns(db *gorm.DB) (int, error) {
+	return reencryptColumns(db, auth.ActiveEncryptionPrefix())
+}
+
+// reencryptColumns brings the values of every encrypted column not starting with prefix up to
+// date with the active master key.
+func reencryptColumns(db *gorm.DB, prefix string) (int, error) {
+	total := 0
+	for _, encrypted := range encryptedColumns {
+		count, err := reencryptColumn(db, encrypted, prefix)
+		total += count
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// reencryptColumn brings the values of a column not starting with prefix up to date with the
+// active master key, batch by batch in the order of the key column.
+func reencryptColumn(db *gorm.DB, encrypted encryptedColumn, prefix string) (int, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(encrypted.model); err != nil {
+		return 0, err
+	}
+	table := stmt.Schema.Table
+	binding := legacyEncryptionContext(table, encrypted.column)
+
+	type row struct {
+		RowKey   string
+		RowValue string
+	}
+	count := 0
+	last := ""
+	for {
+		var rows []row
+		query := db.Table(table).
+			Select(fmt.Sprintf("%s::text AS row_key, %s AS row_value", encrypted.key, encrypted.column)).
+			Where(fmt.Sprintf("%s <> '' AND %s NOT LIKE ?", encrypted.column, encrypted.column), prefix+"%")
+		if last != "" {
+			query = query.Where(fmt.Sprintf("%s::text > ?", encrypted.key), last)
+		}
+		if err := query.Order("row_key").Limit(reencryptBatchSize).Scan(&rows).Error; err != nil {
+			return count, fmt.Errorf("failed to read %s: %w", binding, err)
+		}
+		for _, r := range rows {
+			value, err := auth.Reencrypt(r.RowValue, encryptionContext(table, encrypted.column, r.RowKey), binding)
+			if err != nil {
+				return count, fmt.Errorf("failed to re-encrypt %s of %s: %w", binding, r.RowKey, err)
+			}
+			// The update is skipped if the value has changed since it was read.
+			result := db.Table(table).
+				Where(fmt.Sprintf("%s::text = ? AND %s = ?", encrypted.key, encrypted.column), r.RowKey, r.RowValue).
+				Update(encrypted.column, value)
+			if result.Error != nil {
+				return count, fmt.Errorf("failed to update %s of %s: %w", binding, r.RowKey, result.Error)
+			}
+			count += int(result.RowsAffected)
+		}
+		if len(rows) < reencryptBatchSize {
+			return count, nil
+		}
+		last = rows[len(rows)-1].RowKey
+	}
+}
+
+// encryptColumns encrypts the sensitive values stored before their columns were encrypted.
+func encryptColumns() error {
+	count, err := EncryptColumns(DB)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Printf("encrypted %d sensitive values", count)
+	}
+	return nil
+}
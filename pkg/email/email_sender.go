@@ -4,12 +4,8 @@ import (
 	"bytes"
 	"errors"
 	"github.com/drunkleen/rasta/config"
-	commonerrors "github.com/drunkleen/rasta/internal/common/errors"
-	newslettermodel "github.com/drunkleen/rasta/internal/models/newsletter"
 	"github.com/drunkleen/rasta/internal/models/user"
-	"gopkg.in/gomail.v2"
 	"html/template"
-	"log"
 	"time"
 )
 
@@ -31,18 +27,102 @@ type NewsletterEmailData struct {
 	DateNow           time.Time
 }
 
+type AccountStatusEmailData struct {
+	FirstName         string
+	Username          string
+	Suspended         bool
+	Reason            string
+	SuspendedUntil    *time.Time
+	HelpCenterEmail   string
+	HelpCenterAddress string
+	IssuerName        string
+	DateNow           time.Time
+}
+
+type AccountLockedEmailData struct {
+	FirstName         string
+	Username          string
+	Failures          int
+	IpAddress         string
+	LockedUntil       time.Time
+	HelpCenterEmail   string
+	HelpCenterAddress string
+	IssuerName        string
+	DateNow           time.Time
+}
+
+type LoginLinkEmailData struct {
+	FirstName         string
+	Username          string
+	Link              string
+	Code              string
+	ExpiresIn         int
+	HelpCenterEmail   string
+	HelpCenterAddress string
+	IssuerName        string
+	DateNow           time.Time
+}
+
+type EmailChangeEmailData struct {
+	FirstName         string
+	Username          string
+	NewEmail          string
+	Code              string
+	ExpiresIn         int
+	Link              string
+	CancelableUntil   time.Time
+	HelpCenterEmail   string
+	HelpCenterAddress string
+	IssuerName        string
+	DateNow           time.Time
+}
+
+type AccountDeletionEmailData struct {
+	FirstName         string
+	Username          string
+	ScheduledFor      time.Time
+	Completed         bool
+	HelpCenterEmail   string
+	HelpCenterAddress string
+	IssuerName        string
+	DateNow           time.Time
+}
+
+type NewLoginEmailData struct {
+	FirstName         string
+	Username          string
+	Device            string
+	IpAddress         string
+	Location          string
+	LoginAt           time.Time
+	Link              string
+	ReportableUntil   time.Time
+	HelpCenterEmail   string
+	HelpCenterAddress string
+	IssuerName        string
+	DateNow           time.Time
+}
+
 // SendEmail sends an email to the target email address using the provided HTML template and email data.
 //
-// Parameter htmlPathFile is the path to the HTML template file, targetEmail is the recipient's email address, subject is the email subject, and EmailData is the email data to be used in the template.
+// Parameter mailer delivers the email, htmlPathFile is the path to the HTML template file, targetEmail is the recipient's email address, subject is the email subject, and EmailData is the email data to be used in the template.
 // Return type is an error object that is returned if the email sending fails.
-// SendEmail sends an email to the target email address using the provided HTML template and email data.
+func SendEmail(mailer Mailer, htmlPathFile string, targetEmail string, subject string, EmailData any) error {
+	message, err := RenderEmail(htmlPathFile, targetEmail, subject, EmailData)
+	if err != nil {
+		return err
+	}
+	return mailer.Send(message)
+}
+
+// RenderEmail renders an email to the target email address using the provided HTML template and email data, without sending it.
 //
 // Parameter htmlPathFile is the path to the HTML template file, targetEmail is the recipient's email address, subject is the email subject, and EmailData is the email data to be used in the template.
-// Return type is an error object that is returned if the email sending fails.
-func SendEmail(htmlPathFile string, targetEmail string, subject string, EmailData any) error {
+// Return type is the rendered message, and an error object that is returned if the template cannot be rendered.
+func RenderEmail(htmlPathFile string, targetEmail string, subject string, EmailData any) (*Message, error) {
 	tmpl, err := template.ParseFiles(htmlPathFile)
 	if err != nil {
-		return errors.New("internal server error")
+		return nil, errors.New("internal server error")
 	}
 
 	var data any
@@ -51,37 +131,60 @@ func SendEmail(htmlPathFile string, targetEmail string, subject string, EmailDat
 	case *OtpEmailData:
 		data, ok = EmailData.(*OtpEmailData)
 		if !ok {
-			return errors.New("internal server error")
+			return nil, errors.New("internal server error")
 		}
 	case *NewsletterEmailData:
 		data, ok = EmailData.(*NewsletterEmailData)
 		if !ok {
-			return errors.New("internal server error")
+			return nil, errors.New("internal server error")
+		}
+	case *AccountStatusEmailData:
+		data, ok = EmailData.(*AccountStatusEmailData)
+		if !ok {
+			return nil, errors.New("internal server error")
+		}
+	case *AccountLockedEmailData:
+		data, ok = EmailData.(*AccountLockedEmailData)
+		if !ok {
+			return nil, errors.New("internal server error")
+		}
+	case *LoginLinkEmailData:
+		data, ok = EmailData.(*LoginLinkEmailData)
+		if !ok {
+			return nil, errors.New("internal server error")
+		}
+	case *EmailChangeEmailData:
+		data, ok = EmailData.(*EmailChangeEmailData)
+		if !ok {
+			return nil, errors.New("internal server error")
+		}
+	case *AccountDeletionEmailData:
+		data, ok = EmailData.(*AccountDeletionEmailData)
+		if !ok {
+			return nil, errors.New("internal server error")
+		}
+	case *NewLoginEmailData:
+		data, ok = EmailData.(*NewLoginEmailData)
+		if !ok {
+			return nil, errors.New("internal server error")
 		}
 	default:
-		return errors.New("internal server error")
+		return nil, errors.New("internal server error")
 	}
 	var body bytes.Buffer
 	if err = tmpl.Execute(&body, data); err != nil {
-		return errors.New("internal server error")
+		return nil, errors.New("internal server error")
 	}
 
-	m := gomail.NewMessage()
-	m.SetHeader("From", config.GetEmailUsername())
-	m.SetHeader("To", targetEmail)
-	m.SetHeader("Subject", config.GetJwtIssuer()+" - "+subject)
-	m.SetBody("text/html", body.String())
-
-	d := gomail.NewDialer(config.GetEmailHost(), config.GetEmailPort(), config.GetEmailUsername(), config.GetEmailPassword())
-
-	if err = d.DialAndSend(m); err != nil {
-		return err
-
-	}
-	return nil
+	return &Message{
+		From:    config.GetEmailUsername(),
+		To:      targetEmail,
+		Subject: config.GetJwtIssuer() + " - " + subject,
+		HTML:    body.String(),
+	}, nil
 }
 
-// SendEmailVerify sends an email to the user with the OTP code to verify his email address.
+// NewEmailVerify renders the email giving the user the OTP code to verify his email address.
 //
 // It uses the `welcome_and_verify.html` template to render the email content.
 //
@@ -89,8 +192,8 @@ func SendEmail(htmlPathFile string, targetEmail string, subject string, EmailDat
 // - user: The user to which the email must be sent.
 //
 // Returns:
-// An error if the email was not sent successfully.
-func SendEmailVerify(user *usermodel.User) error {
+// The email, and an error if it could not be rendered.
+func NewEmailVerify(user *usermodel.User) (*Message, error) {
 	data := &OtpEmailData{
 		Otp:               user.OtpEmail.Code,
 		FirstName:         user.FirstName,
@@ -100,7 +203,7 @@ func SendEmailVerify(user *usermodel.User) error {
 		IssuerName:        config.GetJwtIssuer(),
 		DateNow:           time.Now().Truncate(24 * time.Hour),
 	}
-	return SendEmail(
+	return RenderEmail(
 		"pkg/email/email_templates/welcome_and_verify.html",
 		user.Email,
 		"Verify your E-mail address",
@@ -108,14 +211,14 @@ func SendEmailVerify(user *usermodel.User) error {
 	)
 }
 
-// SendEmailResetPassword sends an email to the user with the OTP code to reset his password.
+// NewEmailResetPassword renders the email giving the user the OTP code to reset his password.
 //
 // Parameters:
 // - user: The user to which the email must be sent.
 //
 // Returns:
-// An error if the email was not sent successfully.
-func SendEmailResetPassword(user *usermodel.User) error {
+// The email, and an error if it could not be rendered.
+func NewEmailResetPassword(user *usermodel.User) (*Message, error) {
 	data := &OtpEmailData{
 		Otp:               user.ResetPwd.Code,
 		FirstName:         user.FirstName,
@@ -125,7 +228,7 @@ func SendEmailResetPassword(user *usermodel.User) error {
 		IssuerName:        config.GetJwtIssuer(),
 		DateNow:           time.Now().Truncate(24 * time.Hour),
 	}
-	return SendEmail(
+	return RenderEmail(
 		"pkg/email/email_templates/reset_password.html",
 		user.Email,
 		"Reset password",
@@ -133,29 +236,238 @@ func SendEmailResetPassword(user *usermodel.User) error {
 	)
 }
 
-// SendNewsletter sends a newsletter to a list of target email addresses.
+// NewEmailAccountStatus renders the email notifying the user that their account has been suspended or reinstated.
 //
 // Parameters:
-// - targetEmails: A list of email addresses to which the newsletter must be sent.
-// - EmailBody: The body of the email.
+// - user: The user whose account status changed.
 //
 // Returns:
-// An error if the email was not sent successfully.
-func SendNewsletter(targetEmails *[]newslettermodel.Newsletter, EmailBody *string) error {
-	subject := "Newsletter"
-	for _, email := range *targetEmails {
-		data := NewsletterEmailData{
-			Body:              *EmailBody,
-			HelpCenterEmail:   config.GetHelpCenterEmail(),
-			HelpCenterAddress: config.GetHelpCenterAddress(),
-			IssuerName:        config.GetJwtIssuer(),
-			DateNow:           time.Now().Truncate(24 * time.Hour),
-		}
-		err := SendEmail("pkg/email/email_templates/news_letter.html", email.Email, subject, &data)
-		if err != nil {
-			log.Printf("failed to send email: %v", err)
-			return errors.New(commonerrors.ErrInternalServer)
-		}
+// The email, and an error if it could not be rendered.
+func NewEmailAccountStatus(user *usermodel.User) (*Message, error) {
+	data := &AccountStatusEmailData{
+		FirstName:         user.FirstName,
+		Username:          user.Username,
+		Suspended:         user.IsDisabled,
+		Reason:            user.DisabledReason,
+		SuspendedUntil:    user.DisabledUntil,
+		HelpCenterEmail:   config.GetHelpCenterEmail(),
+		HelpCenterAddress: config.GetHelpCenterAddress(),
+		IssuerName:        config.GetJwtIssuer(),
+		DateNow:           time.Now().Truncate(24 * time.Hour),
+	}
+	subject := "Your account has been reinstated"
+	if user.IsDisabled {
+		subject = "Your account has been suspended"
+	}
+	return RenderEmail(
+		"pkg/email/email_templates/account_status.html",
+		user.Email,
+		subject,
+		data,
+	)
+}
+
+// NewEmailAccountLocked renders the email notifying the user that their account has been locked after too many failed logins.
+//
+// Parameters:
+// - user: The user whose account has been locked.
+// - failures: The number of failed logins.
+// - ipAddress: The IP address of the last failed login.
+// - lockedUntil: The end of the lockout.
+//
+// Returns:
+// The email, and an error if it could not be rendered.
+func NewEmailAccountLocked(user *usermodel.User, failures int, ipAddress string, lockedUntil time.Time) (*Message, error) {
+	data := &AccountLockedEmailData{
+		FirstName:         user.FirstName,
+		Username:          user.Username,
+		Failures:          failures,
+		IpAddress:         ipAddress,
+		LockedUntil:       lockedUntil,
+		HelpCenterEmail:   config.GetHelpCenterEmail(),
+		HelpCenterAddress: config.GetHelpCenterAddress(),
+		IssuerName:        config.GetJwtIssuer(),
+		DateNow:           time.Now().Truncate(24 * time.Hour),
+	}
+	return RenderEmail(
+		"pkg/email/email_templates/account_locked.html",
+		user.Email,
+		"Your account has been locked",
+		data,
+	)
+}
+
+// NewEmailLoginLink renders the email giving the user a single-use link and code to sign in without a password.
+//
+// Parameters:
+// - user: The user signing in.
+// - link: The sign-in link.
+// - code: The sign-in code.
+// - expiresAt: The expiry of the link and the code.
+//
+// Returns:
+// The email, and an error if it could not be rendered.
+func NewEmailLoginLink(user *usermodel.User, link, code string, expiresAt time.Time) (*Message, error) {
+	data := &LoginLinkEmailData{
+		FirstName:         user.FirstName,
+		Username:          user.Username,
+		Link:              link,
+		Code:              code,
+		ExpiresIn:         int(time.Until(expiresAt).Round(time.Minute).Minutes()),
+		HelpCenterEmail:   config.GetHelpCenterEmail(),
+		HelpCenterAddress: config.GetHelpCenterAddress(),
+		IssuerName:        config.GetJwtIssuer(),
+		DateNow:           time.Now().Truncate(24 * time.Hour),
+	}
+	return RenderEmail(
+		"pkg/email/email_templates/login_link.html",
+		user.Email,
+		"Your sign-in link",
+		data,
+	)
+}
+
+// NewEmailChangeCode renders the email giving the new address the code confirming a change of email address.
+//
+// Parameters:
+// - user: The user changing their email address.
+// - newEmail: The new email address.
+// - code: The confirmation code.
+// - expiresAt: The expiry of the code.
+//
+// Returns:
+// The email, and an error if it could not be rendered.
+func NewEmailChangeCode(user *usermodel.User, newEmail, code string, expiresAt time.Time) (*Message, error) {
+	data := &EmailChangeEmailData{
+		FirstName:         user.FirstName,
+		Username:          user.Username,
+		NewEmail:          newEmail,
+		Code:              code,
+		ExpiresIn:         int(time.Until(expiresAt).Round(time.Minute).Minutes()),
+		HelpCenterEmail:   config.GetHelpCenterEmail(),
+		HelpCenterAddress: config.GetHelpCenterAddress(),
+		IssuerName:        config.GetJwtIssuer(),
+		DateNow:           time.Now().Truncate(24 * time.Hour),
+	}
+	return RenderEmail(
+		"pkg/email/email_templates/email_change_verify.html",
+		newEmail,
+		"Confirm your new email address",
+		data,
+	)
+}
+
+// NewEmailChangeNotice renders the email notifying the current address of a user that their email address is being changed.
+//
+// Parameters:
+// - user: The user changing their email address, still holding the current address.
+// - newEmail: The new email address.
+// - link: The link cancelling the change.
+// - cancelableUntil: The end of the time the link works.
+//
+// Returns:
+// The email, and an error if it could not be rendered.
+func NewEmailChangeNotice(user *usermodel.User, newEmail, link string, cancelableUntil time.Time) (*Message, error) {
+	data := &EmailChangeEmailData{
+		FirstName:         user.FirstName,
+		Username:          user.Username,
+		NewEmail:          newEmail,
+		Link:              link,
+		CancelableUntil:   cancelableUntil,
+		HelpCenterEmail:   config.GetHelpCenterEmail(),
+		HelpCenterAddress: config.GetHelpCenterAddress(),
+		IssuerName:        config.GetJwtIssuer(),
+		DateNow:           time.Now().Truncate(24 * time.Hour),
+	}
+	return RenderEmail(
+		"pkg/email/email_templates/email_change_notice.html",
+		user.Email,
+		"Your email address is being changed",
+		data,
+	)
+}
+
+// NewEmailAccountDeletion renders the email notifying a user that their account is scheduled for deletion, or has been deleted.
+//
+// It uses the `account_deletion.html` template to render the email content.
+//
+// Parameters:
+// - user: The user to which the email must be sent.
+// - scheduledFor: When the account is, or was, deleted.
+// - completed: Whether the account has already been deleted.
+//
+// Returns:
+// The email, and an error if it could not be rendered.
+func NewEmailAccountDeletion(user *usermodel.User, scheduledFor time.Time, completed bool) (*Message, error) {
+	data := &AccountDeletionEmailData{
+		FirstName:         user.FirstName,
+		Username:          user.Username,
+		ScheduledFor:      scheduledFor,
+		Completed:         completed,
+		HelpCenterEmail:   config.GetHelpCenterEmail(),
+		HelpCenterAddress: config.GetHelpCenterAddress(),
+		IssuerName:        config.GetJwtIssuer(),
+		DateNow:           time.Now().Truncate(24 * time.Hour),
+	}
+	subject := "Your account is scheduled for deletion"
+	if completed {
+		subject = "Your account has been deleted"
+	}
+	return RenderEmail(
+		"pkg/email/email_templates/account_deletion.html",
+		user.Email,
+		subject,
+		data,
+	)
+}
+
+// NewEmailNewLogin renders the email notifying a user that their account was just signed in to from a device not seen before.
+//
+// Parameters:
+// - user: The user signed in to.
+// - device: The device signed in from.
+// - link: The link reporting the login as not made by the user.
+//
+// Returns:
+// The email, and an error if it could not be rendered.
+func NewEmailNewLogin(user *usermodel.User, device *usermodel.KnownDevice, link string) (*Message, error) {
+	data := &NewLoginEmailData{
+		FirstName:         user.FirstName,
+		Username:          user.Username,
+		Device:            device.Device,
+		IpAddress:         device.IpAddress,
+		Location:          device.Location,
+		LoginAt:           time.Now(),
+		Link:              link,
+		ReportableUntil:   *device.ReportableUntil,
+		HelpCenterEmail:   config.GetHelpCenterEmail(),
+		HelpCenterAddress: config.GetHelpCenterAddress(),
+		IssuerName:        config.GetJwtIssuer(),
+		DateNow:           time.Now().Truncate(24 * time.Hour),
+	}
+	return RenderEmail(
+		"pkg/email/email_templates/new_login.html",
+		user.Email,
+		"New sign-in to your account",
+		data,
+	)
+}
+
+// NewNewsletter renders a newsletter for a subscriber.
+//
+// Parameters:
+// - targetEmail: The email address of the subscriber.
+// - emailBody: The body of the newsletter.
+//
+// Returns:
+// The email, and an error if it could not be rendered.
+func NewNewsletter(targetEmail, emailBody string) (*Message, error) {
+	data := &NewsletterEmailData{
+		Body:              emailBody,
+		HelpCenterEmail:   config.GetHelpCenterEmail(),
+		HelpCenterAddress: config.GetHelpCenterAddress(),
+		IssuerName:        config.GetJwtIssuer(),
+		DateNow:           time.Now().Truncate(24 * time.Hour),
 	}
-	return nil
+	return RenderEmail("pkg/email/email_templates/news_letter.html", targetEmail, "Newsletter", data)
 }
@@ -0,0 +1,308 @@
+package emailPkg
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/drunkleen/rasta/config"
+	"gopkg.in/gomail.v2"
+)
+
+// smtpTimeout bounds connecting to the SMTP server and every exchange with it.
+const smtpTimeout = 15 * time.Second
+
+// Message is an email with an HTML body.
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	HTML    string
+}
+
+// WriteTo writes the message in the MIME format, as sent over SMTP or saved to an .eml file.
+func (m *Message) WriteTo(w io.Writer) (int64, error) {
+	msg := gomail.NewMessage()
+	msg.SetHeader("From", m.From)
+	msg.SetHeader("To", m.To)
+	msg.SetHeader("Subject", m.Subject)
+	msg.SetBody("text/html", m.HTML)
+	return msg.WriteTo(w)
+}
+
+// Mailer delivers emails.
+type Mailer interface {
+	Send(message *Message) error
+}
+
+// SMTPMailer delivers messages through an SMTP server. Up to PoolSize connections are kept open
+// between messages, for IdleTimeout at most, so that messages do not pay for a new connection,
+// TLS handshake and authentication each.
+//
+// TLS is "tls" to connect over TLS, "starttls" to require upgrading the connection with STARTTLS,
+// or "none" for local servers; credentials are then only sent to localhost.
+type SMTPMailer struct {
+	Host        string
+	Port        int
+	Username    string
+	Password    string
+	TLS         string
+	PoolSize    int
+	IdleTimeout time.Duration
+
+	mu   sync.Mutex
+	idle []*smtpConn
+}
+
+// smtpConn is a connection to the SMTP server, authenticated and ready to send.
+type smtpConn struct {
+	conn     net.Conn
+	client   *smtp.Client
+	lastUsed time.Time
+}
+
+// Send implements the Mailer interface.
+func (m *SMTPMailer) Send(message *Message) error {
+	conn, err := m.get()
+	if err != nil {
+		return err
+	}
+	if err = conn.send(message); err != nil {
+		// The connection is in an unknown state after a failure.
+		_ = conn.client.Close()
+		return err
+	}
+	m.put(conn)
+	return nil
+}
+
+// get returns an idle connection that still works, or a new one.
+func (m *SMTPMailer) get() (*smtpConn, error) {
+	for {
+		m.mu.Lock()
+		if len(m.idle) == 0 {
+			m.mu.Unlock()
+			return m.dial()
+		}
+		conn := m.idle[len(m.idle)-1]
+		m.idle = m.idle[:len(m.idle)-1]
+		m.mu.Unlock()
+
+		if time.Since(conn.lastUsed) < m.IdleTimeout {
+			// The server may have closed the connection while it was idle.
+			_ = conn.conn.SetDeadline(time.Now().Add(smtpTimeout))
+			if conn.client.Reset() == nil {
+				return conn, nil
+			}
+		}
+		_ = conn.client.Close()
+	}
+}
+
+// put keeps a connection for the next message, or closes it if the pool is full.
+func (m *SMTPMailer) put(conn *smtpConn) {
+	conn.lastUsed = time.Now()
+	m.mu.Lock()
+	if len(m.idle) < m.PoolSize {
+		m.idle = append(m.idle, conn)
+		m.mu.Unlock()
+		return
+	}
+	m.mu.Unlock()
+	_ = conn.client.Quit()
+}
+
+// dial connects and authenticates to the SMTP server.
+func (m *SMTPMailer) dial() (*smtpConn, error) {
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	tlsConfig := &tls.Config{ServerName: m.Host, MinVersion: tls.VersionTLS12}
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	var conn net.Conn
+	var err error
+	if m.TLS == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(smtpTimeout))
+	client, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if m.TLS == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			_ = client.Close()
+			return nil, errors.New("smtp server does not support STARTTLS")
+		}
+		if err = client.StartTLS(tlsConfig); err != nil {
+			_ = client.Close()
+			return nil, err
+		}
+	}
+	if m.Username != "" {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err = client.Auth(smtp.PlainAuth("", m.Username, m.Password, m.Host)); err != nil {
+				_ = client.Close()
+				return nil, err
+			}
+		}
+	}
+	return &smtpConn{conn: conn, client: client}, nil
+}
+
+// send sends a message over the connection.
+func (c *smtpConn) send(message *Message) error {
+	_ = c.conn.SetDeadline(time.Now().Add(smtpTimeout))
+	if err := c.client.Mail(message.From); err != nil {
+		return err
+	}
+	if err := c.client.Rcpt(message.To); err != nil {
+		return err
+	}
+	w, err := c.client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = message.WriteTo(w); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// IsBounce reports whether a delivery error is the mail server refusing a message for good, such as
+// for an unknown mailbox, so that sending it again is pointless.
+func IsBounce(err error) bool {
+	var protoErr *textproto.Error
+	return errors.As(err, &protoErr) && protoErr.Code >= 550 && protoErr.Code <= 554
+}
+
+// FileMailer saves messages as .eml files in the maildir Dir instead of delivering them, for
+// development: each message is written to Dir/tmp, then moved to Dir/new.
+type FileMailer struct {
+	Dir string
+}
+
+// Send implements the Mailer interface.
+func (m *FileMailer) Send(message *Message) error {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(m.Dir, sub), 0o700); err != nil {
+			return err
+		}
+	}
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d.%s.eml", time.Now().UnixNano(), hex.EncodeToString(suffix))
+	tmp := filepath.Join(m.Dir, "tmp", name)
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = message.WriteTo(file); err != nil {
+		_ = file.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err = file.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(m.Dir, "new", name))
+}
+
+// MemoryMailer records messages instead of delivering them, so tests can read what was sent.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// Send implements the Mailer interface.
+func (m *MemoryMailer) Send(message *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, *message)
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first.
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
+
+// Reset forgets the messages sent so far.
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
+
+// defaultMailer is the mailer configured by Init. It is nil until then, so that a process which
+// forgot to call Init fails instead of silently dropping its emails.
+var defaultMailer Mailer
+
+// NewMailer returns the driver EMAIL_DRIVER names: smtp, file or memory.
+//
+// Returns an error if the driver or the TLS mode of the smtp driver is unknown.
+func NewMailer() (Mailer, error) {
+	switch config.GetEmailDriver() {
+	case "smtp":
+		tlsMode := config.GetEmailSmtpTls()
+		if tlsMode != "tls" && tlsMode != "starttls" && tlsMode != "none" {
+			return nil, fmt.Errorf("unknown smtp tls mode: %s", tlsMode)
+		}
+		return &SMTPMailer{
+			Host:        config.GetEmailHost(),
+			Port:        config.GetEmailPort(),
+			Username:    config.GetEmailUsername(),
+			Password:    config.GetEmailPassword(),
+			TLS:         tlsMode,
+			PoolSize:    config.GetEmailSmtpPoolSize(),
+			IdleTimeout: time.Duration(config.GetEmailSmtpIdleTimeout()) * time.Second,
+		}, nil
+	case "file":
+		return &FileMailer{Dir: config.GetEmailFileDir()}, nil
+	case "memory":
+		return &MemoryMailer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown email driver: %s", config.GetEmailDriver())
+	}
+}
+
+// Init configures the mailer emails are sent with, from NewMailer.
+func Init() error {
+	mailer, err := NewMailer()
+	if err != nil {
+		return err
+	}
+	defaultMailer = mailer
+	return nil
+}
+
+// DefaultMailer returns the mailer configured by Init, which services are given to send emails with.
+//
+// It panics if Init has not been called.
+func DefaultMailer() Mailer {
+	if defaultMailer == nil {
+		panic("email: mailer not initialised, call emailPkg.Init first")
+	}
+	return defaultMailer
+}
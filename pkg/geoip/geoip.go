@@ -0,0 +1,131 @@
+package geoip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/drunkleen/rasta/config"
+)
+
+// Location is the coarse location an IP address is registered in.
+type Location struct {
+	Country string
+	Region  string
+	City    string
+}
+
+// String returns the location as "City, Region, Country", leaving out the parts that are unknown.
+func (l Location) String() string {
+	var parts []string
+	for _, part := range []string{l.City, l.Region, l.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ipRange is a range of IP addresses, both ends included, registered in the same location.
+type ipRange struct {
+	start    netip.Addr
+	end      netip.Addr
+	location Location
+}
+
+var ranges []ipRange
+
+// Init loads the GeoIP database named by GEOIP_DATABASE, if any.
+//
+// The database is a CSV file of IP ranges in the format of the DB-IP lite databases, either
+// "start,end,country" or "start,end,continent,country,region,city,..." per line, for IPv4
+// and IPv6 addresses alike. Without a database, Lookup finds no location.
+// Returns an error if the database cannot be read.
+func Init() error {
+	path := config.GetGeoIPDatabase()
+	if path == "" {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open geoip database: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	var loaded []ipRange
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read geoip database: %w", err)
+		}
+		entry, err := parseRange(record)
+		if err != nil {
+			return fmt.Errorf("invalid geoip database entry on line %d: %w", line, err)
+		}
+		loaded = append(loaded, entry)
+	}
+	sort.Slice(loaded, func(i, j int) bool {
+		return loaded[i].start.Less(loaded[j].start)
+	})
+	ranges = loaded
+	return nil
+}
+
+// parseRange parses a line of the GeoIP database.
+func parseRange(record []string) (ipRange, error) {
+	if len(record) != 3 && len(record) < 6 {
+		return ipRange{}, fmt.Errorf("expected 3 or at least 6 fields, got %d", len(record))
+	}
+	start, err := netip.ParseAddr(record[0])
+	if err != nil {
+		return ipRange{}, err
+	}
+	end, err := netip.ParseAddr(record[1])
+	if err != nil {
+		return ipRange{}, err
+	}
+	if start.Is4() != end.Is4() || end.Less(start) {
+		return ipRange{}, errors.New("invalid range")
+	}
+	entry := ipRange{start: start, end: end}
+	if len(record) == 3 {
+		entry.location = Location{Country: record[2]}
+	} else {
+		entry.location = Location{Country: record[3], Region: record[4], City: record[5]}
+	}
+	return entry, nil
+}
+
+// Lookup finds the location an IP address is registered in.
+//
+// Returns the location and true, or false if the address is invalid, private or not in the database.
+func Lookup(ipAddress string) (Location, bool) {
+	addr, err := netip.ParseAddr(ipAddress)
+	if err != nil {
+		return Location{}, false
+	}
+	addr = addr.Unmap().WithZone("")
+	// The range holding addr, if any, is the last one starting at or before it.
+	i := sort.Search(len(ranges), func(i int) bool {
+		return addr.Less(ranges[i].start)
+	}) - 1
+	if i < 0 || ranges[i].end.Less(addr) || ranges[i].start.Is4() != addr.Is4() {
+		return Location{}, false
+	}
+	location := ranges[i].location
+	if location.Country == "" || location.Country == "ZZ" {
+		return Location{}, false
+	}
+	return location, true
+}
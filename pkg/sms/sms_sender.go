@@ -0,0 +1,152 @@
+package smsPkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/drunkleen/rasta/config"
+)
+
+// Sender delivers text messages to phone numbers in the E.164 format.
+type Sender interface {
+	Send(to, message string) error
+}
+
+// ConsoleSender writes messages to the log instead of delivering them, for development.
+type ConsoleSender struct{}
+
+// Send implements the Sender interface.
+func (ConsoleSender) Send(to, message string) error {
+	log.Printf("sms to %s: %s", to, message)
+	return nil
+}
+
+// FileSender appends messages to a file instead of delivering them, one JSON object per line,
+// so tests and developers can read the codes sent.
+type FileSender struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// Send implements the Sender interface.
+func (s *FileSender) Send(to, message string) error {
+	now := time.Now()
+	line, err := json.Marshal(smsMessage{To: to, Message: message, SentAt: &now})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// HTTPSender delivers messages through an SMS gateway, posting them as JSON to Url with Token as
+// a bearer token. Any response but a 2xx is an error.
+type HTTPSender struct {
+	Url    string
+	Token  string
+	From   string
+	Client *http.Client
+}
+
+// Send implements the Sender interface.
+func (s *HTTPSender) Send(to, message string) error {
+	body, err := json.Marshal(smsMessage{From: s.From, To: to, Message: message})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sms gateway responded %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	return nil
+}
+
+// smsMessage is a message as posted to the SMS gateway or written by FileSender.
+type smsMessage struct {
+	From    string     `json:"from,omitempty"`
+	To      string     `json:"to"`
+	Message string     `json:"message"`
+	SentAt  *time.Time `json:"sent_at,omitempty"`
+}
+
+var sender Sender = ConsoleSender{}
+
+// Init configures the driver SMS_DRIVER names: console, file or http.
+//
+// Returns an error if the driver is unknown or the http driver has no SMS_HTTP_URL.
+func Init() error {
+	switch config.GetSmsDriver() {
+	case "console":
+		sender = ConsoleSender{}
+	case "file":
+		sender = &FileSender{Path: config.GetSmsFile()}
+	case "http":
+		if config.GetSmsHttpUrl() == "" {
+			return errors.New("SMS_HTTP_URL is required by the http sms driver")
+		}
+		sender = &HTTPSender{
+			Url:    config.GetSmsHttpUrl(),
+			Token:  config.GetSmsHttpToken(),
+			From:   config.GetSmsFrom(),
+			Client: &http.Client{Timeout: 10 * time.Second},
+		}
+	default:
+		return fmt.Errorf("unknown sms driver: %s", config.GetSmsDriver())
+	}
+	return nil
+}
+
+// SendSms sends a text message to a phone number with the configured driver.
+func SendSms(to, message string) error {
+	return sender.Send(to, message)
+}
+
+// SendSmsCode sends a one-time code to a phone number.
+//
+// Parameters:
+// - to: The phone number, in the E.164 format.
+// - purpose: What the code is for, such as "verify your account".
+// - code: The one-time code.
+// - expiresAt: When the code expires.
+//
+// Returns:
+// An error if the message was not sent successfully.
+func SendSmsCode(to, purpose, code string, expiresAt time.Time) error {
+	minutes := int(time.Until(expiresAt).Round(time.Minute).Minutes())
+	if minutes < 1 {
+		minutes = 1
+	}
+	return SendSms(to, fmt.Sprintf(
+		"%s: %s is your code to %s. It expires in %d minutes. Never share it with anyone.",
+		config.GetJwtIssuer(), code, purpose, minutes,
+	))
+}